@@ -5,19 +5,25 @@ package cmd
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand/v2"
 	"net/http"
 	"net/url"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"api-stress-test/internal/request"
+	"api-stress-test/internal/scenario"
 	"api-stress-test/internal/stats"
 	"api-stress-test/internal/ui"
 
@@ -45,13 +51,38 @@ type StressTestOptions struct {
 	Duration         time.Duration
 	OutputFormat     string
 	Insecure         bool
+	CACert           string // PEM root certificates trusted instead of the system pool
+	ClientCert       string // PEM client certificate presented to the server, with ClientKey
+	ClientKey        string
+	HTTP1            bool // force HTTP/1.1 (no ALPN upgrade to HTTP/2)
+	HTTP2            bool // force HTTP/2, using h2c prior knowledge for http:// targets
 	DisableKeepalive bool
 	DisableRedirects bool
-	ExpectStatus     int
+	ExpectStatus     request.StatusSet // accepted status codes; empty means any 2xx
 	ExpectBody       string
+	FailOnBody       string
+	AssertJSON       []request.JSONAssertion
+	BodyReadLimit    int64 // bytes of each body the checks see; 0 means the default
 	Warmup           time.Duration
+	WarmupRequests   int // unrecorded requests sent before the test; alternative to Warmup
 	OutputFile       string
+	// OutputCSV receives a CSV summary, or one row per request with RawRecords
+	OutputCSV        string
+	RawRecords       bool
 	Proxy            string
+	NoProxy          bool
+	ThinkTime        time.Duration
+	ThinkTimeJitter  float64 // ± percentage applied to ThinkTime
+	Histogram        bool
+	HistogramBuckets int
+	DetailedTimings  bool                     // trace DNS, connect, TLS, TTFB and transfer per request
+	PrintResponse    int                      // >0: first N non-2xx bodies; <0: first |N| bodies of any status
+	Scenario         *scenario.Scenario       // when set, each job runs every step instead of a single request
+	SLA              map[string]time.Duration // latency metric (p50, p90, p99, avg) -> maximum allowed
+
+	// ProgressWriter receives the live progress line (stderr on a terminal);
+	// nil hides it
+	ProgressWriter io.Writer
 }
 
 // Execute sets up the Cobra root command and runs the CLI.
@@ -73,13 +104,37 @@ func Execute() {
 		duration         string
 		outputFormat     string
 		insecure         bool
+		caCert           string
+		clientCert       string
+		clientKey        string
+		http1            bool
+		http2Mode        string
 		disableKeepalive bool
 		disableRedirects bool
-		expectStatus     int
+		expectStatus     string
 		expectBody       string
+		failOnBody       string
+		assertJSON       []string
+		bodyReadLimit    int64
 		warmup           string
 		outputFile       string
+		outputCSV        string
+		rawRecords       bool
+		quiet            bool
 		proxy            string
+		noProxy          bool
+		thinkTime        string
+		thinkTimeJitter  float64
+		histogram        bool
+		histogramBuckets int
+		detailedTimings  bool
+		printResponse    int
+		scenarioFile     string
+		slaP50           string
+		slaP90           string
+		slaP99           string
+		slaAvg           string
+		multipartBody    bool
 	)
 
 	rootCmd := &cobra.Command{
@@ -88,15 +143,44 @@ func Execute() {
 		Long:  "A CLI tool for HTTP load and stress testing with concurrent workers, latency percentiles, and detailed statistics.",
 		Example: `  api-stress-test --url http://example.com/api --requests 1000 --concurrency 50
   api-stress-test --url http://example.com/api --method POST --json-body '{"key":"value"}'
+  api-stress-test --url http://example.com/upload --method POST --multipart --data 'name=report&file=@./report.pdf'
   api-stress-test --url http://example.com/api --headers "Authorization:Bearer token;Accept:application/json"
   api-stress-test --url http://example.com/api --duration 30s --concurrency 20
   api-stress-test --url http://example.com/api --requests 500 --rate 50
+  api-stress-test --url http://example.com/api --duration 1m --rps 200 --concurrency 50
+  api-stress-test --url http://example.com/api --duration 1m --concurrency 10 --think-time 1s --think-time-jitter 20
   api-stress-test --url http://example.com/api --requests 100 --output json
+  api-stress-test --url http://example.com/api --requests 1000 --histogram --histogram-buckets 20
+  api-stress-test --url https://example.com/api --requests 500 --detailed-timings
   api-stress-test --url https://example.com/api --insecure --expect-status 200
+  api-stress-test --url http://example.com/api --expect-status 200-299,404 --fail-on-body '"error"'
+  api-stress-test --url http://example.com/api/user --assert-json 'data.id!=null' --assert-json 'status==ok'
+  api-stress-test --url https://staging.internal/api --mtls-cert client.pem --mtls-key client-key.pem --mtls-ca ca.pem
+  api-stress-test --url https://example.com/api --requests 1000 --http2
+  api-stress-test --url https://example.com/api --requests 1000 --http2=off --disable-keepalive
   api-stress-test --url http://example.com/api --requests 50 --output-file result.json
-  api-stress-test --url http://example.com/api --requests 50 --proxy http://proxy:8080`,
+  api-stress-test --url http://example.com/api --requests 500 --output-json run.json --output-csv requests.csv --raw
+  api-stress-test --url http://example.com/api --requests 1000 --sla-p99 200ms --sla-avg 50ms
+  api-stress-test --url http://example.com/api --requests 50 --proxy http://proxy:8080
+  api-stress-test --url http://example.com/api --requests 50 --no-proxy
+  api-stress-test --scenario login-flow.yaml --url https://staging.example.com --requests 200`,
 		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			var sc *scenario.Scenario
+			if scenarioFile != "" {
+				var err error
+				if sc, err = scenario.Load(scenarioFile); err != nil {
+					return err
+				}
+				// --url overrides base_url so one file can target several environments
+				if targetURL != "" {
+					sc.BaseURL = targetURL
+				}
+				if sc.BaseURL == "" {
+					return fmt.Errorf("scenario needs a base URL: set base_url in %s or pass --url", scenarioFile)
+				}
+				targetURL = sc.BaseURL
+			}
 			if err := ValidateURL(targetURL); err != nil {
 				return err
 			}
@@ -107,6 +191,12 @@ func Execute() {
 				return fmt.Errorf("unsupported output format: %s (supported: text, json)", outputFormat)
 			}
 
+			if proxy != "" {
+				if _, err := ParseProxyURL(proxy); err != nil {
+					return err
+				}
+			}
+
 			parsedHeaders := request.ParseHeaders(headers)
 
 			parsedData, err := request.ParseData(data)
@@ -114,7 +204,16 @@ func Execute() {
 				return fmt.Errorf("parsing --data: %w", err)
 			}
 
-			body, contentType, err := request.PrepareBody(jsonBody, jsonFile, parsedData, rawBody, rawFile, contentTypeFlag)
+			var body []byte
+			var contentType string
+			if multipartBody {
+				if parsedData == nil {
+					return fmt.Errorf("--multipart requires --data")
+				}
+				body, contentType, err = request.PrepareMultipartBody(parsedData)
+			} else {
+				body, contentType, err = request.PrepareBody(jsonBody, jsonFile, parsedData, rawBody, rawFile, contentTypeFlag)
+			}
 			if err != nil {
 				return fmt.Errorf("preparing body: %w", err)
 			}
@@ -122,7 +221,7 @@ func Execute() {
 			if timeout <= 0 {
 				return fmt.Errorf("timeout must be positive (got %.2f)", timeout)
 			}
-			if cmd.Flags().Changed("rate") && rate <= 0 {
+			if (cmd.Flags().Changed("rate") || cmd.Flags().Changed("rps")) && rate <= 0 {
 				return fmt.Errorf("rate must be positive when specified (got %.2f)", rate)
 			}
 			if concurrency > 10000 {
@@ -144,12 +243,80 @@ func Execute() {
 				}
 			}
 
+			// --warmup accepts a duration (5s) or a plain request count (50)
 			var warmupDur time.Duration
+			var warmupRequests int
 			if warmup != "" {
-				warmupDur, err = time.ParseDuration(warmup)
+				if n, convErr := strconv.Atoi(warmup); convErr == nil {
+					if n < 0 {
+						return fmt.Errorf("warmup request count must not be negative (got %d)", n)
+					}
+					warmupRequests = n
+				} else {
+					warmupDur, err = time.ParseDuration(warmup)
+					if err != nil {
+						return fmt.Errorf("invalid warmup (expected duration or request count): %w", err)
+					}
+				}
+			}
+
+			var thinkTimeDur time.Duration
+			if thinkTime != "" {
+				thinkTimeDur, err = time.ParseDuration(thinkTime)
+				if err != nil {
+					return fmt.Errorf("invalid think time: %w", err)
+				}
+				if thinkTimeDur < 0 {
+					return fmt.Errorf("think time must not be negative (got %s)", thinkTime)
+				}
+			}
+			if thinkTimeJitter < 0 || thinkTimeJitter > 100 {
+				return fmt.Errorf("think-time-jitter must be between 0 and 100 (got %.2f)", thinkTimeJitter)
+			}
+
+			if rawRecords && outputCSV == "" {
+				return fmt.Errorf("--raw requires --output-csv")
+			}
+
+			expectStatuses, err := request.ParseStatusSet(expectStatus)
+			if err != nil {
+				return fmt.Errorf("invalid --expect-status: %w", err)
+			}
+			var assertions []request.JSONAssertion
+			for _, expr := range assertJSON {
+				a, err := request.ParseJSONAssertion(expr)
 				if err != nil {
-					return fmt.Errorf("invalid warmup duration: %w", err)
+					return fmt.Errorf("invalid --assert-json %q: %w", expr, err)
 				}
+				assertions = append(assertions, a)
+			}
+			http2, forceHTTP1, err := parseHTTP2Mode(http2Mode)
+			if err != nil {
+				return err
+			}
+			http1 = http1 || forceHTTP1
+
+			if bodyReadLimit <= 0 {
+				return fmt.Errorf("body-read-limit must be positive (got %d)", bodyReadLimit)
+			}
+
+			if histogramBuckets <= 0 {
+				return fmt.Errorf("histogram-buckets must be positive (got %d)", histogramBuckets)
+			}
+
+			sla, err := parseSLA(map[string]string{"p50": slaP50, "p90": slaP90, "p99": slaP99, "avg": slaAvg})
+			if err != nil {
+				return err
+			}
+
+			if insecure {
+				ui.PrintWarning(os.Stderr, "TLS certificate verification is disabled (--insecure); the server's identity is not checked")
+			}
+
+			// The progress line redraws itself, which only works on a terminal
+			var progressOut io.Writer
+			if !quiet && ui.IsTerminal(os.Stderr) {
+				progressOut = os.Stderr
 			}
 
 			return RunStressTest(StressTestOptions{
@@ -166,20 +333,40 @@ func Execute() {
 				Duration:         dur,
 				OutputFormat:     outputFormat,
 				Insecure:         insecure,
+				CACert:           caCert,
+				ClientCert:       clientCert,
+				ClientKey:        clientKey,
+				HTTP1:            http1,
+				HTTP2:            http2,
 				DisableKeepalive: disableKeepalive,
 				DisableRedirects: disableRedirects,
-				ExpectStatus:     expectStatus,
+				ExpectStatus:     expectStatuses,
 				ExpectBody:       expectBody,
+				FailOnBody:       failOnBody,
+				AssertJSON:       assertions,
+				BodyReadLimit:    bodyReadLimit,
 				Warmup:           warmupDur,
+				WarmupRequests:   warmupRequests,
 				OutputFile:       outputFile,
+				OutputCSV:        outputCSV,
+				RawRecords:       rawRecords,
+				ProgressWriter:   progressOut,
 				Proxy:            proxy,
+				NoProxy:          noProxy,
+				ThinkTime:        thinkTimeDur,
+				ThinkTimeJitter:  thinkTimeJitter,
+				Histogram:        histogram,
+				HistogramBuckets: histogramBuckets,
+				DetailedTimings:  detailedTimings,
+				PrintResponse:    printResponse,
+				Scenario:         sc,
+				SLA:              sla,
 			})
 		},
 	}
 
-	// Required flag
-	rootCmd.Flags().StringVar(&targetURL, "url", "", "Target URL (required)")
-	_ = rootCmd.MarkFlagRequired("url")
+	// Target (--url is required unless the scenario file sets base_url)
+	rootCmd.Flags().StringVar(&targetURL, "url", "", "Target URL (required; base URL for --scenario)")
 
 	// Request options
 	rootCmd.Flags().StringVar(&method, "method", "GET", "HTTP method (GET, POST, PUT, DELETE, PATCH, HEAD, OPTIONS)")
@@ -193,33 +380,83 @@ func Execute() {
 	rootCmd.Flags().StringVar(&rawBody, "body", "", "Raw body string")
 	rootCmd.Flags().StringVar(&rawFile, "file", "", "Path to file for body")
 	rootCmd.Flags().StringVar(&contentTypeFlag, "content-type", "", "Explicit Content-Type header")
+	rootCmd.Flags().StringVar(&scenarioFile, "scenario", "", "YAML file of request steps run in order per iteration; --requests counts iterations")
+	rootCmd.Flags().BoolVar(&multipartBody, "multipart", false, "Send --data as multipart/form-data; values starting with '@' are file paths")
 
 	// Load control
-	rootCmd.Flags().Float64Var(&rate, "rate", 0, "Max requests per second (0 = unlimited)")
+	rootCmd.Flags().Float64Var(&rate, "rate", 0, "Target requests per second across all workers (0 = unlimited)")
+	rootCmd.Flags().Float64Var(&rate, "rps", 0, "Alias for --rate")
 	rootCmd.Flags().StringVar(&duration, "duration", "", "Test duration (e.g., 30s, 1m) instead of fixed request count")
+	rootCmd.Flags().StringVar(&thinkTime, "think-time", "", "Pause per worker between requests (e.g., 500ms)")
+	rootCmd.Flags().Float64Var(&thinkTimeJitter, "think-time-jitter", 0, "Random ±percentage variation applied to --think-time (0-100)")
 
 	// Transport tuning
 	rootCmd.Flags().BoolVarP(&insecure, "insecure", "k", false, "Skip TLS certificate verification")
+	rootCmd.Flags().StringVar(&caCert, "cacert", "", "PEM file of CA certificates to trust instead of the system roots")
+	rootCmd.Flags().StringVar(&clientCert, "cert", "", "PEM client certificate for servers that require one (with --key)")
+	rootCmd.Flags().StringVar(&clientKey, "key", "", "PEM private key of --cert")
+	rootCmd.Flags().StringVar(&clientCert, "mtls-cert", "", "Alias for --cert")
+	rootCmd.Flags().StringVar(&clientKey, "mtls-key", "", "Alias for --key")
+	rootCmd.Flags().StringVar(&caCert, "mtls-ca", "", "Alias for --cacert")
+	rootCmd.Flags().BoolVar(&http1, "http1", false, "Force HTTP/1.1 (disable HTTP/2 negotiation)")
+	rootCmd.Flags().StringVar(&http2Mode, "http2", "auto", "HTTP/2: auto (negotiate via ALPN), on (force; h2c prior knowledge for http:// URLs) or off (true/false also accepted); bare --http2 means on")
+	rootCmd.Flags().Lookup("http2").NoOptDefVal = "on"
 	rootCmd.Flags().BoolVar(&disableKeepalive, "disable-keepalive", false, "Disable HTTP keep-alive (new connection per request)")
 	rootCmd.Flags().BoolVar(&disableRedirects, "disable-redirects", false, "Do not follow HTTP redirects")
 
 	// Proxy
-	rootCmd.Flags().StringVar(&proxy, "proxy", "", "HTTP proxy URL (e.g., http://proxy:8080)")
+	rootCmd.Flags().StringVar(&proxy, "proxy", "", "HTTP or HTTPS proxy URL (e.g., http://proxy:8080); defaults to HTTP_PROXY/HTTPS_PROXY")
+	rootCmd.Flags().BoolVar(&noProxy, "no-proxy", false, "Disable proxying, ignoring HTTP_PROXY/HTTPS_PROXY")
 
 	// Response validation
-	rootCmd.Flags().IntVar(&expectStatus, "expect-status", 0, "Expected HTTP status code (others count as failure)")
+	rootCmd.Flags().StringVar(&expectStatus, "expect-status", "", "Status codes counted as success, e.g. 201 or 200-299,404 (default any 2xx)")
 	rootCmd.Flags().StringVar(&expectBody, "expect-body", "", "Expected substring in response body")
+	rootCmd.Flags().StringVar(&failOnBody, "fail-on-body", "", "Count a response as failed when its body contains this substring")
+	rootCmd.Flags().StringArrayVar(&assertJSON, "assert-json", nil, "Assert on the JSON response, e.g. data.id!=null or status==ok (repeatable; failures count as assertion errors)")
+	rootCmd.Flags().Int64Var(&bodyReadLimit, "body-read-limit", request.DefaultBodyReadLimit, "Bytes of each response body read for --expect-body, --fail-on-body, --assert-json, --print-response and scenario extracts (the rest is drained unchecked; a larger JSON body fails --assert-json as truncated)")
 
 	// Warm-up
-	rootCmd.Flags().StringVar(&warmup, "warmup", "", "Warm-up duration before recording stats (e.g., 5s)")
+	rootCmd.Flags().StringVar(&warmup, "warmup", "", "Warm-up before recording stats: a duration (e.g., 5s) or a request count (e.g., 50)")
 
 	// Output
 	rootCmd.Flags().StringVar(&outputFormat, "output", "text", "Output format: text or json")
+	rootCmd.Flags().BoolVar(&histogram, "histogram", false, "Print a detailed ASCII latency histogram")
+	rootCmd.Flags().IntVar(&histogramBuckets, "histogram-buckets", 20, "Number of equal-width buckets for --histogram")
+	rootCmd.Flags().BoolVar(&detailedTimings, "detailed-timings", false, "Trace each request and print a DNS/connect/TLS/TTFB/transfer timing breakdown")
+	rootCmd.Flags().IntVar(&printResponse, "print-response", 0, "Print bodies of the first N non-2xx responses (negative N: first |N| responses of any status)")
 	rootCmd.Flags().StringVar(&outputFile, "output-file", "", "Write JSON results to file (works with any output format)")
+	rootCmd.Flags().StringVar(&outputFile, "output-json", "", "Alias for --output-file")
+	rootCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Hide the live progress line (it is also off when stderr is not a terminal)")
+	rootCmd.Flags().StringVar(&outputCSV, "output-csv", "", "Write a CSV summary to file (per-request rows with --raw)")
+	rootCmd.Flags().BoolVar(&rawRecords, "raw", false, "With --output-csv, write one row per request (status, latency, error); keeps every result in memory")
+
+	// SLA assertions (exit code 1 when exceeded)
+	rootCmd.Flags().StringVar(&slaP50, "sla-p50", "", "Fail if p50 latency exceeds this duration (e.g., 50ms)")
+	rootCmd.Flags().StringVar(&slaP90, "sla-p90", "", "Fail if p90 latency exceeds this duration (e.g., 150ms)")
+	rootCmd.Flags().StringVar(&slaP99, "sla-p99", "", "Fail if p99 latency exceeds this duration (e.g., 200ms)")
+	rootCmd.Flags().StringVar(&slaAvg, "sla-avg", "", "Fail if average latency exceeds this duration (e.g., 80ms)")
 
 	// Mutual exclusivity
 	rootCmd.MarkFlagsMutuallyExclusive("data", "json-body", "json-file", "body", "file")
 	rootCmd.MarkFlagsMutuallyExclusive("requests", "duration")
+	rootCmd.MarkFlagsMutuallyExclusive("rate", "rps")
+	rootCmd.MarkFlagsMutuallyExclusive("output-file", "output-json")
+	rootCmd.MarkFlagsMutuallyExclusive("proxy", "no-proxy")
+	rootCmd.MarkFlagsMutuallyExclusive("http1", "http2")
+	rootCmd.MarkFlagsRequiredTogether("cert", "key")
+	rootCmd.MarkFlagsRequiredTogether("mtls-cert", "mtls-key")
+	rootCmd.MarkFlagsMutuallyExclusive("cert", "mtls-cert")
+	rootCmd.MarkFlagsMutuallyExclusive("key", "mtls-key")
+	rootCmd.MarkFlagsMutuallyExclusive("cacert", "mtls-ca")
+	// The --mtls-* spellings are for verified mutual TLS; --cert/--key/--cacert
+	// share their variables but may be combined with --insecure
+	for _, name := range []string{"mtls-ca", "mtls-cert", "mtls-key"} {
+		rootCmd.MarkFlagsMutuallyExclusive("insecure", name)
+	}
+	rootCmd.MarkFlagsMutuallyExclusive("multipart", "content-type")
+	for _, name := range []string{"method", "headers", "data", "json-body", "json-file", "body", "file", "content-type", "multipart"} {
+		rootCmd.MarkFlagsMutuallyExclusive("scenario", name)
+	}
 
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
@@ -233,6 +470,20 @@ func RunStressTest(opts StressTestOptions) error {
 	isJSON := opts.OutputFormat == "json"
 	isDurationMode := opts.Duration > 0
 
+	// Requests sent per job: one, or every step of the scenario
+	requestsPerJob := 1
+	scenarioSteps := 0
+	if opts.Scenario != nil {
+		scenarioSteps = len(opts.Scenario.Steps)
+		requestsPerJob = scenarioSteps
+	}
+
+	// Built first, so bad proxy or TLS settings abort before any output
+	client, err := newHTTPClient(opts)
+	if err != nil {
+		return err
+	}
+
 	if !isJSON {
 		durationStr := ""
 		if isDurationMode {
@@ -249,35 +500,21 @@ func RunStressTest(opts StressTestOptions) error {
 			Duration:       durationStr,
 			BodyLen:        len(opts.Body),
 			ContentType:    opts.ContentType,
+			ThinkTime:      opts.ThinkTime,
+			ThinkJitter:    opts.ThinkTimeJitter,
+			ScenarioSteps:  scenarioSteps,
+			Protocol:       protocolLabel(opts),
+			TLS:            tlsLabel(opts),
 		})
 	}
 
-	// Configure HTTP Transport
-	transport := &http.Transport{
-		MaxIdleConns:        opts.Concurrency,
-		MaxIdleConnsPerHost: opts.Concurrency,
-		IdleConnTimeout:     90 * time.Second,
-		DisableKeepAlives:   opts.DisableKeepalive,
-	}
-	if opts.Insecure {
-		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec
-	}
-	if opts.Proxy != "" {
-		proxyURL, err := url.Parse(opts.Proxy)
-		if err != nil {
-			return fmt.Errorf("invalid proxy URL: %w", err)
-		}
-		transport.Proxy = http.ProxyURL(proxyURL)
-	}
-
-	client := &http.Client{
-		Transport: transport,
-		Timeout:   opts.Timeout,
-	}
-	if opts.DisableRedirects {
-		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
+	// runJob performs one unit of work: a single request or a full scenario iteration
+	runJob := func(ctx context.Context, expect request.Expectations, sampler *request.ResponseSampler, emit func(request.Result)) {
+		if opts.Scenario != nil {
+			opts.Scenario.Run(ctx, client, expect, sampler, emit)
+			return
 		}
+		emit(request.ExecuteRequest(ctx, client, opts.Method, opts.TargetURL, opts.Headers, opts.Body, opts.ContentType, expect, false, sampler))
 	}
 
 	// Setup signal handling once for the entire test lifecycle
@@ -286,13 +523,26 @@ func RunStressTest(opts StressTestOptions) error {
 	defer signal.Stop(sigChan)
 
 	// Run warm-up phase (requests without recording stats)
-	if opts.Warmup > 0 {
-		if !isJSON {
-			fmt.Fprintf(w, "Warming up for %s...\n", opts.Warmup)
+	if opts.Warmup > 0 || opts.WarmupRequests > 0 {
+		var warmCtx context.Context
+		var warmCancel context.CancelFunc
+		if opts.WarmupRequests > 0 {
+			if !isJSON {
+				fmt.Fprintf(w, "Running %d warmup requests...\n", opts.WarmupRequests)
+			}
+			warmCtx, warmCancel = context.WithCancel(context.Background())
+		} else {
+			if !isJSON {
+				fmt.Fprintf(w, "Warming up for %s...\n", opts.Warmup)
+			}
+			warmCtx, warmCancel = context.WithTimeout(context.Background(), opts.Warmup)
 		}
-		warmCtx, warmCancel := context.WithTimeout(context.Background(), opts.Warmup)
 		defer warmCancel()
 
+		// Count mode: workers share a budget of warmup requests
+		var warmRemaining atomic.Int64
+		warmRemaining.Store(int64(opts.WarmupRequests))
+
 		// Signal listener for warmup phase
 		warmDone := make(chan struct{})
 		go func() {
@@ -315,16 +565,22 @@ func RunStressTest(opts StressTestOptions) error {
 			go func() {
 				defer warmWg.Done()
 				for warmCtx.Err() == nil {
-					res := request.ExecuteRequest(warmCtx, client, opts.Method, opts.TargetURL, opts.Headers, opts.Body, opts.ContentType, 0, "")
-					if !res.OK && res.Elapsed < 0.01 {
+					if opts.WarmupRequests > 0 && warmRemaining.Add(-1) < 0 {
+						return
+					}
+					failedFast := false
+					runJob(warmCtx, request.Expectations{}, nil, func(res request.Result) {
+						failedFast = failedFast || (!res.OK && res.Elapsed < 0.01)
+					})
+					if failedFast {
 						time.Sleep(10 * time.Millisecond)
 					}
 				}
 			}()
 		}
 		warmWg.Wait()
-		warmCancel()   // Ensure warmCtx is done
-		<-warmDone     // Wait for signal goroutine to exit before starting main phase
+		warmCancel() // Ensure warmCtx is done
+		<-warmDone   // Wait for signal goroutine to exit before starting main phase
 
 		if !isJSON {
 			fmt.Fprintln(w, "Warm-up complete. Starting test...")
@@ -342,6 +598,20 @@ func RunStressTest(opts StressTestOptions) error {
 	}
 	defer cancel()
 
+	expect := request.Expectations{
+		Status:        opts.ExpectStatus,
+		Body:          opts.ExpectBody,
+		FailOnBody:    opts.FailOnBody,
+		JSON:          opts.AssertJSON,
+		BodyReadLimit: opts.BodyReadLimit,
+	}
+
+	// Jobs trace their requests with --detailed-timings; warm-up ones do not
+	jobCtx := ctx
+	if opts.DetailedTimings {
+		jobCtx = request.WithTimings(ctx)
+	}
+
 	go func() {
 		select {
 		case <-sigChan:
@@ -356,28 +626,38 @@ func RunStressTest(opts StressTestOptions) error {
 	startTime := time.Now()
 
 	// Pre-allocate collector capacity
-	initialCap := opts.TotalRequests
+	initialCap := opts.TotalRequests * requestsPerJob
 	if isDurationMode {
 		initialCap = opts.Concurrency * 1000
 	}
 	collector := stats.NewCollector(initialCap)
+	if opts.RawRecords {
+		collector.KeepRecords()
+	}
 
 	// Setup rate limiter
 	limiter := request.NewRateLimiter(opts.Rate)
 	defer limiter.Stop()
 
-	// Setup live progress display
-	var progress *ui.Progress
-	if !isJSON {
-		progress = ui.NewProgress(w, int64(opts.TotalRequests), isDurationMode, opts.Duration)
-		progress.Start()
-	}
+	// Live progress on stderr. The window samples behind it are taken even
+	// when nothing is drawn, for the JSON progress timeline.
+	progress := ui.NewProgress(opts.ProgressWriter, int64(opts.TotalRequests*requestsPerJob), isDurationMode, opts.Duration)
+	progress.SetWindowSource(collector.TakeWindow)
+	progress.Start()
+
+	sampler := request.NewResponseSampler(opts.PrintResponse)
 
 	// Worker pool
 	jobs := make(chan struct{}, opts.Concurrency*2)
 	results := make(chan request.Result, opts.Concurrency*2)
 	var wg sync.WaitGroup
 
+	// Think time only separates requests: once the last job is claimed, no
+	// worker pauses (or keeps pausing) after its final request
+	thinkCtx, stopThinking := context.WithCancel(ctx)
+	defer stopThinking()
+	var claimed atomic.Int64
+
 	// Start workers
 	for i := 0; i < opts.Concurrency; i++ {
 		wg.Add(1)
@@ -387,17 +667,35 @@ func RunStressTest(opts StressTestOptions) error {
 				if ctx.Err() != nil {
 					return
 				}
+				if n := claimed.Add(1); !isDurationMode && n >= int64(opts.TotalRequests) {
+					stopThinking()
+				}
+				emitted := 0
+				emit := func(res request.Result) {
+					emitted++
+					results <- res
+				}
 				func() {
 					defer func() {
 						if r := recover(); r != nil {
-							results <- request.Result{
-								OK:    false,
-								Error: fmt.Sprintf("panic: %v", r),
-							}
+							emit(request.Result{
+								OK:          false,
+								Error:       fmt.Sprintf("panic: %v", r),
+								CompletedAt: time.Now(),
+								ErrorKind:   request.ErrorOther,
+							})
 						}
 					}()
-					results <- request.ExecuteRequest(ctx, client, opts.Method, opts.TargetURL, opts.Headers, opts.Body, opts.ContentType, opts.ExpectStatus, opts.ExpectBody)
+					runJob(jobCtx, expect, sampler, emit)
 				}()
+				// A failed scenario step ends the iteration; its remaining steps
+				// are not sent but still count toward the progress total
+				if skipped := requestsPerJob - emitted; skipped > 0 {
+					progress.Add(int64(skipped))
+				}
+				if opts.ThinkTime > 0 && !sleepContext(thinkCtx, thinkTimeDelay(opts.ThinkTime, opts.ThinkTimeJitter)) {
+					return
+				}
 			}
 		}()
 	}
@@ -437,6 +735,13 @@ func RunStressTest(opts StressTestOptions) error {
 	}()
 
 	// Process results
+	record := func(result request.Result) {
+		collector.Record(result.CompletedAt, result.StatusCode, result.Elapsed, result.OK, result.Error, result.ErrorKind.String(), result.ResponseSize)
+		collector.RecordProtocol(result.Proto)
+		if t := result.Timings; t != nil {
+			collector.RecordTimings(t.DNS, t.Connect, t.TLS, t.TTFB, t.Transfer, t.Reused)
+		}
+	}
 	batchSize := max(1, opts.Concurrency/2)
 	batch := make([]request.Result, 0, batchSize)
 
@@ -445,11 +750,9 @@ func RunStressTest(opts StressTestOptions) error {
 
 		if len(batch) >= batchSize {
 			for _, result := range batch {
-				collector.Record(result.StatusCode, result.Elapsed, result.OK, result.Error, result.ResponseSize)
-			}
-			if progress != nil {
-				progress.Add(int64(len(batch)))
+				record(result)
 			}
+			progress.Add(int64(len(batch)))
 			batch = batch[:0]
 		}
 	}
@@ -457,19 +760,16 @@ func RunStressTest(opts StressTestOptions) error {
 	// Flush remaining batch
 	if len(batch) > 0 {
 		for _, result := range batch {
-			collector.Record(result.StatusCode, result.Elapsed, result.OK, result.Error, result.ResponseSize)
-		}
-		if progress != nil {
-			progress.Add(int64(len(batch)))
+			record(result)
 		}
+		progress.Add(int64(len(batch)))
 	}
 
-	// Stop progress display
-	if progress != nil {
-		progress.Stop()
-	}
+	// Clear the progress line before the summary
+	progress.Stop()
 
-	totalTime := time.Since(startTime).Seconds()
+	endTime := time.Now()
+	totalTime := endTime.Sub(startTime).Seconds()
 	stat := collector.GetStatistics()
 
 	if stat.Total == 0 {
@@ -495,6 +795,8 @@ func RunStressTest(opts StressTestOptions) error {
 		Statistics: stat,
 		TotalTime:  totalTime,
 		ReqPerSec:  reqPerSec,
+		StartTime:  startTime.Format(time.RFC3339Nano),
+		EndTime:    endTime.Format(time.RFC3339Nano),
 	}
 	if isDurationMode {
 		output.Config.Duration = opts.Duration.String()
@@ -504,31 +806,295 @@ func RunStressTest(opts StressTestOptions) error {
 	if opts.Rate > 0 {
 		output.Config.Rate = opts.Rate
 	}
+	if opts.ThinkTime > 0 {
+		output.Config.ThinkTime = opts.ThinkTime.String()
+	}
+	if opts.HTTP1 || opts.HTTP2 || opts.DisableKeepalive {
+		output.Config.Protocol = protocolLabel(opts)
+	}
+	output.Config.TLS = tlsLabel(opts)
+	if scenarioSteps > 0 {
+		output.Config.Method = ""
+		output.Config.Scenario = scenarioSteps
+	}
+	if opts.Histogram {
+		output.Statistics.Histogram = collector.LatencyHistogram(opts.HistogramBuckets)
+	}
+	output.ProgressTimeline = collector.Windows()
+	output.ResponseSamples = sampler.Samples()
+	output.SLA = stats.EvaluateSLA(stat, opts.SLA)
+	if opts.Rate > 0 {
+		// The limiter paces jobs, so a scenario's steps do not count separately
+		rate := stats.EvaluateRate(stat, opts.Rate, reqPerSec/float64(requestsPerJob), opts.Concurrency, requestsPerJob, opts.ThinkTime)
+		output.Rate = &rate
+	}
 
 	// Output results
 	if isJSON {
 		if err := ui.PrintJSONResult(w, output); err != nil {
 			return err
 		}
+		if output.Rate != nil && !output.Rate.Met {
+			ui.PrintWarning(os.Stderr, ui.RateWarning(*output.Rate))
+		}
 	} else {
-		ui.PrintTextResult(w, stat, totalTime, reqPerSec)
+		textStat := stat
+		if opts.Histogram {
+			textStat.Histogram = nil // replaced by the detailed histogram below
+		}
+		ui.PrintTextResult(w, textStat, totalTime, reqPerSec)
+		if opts.Histogram {
+			ui.PrintLatencyHistogram(w, output.Statistics.Histogram)
+		}
+		ui.PrintTimingBreakdown(w, stat.Timings)
+		ui.PrintResponseSamples(w, output.ResponseSamples)
+		ui.PrintSLAResults(w, output.SLA)
+		ui.PrintRateResult(w, output.Rate)
 	}
 
-	// Write results to file if requested
+	// Result files come last: the summary above is already out if they fail
+	var errs []error
 	if opts.OutputFile != "" {
 		jsonData, err := json.MarshalIndent(output, "", "  ")
 		if err != nil {
-			return fmt.Errorf("failed to marshal output file JSON: %w", err)
+			errs = append(errs, fmt.Errorf("failed to marshal output file JSON: %w", err))
+		} else if err := os.WriteFile(opts.OutputFile, jsonData, 0644); err != nil {
+			errs = append(errs, fmt.Errorf("failed to write output file: %w", err))
 		}
-		if err := os.WriteFile(opts.OutputFile, jsonData, 0644); err != nil {
-			return fmt.Errorf("failed to write output file: %w", err)
+	}
+	if opts.OutputCSV != "" {
+		err := writeFile(opts.OutputCSV, func(f io.Writer) error {
+			if opts.RawRecords {
+				return ui.WriteCSVRecords(f, collector.Records())
+			}
+			return ui.WriteCSVSummary(f, output)
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to write CSV file: %w", err))
 		}
 	}
 
 	if stat.Failures > 0 {
-		return fmt.Errorf("%d out of %d requests failed", stat.Failures, stat.Total)
+		errs = append(errs, fmt.Errorf("%d out of %d requests failed", stat.Failures, stat.Total))
 	}
-	return nil
+	for _, r := range output.SLA {
+		if !r.Pass {
+			errs = append(errs, fmt.Errorf("SLA %s %s exceeded (actual: %s)", r.Metric, ui.FormatSeconds(r.ThresholdSec), ui.FormatSeconds(r.ActualSec)))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// writeFile creates path and fills it with write, reporting a failed close
+// as well (on some file systems that is where a full disk shows up).
+func writeFile(path string, write func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// thinkTimeDelay returns the pause before a worker's next request, varied
+// uniformly by ±jitterPct percent so workers do not fire in lockstep.
+func thinkTimeDelay(base time.Duration, jitterPct float64) time.Duration {
+	if jitterPct <= 0 {
+		return base
+	}
+	factor := 1 + (rand.Float64()*2-1)*jitterPct/100
+	return time.Duration(float64(base) * factor)
+}
+
+// sleepContext sleeps for d or until ctx is done. Returns false if ctx ended first.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// newHTTPClient builds the shared HTTP client and transport for a test run.
+func newHTTPClient(opts StressTestOptions) (*http.Client, error) {
+	transport := &http.Transport{
+		MaxIdleConns:        opts.Concurrency,
+		MaxIdleConnsPerHost: opts.Concurrency,
+		IdleConnTimeout:     90 * time.Second,
+		DisableKeepAlives:   opts.DisableKeepalive,
+		// A custom TLSClientConfig would otherwise silently disable HTTP/2
+		ForceAttemptHTTP2: true,
+	}
+	tlsConfig, err := newTLSConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	// Without either flag the transport negotiates HTTP/2 via ALPN when the
+	// server offers it. Restricting Protocols keeps every other transport
+	// setting (proxy, TLS, keep-alive) intact.
+	switch {
+	case opts.HTTP1:
+		var protocols http.Protocols
+		protocols.SetHTTP1(true)
+		transport.Protocols = &protocols
+	case opts.HTTP2:
+		var protocols http.Protocols
+		protocols.SetHTTP2(true)
+		protocols.SetUnencryptedHTTP2(true)
+		transport.Protocols = &protocols
+	}
+
+	// Explicit --proxy wins, --no-proxy disables proxying entirely, and
+	// otherwise HTTP_PROXY/HTTPS_PROXY/NO_PROXY are honored.
+	switch {
+	case opts.NoProxy:
+		transport.Proxy = nil
+	case opts.Proxy != "":
+		proxyURL, err := ParseProxyURL(opts.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	default:
+		transport.Proxy = http.ProxyFromEnvironment
+	}
+
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   opts.Timeout,
+	}
+	if opts.DisableRedirects {
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+	return client, nil
+}
+
+// parseHTTP2Mode parses --http2: auto, on or off, with true and false
+// accepted for on and off. off is the same as --http1.
+func parseHTTP2Mode(mode string) (http2, http1 bool, err error) {
+	switch mode {
+	case "auto":
+		return false, false, nil
+	case "on", "true":
+		return true, false, nil
+	case "off", "false":
+		return false, true, nil
+	}
+	return false, false, fmt.Errorf("invalid --http2 %q: must be auto, on or off", mode)
+}
+
+// parseSLA parses the --sla-* values keyed by metric; empty values are unset.
+// Metrics are checked in stats.SLAMetrics order, so with several invalid
+// flags the same one is always reported.
+func parseSLA(values map[string]string) (map[string]time.Duration, error) {
+	sla := make(map[string]time.Duration)
+	for _, metric := range stats.SLAMetrics {
+		raw := values[metric]
+		if raw == "" {
+			continue
+		}
+		threshold, err := time.ParseDuration(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --sla-%s: %w", metric, err)
+		}
+		if threshold <= 0 {
+			return nil, fmt.Errorf("--sla-%s must be positive (got %s)", metric, raw)
+		}
+		sla[metric] = threshold
+	}
+	return sla, nil
+}
+
+// newTLSConfig loads the --insecure, --cacert and --cert/--key settings; nil
+// when none is set, leaving the transport's defaults.
+func newTLSConfig(opts StressTestOptions) (*tls.Config, error) {
+	if !opts.Insecure && opts.CACert == "" && opts.ClientCert == "" && opts.ClientKey == "" {
+		return nil, nil
+	}
+	cfg := &tls.Config{InsecureSkipVerify: opts.Insecure} //nolint:gosec
+
+	if opts.CACert != "" {
+		pem, err := os.ReadFile(opts.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA certificates: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in %s", opts.CACert)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if opts.ClientCert != "" || opts.ClientKey != "" {
+		if opts.ClientCert == "" || opts.ClientKey == "" {
+			return nil, fmt.Errorf("a client certificate needs both --cert and --key")
+		}
+		cert, err := tls.LoadX509KeyPair(opts.ClientCert, opts.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// tlsLabel describes the TLS settings for output; empty for the defaults.
+func tlsLabel(opts StressTestOptions) string {
+	var parts []string
+	if opts.Insecure {
+		parts = append(parts, "verification disabled (--insecure)")
+	}
+	if opts.ClientCert != "" {
+		parts = append(parts, fmt.Sprintf("mTLS (cert %s, key %s)", opts.ClientCert, opts.ClientKey))
+	}
+	if opts.CACert != "" {
+		parts = append(parts, "CA "+opts.CACert)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// protocolLabel describes the HTTP protocol selection for output.
+func protocolLabel(opts StressTestOptions) string {
+	label := "auto"
+	switch {
+	case opts.HTTP1:
+		label = "HTTP/1.1"
+	case opts.HTTP2:
+		label = "HTTP/2"
+	}
+	if opts.DisableKeepalive {
+		label += ", keep-alive off"
+	}
+	return label
+}
+
+// ParseProxyURL validates a proxy URL. HTTP and HTTPS proxies are supported;
+// HTTPS targets are tunneled through the proxy with CONNECT.
+func ParseProxyURL(raw string) (*url.URL, error) {
+	proxyURL, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	if proxyURL.Scheme != "http" && proxyURL.Scheme != "https" {
+		return nil, fmt.Errorf("invalid proxy URL: scheme must be http or https")
+	}
+	if proxyURL.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL: missing host")
+	}
+	return proxyURL, nil
 }
 
 // ValidateURL validates that the URL is a valid HTTP/HTTPS URL.
@@ -0,0 +1,146 @@
+package request
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// JSONAssertion is one --assert-json check: the value at a dotted path of the
+// JSON response compared with an expected value, e.g. "data.id!=null" or
+// "status==ok".
+type JSONAssertion struct {
+	Expr     string   // the expression as given, used in error messages
+	Path     []string // object keys or array indexes, "$" prefix removed
+	Negate   bool     // != instead of ==
+	Expected string   // compared with the value formatted by jsonValueString
+
+	number *big.Rat // Expected as a number when it is an unquoted JSON number
+}
+
+// ParseJSONAssertion parses "path==value" or "path!=value". The path is a
+// dotted list of keys and array indexes ("items.0.id"), optionally starting
+// with "$."; the value is null, a JSON literal or a bare word compared as a
+// string. A number matches any JSON number of the same value (1 and 1.0).
+func ParseJSONAssertion(expr string) (JSONAssertion, error) {
+	a := JSONAssertion{Expr: expr}
+	path, value, found := strings.Cut(expr, "!=")
+	if found {
+		a.Negate = true
+	} else if path, value, found = strings.Cut(expr, "=="); !found {
+		return a, fmt.Errorf("expected path==value or path!=value")
+	}
+
+	path = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(path), "$"), ".")
+	if path == "" {
+		return a, fmt.Errorf("empty path")
+	}
+	for _, key := range strings.Split(path, ".") {
+		if key == "" {
+			return a, fmt.Errorf("empty segment in path %q", path)
+		}
+		a.Path = append(a.Path, key)
+	}
+
+	value = strings.TrimSpace(value)
+	if unquoted, err := strconv.Unquote(value); err == nil && strings.HasPrefix(value, `"`) {
+		value = unquoted
+	} else if isJSONNumber(value) {
+		a.number, _ = new(big.Rat).SetString(value)
+	}
+	a.Expected = value
+	return a, nil
+}
+
+// check reports whether doc, a decoded JSON response, satisfies a. A missing
+// path has the value null.
+func (a JSONAssertion) check(doc any) bool {
+	v := lookupJSON(doc, a.Path)
+	if n, ok := v.(json.Number); ok && a.number != nil {
+		// Compared exactly, so large integer ids do not lose digits
+		got, ok := new(big.Rat).SetString(n.String())
+		return (ok && got.Cmp(a.number) == 0) != a.Negate
+	}
+	return (jsonValueString(v) == a.Expected) != a.Negate
+}
+
+// isJSONNumber reports whether s is a JSON number literal such as -1.5e3.
+func isJSONNumber(s string) bool {
+	return s != "" && (s[0] == '-' || s[0] >= '0' && s[0] <= '9') && json.Valid([]byte(s))
+}
+
+// decodeJSON decodes a whole JSON document, keeping numbers as json.Number.
+func decodeJSON(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("invalid data after top-level value")
+	}
+	return doc, nil
+}
+
+// lookupJSON walks path through objects and arrays; nil when it is missing.
+func lookupJSON(doc any, path []string) any {
+	cur := doc
+	for _, key := range path {
+		switch node := cur.(type) {
+		case map[string]any:
+			cur = node[key]
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil
+			}
+			cur = node[i]
+		default:
+			return nil
+		}
+	}
+	return cur
+}
+
+// jsonValueString formats a decoded JSON value for comparison: strings as
+// is, null as "null", numbers as written, objects and arrays as compact
+// JSON.
+func jsonValueString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// checkJSON decodes body and runs every assertion; it returns the message of
+// the first failure, or "" when all pass.
+func checkJSON(body []byte, assertions []JSONAssertion, truncated bool) string {
+	doc, err := decodeJSON(body)
+	if err != nil {
+		if truncated {
+			return fmt.Sprintf("assertion failed: response is not valid JSON (body truncated at %d bytes)", len(body))
+		}
+		return "assertion failed: response is not valid JSON"
+	}
+	for _, a := range assertions {
+		if !a.check(doc) {
+			return "assertion failed: " + a.Expr
+		}
+	}
+	return ""
+}
@@ -8,9 +8,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 )
@@ -27,6 +31,12 @@ type Result struct {
 	Elapsed      float64 // Request duration in seconds
 	Error        string  // Error message if request failed
 	ResponseSize int64   // Response body size in bytes
+	Body         []byte  // Response body, only populated when keepBody is requested
+
+	CompletedAt time.Time     // When the request finished (or failed)
+	Timings     *PhaseTimings // Set for a response when ctx came from WithTimings
+	ErrorKind   ErrorKind     // Why the request failed; ErrorNone when OK
+	Proto       string        // Negotiated protocol of the response ("HTTP/2.0"); empty without one
 }
 
 // ParseHeaders parses HTTP headers from a semicolon-separated string format.
@@ -101,9 +111,10 @@ func ParseData(raw string) (map[string]string, error) {
 
 // PrepareBody prepares the HTTP request body and determines the Content-Type header.
 // It processes body sources in the following priority order:
-//   1. JSON body (from file or string) - validates JSON and sets Content-Type to application/json
-//   2. Form data - encodes as application/x-www-form-urlencoded
-//   3. Raw body (from file or string) - uses provided Content-Type or defaults to text/plain
+//  1. JSON body (from file or string) - validates JSON and sets Content-Type to application/json
+//  2. Form data - encodes as application/x-www-form-urlencoded
+//  3. Raw body (from file or string) - uses provided Content-Type or defaults to text/plain
+//
 // Returns the body bytes, content type, and any error encountered during processing.
 func PrepareBody(
 	jsonBody string, jsonFile string,
@@ -166,9 +177,55 @@ func PrepareBody(
 	return nil, "", nil
 }
 
+// PrepareMultipartBody encodes form data as multipart/form-data.
+// Values starting with '@' are treated as file paths; the file content becomes
+// the part body. Fields are written in sorted key order so the body is stable.
+// The returned bytes are built once and can be shared read-only across workers.
+func PrepareMultipartBody(formData map[string]string) ([]byte, string, error) {
+	if len(formData) == 0 {
+		return nil, "", fmt.Errorf("multipart body requires form data")
+	}
+
+	keys := make([]string, 0, len(formData))
+	for k := range formData {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for _, key := range keys {
+		value := formData[key]
+		if path, isFile := strings.CutPrefix(value, "@"); isFile {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to read multipart file for %q: %w", key, err)
+			}
+			part, err := mw.CreateFormFile(key, filepath.Base(path))
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to create multipart file part: %w", err)
+			}
+			if _, err := part.Write(data); err != nil {
+				return nil, "", fmt.Errorf("failed to write multipart file part: %w", err)
+			}
+			continue
+		}
+		if err := mw.WriteField(key, value); err != nil {
+			return nil, "", fmt.Errorf("failed to write multipart field: %w", err)
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, "", fmt.Errorf("failed to finalize multipart body: %w", err)
+	}
+
+	return buf.Bytes(), mw.FormDataContentType(), nil
+}
+
 // ExecuteRequest executes a single HTTP request and measures its performance.
-// expectStatus > 0 means only that specific status counts as success.
-// expectBody non-empty means the response body must contain that substring.
+// expect decides which responses count as a success (by default any 2xx).
+// keepBody returns the (size-limited) response body in Result.Body.
+// sampler may be nil; when it has a free slot the response body is captured.
+// A ctx from WithTimings adds the connection phase timings to the Result.
 func ExecuteRequest(
 	ctx context.Context,
 	client *http.Client,
@@ -176,11 +233,18 @@ func ExecuteRequest(
 	headers map[string]string,
 	body []byte,
 	contentType string,
-	expectStatus int,
-	expectBody string,
+	expect Expectations,
+	keepBody bool,
+	sampler *ResponseSampler,
 ) Result {
 	startedAt := time.Now()
 
+	var trace *phaseTrace
+	if timingsEnabled(ctx) {
+		trace = &phaseTrace{}
+		ctx = httptrace.WithClientTrace(ctx, trace.clientTrace())
+	}
+
 	var reqBody io.Reader
 	if len(body) > 0 {
 		reqBody = bytes.NewReader(body)
@@ -189,9 +253,11 @@ func ExecuteRequest(
 	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(method), targetURL, reqBody)
 	if err != nil {
 		return Result{
-			OK:      false,
-			Elapsed: time.Since(startedAt).Seconds(),
-			Error:   fmt.Sprintf("failed to create request: %v", err),
+			OK:          false,
+			Elapsed:     time.Since(startedAt).Seconds(),
+			Error:       fmt.Sprintf("failed to create request: %v", err),
+			CompletedAt: time.Now(),
+			ErrorKind:   ErrorOther,
 		}
 	}
 
@@ -207,55 +273,91 @@ func ExecuteRequest(
 
 	if err != nil {
 		return Result{
-			OK:      false,
-			Elapsed: elapsed,
-			Error:   normalizeError(err.Error()),
+			OK:          false,
+			Elapsed:     elapsed,
+			Error:       normalizeError(err.Error()),
+			CompletedAt: time.Now(),
+			ErrorKind:   classifyError(err),
 		}
 	}
 	defer resp.Body.Close()
 
-	// Read limited body for validation or drain for connection reuse
+	statusCode := resp.StatusCode
+	capture := sampler.claim(statusCode)
+
+	// Read a bounded prefix of the body for checks/extracts/sampling, then
+	// drain the rest for connection reuse. One byte past the limit is read so
+	// truncation is detected even when nothing is left to drain.
 	var respBody []byte
 	var responseSize int64
-	if expectBody != "" {
-		respBody, _ = io.ReadAll(io.LimitReader(resp.Body, maxResponseDrain))
+	var truncated bool
+	limit := expect.bodyReadLimit()
+	if expect.checksBody() || keepBody || capture {
+		respBody, _ = io.ReadAll(io.LimitReader(resp.Body, limit+1))
 		responseSize = int64(len(respBody))
-	} else {
-		responseSize, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseDrain))
+		if responseSize > limit {
+			truncated = true
+			respBody = respBody[:limit]
+		}
+	}
+	if responseSize < maxResponseDrain {
+		drained, _ := io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseDrain-responseSize))
+		responseSize += drained
+	}
+	if capture {
+		sampler.add(statusCode, respBody)
 	}
-
-	statusCode := resp.StatusCode
 
 	// Determine success
-	var ok bool
+	ok := expect.Status.Match(statusCode)
 	var errMsg string
-	if expectStatus > 0 {
-		ok = statusCode == expectStatus
-		if !ok {
-			errMsg = fmt.Sprintf("expected status %d, got %d", expectStatus, statusCode)
+	kind := ErrorNone
+	if !ok {
+		kind = ErrorStatus
+		if len(expect.Status) > 0 {
+			errMsg = fmt.Sprintf("expected status %s, got %d", expect.Status, statusCode)
 		}
-	} else {
-		ok = statusCode >= 200 && statusCode < 300
 	}
 
-	if ok && expectBody != "" {
-		if !strings.Contains(string(respBody), expectBody) {
+	if ok && expect.Body != "" && !bytes.Contains(respBody, []byte(expect.Body)) {
+		ok = false
+		kind = ErrorBody
+		if truncated {
+			errMsg = fmt.Sprintf("response body missing expected content (body truncated at %d bytes)", limit)
+		} else {
+			errMsg = "response body missing expected content"
+		}
+	}
+	if ok && expect.FailOnBody != "" && bytes.Contains(respBody, []byte(expect.FailOnBody)) {
+		ok = false
+		kind = ErrorBody
+		errMsg = "response body contains failure content"
+	}
+	if ok && len(expect.JSON) > 0 {
+		if msg := checkJSON(respBody, expect.JSON, truncated); msg != "" {
 			ok = false
-			if responseSize >= maxResponseDrain {
-				errMsg = fmt.Sprintf("response body missing expected content (body truncated at %d bytes)", maxResponseDrain)
-			} else {
-				errMsg = "response body missing expected content"
-			}
+			kind = ErrorAssertion
+			errMsg = msg
 		}
 	}
 
-	return Result{
+	result := Result{
 		OK:           ok,
 		StatusCode:   statusCode,
 		Elapsed:      elapsed,
 		Error:        errMsg,
 		ResponseSize: responseSize,
+		CompletedAt:  time.Now(),
+		ErrorKind:    kind,
+		Proto:        resp.Proto,
+	}
+	if trace != nil {
+		result.Timings = trace.timings(result.CompletedAt)
+	}
+	if keepBody {
+		result.Body = respBody
 	}
+	return result
 }
 
 // normalizeError maps verbose Go HTTP error messages to concise categories
@@ -1,8 +1,14 @@
 package request
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -120,8 +126,8 @@ func TestParseData(t *testing.T) {
 			expected: map[string]string{"key": "val=ue"},
 		},
 		{
-			name:    "empty entries skipped",
-			input:   "key=value&&other=data",
+			name:     "empty entries skipped",
+			input:    "key=value&&other=data",
 			expected: map[string]string{"key": "value", "other": "data"},
 		},
 	}
@@ -281,6 +287,58 @@ func TestPrepareBody(t *testing.T) {
 	})
 }
 
+func TestPrepareMultipartBody(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "upload.txt")
+	if err := os.WriteFile(filePath, []byte("file contents"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	body, ct, err := PrepareMultipartBody(map[string]string{
+		"name": "report",
+		"file": "@" + filePath,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(ct)
+	if err != nil || mediaType != "multipart/form-data" || params["boundary"] == "" {
+		t.Fatalf("content-type = %q, want multipart/form-data with boundary", ct)
+	}
+
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	form, err := reader.ReadForm(1 << 20)
+	if err != nil {
+		t.Fatalf("failed to parse multipart body: %v", err)
+	}
+	if got := form.Value["name"]; len(got) != 1 || got[0] != "report" {
+		t.Errorf("name field = %v, want [report]", got)
+	}
+	files := form.File["file"]
+	if len(files) != 1 || files[0].Filename != "upload.txt" {
+		t.Fatalf("file part = %v, want upload.txt", files)
+	}
+	f, err := files[0].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	data, _ := io.ReadAll(f)
+	if string(data) != "file contents" {
+		t.Errorf("file part content = %q, want %q", data, "file contents")
+	}
+}
+
+func TestPrepareMultipartBody_Errors(t *testing.T) {
+	if _, _, err := PrepareMultipartBody(nil); err == nil {
+		t.Error("expected error for empty form data")
+	}
+	if _, _, err := PrepareMultipartBody(map[string]string{"file": "@/nonexistent/file.bin"}); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
 func TestExecuteRequest_Success200(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -289,11 +347,15 @@ func TestExecuteRequest_Success200(t *testing.T) {
 	defer server.Close()
 
 	client := server.Client()
-	result := ExecuteRequest(context.Background(), client, "GET", server.URL, nil, nil, "", 0, "")
+	before := time.Now()
+	result := ExecuteRequest(context.Background(), client, "GET", server.URL, nil, nil, "", Expectations{}, false, nil)
 
 	if !result.OK {
 		t.Errorf("expected OK=true, got false")
 	}
+	if result.CompletedAt.Before(before) || result.CompletedAt.After(time.Now()) {
+		t.Errorf("CompletedAt = %v, want during the call", result.CompletedAt)
+	}
 	if result.StatusCode != 200 {
 		t.Errorf("status = %d, want 200", result.StatusCode)
 	}
@@ -305,6 +367,34 @@ func TestExecuteRequest_Success200(t *testing.T) {
 	}
 }
 
+func TestExecuteRequest_Timings(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	client := server.Client()
+
+	ctx := WithTimings(context.Background())
+	first := ExecuteRequest(ctx, client, "GET", server.URL, nil, nil, "", Expectations{}, false, nil)
+	second := ExecuteRequest(ctx, client, "GET", server.URL, nil, nil, "", Expectations{}, false, nil)
+	if first.Timings == nil || second.Timings == nil {
+		t.Fatalf("Timings = %+v, %+v, want both set", first.Timings, second.Timings)
+	}
+	if first.Timings.Reused || first.Timings.Connect <= 0 || first.Timings.TLS <= 0 {
+		t.Errorf("first request timings = %+v, want connect and TLS measured", first.Timings)
+	}
+	if !second.Timings.Reused || second.Timings.Connect != 0 || second.Timings.TLS != 0 {
+		t.Errorf("second request timings = %+v, want a reused connection without connect/TLS", second.Timings)
+	}
+	if second.Timings.TTFB <= 0 {
+		t.Errorf("TTFB = %v, want > 0", second.Timings.TTFB)
+	}
+
+	if res := ExecuteRequest(context.Background(), client, "GET", server.URL, nil, nil, "", Expectations{}, false, nil); res.Timings != nil {
+		t.Errorf("Timings = %+v without WithTimings, want nil", res.Timings)
+	}
+}
+
 func TestExecuteRequest_ServerError(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -312,7 +402,7 @@ func TestExecuteRequest_ServerError(t *testing.T) {
 	defer server.Close()
 
 	client := server.Client()
-	result := ExecuteRequest(context.Background(), client, "GET", server.URL, nil, nil, "", 0, "")
+	result := ExecuteRequest(context.Background(), client, "GET", server.URL, nil, nil, "", Expectations{}, false, nil)
 
 	if result.OK {
 		t.Errorf("expected OK=false for 500 status")
@@ -320,6 +410,9 @@ func TestExecuteRequest_ServerError(t *testing.T) {
 	if result.StatusCode != 500 {
 		t.Errorf("status = %d, want 500", result.StatusCode)
 	}
+	if result.ErrorKind != ErrorStatus {
+		t.Errorf("ErrorKind = %v, want %v", result.ErrorKind, ErrorStatus)
+	}
 }
 
 func TestExecuteRequest_Timeout(t *testing.T) {
@@ -330,7 +423,7 @@ func TestExecuteRequest_Timeout(t *testing.T) {
 	defer server.Close()
 
 	client := &http.Client{Timeout: 50 * time.Millisecond}
-	result := ExecuteRequest(context.Background(), client, "GET", server.URL, nil, nil, "", 0, "")
+	result := ExecuteRequest(context.Background(), client, "GET", server.URL, nil, nil, "", Expectations{}, false, nil)
 
 	if result.OK {
 		t.Errorf("expected OK=false for timeout")
@@ -338,6 +431,9 @@ func TestExecuteRequest_Timeout(t *testing.T) {
 	if result.Error == "" {
 		t.Errorf("expected error message for timeout")
 	}
+	if result.ErrorKind != ErrorTimeout {
+		t.Errorf("ErrorKind = %v, want %v", result.ErrorKind, ErrorTimeout)
+	}
 }
 
 func TestExecuteRequest_ContextCancelled(t *testing.T) {
@@ -351,7 +447,7 @@ func TestExecuteRequest_ContextCancelled(t *testing.T) {
 	cancel() // cancel immediately
 
 	client := server.Client()
-	result := ExecuteRequest(ctx, client, "GET", server.URL, nil, nil, "", 0, "")
+	result := ExecuteRequest(ctx, client, "GET", server.URL, nil, nil, "", Expectations{}, false, nil)
 
 	if result.OK {
 		t.Errorf("expected OK=false for cancelled context")
@@ -359,6 +455,51 @@ func TestExecuteRequest_ContextCancelled(t *testing.T) {
 	if result.Error == "" {
 		t.Errorf("expected error message for cancelled context")
 	}
+	if result.ErrorKind != ErrorCanceled {
+		t.Errorf("ErrorKind = %v, want %v", result.ErrorKind, ErrorCanceled)
+	}
+}
+
+func TestExecuteRequest_TransportErrorKinds(t *testing.T) {
+	// A listener closed right away: nothing accepts on its port
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	refusedURL := "http://" + ln.Addr().String()
+	ln.Close()
+
+	// A server that drops the connection without answering
+	reset := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		conn.Close()
+	}))
+	defer reset.Close()
+
+	// A TLS server whose certificate the default client does not trust
+	untrusted := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	untrusted.Config.ErrorLog = log.New(io.Discard, "", 0)
+	defer untrusted.Close()
+
+	tests := []struct {
+		name string
+		url  string
+		want ErrorKind
+	}{
+		{"refused", refusedURL, ErrorRefused},
+		{"reset", reset.URL, ErrorReset},
+		{"tls", untrusted.URL, ErrorTLS},
+		{"dns", "http://host.invalid", ErrorDNS},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &http.Client{Timeout: 5 * time.Second}
+			result := ExecuteRequest(context.Background(), client, "GET", tt.url, nil, nil, "", Expectations{}, false, nil)
+			if result.OK || result.ErrorKind != tt.want {
+				t.Errorf("ErrorKind = %v (error %q), want %v", result.ErrorKind, result.Error, tt.want)
+			}
+		})
+	}
 }
 
 func TestExecuteRequest_HeadersAndBody(t *testing.T) {
@@ -382,7 +523,7 @@ func TestExecuteRequest_HeadersAndBody(t *testing.T) {
 	body := []byte(`{"key":"value"}`)
 
 	client := server.Client()
-	result := ExecuteRequest(context.Background(), client, "POST", server.URL, headers, body, "application/json", 0, "")
+	result := ExecuteRequest(context.Background(), client, "POST", server.URL, headers, body, "application/json", Expectations{}, false, nil)
 
 	if !result.OK {
 		t.Fatalf("expected OK=true, got error: %s", result.Error)
@@ -413,7 +554,7 @@ func TestExecuteRequest_LargeResponseDrained(t *testing.T) {
 	defer server.Close()
 
 	client := server.Client()
-	result := ExecuteRequest(context.Background(), client, "GET", server.URL, nil, nil, "", 0, "")
+	result := ExecuteRequest(context.Background(), client, "GET", server.URL, nil, nil, "", Expectations{}, false, nil)
 
 	if !result.OK {
 		t.Errorf("expected OK=true, got error: %s", result.Error)
@@ -433,7 +574,7 @@ func TestExecuteRequest_NoBody(t *testing.T) {
 	defer server.Close()
 
 	client := server.Client()
-	result := ExecuteRequest(context.Background(), client, "GET", server.URL, nil, nil, "", 0, "")
+	result := ExecuteRequest(context.Background(), client, "GET", server.URL, nil, nil, "", Expectations{}, false, nil)
 
 	if !result.OK {
 		t.Errorf("expected OK=true, got error: %s", result.Error)
@@ -467,7 +608,7 @@ func TestExecuteRequest_StatusCodeClassification(t *testing.T) {
 			client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
 				return http.ErrUseLastResponse
 			}}
-			result := ExecuteRequest(context.Background(), client, "GET", server.URL, nil, nil, "", 0, "")
+			result := ExecuteRequest(context.Background(), client, "GET", server.URL, nil, nil, "", Expectations{}, false, nil)
 
 			if result.OK != tt.wantOK {
 				t.Errorf("status %d: OK = %v, want %v", tt.statusCode, result.OK, tt.wantOK)
@@ -514,13 +655,13 @@ func TestExecuteRequest_ExpectStatusMatch(t *testing.T) {
 	client := server.Client()
 
 	// Expect 201, server returns 201 → should succeed
-	result := ExecuteRequest(context.Background(), client, "GET", server.URL, nil, nil, "", 201, "")
+	result := ExecuteRequest(context.Background(), client, "GET", server.URL, nil, nil, "", Expectations{Status: StatusSet{{Min: 201, Max: 201}}}, false, nil)
 	if !result.OK {
 		t.Errorf("expected OK=true when expect-status matches, got error: %s", result.Error)
 	}
 
 	// Expect 200, server returns 201 → should fail
-	result = ExecuteRequest(context.Background(), client, "GET", server.URL, nil, nil, "", 200, "")
+	result = ExecuteRequest(context.Background(), client, "GET", server.URL, nil, nil, "", Expectations{Status: StatusSet{{Min: 200, Max: 200}}}, false, nil)
 	if result.OK {
 		t.Error("expected OK=false when expect-status doesn't match")
 	}
@@ -529,6 +670,162 @@ func TestExecuteRequest_ExpectStatusMatch(t *testing.T) {
 	}
 }
 
+func TestParseStatusSet(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string // String() of the set
+		wantErr bool
+	}{
+		{in: "", want: "2xx"},
+		{in: "201", want: "201"},
+		{in: "200-299, 404", want: "200-299,404"},
+		{in: "299-200", wantErr: true},
+		{in: "abc", wantErr: true},
+		{in: "99", wantErr: true},
+		{in: "200-", wantErr: true},
+	}
+	for _, tt := range tests {
+		set, err := ParseStatusSet(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseStatusSet(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if err == nil && set.String() != tt.want {
+			t.Errorf("ParseStatusSet(%q) = %s, want %s", tt.in, set, tt.want)
+		}
+	}
+
+	set, _ := ParseStatusSet("200-299,404")
+	for code, want := range map[int]bool{200: true, 250: true, 299: true, 404: true, 300: false, 403: false, 500: false} {
+		if got := set.Match(code); got != want {
+			t.Errorf("Match(%d) = %v, want %v", code, got, want)
+		}
+	}
+}
+
+func TestParseJSONAssertion(t *testing.T) {
+	tests := []struct {
+		expr    string
+		path    []string
+		negate  bool
+		want    string
+		wantErr bool
+	}{
+		{expr: "data.id!=null", path: []string{"data", "id"}, negate: true, want: "null"},
+		{expr: "$.status == ok", path: []string{"status"}, want: "ok"},
+		{expr: `items.0.name=="a b"`, path: []string{"items", "0", "name"}, want: "a b"},
+		{expr: "count==3", path: []string{"count"}, want: "3"},
+		{expr: "data.id", wantErr: true},
+		{expr: "==1", wantErr: true},
+		{expr: "a..b==1", wantErr: true},
+	}
+	for _, tt := range tests {
+		a, err := ParseJSONAssertion(tt.expr)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseJSONAssertion(%q) error = %v, wantErr %v", tt.expr, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (strings.Join(a.Path, ".") != strings.Join(tt.path, ".") || a.Negate != tt.negate || a.Expected != tt.want) {
+			t.Errorf("ParseJSONAssertion(%q) = %+v", tt.expr, a)
+		}
+	}
+}
+
+func TestJSONAssertionCheck(t *testing.T) {
+	body := []byte(`{"status":"ok","count":3,"ratio":0.5,"big":12345678901234567890,"live":true,"data":{"id":"u1","tags":[]},"items":[{"id":7}]}`)
+	tests := map[string]bool{
+		"data.id!=null":             true,
+		"data.name!=null":           false, // missing counts as null
+		"data.name==null":           true,
+		"status==ok":                true,
+		`status=="ok"`:              true,
+		"status!=ok":                false,
+		"count==3":                  true,
+		"ratio==0.5":                true,
+		"live==true":                true,
+		"items.0.id==7":             true,
+		"items.1.id==null":          true,
+		"data.tags==[]":             true,
+		"count==3.0":                true,
+		"count==3e0":                true,
+		"count==4":                  false,
+		"count!=4":                  true,
+		`count=="3"`:                true,
+		"ratio==0.50":               true,
+		"big==12345678901234567890": true,
+		"big==12345678901234567891": false,
+		"big!=12345678901234567891": true,
+	}
+	for expr, want := range tests {
+		a, err := ParseJSONAssertion(expr)
+		if err != nil {
+			t.Fatalf("ParseJSONAssertion(%q): %v", expr, err)
+		}
+		if got := checkJSON(body, []JSONAssertion{a}, false) == ""; got != want {
+			t.Errorf("%s: passed = %v, want %v", expr, got, want)
+		}
+	}
+
+	for _, body := range []string{"not json", `{"a":1} {"b":2}`} {
+		if msg := checkJSON([]byte(body), nil, false); !strings.Contains(msg, "not valid JSON") {
+			t.Errorf("invalid JSON %q message = %q", body, msg)
+		}
+	}
+}
+
+func TestExecuteRequest_AssertJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"id":null}}`))
+	}))
+	defer server.Close()
+
+	a, _ := ParseJSONAssertion("data.id!=null")
+	res := ExecuteRequest(context.Background(), server.Client(), "GET", server.URL, nil, nil, "", Expectations{JSON: []JSONAssertion{a}}, false, nil)
+	if res.OK || res.ErrorKind != ErrorAssertion || res.Error != "assertion failed: data.id!=null" {
+		t.Errorf("OK = %v, kind %v, error %q; want a failed assertion", res.OK, res.ErrorKind, res.Error)
+	}
+}
+
+func TestExecuteRequest_AssertJSONTruncatedAtDefaultLimit(t *testing.T) {
+	body := `{"pad":"` + strings.Repeat("a", DefaultBodyReadLimit) + `"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	a, _ := ParseJSONAssertion("pad!=null")
+	res := ExecuteRequest(context.Background(), server.Client(), "GET", server.URL, nil, nil, "", Expectations{JSON: []JSONAssertion{a}}, false, nil)
+	want := fmt.Sprintf("(body truncated at %d bytes)", DefaultBodyReadLimit)
+	if res.OK || !strings.Contains(res.Error, want) {
+		t.Errorf("OK = %v, error %q; want %q", res.OK, res.Error, want)
+	}
+}
+
+func TestExecuteRequest_StatusSetAndFailOnBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"error":"quota exceeded"}`))
+	}))
+	defer server.Close()
+	client := server.Client()
+
+	// A 404 from a negative-test endpoint counts as a success
+	expect := Expectations{Status: StatusSet{{Min: 200, Max: 299}, {Min: 404, Max: 404}}}
+	if res := ExecuteRequest(context.Background(), client, "GET", server.URL+"/missing", nil, nil, "", expect, false, nil); !res.OK {
+		t.Errorf("404 with expect 200-299,404: OK = false (%s)", res.Error)
+	}
+
+	// ...and a 200 with an error payload does not
+	expect.FailOnBody = `"error"`
+	res := ExecuteRequest(context.Background(), client, "GET", server.URL, nil, nil, "", expect, false, nil)
+	if res.OK || res.ErrorKind != ErrorBody || !strings.Contains(res.Error, "failure content") {
+		t.Errorf("200 with fail-on-body match: OK = %v, kind %v, error %q", res.OK, res.ErrorKind, res.Error)
+	}
+}
+
 func TestExecuteRequest_ExpectBodyMatch(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -539,13 +836,13 @@ func TestExecuteRequest_ExpectBodyMatch(t *testing.T) {
 	client := server.Client()
 
 	// Body contains expected substring → success
-	result := ExecuteRequest(context.Background(), client, "GET", server.URL, nil, nil, "", 0, "hello world")
+	result := ExecuteRequest(context.Background(), client, "GET", server.URL, nil, nil, "", Expectations{Body: "hello world"}, false, nil)
 	if !result.OK {
 		t.Errorf("expected OK=true when body matches, got error: %s", result.Error)
 	}
 
 	// Body doesn't contain expected substring → failure
-	result = ExecuteRequest(context.Background(), client, "GET", server.URL, nil, nil, "", 0, "not found text")
+	result = ExecuteRequest(context.Background(), client, "GET", server.URL, nil, nil, "", Expectations{Body: "not found text"}, false, nil)
 	if result.OK {
 		t.Error("expected OK=false when body doesn't match")
 	}
@@ -555,8 +852,8 @@ func TestExecuteRequest_ExpectBodyMatch(t *testing.T) {
 }
 
 func TestExecuteRequest_ExpectBodyTruncationWarning(t *testing.T) {
-	// Server returns exactly maxResponseDrain bytes, simulating a truncated response
-	largeBody := strings.Repeat("a", maxResponseDrain)
+	// Server returns more than the body read limit, so the check sees a prefix
+	largeBody := strings.Repeat("a", 2048) + "needle"
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte(largeBody))
@@ -564,14 +861,61 @@ func TestExecuteRequest_ExpectBodyTruncationWarning(t *testing.T) {
 	defer server.Close()
 
 	client := server.Client()
-	result := ExecuteRequest(context.Background(), client, "GET", server.URL, nil, nil, "", 0, "not-in-body")
+	result := ExecuteRequest(context.Background(), client, "GET", server.URL, nil, nil, "", Expectations{Body: "needle", BodyReadLimit: 1024}, false, nil)
 
 	if result.OK {
 		t.Error("expected OK=false when body doesn't match")
 	}
-	if !strings.Contains(result.Error, "truncated") {
+	if result.ResponseSize != int64(len(largeBody)) {
+		t.Errorf("ResponseSize = %d, want %d (the rest is drained)", result.ResponseSize, len(largeBody))
+	}
+	if !strings.Contains(result.Error, "truncated at 1024 bytes") {
 		t.Errorf("expected truncation warning in error, got: %s", result.Error)
 	}
+	if result.ErrorKind != ErrorBody {
+		t.Errorf("ErrorKind = %v, want %v", result.ErrorKind, ErrorBody)
+	}
+}
+
+func TestExecuteRequest_ExpectBodyTruncationWarningDefaultLimit(t *testing.T) {
+	// At the default limit nothing is drained after the prefix, so truncation
+	// must be detected from the read itself
+	largeBody := strings.Repeat("a", DefaultBodyReadLimit) + "needle"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(largeBody))
+	}))
+	defer server.Close()
+
+	client := server.Client()
+	result := ExecuteRequest(context.Background(), client, "GET", server.URL, nil, nil, "", Expectations{Body: "needle"}, false, nil)
+
+	if result.OK {
+		t.Error("expected OK=false when body doesn't match")
+	}
+	want := fmt.Sprintf("truncated at %d bytes", DefaultBodyReadLimit)
+	if !strings.Contains(result.Error, want) {
+		t.Errorf("expected %q in error, got: %s", want, result.Error)
+	}
+}
+
+func TestExecuteRequest_ExpectBodyExactlyAtLimit(t *testing.T) {
+	body := strings.Repeat("a", 1024)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	client := server.Client()
+	result := ExecuteRequest(context.Background(), client, "GET", server.URL, nil, nil, "", Expectations{Body: "needle", BodyReadLimit: 1024}, false, nil)
+
+	if result.Error != "response body missing expected content" {
+		t.Errorf("Error = %q, want no truncation note for a body that fits the limit", result.Error)
+	}
+	if result.ResponseSize != 1024 {
+		t.Errorf("ResponseSize = %d, want 1024", result.ResponseSize)
+	}
 }
 
 func TestExecuteRequest_ResponseSize(t *testing.T) {
@@ -583,9 +927,68 @@ func TestExecuteRequest_ResponseSize(t *testing.T) {
 	defer server.Close()
 
 	client := server.Client()
-	result := ExecuteRequest(context.Background(), client, "GET", server.URL, nil, nil, "", 0, "")
+	result := ExecuteRequest(context.Background(), client, "GET", server.URL, nil, nil, "", Expectations{}, false, nil)
 
 	if result.ResponseSize != 1024 {
 		t.Errorf("ResponseSize = %d, want 1024", result.ResponseSize)
 	}
 }
+
+func TestResponseSampler(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadGateway)
+			fmt.Fprint(w, "upstream down")
+			return
+		}
+		fmt.Fprint(w, "fine")
+	}))
+	defer server.Close()
+
+	client := server.Client()
+	sampler := NewResponseSampler(2)
+	for _, path := range []string{"/ok", "/fail", "/ok", "/fail", "/fail"} {
+		ExecuteRequest(context.Background(), client, "GET", server.URL+path, nil, nil, "", Expectations{}, false, sampler)
+	}
+
+	samples := sampler.Samples()
+	if len(samples) != 2 {
+		t.Fatalf("got %d samples, want 2", len(samples))
+	}
+	for _, s := range samples {
+		if s.StatusCode != http.StatusBadGateway || s.Body != "upstream down" {
+			t.Errorf("unexpected sample %+v", s)
+		}
+	}
+}
+
+func TestResponseSampler_AnyStatusAndTruncation(t *testing.T) {
+	large := strings.Repeat("x", maxSampleBytes+10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, large)
+	}))
+	defer server.Close()
+
+	sampler := NewResponseSampler(-1)
+	for i := 0; i < 3; i++ {
+		ExecuteRequest(context.Background(), server.Client(), "GET", server.URL, nil, nil, "", Expectations{}, false, sampler)
+	}
+
+	samples := sampler.Samples()
+	if len(samples) != 1 {
+		t.Fatalf("got %d samples, want 1", len(samples))
+	}
+	if !samples[0].Truncated || len(samples[0].Body) != maxSampleBytes {
+		t.Errorf("sample not truncated to %d bytes: truncated=%v len=%d", maxSampleBytes, samples[0].Truncated, len(samples[0].Body))
+	}
+}
+
+func TestNewResponseSampler_Disabled(t *testing.T) {
+	if s := NewResponseSampler(0); s != nil {
+		t.Fatalf("NewResponseSampler(0) = %v, want nil", s)
+	}
+	var s *ResponseSampler
+	if got := s.Samples(); got != nil {
+		t.Errorf("nil sampler Samples() = %v, want nil", got)
+	}
+}
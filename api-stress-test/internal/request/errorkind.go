@@ -0,0 +1,79 @@
+package request
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+)
+
+// ErrorKind tells why a request failed; ErrorNone for a success.
+type ErrorKind uint8
+
+const (
+	ErrorNone      ErrorKind = iota
+	ErrorTimeout             // client timeout or deadline, including dial and TLS timeouts
+	ErrorDNS                 // host name resolution failed
+	ErrorRefused             // nothing listening: connection refused
+	ErrorReset               // connection reset or closed before a response
+	ErrorTLS                 // handshake or certificate failure
+	ErrorCanceled            // the run was stopped while the request was in flight
+	ErrorStatus              // a response with an unexpected status code
+	ErrorBody                // a response whose body failed --expect-body or a scenario extract
+	ErrorAssertion           // a response that failed an --assert-json check
+	ErrorOther               // anything else; its message is kept in the top errors
+)
+
+var errorKindNames = [...]string{
+	ErrorNone:      "",
+	ErrorTimeout:   "timeout",
+	ErrorDNS:       "dns",
+	ErrorRefused:   "connection_refused",
+	ErrorReset:     "connection_reset",
+	ErrorTLS:       "tls",
+	ErrorCanceled:  "canceled",
+	ErrorStatus:    "http_status",
+	ErrorBody:      "body_mismatch",
+	ErrorAssertion: "assertion",
+	ErrorOther:     "other",
+}
+
+// String returns the name used in the JSON output, e.g. "connection_refused".
+func (k ErrorKind) String() string {
+	if int(k) < len(errorKindNames) {
+		return errorKindNames[k]
+	}
+	return "other"
+}
+
+// classifyError maps an error returned by http.Client.Do to its kind.
+func classifyError(err error) ErrorKind {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	var recordErr tls.RecordHeaderError
+	var certErr *tls.CertificateVerificationError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return ErrorCanceled
+	case errors.As(err, &dnsErr): // before timeouts: a DNS timeout is a DNS failure
+		return ErrorDNS
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorTimeout
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ErrorRefused
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return ErrorReset
+	case errors.As(err, &recordErr), errors.As(err, &certErr), errors.As(err, &authorityErr),
+		errors.As(err, &hostnameErr), errors.As(err, &invalidErr):
+		return ErrorTLS
+	default:
+		return ErrorOther
+	}
+}
@@ -0,0 +1,114 @@
+package request
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultBodyReadLimit is how much of a response body is read for the body
+// checks, extracts and samples when Expectations.BodyReadLimit is unset.
+const DefaultBodyReadLimit = maxResponseDrain
+
+// StatusRange is an inclusive range of HTTP status codes; Min == Max for a
+// single code.
+type StatusRange struct {
+	Min, Max int
+}
+
+// StatusSet lists the status codes that count as a success. An empty set
+// means any 2xx.
+type StatusSet []StatusRange
+
+// ParseStatusSet parses a comma-separated list of codes and ranges, e.g.
+// "200-299,404". An empty string gives the empty (2xx) set.
+func ParseStatusSet(s string) (StatusSet, error) {
+	var set StatusSet
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		lo, hi, isRange := strings.Cut(part, "-")
+		from, err := parseStatusCode(lo)
+		if err != nil {
+			return nil, fmt.Errorf("invalid status %q: %w", part, err)
+		}
+		to := from
+		if isRange {
+			if to, err = parseStatusCode(hi); err != nil {
+				return nil, fmt.Errorf("invalid status %q: %w", part, err)
+			}
+			if to < from {
+				return nil, fmt.Errorf("invalid status range %q: end is below start", part)
+			}
+		}
+		set = append(set, StatusRange{Min: from, Max: to})
+	}
+	return set, nil
+}
+
+func parseStatusCode(s string) (int, error) {
+	code, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || code < 100 || code > 599 {
+		return 0, fmt.Errorf("status codes are 100-599")
+	}
+	return code, nil
+}
+
+// Match reports whether code counts as a success.
+func (s StatusSet) Match(code int) bool {
+	if len(s) == 0 {
+		return code >= 200 && code < 300
+	}
+	for _, r := range s {
+		if code >= r.Min && code <= r.Max {
+			return true
+		}
+	}
+	return false
+}
+
+// String formats the set the way ParseStatusSet reads it; "2xx" when empty.
+func (s StatusSet) String() string {
+	if len(s) == 0 {
+		return "2xx"
+	}
+	parts := make([]string, len(s))
+	for i, r := range s {
+		if r.Min == r.Max {
+			parts[i] = strconv.Itoa(r.Min)
+		} else {
+			parts[i] = fmt.Sprintf("%d-%d", r.Min, r.Max)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// Expectations decide whether a response counts as a success. The zero value
+// accepts any 2xx response.
+type Expectations struct {
+	Status     StatusSet // accepted status codes (--expect-status)
+	Body       string    // the body must contain this substring (--expect-body)
+	FailOnBody string    // the body must not contain this substring (--fail-on-body)
+
+	// JSON assertions run on the decoded body (--assert-json); the body is
+	// only decoded when there are any
+	JSON []JSONAssertion
+
+	// BodyReadLimit caps the bytes of the body kept for the checks above;
+	// 0 means DefaultBodyReadLimit. The checks only see this prefix.
+	BodyReadLimit int64
+}
+
+// checksBody reports whether the response body has to be read.
+func (e Expectations) checksBody() bool {
+	return e.Body != "" || e.FailOnBody != "" || len(e.JSON) > 0
+}
+
+func (e Expectations) bodyReadLimit() int64 {
+	if e.BodyReadLimit > 0 {
+		return e.BodyReadLimit
+	}
+	return DefaultBodyReadLimit
+}
@@ -0,0 +1,82 @@
+package request
+
+import "sync/atomic"
+
+// maxSampleBytes bounds how much of each captured response body is kept.
+const maxSampleBytes = 8 << 10
+
+// ResponseSample is a captured response body kept for debugging output.
+type ResponseSample struct {
+	StatusCode int    `json:"status_code"`
+	Body       string `json:"body"`
+	Truncated  bool   `json:"truncated,omitempty"`
+}
+
+// ResponseSampler captures a bounded number of response bodies.
+// Slots are claimed with an atomic counter so the hot path never takes a lock,
+// and samples are delivered on a channel sized to the limit so sends never block.
+type ResponseSampler struct {
+	limit        int64
+	onlyFailures bool
+	claimed      atomic.Int64
+	samples      chan ResponseSample
+}
+
+// NewResponseSampler returns a sampler for the first n non-2xx responses,
+// or for the first -n responses of any status when n is negative.
+// Returns nil when n is 0, which disables sampling.
+func NewResponseSampler(n int) *ResponseSampler {
+	if n == 0 {
+		return nil
+	}
+	onlyFailures := n > 0
+	if n < 0 {
+		n = -n
+	}
+	return &ResponseSampler{
+		limit:        int64(n),
+		onlyFailures: onlyFailures,
+		samples:      make(chan ResponseSample, n),
+	}
+}
+
+// claim reserves a capture slot for a response with the given status.
+func (s *ResponseSampler) claim(statusCode int) bool {
+	if s == nil {
+		return false
+	}
+	if s.onlyFailures && statusCode >= 200 && statusCode < 300 {
+		return false
+	}
+	if s.claimed.Load() >= s.limit {
+		return false
+	}
+	return s.claimed.Add(1) <= s.limit
+}
+
+// add stores a captured body for a previously claimed slot.
+func (s *ResponseSampler) add(statusCode int, body []byte) {
+	sample := ResponseSample{StatusCode: statusCode}
+	if len(body) > maxSampleBytes {
+		body = body[:maxSampleBytes]
+		sample.Truncated = true
+	}
+	sample.Body = string(body)
+	s.samples <- sample
+}
+
+// Samples returns the captured responses. Call only after all requests finish.
+func (s *ResponseSampler) Samples() []ResponseSample {
+	if s == nil {
+		return nil
+	}
+	var out []ResponseSample
+	for {
+		select {
+		case sample := <-s.samples:
+			out = append(out, sample)
+		default:
+			return out
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package request
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// PhaseTimings splits one request into connection phases, in seconds. DNS,
+// Connect and TLS are zero when the phase did not happen: on a reused
+// connection, for an IP address target or for plain HTTP.
+type PhaseTimings struct {
+	DNS      float64
+	Connect  float64
+	TLS      float64
+	TTFB     float64 // from the request being written to the first response byte
+	Transfer float64 // from the first response byte to the end of the body
+	Reused   bool    // the connection came from the pool
+}
+
+type timingsKey struct{}
+
+// WithTimings returns a context that makes ExecuteRequest trace the
+// connection phases of its request into Result.Timings (--detailed-timings).
+// Tracing costs a few clock reads per request, so it is opt-in.
+func WithTimings(ctx context.Context) context.Context {
+	return context.WithValue(ctx, timingsKey{}, true)
+}
+
+func timingsEnabled(ctx context.Context) bool {
+	on, _ := ctx.Value(timingsKey{}).(bool)
+	return on
+}
+
+// phaseTrace collects the httptrace callbacks of one request. The transport
+// may call them from other goroutines (parallel dials, HTTP/2), hence the lock.
+type phaseTrace struct {
+	mu                               sync.Mutex
+	dnsStart, connectStart, tlsStart time.Time
+	wroteRequest, firstByte          time.Time
+	dns, connect, tlsHandshake       time.Duration
+	reused                           bool
+}
+
+func (p *phaseTrace) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			p.mu.Lock()
+			p.reused = info.Reused
+			p.mu.Unlock()
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			p.mu.Lock()
+			p.dnsStart = time.Now()
+			p.mu.Unlock()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			p.mu.Lock()
+			p.dns = time.Since(p.dnsStart)
+			p.mu.Unlock()
+		},
+		ConnectStart: func(_, _ string) {
+			p.mu.Lock()
+			if p.connectStart.IsZero() { // the first of parallel dials
+				p.connectStart = time.Now()
+			}
+			p.mu.Unlock()
+		},
+		ConnectDone: func(_, _ string, err error) {
+			p.mu.Lock()
+			if err == nil && p.connect == 0 {
+				p.connect = time.Since(p.connectStart)
+			}
+			p.mu.Unlock()
+		},
+		TLSHandshakeStart: func() {
+			p.mu.Lock()
+			p.tlsStart = time.Now()
+			p.mu.Unlock()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			p.mu.Lock()
+			p.tlsHandshake = time.Since(p.tlsStart)
+			p.mu.Unlock()
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			p.mu.Lock()
+			p.wroteRequest = time.Now()
+			p.mu.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			p.mu.Lock()
+			p.firstByte = time.Now()
+			p.mu.Unlock()
+		},
+	}
+}
+
+// timings returns the phases of a request whose body was read by end.
+func (p *phaseTrace) timings(end time.Time) *PhaseTimings {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	t := &PhaseTimings{
+		DNS:     p.dns.Seconds(),
+		Connect: p.connect.Seconds(),
+		TLS:     p.tlsHandshake.Seconds(),
+		Reused:  p.reused,
+	}
+	if !p.firstByte.IsZero() {
+		if !p.wroteRequest.IsZero() {
+			t.TTFB = p.firstByte.Sub(p.wroteRequest).Seconds()
+		}
+		t.Transfer = end.Sub(p.firstByte).Seconds()
+	}
+	return t
+}
@@ -0,0 +1,108 @@
+package scenario
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pathSegment is one step of a JSONPath: an object key or an array index.
+type pathSegment struct {
+	key   string
+	index int
+	isIdx bool
+}
+
+// parsePath parses the JSONPath subset used for extraction:
+// $, .key, ['key'] / ["key"] and [n], e.g. $.data.items[0]['id'].
+func parsePath(path string) ([]pathSegment, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("JSONPath %q must start with $", path)
+	}
+
+	var segs []pathSegment
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("JSONPath %q has an empty key", path)
+			}
+			segs = append(segs, pathSegment{key: rest[:end]})
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("JSONPath %q has an unclosed '['", path)
+			}
+			inner := rest[1:end]
+			rest = rest[end+1:]
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				segs = append(segs, pathSegment{key: inner[1 : len(inner)-1]})
+				continue
+			}
+			n, err := strconv.Atoi(inner)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("JSONPath %q has an invalid index [%s]", path, inner)
+			}
+			segs = append(segs, pathSegment{index: n, isIdx: true})
+		default:
+			return nil, fmt.Errorf("JSONPath %q: unexpected %q", path, rest[0])
+		}
+	}
+	return segs, nil
+}
+
+// Extract evaluates path against a JSON document. Strings are returned
+// unquoted; numbers, booleans, objects and arrays as their JSON text.
+func Extract(data []byte, path string) (string, error) {
+	segs, err := parsePath(path)
+	if err != nil {
+		return "", err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var node any
+	if err := dec.Decode(&node); err != nil {
+		return "", fmt.Errorf("response is not valid JSON: %w", err)
+	}
+
+	for _, seg := range segs {
+		if seg.isIdx {
+			arr, ok := node.([]any)
+			if !ok || seg.index >= len(arr) {
+				return "", fmt.Errorf("%s: index [%d] not found", path, seg.index)
+			}
+			node = arr[seg.index]
+			continue
+		}
+		obj, ok := node.(map[string]any)
+		if !ok {
+			return "", fmt.Errorf("%s: key %q not found", path, seg.key)
+		}
+		if node, ok = obj[seg.key]; !ok {
+			return "", fmt.Errorf("%s: key %q not found", path, seg.key)
+		}
+	}
+
+	switch v := node.(type) {
+	case string:
+		return v, nil
+	case nil:
+		return "", fmt.Errorf("%s: value is null", path)
+	default:
+		out, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(out), nil
+	}
+}
@@ -0,0 +1,232 @@
+// Package scenario loads multi-step request chains from YAML and executes
+// them, threading values extracted from one response into later steps.
+//
+// Example scenario file:
+//
+//	base_url: https://api.example.com
+//	steps:
+//	  - name: login
+//	    method: POST
+//	    url: "{{.BaseURL}}/login"
+//	    content_type: application/json
+//	    body: '{"user":"alice","password":"secret"}'
+//	    extract:
+//	      token: $.data.token
+//	  - name: profile
+//	    url: "{{.BaseURL}}/me"
+//	    headers:
+//	      Authorization: "Bearer {{.token}}"
+package scenario
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"api-stress-test/internal/request"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// baseURLVar is the template variable holding the scenario base URL.
+const baseURLVar = "BaseURL"
+
+// validVarName restricts extracted variable names to template-friendly identifiers.
+var validVarName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Step is a single request in a scenario. URL, header values and body are
+// Go templates evaluated against {{.BaseURL}} and previously extracted values.
+type Step struct {
+	Name         string            `yaml:"name"`
+	Method       string            `yaml:"method"`
+	URL          string            `yaml:"url"`
+	Headers      map[string]string `yaml:"headers"`
+	Body         string            `yaml:"body"`
+	ContentType  string            `yaml:"content_type"`
+	ExpectStatus int               `yaml:"expect_status"`
+	Extract      map[string]string `yaml:"extract"` // variable name -> JSONPath
+
+	urlTmpl     *template.Template
+	bodyTmpl    *template.Template
+	headerTmpls map[string]*template.Template
+}
+
+// Scenario is an ordered list of steps executed as one iteration.
+type Scenario struct {
+	BaseURL string `yaml:"base_url"`
+	Steps   []Step `yaml:"steps"`
+}
+
+// Load reads and validates a scenario file.
+func Load(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading scenario: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse decodes a YAML scenario, applies defaults and pre-compiles templates.
+func Parse(data []byte) (*Scenario, error) {
+	var s Scenario
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&s); err != nil {
+		return nil, fmt.Errorf("parsing scenario: %w", err)
+	}
+	if len(s.Steps) == 0 {
+		return nil, fmt.Errorf("scenario has no steps")
+	}
+
+	for i := range s.Steps {
+		if err := s.Steps[i].compile(i); err != nil {
+			return nil, err
+		}
+	}
+	return &s, nil
+}
+
+// compile fills defaults and parses the step templates.
+func (st *Step) compile(index int) error {
+	if st.Name == "" {
+		st.Name = fmt.Sprintf("step %d", index+1)
+	}
+	if st.URL == "" {
+		return fmt.Errorf("%s: url is required", st.Name)
+	}
+	st.Method = strings.ToUpper(st.Method)
+	if st.Method == "" {
+		st.Method = http.MethodGet
+	}
+	for name, path := range st.Extract {
+		if !validVarName.MatchString(name) || name == baseURLVar {
+			return fmt.Errorf("%s: invalid extract variable name %q", st.Name, name)
+		}
+		if _, err := parsePath(path); err != nil {
+			return fmt.Errorf("%s: extract %s: %w", st.Name, name, err)
+		}
+	}
+
+	var err error
+	if st.urlTmpl, err = newTemplate(st.Name+" url", st.URL); err != nil {
+		return err
+	}
+	if st.bodyTmpl, err = newTemplate(st.Name+" body", st.Body); err != nil {
+		return err
+	}
+	st.headerTmpls = make(map[string]*template.Template, len(st.Headers))
+	for k, v := range st.Headers {
+		if st.headerTmpls[k], err = newTemplate(st.Name+" header "+k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newTemplate(name, text string) (*template.Template, error) {
+	t, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parsing template %s: %w", name, err)
+	}
+	return t, nil
+}
+
+func render(t *template.Template, vars map[string]string) (string, error) {
+	var buf strings.Builder
+	if err := t.Execute(&buf, vars); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Run executes every step once, in order, passing each result to emit.
+// A failed step ends the iteration since later steps usually depend on it.
+// expect holds the global checks; a step's own expect_status takes
+// precedence over expect.Status.
+func (s *Scenario) Run(
+	ctx context.Context,
+	client *http.Client,
+	expect request.Expectations,
+	sampler *request.ResponseSampler,
+	emit func(request.Result),
+) {
+	vars := map[string]string{baseURLVar: s.BaseURL}
+
+	for i := range s.Steps {
+		if ctx.Err() != nil {
+			return
+		}
+		st := &s.Steps[i]
+
+		res, ok := st.execute(ctx, client, vars, expect, sampler)
+		emit(res)
+		if !ok {
+			return
+		}
+	}
+}
+
+// execute renders and sends one step, storing extracted values into vars.
+func (st *Step) execute(
+	ctx context.Context,
+	client *http.Client,
+	vars map[string]string,
+	expect request.Expectations,
+	sampler *request.ResponseSampler,
+) (request.Result, bool) {
+	targetURL, err := render(st.urlTmpl, vars)
+	if err != nil {
+		return st.failure(err), false
+	}
+	body, err := render(st.bodyTmpl, vars)
+	if err != nil {
+		return st.failure(err), false
+	}
+	headers := make(map[string]string, len(st.headerTmpls))
+	for k, t := range st.headerTmpls {
+		if headers[k], err = render(t, vars); err != nil {
+			return st.failure(err), false
+		}
+	}
+
+	if st.ExpectStatus > 0 {
+		expect.Status = request.StatusSet{{Min: st.ExpectStatus, Max: st.ExpectStatus}}
+	}
+	var bodyBytes []byte
+	if body != "" {
+		bodyBytes = []byte(body)
+	}
+
+	res := request.ExecuteRequest(ctx, client, st.Method, targetURL, headers, bodyBytes, st.ContentType,
+		expect, len(st.Extract) > 0, sampler)
+	respBody := res.Body
+	res.Body = nil
+	if !res.OK {
+		if res.Error != "" {
+			res.Error = st.Name + ": " + res.Error
+		}
+		return res, false
+	}
+
+	for name, path := range st.Extract {
+		value, err := Extract(respBody, path)
+		if err != nil {
+			res.OK = false
+			res.ErrorKind = request.ErrorBody
+			res.Error = fmt.Sprintf("%s: extract %s: %v", st.Name, name, err)
+			return res, false
+		}
+		vars[name] = value
+	}
+	return res, true
+}
+
+func (st *Step) failure(err error) request.Result {
+	return request.Result{Error: fmt.Sprintf("%s: %v", st.Name, err), CompletedAt: time.Now(), ErrorKind: request.ErrorOther}
+}
@@ -0,0 +1,193 @@
+package scenario
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"api-stress-test/internal/request"
+)
+
+func TestParse(t *testing.T) {
+	s, err := Parse([]byte(`
+base_url: http://example.com
+steps:
+  - url: "{{.BaseURL}}/health"
+  - name: login
+    method: post
+    url: "{{.BaseURL}}/login"
+    extract:
+      token: $.token
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(s.Steps) != 2 {
+		t.Fatalf("got %d steps, want 2", len(s.Steps))
+	}
+	if s.Steps[0].Name != "step 1" || s.Steps[0].Method != "GET" {
+		t.Errorf("defaults not applied: %+v", s.Steps[0])
+	}
+	if s.Steps[1].Method != "POST" {
+		t.Errorf("method = %q, want POST", s.Steps[1].Method)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{"no steps", "base_url: http://example.com\n", "no steps"},
+		{"missing url", "steps:\n  - name: a\n", "url is required"},
+		{"unknown field", "steps:\n  - url: /x\n    mehtod: GET\n", "mehtod"},
+		{"bad template", "steps:\n  - url: \"{{.BaseURL\"\n", "parsing template"},
+		{"bad variable name", "steps:\n  - url: /x\n    extract:\n      BaseURL: $.a\n", "invalid extract variable"},
+		{"bad path", "steps:\n  - url: /x\n    extract:\n      id: data.id\n", "must start with $"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.yaml))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Parse error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtract(t *testing.T) {
+	doc := []byte(`{"data":{"token":"abc","id":42,"ok":true,"items":[{"name":"x"},{"name":"y"}],"key.with.dots":"d"}}`)
+
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{path: "$.data.token", want: "abc"},
+		{path: "$.data.id", want: "42"},
+		{path: "$.data.ok", want: "true"},
+		{path: "$.data.items[1].name", want: "y"},
+		{path: "$.data['key.with.dots']", want: "d"},
+		{path: "$.data.items[0]", want: `{"name":"x"}`},
+		{path: "$.data.missing", wantErr: true},
+		{path: "$.data.items[5]", wantErr: true},
+		{path: "$.data.token.x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := Extract(doc, tt.path)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Extract(%q) = %q, want error", tt.path, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Extract(%q) unexpected error: %v", tt.path, err)
+			}
+			if got != tt.want {
+				t.Errorf("Extract(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRun_ChainsExtractedValues(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			body, _ := io.ReadAll(r.Body)
+			if string(body) != `{"user":"alice"}` {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			fmt.Fprint(w, `{"data":{"token":"t-123","user_id":7}}`)
+		case "/users/7":
+			if r.Header.Get("Authorization") != "Bearer t-123" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{"name":"alice"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	s, err := Parse([]byte(`
+steps:
+  - name: login
+    method: POST
+    url: "{{.BaseURL}}/login"
+    content_type: application/json
+    body: '{"user":"alice"}'
+    extract:
+      token: $.data.token
+      uid: $.data.user_id
+  - name: profile
+    url: "{{.BaseURL}}/users/{{.uid}}"
+    headers:
+      Authorization: "Bearer {{.token}}"
+`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	s.BaseURL = server.URL
+
+	var results []request.Result
+	s.Run(context.Background(), server.Client(), request.Expectations{}, nil, func(r request.Result) {
+		results = append(results, r)
+	})
+
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	for i, r := range results {
+		if !r.OK {
+			t.Errorf("step %d failed: status=%d error=%q", i, r.StatusCode, r.Error)
+		}
+		if r.Body != nil {
+			t.Errorf("step %d result kept its body", i)
+		}
+	}
+}
+
+func TestRun_StopsAfterFailedStep(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"other":1}`)
+	}))
+	defer server.Close()
+
+	s, err := Parse([]byte(`
+steps:
+  - name: login
+    url: "{{.BaseURL}}/login"
+    extract:
+      token: $.token
+  - name: profile
+    url: "{{.BaseURL}}/me"
+`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	s.BaseURL = server.URL
+
+	var results []request.Result
+	s.Run(context.Background(), server.Client(), request.Expectations{}, nil, func(r request.Result) {
+		results = append(results, r)
+	})
+
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1 (chain should stop)", len(results))
+	}
+	if results[0].OK || !strings.HasPrefix(results[0].Error, "login: extract token:") {
+		t.Errorf("unexpected result: %+v", results[0])
+	}
+}
@@ -3,6 +3,7 @@
 package stats
 
 import (
+	"math"
 	"math/rand/v2"
 	"sort"
 	"sync"
@@ -17,6 +18,9 @@ import (
 // workloads while keeping memory bounded regardless of total request count.
 const reservoirSize = 10000
 
+// defaultHistogramBuckets is the bucket count used for Statistics.Histogram.
+const defaultHistogramBuckets = 10
+
 // Collector collects and calculates statistics for stress test results.
 // It is thread-safe and designed to handle concurrent result recording.
 // Uses reservoir sampling to bound memory for latency percentiles.
@@ -24,17 +28,67 @@ type Collector struct {
 	mu                sync.Mutex
 	successes         int64
 	failures          int64
-	totalCount        int64           // Total requests recorded
-	reservoir         []float64       // Reservoir-sampled latencies (max reservoirSize)
-	latencySum        float64         // Running sum for average calculation
-	statusCount       map[int]int     // Distribution of HTTP status codes
-	errorMessages     map[string]int  // Error message frequency
+	totalCount        int64          // Total requests recorded
+	reservoir         []float64      // Reservoir-sampled latencies (max reservoirSize)
+	latencySum        float64        // Running sum for average calculation
+	statusCount       map[int]int    // Distribution of HTTP status codes
+	errorMessages     map[string]int // Error message frequency
+	failureKinds      map[string]int // Failures per kind (timeout, dns, ...)
+	protocols         map[string]int // Responses per negotiated protocol (HTTP/1.1, HTTP/2.0)
 	minLatency        float64
 	maxLatency        float64
 	firstLatency      bool
-	startTime         int64           // Unix timestamp when first record was added
-	throughput        map[int]int     // Per-second request counts (second offset -> count)
-	totalResponseSize int64           // Total response body bytes received
+	timeline          map[int64]*secondBucket // Results by Unix second of completion
+	totalResponseSize int64                   // Total response body bytes received
+
+	// records holds every result when keepRecords is set (--raw). Unlike the
+	// reservoir it grows with the run, so it is opt-in.
+	keepRecords bool
+	records     []RequestRecord
+
+	// The current progress window: results since the last TakeWindow, with a
+	// bounded latency sample for its median. windows keeps every sample taken.
+	created         time.Time
+	windowStart     time.Time
+	windowCount     int64
+	windowSuccesses int64
+	windowLatencies []float64
+	windows         []WindowSample
+
+	// timings is allocated by the first RecordTimings (--detailed-timings)
+	timings *phaseTimings
+}
+
+// windowSampleSize bounds the latencies kept per progress window; beyond it
+// the window median is taken from a reservoir sample.
+const windowSampleSize = 2000
+
+// WindowSample summarizes the results recorded during one progress interval
+// (about a second), for the live progress line and the JSON timeline.
+type WindowSample struct {
+	Second         int     `json:"second"` // seconds since the collector was created, at the end of the window
+	Requests       int64   `json:"requests"`
+	RequestsPerSec float64 `json:"requests_per_second"`
+	SuccessRate    float64 `json:"success_rate"` // percent; 0 when the window is empty
+	P50Latency     float64 `json:"p50_latency"`
+}
+
+// secondBucket accumulates the results that completed within one wall-clock
+// second, for Statistics.Throughput.
+type secondBucket struct {
+	requests   int
+	errors     int
+	latencySum float64
+}
+
+// RequestRecord is one recorded result, kept by Collector.KeepRecords.
+type RequestRecord struct {
+	Time         time.Time
+	StatusCode   int
+	Elapsed      float64 // seconds
+	OK           bool
+	Error        string
+	ResponseSize int64
 }
 
 // NewCollector creates a new statistics collector.
@@ -43,32 +97,110 @@ func NewCollector(initialCapacity int) *Collector {
 	if cap > reservoirSize {
 		cap = reservoirSize
 	}
+	now := time.Now()
 	return &Collector{
 		reservoir:     make([]float64, 0, cap),
 		statusCount:   make(map[int]int),
 		errorMessages: make(map[string]int),
-		throughput:    make(map[int]int),
+		failureKinds:  make(map[string]int),
+		protocols:     make(map[string]int),
+		timeline:      make(map[int64]*secondBucket),
 		firstLatency:  true,
+		created:       now,
+		windowStart:   now,
 	}
 }
 
+// TakeWindow closes the current progress window at now, records its summary
+// for Windows and starts the next one.
+func (c *Collector) TakeWindow(now time.Time) WindowSample {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sample := WindowSample{
+		Second:   int(now.Sub(c.created).Round(time.Second) / time.Second),
+		Requests: c.windowCount,
+	}
+	if dt := now.Sub(c.windowStart).Seconds(); dt > 0 {
+		sample.RequestsPerSec = float64(c.windowCount) / dt
+	}
+	if c.windowCount > 0 {
+		sample.SuccessRate = float64(c.windowSuccesses) / float64(c.windowCount) * 100
+		sort.Float64s(c.windowLatencies)
+		sample.P50Latency = percentile(c.windowLatencies, 0.50)
+	}
+	c.windows = append(c.windows, sample)
+
+	c.windowStart = now
+	c.windowCount = 0
+	c.windowSuccesses = 0
+	c.windowLatencies = c.windowLatencies[:0]
+	return sample
+}
+
+// Windows returns every sample taken by TakeWindow, oldest first.
+func (c *Collector) Windows() []WindowSample {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]WindowSample(nil), c.windows...)
+}
+
+// KeepRecords makes the collector retain every result for Records. Call it
+// before recording starts.
+func (c *Collector) KeepRecords() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keepRecords = true
+}
+
+// Records returns a copy of the results retained since KeepRecords, in
+// recording order.
+func (c *Collector) Records() []RequestRecord {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]RequestRecord(nil), c.records...)
+}
+
 // Record adds a request result to the collector in a thread-safe manner.
-func (c *Collector) Record(statusCode int, elapsed float64, ok bool, errorMsg string, responseSize int64) {
-	now := time.Now().Unix() // Computed before lock to reduce mutex contention
+// completedAt is when the request finished; results are recorded in batches,
+// so it places the result in the per-second timeline more precisely than the
+// time of the call would. A zero completedAt means now. errorKind names the
+// failure category of a result that is not ok; empty counts as "other".
+func (c *Collector) Record(completedAt time.Time, statusCode int, elapsed float64, ok bool, errorMsg, errorKind string, responseSize int64) {
+	if completedAt.IsZero() {
+		completedAt = time.Now() // Computed before lock to reduce mutex contention
+	}
+	sec := completedAt.Unix()
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if c.keepRecords {
+		c.records = append(c.records, RequestRecord{
+			Time:         completedAt,
+			StatusCode:   statusCode,
+			Elapsed:      elapsed,
+			OK:           ok,
+			Error:        errorMsg,
+			ResponseSize: responseSize,
+		})
+	}
+
 	c.totalCount++
 	c.latencySum += elapsed
 	c.totalResponseSize += responseSize
 	c.statusCount[statusCode]++
 
-	// Track throughput per second
-	if c.startTime == 0 {
-		c.startTime = now
+	// Track the per-second timeline
+	bucket := c.timeline[sec]
+	if bucket == nil {
+		bucket = &secondBucket{}
+		c.timeline[sec] = bucket
+	}
+	bucket.requests++
+	bucket.latencySum += elapsed
+	if !ok {
+		bucket.errors++
 	}
-	sec := int(now - c.startTime)
-	c.throughput[sec]++
 
 	// Reservoir sampling: keep exactly reservoirSize samples with uniform probability.
 	// When total requests exceed reservoirSize, each new sample replaces an existing
@@ -83,6 +215,16 @@ func (c *Collector) Record(statusCode int, elapsed float64, ok bool, errorMsg st
 		}
 	}
 
+	c.windowCount++
+	if ok {
+		c.windowSuccesses++
+	}
+	if len(c.windowLatencies) < windowSampleSize {
+		c.windowLatencies = append(c.windowLatencies, elapsed)
+	} else if j := rand.IntN(int(c.windowCount)); j < windowSampleSize {
+		c.windowLatencies[j] = elapsed
+	}
+
 	if errorMsg != "" {
 		c.errorMessages[errorMsg]++
 	}
@@ -104,15 +246,43 @@ func (c *Collector) Record(statusCode int, elapsed float64, ok bool, errorMsg st
 		c.successes++
 	} else {
 		c.failures++
+		if errorKind == "" {
+			errorKind = "other"
+		}
+		c.failureKinds[errorKind]++
 	}
 }
 
+// RecordProtocol counts a response by its negotiated protocol, as in
+// http.Response.Proto ("HTTP/1.1", "HTTP/2.0"). Requests that got no
+// response have no protocol and are not counted.
+func (c *Collector) RecordProtocol(proto string) {
+	if proto == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.protocols[proto]++
+}
+
 // ErrorEntry represents an error message and its occurrence count.
 type ErrorEntry struct {
 	Message string `json:"message"`
 	Count   int    `json:"count"`
 }
 
+// FailureKindCount is the number of failures of one kind, e.g. "timeout".
+type FailureKindCount struct {
+	Kind  string `json:"kind"`
+	Count int    `json:"count"`
+}
+
+// ProtocolCount is the number of responses that used one protocol.
+type ProtocolCount struct {
+	Proto string `json:"proto"`
+	Count int    `json:"count"`
+}
+
 // HistogramBucket represents a single bucket in a latency histogram.
 type HistogramBucket struct {
 	MinSec float64 `json:"min_sec"`
@@ -120,33 +290,42 @@ type HistogramBucket struct {
 	Count  int     `json:"count"`
 }
 
-// ThroughputEntry records requests completed in a one-second interval.
+// ThroughputEntry summarizes the requests completed in a one-second interval.
 type ThroughputEntry struct {
-	Second   int `json:"second"`
-	Requests int `json:"requests"`
+	Second     int     `json:"second"` // 1 for the second of the first completed request
+	Requests   int     `json:"requests"`
+	Errors     int     `json:"errors"`      // results that did not count as a success
+	AvgLatency float64 `json:"avg_latency"` // 0 for a second without results
 }
 
 // Statistics holds the calculated final statistics from a stress test run.
 type Statistics struct {
-	Successes          int64             `json:"successes"`
-	Failures           int64             `json:"failures"`
-	Total              int64             `json:"total"`
-	SuccessRate        float64           `json:"success_rate"`
-	StatusCount        map[int]int       `json:"status_count"`
-	MinLatency         float64           `json:"min_latency"`
-	MaxLatency         float64           `json:"max_latency"`
-	AvgLatency         float64           `json:"avg_latency"`
-	P50Latency         float64           `json:"p50_latency"`
-	P90Latency         float64           `json:"p90_latency"`
-	P95Latency         float64           `json:"p95_latency"`
-	P99Latency         float64           `json:"p99_latency"`
-	TopErrors          []ErrorEntry      `json:"top_errors,omitempty"`
+	Successes   int64        `json:"successes"`
+	Failures    int64        `json:"failures"`
+	Total       int64        `json:"total"`
+	SuccessRate float64      `json:"success_rate"`
+	StatusCount map[int]int  `json:"status_count"`
+	MinLatency  float64      `json:"min_latency"`
+	MaxLatency  float64      `json:"max_latency"`
+	AvgLatency  float64      `json:"avg_latency"`
+	P50Latency  float64      `json:"p50_latency"`
+	P90Latency  float64      `json:"p90_latency"`
+	P95Latency  float64      `json:"p95_latency"`
+	P99Latency  float64      `json:"p99_latency"`
+	TopErrors   []ErrorEntry `json:"top_errors,omitempty"`
+	// FailureBreakdown counts every failure by kind, most frequent first.
+	FailureBreakdown []FailureKindCount `json:"failure_breakdown,omitempty"`
+	// Protocols counts responses by negotiated protocol, most frequent first.
+	Protocols []ProtocolCount `json:"protocols,omitempty"`
 	// Histogram buckets use reservoir-sampled data and are approximate
 	// when total requests exceed 10,000.
 	Histogram          []HistogramBucket `json:"histogram,omitempty"`
 	Throughput         []ThroughputEntry `json:"throughput,omitempty"`
 	AvgResponseBytes   int64             `json:"avg_response_bytes"`
 	TotalResponseBytes int64             `json:"total_response_bytes"`
+
+	// Timings is set when connection phases were recorded (--detailed-timings).
+	Timings *TimingBreakdown `json:"timings,omitempty"`
 }
 
 // GetStatistics calculates and returns final statistics from all collected results.
@@ -190,21 +369,48 @@ func (c *Collector) GetStatistics() Statistics {
 		topErrors = topErrors[:5]
 	}
 
+	var failureBreakdown []FailureKindCount
+	for kind, count := range c.failureKinds {
+		failureBreakdown = append(failureBreakdown, FailureKindCount{Kind: kind, Count: count})
+	}
+	sort.Slice(failureBreakdown, func(i, j int) bool {
+		if failureBreakdown[i].Count != failureBreakdown[j].Count {
+			return failureBreakdown[i].Count > failureBreakdown[j].Count
+		}
+		return failureBreakdown[i].Kind < failureBreakdown[j].Kind
+	})
+
+	var protocols []ProtocolCount
+	for proto, count := range c.protocols {
+		protocols = append(protocols, ProtocolCount{Proto: proto, Count: count})
+	}
+	sort.Slice(protocols, func(i, j int) bool {
+		if protocols[i].Count != protocols[j].Count {
+			return protocols[i].Count > protocols[j].Count
+		}
+		return protocols[i].Proto < protocols[j].Proto
+	})
+
 	// Build histogram from sorted reservoir
-	histogram := buildHistogram(sorted, c.minLatency, c.maxLatency)
+	histogram := buildHistogram(sorted, c.minLatency, c.maxLatency, defaultHistogramBuckets)
 
-	// Build throughput timeline
+	// Build the throughput timeline, including seconds without results
 	var throughput []ThroughputEntry
-	if len(c.throughput) > 0 {
-		maxSec := 0
-		for s := range c.throughput {
-			if s > maxSec {
-				maxSec = s
-			}
+	if len(c.timeline) > 0 {
+		first, last := int64(math.MaxInt64), int64(math.MinInt64)
+		for s := range c.timeline {
+			first = min(first, s)
+			last = max(last, s)
 		}
-		throughput = make([]ThroughputEntry, 0, maxSec+1)
-		for s := 0; s <= maxSec; s++ {
-			throughput = append(throughput, ThroughputEntry{Second: s + 1, Requests: c.throughput[s]})
+		throughput = make([]ThroughputEntry, 0, last-first+1)
+		for s := first; s <= last; s++ {
+			entry := ThroughputEntry{Second: int(s-first) + 1}
+			if b := c.timeline[s]; b != nil {
+				entry.Requests = b.requests
+				entry.Errors = b.errors
+				entry.AvgLatency = b.latencySum / float64(b.requests)
+			}
+			throughput = append(throughput, entry)
 		}
 	}
 
@@ -229,33 +435,47 @@ func (c *Collector) GetStatistics() Statistics {
 		P95Latency:         p95,
 		P99Latency:         p99,
 		TopErrors:          topErrors,
+		FailureBreakdown:   failureBreakdown,
+		Protocols:          protocols,
 		Histogram:          histogram,
 		Throughput:         throughput,
 		AvgResponseBytes:   avgResponseBytes,
 		TotalResponseBytes: c.totalResponseSize,
+		Timings:            c.timings.breakdown(),
 	}
 }
 
-// buildHistogram creates 10 equal-width buckets spanning [min, max].
-func buildHistogram(sorted []float64, minVal, maxVal float64) []HistogramBucket {
-	if len(sorted) == 0 {
+// LatencyHistogram buckets the sampled latencies into numBuckets equal-width
+// ranges spanning the observed min/max latency.
+func (c *Collector) LatencyHistogram(numBuckets int) []HistogramBucket {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.totalCount == 0 || numBuckets <= 0 {
+		return nil
+	}
+	return buildHistogram(c.reservoir, c.minLatency, c.maxLatency, numBuckets)
+}
+
+// buildHistogram creates numBuckets equal-width buckets spanning [min, max].
+func buildHistogram(samples []float64, minVal, maxVal float64, numBuckets int) []HistogramBucket {
+	if len(samples) == 0 {
 		return nil
 	}
 
-	const numBuckets = 10
 	span := maxVal - minVal
 	if span <= 0 {
-		return []HistogramBucket{{MinSec: minVal, MaxSec: maxVal, Count: len(sorted)}}
+		return []HistogramBucket{{MinSec: minVal, MaxSec: maxVal, Count: len(samples)}}
 	}
 
-	bucketWidth := span / numBuckets
+	bucketWidth := span / float64(numBuckets)
 	buckets := make([]HistogramBucket, numBuckets)
 	for i := range buckets {
 		buckets[i].MinSec = minVal + float64(i)*bucketWidth
 		buckets[i].MaxSec = minVal + float64(i+1)*bucketWidth
 	}
 
-	for _, v := range sorted {
+	for _, v := range samples {
 		idx := int((v - minVal) / bucketWidth)
 		if idx >= numBuckets {
 			idx = numBuckets - 1
@@ -1,17 +1,19 @@
 package stats
 
 import (
+	"math"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestCollectorRecord(t *testing.T) {
 	c := NewCollector(10)
 
-	c.Record(200, 0.1, true, "", 100)
-	c.Record(200, 0.2, true, "", 200)
-	c.Record(500, 0.3, false, "server error", 0)
-	c.Record(0, 0.05, false, "connection refused", 0)
+	c.Record(time.Time{}, 200, 0.1, true, "", "", 100)
+	c.Record(time.Time{}, 200, 0.2, true, "", "", 200)
+	c.Record(time.Time{}, 500, 0.3, false, "server error", "http_status", 0)
+	c.Record(time.Time{}, 0, 0.05, false, "connection refused", "connection_refused", 0)
 
 	stat := c.GetStatistics()
 
@@ -35,12 +37,51 @@ func TestCollectorRecord(t *testing.T) {
 	}
 }
 
+func TestCollectorFailureBreakdown(t *testing.T) {
+	c := NewCollector(10)
+	c.Record(time.Time{}, 200, 0.1, true, "", "", 0)
+	c.Record(time.Time{}, 0, 0.1, false, "request timeout", "timeout", 0)
+	c.Record(time.Time{}, 0, 0.1, false, "request timeout", "timeout", 0)
+	c.Record(time.Time{}, 0, 0.1, false, "DNS resolution failed", "dns", 0)
+	c.Record(time.Time{}, 0, 0.1, false, "boom", "", 0) // no kind: other
+
+	want := []FailureKindCount{{"timeout", 2}, {"dns", 1}, {"other", 1}}
+	got := c.GetStatistics().FailureBreakdown
+	if len(got) != len(want) {
+		t.Fatalf("failure breakdown = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("failure breakdown[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestCollectorProtocols(t *testing.T) {
+	c := NewCollector(10)
+	for _, proto := range []string{"HTTP/2.0", "HTTP/1.1", "HTTP/2.0", ""} {
+		c.RecordProtocol(proto)
+	}
+	c.Record(time.Time{}, 200, 0.1, true, "", "", 0)
+
+	want := []ProtocolCount{{"HTTP/2.0", 2}, {"HTTP/1.1", 1}}
+	got := c.GetStatistics().Protocols
+	if len(got) != len(want) {
+		t.Fatalf("protocols = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("protocols[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
 func TestCollectorMinMaxLatency(t *testing.T) {
 	c := NewCollector(5)
 
-	c.Record(200, 0.5, true, "", 0)
-	c.Record(200, 0.1, true, "", 0)
-	c.Record(200, 0.9, true, "", 0)
+	c.Record(time.Time{}, 200, 0.5, true, "", "", 0)
+	c.Record(time.Time{}, 200, 0.1, true, "", "", 0)
+	c.Record(time.Time{}, 200, 0.9, true, "", "", 0)
 
 	stat := c.GetStatistics()
 
@@ -55,9 +96,9 @@ func TestCollectorMinMaxLatency(t *testing.T) {
 func TestCollectorAvgLatency(t *testing.T) {
 	c := NewCollector(3)
 
-	c.Record(200, 0.1, true, "", 0)
-	c.Record(200, 0.2, true, "", 0)
-	c.Record(200, 0.3, true, "", 0)
+	c.Record(time.Time{}, 200, 0.1, true, "", "", 0)
+	c.Record(time.Time{}, 200, 0.2, true, "", "", 0)
+	c.Record(time.Time{}, 200, 0.3, true, "", "", 0)
 
 	stat := c.GetStatistics()
 
@@ -71,12 +112,12 @@ func TestCollectorErrorTracking(t *testing.T) {
 	c := NewCollector(10)
 
 	for i := 0; i < 5; i++ {
-		c.Record(0, 0.1, false, "connection refused", 0)
+		c.Record(time.Time{}, 0, 0.1, false, "connection refused", "", 0)
 	}
 	for i := 0; i < 3; i++ {
-		c.Record(0, 0.1, false, "timeout", 0)
+		c.Record(time.Time{}, 0, 0.1, false, "timeout", "", 0)
 	}
-	c.Record(0, 0.1, false, "dns error", 0)
+	c.Record(time.Time{}, 0, 0.1, false, "dns error", "", 0)
 
 	stat := c.GetStatistics()
 
@@ -96,7 +137,7 @@ func TestCollectorTopErrorsMaxFive(t *testing.T) {
 
 	errors := []string{"err1", "err2", "err3", "err4", "err5", "err6", "err7"}
 	for _, e := range errors {
-		c.Record(0, 0.1, false, e, 0)
+		c.Record(time.Time{}, 0, 0.1, false, e, "", 0)
 	}
 
 	stat := c.GetStatistics()
@@ -117,7 +158,7 @@ func TestCollectorConcurrency(t *testing.T) {
 		go func() {
 			defer wg.Done()
 			for j := 0; j < recordsPerGoroutine; j++ {
-				c.Record(200, 0.1, true, "", 0)
+				c.Record(time.Time{}, 200, 0.1, true, "", "", 0)
 			}
 		}()
 	}
@@ -173,7 +214,7 @@ func TestPercentile(t *testing.T) {
 func TestCollectorP95(t *testing.T) {
 	c := NewCollector(100)
 	for i := 1; i <= 100; i++ {
-		c.Record(200, float64(i)*0.01, true, "", 0)
+		c.Record(time.Time{}, 200, float64(i)*0.01, true, "", "", 0)
 	}
 
 	stat := c.GetStatistics()
@@ -185,10 +226,10 @@ func TestCollectorP95(t *testing.T) {
 func TestCollectorSuccessRate(t *testing.T) {
 	c := NewCollector(10)
 	for i := 0; i < 7; i++ {
-		c.Record(200, 0.1, true, "", 0)
+		c.Record(time.Time{}, 200, 0.1, true, "", "", 0)
 	}
 	for i := 0; i < 3; i++ {
-		c.Record(500, 0.1, false, "error", 0)
+		c.Record(time.Time{}, 500, 0.1, false, "error", "", 0)
 	}
 
 	stat := c.GetStatistics()
@@ -199,9 +240,9 @@ func TestCollectorSuccessRate(t *testing.T) {
 
 func TestCollectorResponseSize(t *testing.T) {
 	c := NewCollector(10)
-	c.Record(200, 0.1, true, "", 1000)
-	c.Record(200, 0.1, true, "", 2000)
-	c.Record(200, 0.1, true, "", 3000)
+	c.Record(time.Time{}, 200, 0.1, true, "", "", 1000)
+	c.Record(time.Time{}, 200, 0.1, true, "", "", 2000)
+	c.Record(time.Time{}, 200, 0.1, true, "", "", 3000)
 
 	stat := c.GetStatistics()
 	if stat.TotalResponseBytes != 6000 {
@@ -212,10 +253,60 @@ func TestCollectorResponseSize(t *testing.T) {
 	}
 }
 
+func TestCollectorKeepRecords(t *testing.T) {
+	c := NewCollector(10)
+	c.Record(time.Time{}, 200, 0.1, true, "", "", 10) // before KeepRecords: not retained
+	c.KeepRecords()
+	c.Record(time.Time{}, 500, 0.2, false, "HTTP 500", "", 20)
+	c.Record(time.Time{}, 0, 0.3, false, "timeout", "", 0)
+
+	records := c.Records()
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2: %+v", len(records), records)
+	}
+	if r := records[0]; r.StatusCode != 500 || r.Elapsed != 0.2 || r.OK || r.Error != "HTTP 500" || r.ResponseSize != 20 || r.Time.IsZero() {
+		t.Errorf("records[0] = %+v", r)
+	}
+	if records[1].Error != "timeout" {
+		t.Errorf("records[1].Error = %q, want timeout", records[1].Error)
+	}
+	if c.GetStatistics().Total != 3 {
+		t.Error("records must not change the statistics")
+	}
+}
+
+func TestCollectorTakeWindow(t *testing.T) {
+	c := NewCollector(10)
+	c.Record(time.Time{}, 200, 0.010, true, "", "", 0)
+	c.Record(time.Time{}, 200, 0.030, true, "", "", 0)
+	c.Record(time.Time{}, 500, 0.020, false, "", "", 0)
+	c.Record(time.Time{}, 200, 0.040, true, "", "", 0)
+
+	first := c.TakeWindow(c.created.Add(2 * time.Second))
+	if first.Second != 2 || first.Requests != 4 || first.RequestsPerSec != 2 || first.SuccessRate != 75 {
+		t.Errorf("first window = %+v, want second 2, 4 requests at 2 req/s, 75%% ok", first)
+	}
+	if first.P50Latency != 0.025 {
+		t.Errorf("first window p50 = %v, want 0.025", first.P50Latency)
+	}
+
+	// The next window starts empty
+	second := c.TakeWindow(c.created.Add(3 * time.Second))
+	if second.Requests != 0 || second.SuccessRate != 0 || second.P50Latency != 0 {
+		t.Errorf("second window = %+v, want empty", second)
+	}
+	if got := c.Windows(); len(got) != 2 || got[0] != first || got[1] != second {
+		t.Errorf("Windows() = %+v, want both samples", got)
+	}
+	if c.GetStatistics().Total != 4 {
+		t.Error("taking windows must not change the statistics")
+	}
+}
+
 func TestCollectorReservoirSampling(t *testing.T) {
 	c := NewCollector(100)
 	for i := 0; i < 15000; i++ {
-		c.Record(200, float64(i)*0.0001, true, "", 0)
+		c.Record(time.Time{}, 200, float64(i)*0.0001, true, "", "", 0)
 	}
 
 	stat := c.GetStatistics()
@@ -230,7 +321,7 @@ func TestCollectorReservoirSampling(t *testing.T) {
 func TestCollectorHistogramSingleValue(t *testing.T) {
 	c := NewCollector(10)
 	for i := 0; i < 10; i++ {
-		c.Record(200, 0.5, true, "", 0)
+		c.Record(time.Time{}, 200, 0.5, true, "", "", 0)
 	}
 
 	stat := c.GetStatistics()
@@ -242,10 +333,39 @@ func TestCollectorHistogramSingleValue(t *testing.T) {
 	}
 }
 
+func TestCollectorLatencyHistogramBuckets(t *testing.T) {
+	c := NewCollector(100)
+	for i := 0; i <= 100; i++ {
+		c.Record(time.Time{}, 200, float64(i)/1000, true, "", "", 0)
+	}
+
+	hist := c.LatencyHistogram(20)
+	if len(hist) != 20 {
+		t.Fatalf("expected 20 buckets, got %d", len(hist))
+	}
+	if hist[0].MinSec != 0 || hist[19].MaxSec != 0.1 {
+		t.Errorf("bucket span = [%f, %f], want [0, 0.1]", hist[0].MinSec, hist[19].MaxSec)
+	}
+	total := 0
+	for _, b := range hist {
+		total += b.Count
+	}
+	if total != 101 {
+		t.Errorf("histogram total = %d, want 101", total)
+	}
+}
+
+func TestCollectorLatencyHistogramEmpty(t *testing.T) {
+	c := NewCollector(10)
+	if hist := c.LatencyHistogram(20); hist != nil {
+		t.Errorf("expected nil histogram for empty collector, got %v", hist)
+	}
+}
+
 func TestCollectorThroughputTimeline(t *testing.T) {
 	c := NewCollector(10)
 	for i := 0; i < 5; i++ {
-		c.Record(200, 0.1, true, "", 0)
+		c.Record(time.Time{}, 200, 0.1, true, "", "", 0)
 	}
 
 	stat := c.GetStatistics()
@@ -261,10 +381,74 @@ func TestCollectorThroughputTimeline(t *testing.T) {
 	}
 }
 
+func TestCollectorTimelineUsesCompletionTime(t *testing.T) {
+	c := NewCollector(10)
+	base := time.Unix(1_700_000_000, 0)
+	c.Record(base.Add(2100*time.Millisecond), 200, 0.3, true, "", "", 0) // recorded out of order
+	c.Record(base.Add(100*time.Millisecond), 200, 0.1, true, "", "", 0)
+	c.Record(base.Add(900*time.Millisecond), 500, 0.3, false, "", "", 0)
+	c.Record(base.Add(2500*time.Millisecond), 0, 0.5, false, "timeout", "", 0)
+
+	want := []ThroughputEntry{
+		{Second: 1, Requests: 2, Errors: 1, AvgLatency: 0.2},
+		{Second: 2}, // no results completed in this second
+		{Second: 3, Requests: 2, Errors: 1, AvgLatency: 0.4},
+	}
+	got := c.GetStatistics().Throughput
+	if len(got) != len(want) {
+		t.Fatalf("throughput = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i].Second != want[i].Second || got[i].Requests != want[i].Requests || got[i].Errors != want[i].Errors ||
+			math.Abs(got[i].AvgLatency-want[i].AvgLatency) > 1e-9 {
+			t.Errorf("throughput[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestCollectorRecordTimings(t *testing.T) {
+	c := NewCollector(10)
+	c.Record(time.Time{}, 200, 0.1, true, "", "", 0)
+	if stat := c.GetStatistics(); stat.Timings != nil {
+		t.Fatalf("Timings = %+v without RecordTimings, want nil", stat.Timings)
+	}
+
+	c.RecordTimings(0.002, 0.004, 0.010, 0.050, 0.001, false)
+	c.RecordTimings(0, 0, 0, 0.030, 0.003, true)
+	c.RecordTimings(0, 0, 0, 0.040, 0.002, true)
+
+	got := c.GetStatistics().Timings
+	if got == nil || got.NewConnections != 1 || got.ReusedConnections != 2 {
+		t.Fatalf("Timings = %+v, want 1 new and 2 reused connections", got)
+	}
+	want := map[string]struct {
+		count int64
+		avg   float64
+	}{
+		"dns":      {1, 0.002}, // phases that did not happen are left out
+		"connect":  {1, 0.004},
+		"tls":      {1, 0.010},
+		"ttfb":     {3, 0.040},
+		"transfer": {3, 0.002},
+	}
+	if len(got.Phases) != len(want) {
+		t.Fatalf("phases = %+v, want %d", got.Phases, len(want))
+	}
+	for _, p := range got.Phases {
+		w := want[p.Name]
+		if p.Count != w.count || math.Abs(p.Avg-w.avg) > 1e-9 {
+			t.Errorf("%s: count %d avg %v, want %d and %v", p.Name, p.Count, p.Avg, w.count, w.avg)
+		}
+	}
+	if ttfb := got.Phases[3]; ttfb.Name != "ttfb" || ttfb.P50 != 0.040 {
+		t.Errorf("ttfb phase = %+v, want p50 0.040", ttfb)
+	}
+}
+
 func BenchmarkCollectorRecord(b *testing.B) {
 	c := NewCollector(b.N)
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		c.Record(200, 0.1, true, "", 100)
+		c.Record(time.Time{}, 200, 0.1, true, "", "", 100)
 	}
 }
@@ -0,0 +1,51 @@
+package stats
+
+import (
+	"math"
+	"time"
+)
+
+// RateTolerance is how far below its --rate target a run may stay and still
+// count as having met it: pacing and start-up cost a few percent.
+const RateTolerance = 0.9
+
+// RateResult compares the achieved request rate with the --rate target.
+// Rates count jobs: single requests, or whole iterations with --scenario.
+type RateResult struct {
+	TargetRPS   float64 `json:"target_rps"`
+	AchievedRPS float64 `json:"achieved_rps"`
+	Met         bool    `json:"met"`
+
+	// CapacityRPS estimates the most the workers could send: concurrency
+	// divided by the time one job takes (latency plus think time).
+	// NeededConcurrency is the worker count that would reach the target at
+	// that latency. Both are set only when the target was missed.
+	CapacityRPS       float64 `json:"capacity_rps,omitempty"`
+	NeededConcurrency int     `json:"needed_concurrency,omitempty"`
+}
+
+// Limited reports whether the missed target is explained by the workers
+// being too few for the observed latency.
+func (r RateResult) Limited() bool {
+	return !r.Met && r.CapacityRPS > 0 && r.CapacityRPS < r.TargetRPS
+}
+
+// EvaluateRate checks the achieved rate against target. stepsPerJob is the
+// number of requests in one job; think is the pause each worker takes
+// between jobs.
+func EvaluateRate(s Statistics, target, achieved float64, concurrency, stepsPerJob int, think time.Duration) RateResult {
+	r := RateResult{
+		TargetRPS:   target,
+		AchievedRPS: achieved,
+		Met:         achieved >= target*RateTolerance,
+	}
+	if r.Met {
+		return r
+	}
+	jobSec := s.AvgLatency*float64(stepsPerJob) + think.Seconds()
+	if jobSec > 0 {
+		r.CapacityRPS = float64(concurrency) / jobSec
+		r.NeededConcurrency = int(math.Ceil(target * jobSec))
+	}
+	return r
+}
@@ -0,0 +1,36 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEvaluateRate(t *testing.T) {
+	s := Statistics{AvgLatency: 0.1}
+
+	met := EvaluateRate(s, 50, 46, 10, 1, 0)
+	if !met.Met || met.CapacityRPS != 0 || met.Limited() {
+		t.Errorf("within tolerance: got %+v, want met without capacity", met)
+	}
+
+	// 4 workers at 100ms per request manage 40 req/s at most
+	missed := EvaluateRate(s, 100, 38, 4, 1, 0)
+	if missed.Met || !missed.Limited() {
+		t.Fatalf("got %+v, want a missed target limited by concurrency", missed)
+	}
+	if missed.CapacityRPS != 40 || missed.NeededConcurrency != 10 {
+		t.Errorf("capacity = %.1f, needed = %d; want 40 and 10", missed.CapacityRPS, missed.NeededConcurrency)
+	}
+
+	// A scenario job of 2 steps plus 300ms think time takes 500ms
+	scenario := EvaluateRate(s, 20, 5, 4, 2, 300*time.Millisecond)
+	if scenario.CapacityRPS != 8 || scenario.NeededConcurrency != 10 {
+		t.Errorf("scenario: capacity = %.1f, needed = %d; want 8 and 10", scenario.CapacityRPS, scenario.NeededConcurrency)
+	}
+
+	// Enough workers: the shortfall has another cause
+	other := EvaluateRate(s, 10, 5, 50, 1, 0)
+	if other.Met || other.Limited() {
+		t.Errorf("got %+v, want a missed target not limited by concurrency", other)
+	}
+}
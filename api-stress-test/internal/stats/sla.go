@@ -0,0 +1,50 @@
+package stats
+
+import "time"
+
+// SLAMetrics lists the latency metrics that can carry an SLA, in report order.
+var SLAMetrics = []string{"p50", "p90", "p99", "avg"}
+
+// SLAResult is the outcome of comparing one latency metric against its threshold.
+type SLAResult struct {
+	Metric       string  `json:"metric"`
+	ThresholdSec float64 `json:"threshold_seconds"`
+	ActualSec    float64 `json:"actual_seconds"`
+	Pass         bool    `json:"pass"`
+}
+
+// LatencyMetric returns the named latency metric in seconds.
+func (s Statistics) LatencyMetric(metric string) (float64, bool) {
+	switch metric {
+	case "p50":
+		return s.P50Latency, true
+	case "p90":
+		return s.P90Latency, true
+	case "p99":
+		return s.P99Latency, true
+	case "avg":
+		return s.AvgLatency, true
+	default:
+		return 0, false
+	}
+}
+
+// EvaluateSLA checks each configured threshold in SLAMetrics order.
+// A metric passes when its actual value does not exceed the threshold.
+func EvaluateSLA(s Statistics, thresholds map[string]time.Duration) []SLAResult {
+	var results []SLAResult
+	for _, metric := range SLAMetrics {
+		threshold, ok := thresholds[metric]
+		if !ok {
+			continue
+		}
+		actualSec, _ := s.LatencyMetric(metric)
+		results = append(results, SLAResult{
+			Metric:       metric,
+			ThresholdSec: threshold.Seconds(),
+			ActualSec:    actualSec,
+			Pass:         actualSec <= threshold.Seconds(),
+		})
+	}
+	return results
+}
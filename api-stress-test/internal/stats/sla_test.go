@@ -0,0 +1,47 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEvaluateSLA(t *testing.T) {
+	s := Statistics{
+		AvgLatency: 0.090,
+		P50Latency: 0.080,
+		P90Latency: 0.150,
+		P99Latency: 0.350,
+	}
+
+	results := EvaluateSLA(s, map[string]time.Duration{
+		"p99": 200 * time.Millisecond,
+		"p50": 100 * time.Millisecond,
+		"avg": 90 * time.Millisecond,
+	})
+
+	want := []struct {
+		metric string
+		pass   bool
+	}{
+		{"p50", true},
+		{"p99", false},
+		{"avg", true}, // equal to the threshold passes
+	}
+	if len(results) != len(want) {
+		t.Fatalf("got %d results, want %d: %+v", len(results), len(want), results)
+	}
+	for i, w := range want {
+		if results[i].Metric != w.metric || results[i].Pass != w.pass {
+			t.Errorf("results[%d] = %+v, want metric %s pass=%v", i, results[i], w.metric, w.pass)
+		}
+	}
+	if results[1].ActualSec != 0.350 || results[1].ThresholdSec != 0.2 {
+		t.Errorf("p99 result = %+v, want actual 0.35s threshold 0.2s", results[1])
+	}
+}
+
+func TestEvaluateSLA_None(t *testing.T) {
+	if results := EvaluateSLA(Statistics{P99Latency: 1}, nil); len(results) != 0 {
+		t.Errorf("expected no results without thresholds, got %+v", results)
+	}
+}
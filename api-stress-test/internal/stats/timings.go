@@ -0,0 +1,113 @@
+package stats
+
+import (
+	"math/rand/v2"
+	"sort"
+)
+
+// Connection phases, in the order of TimingBreakdown.Phases.
+const (
+	phaseDNS = iota
+	phaseConnect
+	phaseTLS
+	phaseTTFB
+	phaseTransfer
+	numPhases
+)
+
+var phaseNames = [numPhases]string{"dns", "connect", "tls", "ttfb", "transfer"}
+
+// PhaseStats summarizes one connection phase, in seconds. Count is the number
+// of requests the phase happened for: DNS, connect and TLS only happen on new
+// connections.
+type PhaseStats struct {
+	Name  string  `json:"name"`
+	Count int64   `json:"count"`
+	Avg   float64 `json:"avg"`
+	P50   float64 `json:"p50"`
+	P90   float64 `json:"p90"`
+	P99   float64 `json:"p99"`
+}
+
+// TimingBreakdown is the --detailed-timings summary.
+type TimingBreakdown struct {
+	Phases            []PhaseStats `json:"phases"`
+	NewConnections    int64        `json:"new_connections"`
+	ReusedConnections int64        `json:"reused_connections"`
+}
+
+// phaseSample accumulates one phase: an exact sum for the average and a
+// reservoir sample for the percentiles, like the latency reservoir.
+type phaseSample struct {
+	count     int64
+	sum       float64
+	reservoir []float64
+}
+
+func (s *phaseSample) add(v float64) {
+	s.count++
+	s.sum += v
+	if len(s.reservoir) < reservoirSize {
+		s.reservoir = append(s.reservoir, v)
+	} else if j := rand.IntN(int(s.count)); j < reservoirSize {
+		s.reservoir[j] = v
+	}
+}
+
+// phaseTimings is the Collector state behind Statistics.Timings.
+type phaseTimings struct {
+	phases      [numPhases]phaseSample
+	newConns    int64
+	reusedConns int64
+}
+
+// RecordTimings adds the connection phases of one response, in seconds.
+// DNS, connect and TLS are left out of their phase when zero, as they did not
+// happen; reused tells whether the connection came from the pool.
+func (c *Collector) RecordTimings(dns, connect, tls, ttfb, transfer float64, reused bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.timings == nil {
+		c.timings = &phaseTimings{}
+	}
+	t := c.timings
+	if reused {
+		t.reusedConns++
+	} else {
+		t.newConns++
+	}
+	for i, v := range [...]float64{phaseDNS: dns, phaseConnect: connect, phaseTLS: tls} {
+		if v > 0 {
+			t.phases[i].add(v)
+		}
+	}
+	t.phases[phaseTTFB].add(ttfb)
+	t.phases[phaseTransfer].add(transfer)
+}
+
+// breakdown summarizes the recorded phases; nil when RecordTimings was never called.
+func (t *phaseTimings) breakdown() *TimingBreakdown {
+	if t == nil {
+		return nil
+	}
+	b := &TimingBreakdown{
+		Phases:            make([]PhaseStats, 0, numPhases),
+		NewConnections:    t.newConns,
+		ReusedConnections: t.reusedConns,
+	}
+	for i := range t.phases {
+		s := &t.phases[i]
+		ps := PhaseStats{Name: phaseNames[i], Count: s.count}
+		if s.count > 0 {
+			sorted := append([]float64(nil), s.reservoir...)
+			sort.Float64s(sorted)
+			ps.Avg = s.sum / float64(s.count)
+			ps.P50 = percentile(sorted, 0.50)
+			ps.P90 = percentile(sorted, 0.90)
+			ps.P99 = percentile(sorted, 0.99)
+		}
+		b.Phases = append(b.Phases, ps)
+	}
+	return b
+}
@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"api-stress-test/internal/stats"
+)
+
+// formatFloat renders a float for CSV without exponent notation.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// WriteCSVSummary writes the run summary as "metric,value" rows, one per
+// headline number, for spreadsheets and graphing.
+func WriteCSVSummary(w io.Writer, output JSONOutput) error {
+	s := output.Statistics
+	rows := [][]string{
+		{"metric", "value"},
+		{"url", output.Config.URL},
+		{"method", output.Config.Method},
+		{"concurrency", strconv.Itoa(output.Config.Concurrency)},
+		{"start_time", output.StartTime},
+		{"end_time", output.EndTime},
+		{"total_time_seconds", formatFloat(output.TotalTime)},
+		{"requests_per_second", formatFloat(output.ReqPerSec)},
+		{"total", strconv.FormatInt(s.Total, 10)},
+		{"successes", strconv.FormatInt(s.Successes, 10)},
+		{"failures", strconv.FormatInt(s.Failures, 10)},
+		{"success_rate", formatFloat(s.SuccessRate)},
+		{"min_latency", formatFloat(s.MinLatency)},
+		{"avg_latency", formatFloat(s.AvgLatency)},
+		{"p50_latency", formatFloat(s.P50Latency)},
+		{"p90_latency", formatFloat(s.P90Latency)},
+		{"p95_latency", formatFloat(s.P95Latency)},
+		{"p99_latency", formatFloat(s.P99Latency)},
+		{"max_latency", formatFloat(s.MaxLatency)},
+		{"total_response_bytes", strconv.FormatInt(s.TotalResponseBytes, 10)},
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// WriteCSVRecords writes one row per request (--raw): when it completed,
+// its status (0 when no response arrived), latency in seconds, outcome,
+// error message and response size.
+func WriteCSVRecords(w io.Writer, records []stats.RequestRecord) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"timestamp", "status", "latency_seconds", "ok", "error", "response_bytes"}); err != nil {
+		return err
+	}
+	for _, r := range records {
+		if err := cw.Write([]string{
+			r.Time.Format(time.RFC3339Nano),
+			strconv.Itoa(r.StatusCode),
+			formatFloat(r.Elapsed),
+			strconv.FormatBool(r.OK),
+			r.Error,
+			strconv.FormatInt(r.ResponseSize, 10),
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,46 @@
+package ui
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"api-stress-test/internal/stats"
+)
+
+func TestWriteCSVRecords(t *testing.T) {
+	at := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	var buf bytes.Buffer
+	err := WriteCSVRecords(&buf, []stats.RequestRecord{
+		{Time: at, StatusCode: 200, Elapsed: 0.0125, OK: true, ResponseSize: 42},
+		{Time: at, Elapsed: 5, Error: `dial tcp: "refused", retry`},
+	})
+	if err != nil {
+		t.Fatalf("WriteCSVRecords: %v", err)
+	}
+	want := "timestamp,status,latency_seconds,ok,error,response_bytes\n" +
+		"2025-01-02T03:04:05Z,200,0.0125,true,,42\n" +
+		`2025-01-02T03:04:05Z,0,5,false,"dial tcp: ""refused"", retry",0` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("CSV =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteCSVSummary(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteCSVSummary(&buf, JSONOutput{
+		Config:     TestConfig{URL: "http://example.com", Method: "GET", Concurrency: 4},
+		Statistics: stats.Statistics{Total: 10, Failures: 1, P99Latency: 0.25},
+		ReqPerSec:  123.5,
+	})
+	if err != nil {
+		t.Fatalf("WriteCSVSummary: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"metric,value\n", "url,http://example.com\n", "concurrency,4\n", "requests_per_second,123.5\n", "total,10\n", "failures,1\n", "p99_latency,0.25\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in CSV:\n%s", want, out)
+		}
+	}
+}
@@ -7,8 +7,11 @@ import (
 	"io"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"api-stress-test/internal/request"
 	"api-stress-test/internal/stats"
 )
 
@@ -84,6 +87,11 @@ type HeaderConfig struct {
 	Duration       string
 	BodyLen        int
 	ContentType    string
+	ThinkTime      time.Duration
+	ThinkJitter    float64
+	ScenarioSteps  int    // >0 when running a --scenario; replaces the method line
+	Protocol       string // "auto", "HTTP/1.1" or "HTTP/2"
+	TLS            string // non-default TLS settings, e.g. "verification disabled (--insecure)"
 }
 
 // TestConfig holds the test configuration for JSON output.
@@ -95,31 +103,70 @@ type TestConfig struct {
 	Concurrency int     `json:"concurrency"`
 	Timeout     float64 `json:"timeout_seconds"`
 	Rate        float64 `json:"rate,omitempty"`
+	ThinkTime   string  `json:"think_time,omitempty"`
+	Scenario    int     `json:"scenario_steps,omitempty"`
+	Protocol    string  `json:"protocol,omitempty"`
+	TLS         string  `json:"tls,omitempty"`
 }
 
 // JSONOutput wraps the full result for JSON output format.
 type JSONOutput struct {
 	Config     TestConfig       `json:"config"`
-	Statistics stats.Statistics  `json:"statistics"`
+	Statistics stats.Statistics `json:"statistics"`
 	TotalTime  float64          `json:"total_time_seconds"`
 	ReqPerSec  float64          `json:"requests_per_second"`
+	StartTime  string           `json:"start_time,omitempty"` // RFC 3339, when recording began
+	EndTime    string           `json:"end_time,omitempty"`
+
+	ResponseSamples []request.ResponseSample `json:"response_samples,omitempty"`
+	SLA             []stats.SLAResult        `json:"sla,omitempty"`
+	Rate            *stats.RateResult        `json:"rate,omitempty"` // set with --rate/--rps
+
+	// ProgressTimeline holds the per-second samples behind the live progress
+	// line: rolling rate, success percentage and median latency.
+	ProgressTimeline []stats.WindowSample `json:"progress_timeline,omitempty"`
+}
+
+// PrintWarning prints a highlighted warning line, typically to stderr.
+func PrintWarning(w io.Writer, msg string) {
+	cw := newColorWriter(w)
+	fmt.Fprintf(w, "%s %s\n", cw.colorize(colorBold+colorYellow, "WARNING:"), msg)
 }
 
 // PrintHeader prints the test configuration before the test starts.
 func PrintHeader(w io.Writer, cfg HeaderConfig) {
 	cw := newColorWriter(w)
 	fmt.Fprintf(w, "%s : %s\n", cw.colorize(colorBold, "Target URL           "), cfg.URL)
-	fmt.Fprintf(w, "%s : %s\n", cw.colorize(colorBold, "HTTP method          "), cfg.Method)
+	if cfg.ScenarioSteps > 0 {
+		fmt.Fprintf(w, "%s : %d step(s)\n", cw.colorize(colorBold, "Scenario             "), cfg.ScenarioSteps)
+	} else {
+		fmt.Fprintf(w, "%s : %s\n", cw.colorize(colorBold, "HTTP method          "), cfg.Method)
+	}
 	if cfg.IsDurationMode {
 		fmt.Fprintf(w, "%s : %s\n", cw.colorize(colorBold, "Duration             "), cfg.Duration)
+	} else if cfg.ScenarioSteps > 0 {
+		fmt.Fprintf(w, "%s : %d\n", cw.colorize(colorBold, "Total iterations     "), cfg.TotalRequests)
 	} else {
 		fmt.Fprintf(w, "%s : %d\n", cw.colorize(colorBold, "Total requests       "), cfg.TotalRequests)
 	}
 	fmt.Fprintf(w, "%s : %d\n", cw.colorize(colorBold, "Concurrency (workers)"), cfg.Concurrency)
 	fmt.Fprintf(w, "%s : %.1f seconds\n", cw.colorize(colorBold, "Timeout per request  "), cfg.TimeoutSec)
+	if cfg.Protocol != "" {
+		fmt.Fprintf(w, "%s : %s\n", cw.colorize(colorBold, "HTTP protocol        "), cfg.Protocol)
+	}
+	if cfg.TLS != "" {
+		fmt.Fprintf(w, "%s : %s\n", cw.colorize(colorBold, "TLS                  "), cfg.TLS)
+	}
 	if cfg.Rate > 0 {
 		fmt.Fprintf(w, "%s : %.0f req/s\n", cw.colorize(colorBold, "Rate limit           "), cfg.Rate)
 	}
+	if cfg.ThinkTime > 0 {
+		if cfg.ThinkJitter > 0 {
+			fmt.Fprintf(w, "%s : %s ±%.0f%%\n", cw.colorize(colorBold, "Think time           "), cfg.ThinkTime, cfg.ThinkJitter)
+		} else {
+			fmt.Fprintf(w, "%s : %s\n", cw.colorize(colorBold, "Think time           "), cfg.ThinkTime)
+		}
+	}
 	if cfg.BodyLen > 0 {
 		fmt.Fprintf(w, "%s : %d bytes\n", cw.colorize(colorBold, "Body size            "), cfg.BodyLen)
 		if cfg.ContentType != "" {
@@ -174,6 +221,17 @@ func PrintTextResult(w io.Writer, stat stats.Statistics, totalTime, reqPerSec fl
 		}
 	}
 
+	if len(stat.Protocols) > 0 {
+		var responses int
+		for _, p := range stat.Protocols {
+			responses += p.Count
+		}
+		fmt.Fprintln(w, "Protocols             :")
+		for _, p := range stat.Protocols {
+			fmt.Fprintf(w, "  %-15s %d (%.1f%%)\n", p.Proto, p.Count, float64(p.Count)/float64(responses)*100)
+		}
+	}
+
 	fmt.Fprintln(w)
 	fmt.Fprintln(w, cw.colorize(colorBold, "Latency (seconds)"))
 	fmt.Fprintf(w, "  Min                 : %.4f\n", stat.MinLatency)
@@ -194,6 +252,8 @@ func PrintTextResult(w io.Writer, stat stats.Statistics, totalTime, reqPerSec fl
 	// Throughput timeline for tests longer than 2 seconds
 	printThroughputTimeline(cw, stat.Throughput)
 
+	printFailureBreakdown(cw, stat.FailureBreakdown, stat.Failures)
+
 	if len(stat.TopErrors) > 0 {
 		fmt.Fprintln(w)
 		fmt.Fprintln(w, cw.colorize(colorBold, "Top Errors            :"))
@@ -232,33 +292,242 @@ func printHistogram(cw *colorWriter, buckets []stats.HistogramBucket) {
 	}
 }
 
-// printThroughputTimeline renders a per-second throughput bar chart for tests > 2 seconds.
-func printThroughputTimeline(cw *colorWriter, throughput []stats.ThroughputEntry) {
-	if len(throughput) < 3 {
+// PrintLatencyHistogram renders a detailed latency histogram with bars scaled
+// to 60 columns and millisecond range labels, e.g. "0-5ms |█████| 850".
+func PrintLatencyHistogram(w io.Writer, buckets []stats.HistogramBucket) {
+	if len(buckets) == 0 {
 		return
 	}
+	cw := newColorWriter(w)
 
+	maxCount := 0
+	labels := make([]string, len(buckets))
+	labelWidth := 0
+	for i, b := range buckets {
+		if b.Count > maxCount {
+			maxCount = b.Count
+		}
+		labels[i] = formatMillis(b.MinSec) + "-" + formatMillis(b.MaxSec) + "ms"
+		if len(labels[i]) > labelWidth {
+			labelWidth = len(labels[i])
+		}
+	}
+	if maxCount == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, cw.colorize(colorBold, "Latency histogram"))
+
+	const barWidth = 60
+	for i, b := range buckets {
+		barLen := b.Count * barWidth / maxCount
+		bar := strings.Repeat("█", barLen) + strings.Repeat(" ", barWidth-barLen)
+		fmt.Fprintf(w, "  %-*s |%s| %d\n", labelWidth, labels[i], cw.colorize(colorCyan, bar), b.Count)
+	}
+}
+
+// failureKindLabels names the failure kinds of Statistics.FailureBreakdown.
+var failureKindLabels = map[string]string{
+	"timeout":            "Timeout",
+	"dns":                "DNS failure",
+	"connection_refused": "Connection refused",
+	"connection_reset":   "Connection reset",
+	"tls":                "TLS error",
+	"canceled":           "Canceled",
+	"http_status":        "Unexpected status",
+	"body_mismatch":      "Body mismatch",
+	"assertion":          "Assertion failed",
+	"other":              "Other",
+}
+
+// printFailureBreakdown prints the failures per kind with their share of all
+// failures.
+func printFailureBreakdown(cw *colorWriter, breakdown []stats.FailureKindCount, failures int64) {
+	if len(breakdown) == 0 || failures == 0 {
+		return
+	}
 	fmt.Fprintln(cw.w)
-	fmt.Fprintln(cw.w, cw.colorize(colorBold, "Throughput timeline (req/s)"))
+	fmt.Fprintln(cw.w, cw.colorize(colorBold, "Failure breakdown     :"))
+	for _, k := range breakdown {
+		label := failureKindLabels[k.Kind]
+		if label == "" {
+			label = k.Kind
+		}
+		fmt.Fprintf(cw.w, "  %-20s %8d  %5.1f%%\n", label, k.Count, float64(k.Count)/float64(failures)*100)
+	}
+}
 
-	maxReqs := 0
-	for _, t := range throughput {
-		if t.Requests > maxReqs {
-			maxReqs = t.Requests
+// phaseLabels names the --detailed-timings phases in the text summary.
+var phaseLabels = map[string]string{
+	"dns":      "DNS lookup",
+	"connect":  "Connect",
+	"tls":      "TLS handshake",
+	"ttfb":     "Time to first byte",
+	"transfer": "Content transfer",
+}
+
+// PrintTimingBreakdown prints the per-phase latency table of --detailed-timings
+// in milliseconds. DNS, connect and TLS only count new connections.
+func PrintTimingBreakdown(w io.Writer, t *stats.TimingBreakdown) {
+	if t == nil {
+		return
+	}
+	cw := newColorWriter(w)
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, cw.colorize(colorBold, "Timing breakdown (ms)"))
+	fmt.Fprintf(w, "  %-20s %8s %8s %8s %8s %8s\n", "", "count", "avg", "p50", "p90", "p99")
+	for _, p := range t.Phases {
+		if p.Count == 0 {
+			fmt.Fprintf(w, "  %-20s %8d %8s %8s %8s %8s\n", phaseLabels[p.Name], 0, "-", "-", "-", "-")
+			continue
 		}
+		fmt.Fprintf(w, "  %-20s %8d %8s %8s %8s %8s\n", phaseLabels[p.Name], p.Count,
+			formatMillis(p.Avg), formatMillis(p.P50), formatMillis(p.P90), formatMillis(p.P99))
+	}
+	fmt.Fprintf(w, "  Connection reused    : %d (new: %d)\n", t.ReusedConnections, t.NewConnections)
+}
+
+// PrintSLAResults prints one line per SLA check,
+// e.g. "SLA p99 200ms: PASS (actual: 180ms)".
+func PrintSLAResults(w io.Writer, results []stats.SLAResult) {
+	if len(results) == 0 {
+		return
+	}
+	cw := newColorWriter(w)
+
+	fmt.Fprintln(w)
+	for _, r := range results {
+		verdict := cw.colorize(colorGreen, "PASS")
+		if !r.Pass {
+			verdict = cw.colorize(colorRed, "FAIL")
+		}
+		fmt.Fprintf(w, "SLA %s %s: %s (actual: %s)\n", r.Metric, FormatSeconds(r.ThresholdSec), verdict, FormatSeconds(r.ActualSec))
+	}
+}
+
+// PrintRateResult prints the --rate target next to the rate achieved, with a
+// warning when the target was missed.
+func PrintRateResult(w io.Writer, r *stats.RateResult) {
+	if r == nil {
+		return
+	}
+	cw := newColorWriter(w)
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, cw.colorize(colorBold, "Rate"))
+	fmt.Fprintf(w, "  Target              : %.2f req/s\n", r.TargetRPS)
+	achieved := fmt.Sprintf("%.2f req/s", r.AchievedRPS)
+	if !r.Met {
+		achieved = cw.colorize(colorYellow, achieved)
+	}
+	fmt.Fprintf(w, "  Achieved            : %s\n", achieved)
+	if !r.Met {
+		PrintWarning(w, RateWarning(*r))
+	}
+}
+
+// RateWarning explains a missed --rate target.
+func RateWarning(r stats.RateResult) string {
+	msg := fmt.Sprintf("target rate of %.2f req/s not reached (achieved %.2f req/s)", r.TargetRPS, r.AchievedRPS)
+	if r.Limited() {
+		msg += fmt.Sprintf("; at the observed latency the workers sustain about %.2f req/s, use --concurrency %d or more", r.CapacityRPS, r.NeededConcurrency)
+	}
+	return msg
+}
+
+// FormatSeconds renders a duration given in seconds, rounded for readability
+// (milliseconds above 1ms, microseconds below).
+func FormatSeconds(sec float64) string {
+	d := time.Duration(sec * float64(time.Second))
+	if d >= time.Millisecond {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(time.Microsecond).String()
+}
+
+// PrintResponseSamples prints response bodies captured with --print-response.
+func PrintResponseSamples(w io.Writer, samples []request.ResponseSample) {
+	if len(samples) == 0 {
+		return
+	}
+	cw := newColorWriter(w)
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, cw.colorize(colorBold, "Response samples"))
+	for i, sample := range samples {
+		statusColor := colorGreen
+		if sample.StatusCode < 200 || sample.StatusCode >= 300 {
+			statusColor = colorRed
+		}
+		suffix := ""
+		if sample.Truncated {
+			suffix = ", truncated"
+		}
+		fmt.Fprintf(w, "  [%d] %s (%d bytes%s)\n", i+1,
+			cw.colorize(statusColor, fmt.Sprintf("HTTP %d", sample.StatusCode)), len(sample.Body), suffix)
+		body := strings.TrimRight(sample.Body, "\n")
+		if body == "" {
+			body = "(empty body)"
+		}
+		for _, line := range strings.Split(body, "\n") {
+			fmt.Fprintf(w, "      %s\n", line)
+		}
+	}
+}
+
+// formatMillis formats seconds as milliseconds with precision suited to the magnitude.
+func formatMillis(sec float64) string {
+	ms := sec * 1000
+	switch {
+	case ms >= 100:
+		return fmt.Sprintf("%.0f", ms)
+	case ms >= 1:
+		return fmt.Sprintf("%.1f", ms)
+	default:
+		return fmt.Sprintf("%.2f", ms)
+	}
+}
+
+// printThroughputTimeline renders a per-second table for tests > 2 seconds:
+// a throughput bar with the request count, the errors and the average
+// latency of the requests completed in that second.
+func printThroughputTimeline(cw *colorWriter, throughput []stats.ThroughputEntry) {
+	if len(throughput) < 3 {
+		return
+	}
+
+	maxReqs, maxErrs := 0, 0
+	for _, t := range throughput {
+		maxReqs = max(maxReqs, t.Requests)
+		maxErrs = max(maxErrs, t.Errors)
 	}
 	if maxReqs == 0 {
 		return
 	}
 
+	fmt.Fprintln(cw.w)
+	fmt.Fprintln(cw.w, cw.colorize(colorBold, "Timeline (req/s, errors, avg latency)"))
+
 	const barWidth = 30
+	reqWidth := len(strconv.Itoa(maxReqs))
+	errWidth := len(strconv.Itoa(maxErrs))
 	for _, t := range throughput {
 		barLen := t.Requests * barWidth / maxReqs
 		bar := strings.Repeat("█", barLen)
-		fmt.Fprintf(cw.w, "  [%3ds] %s %d\n",
+		errs := fmt.Sprintf("%*d err", errWidth, t.Errors)
+		if t.Errors > 0 {
+			errs = cw.colorize(colorRed, errs)
+		}
+		avg := "-"
+		if t.Requests > 0 {
+			avg = formatMillis(t.AvgLatency) + "ms"
+		}
+		fmt.Fprintf(cw.w, "  [%3ds] %s %*d  %s  %s\n",
 			t.Second,
 			cw.colorize(colorCyan, fmt.Sprintf("%-*s", barWidth, bar)),
-			t.Requests)
+			reqWidth, t.Requests, errs, avg)
 	}
 }
 
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 
+	"api-stress-test/internal/request"
 	"api-stress-test/internal/stats"
 )
 
@@ -74,6 +75,14 @@ func TestPrintHeader_DurationMode(t *testing.T) {
 	}
 }
 
+func TestPrintWarning(t *testing.T) {
+	var buf bytes.Buffer
+	PrintWarning(&buf, "TLS certificate verification is disabled")
+	if got, want := buf.String(), "WARNING: TLS certificate verification is disabled\n"; got != want {
+		t.Errorf("PrintWarning output = %q, want %q", got, want)
+	}
+}
+
 func TestPrintTextResult(t *testing.T) {
 	stat := stats.Statistics{
 		Successes:          90,
@@ -115,6 +124,124 @@ func TestPrintTextResult(t *testing.T) {
 	}
 }
 
+func TestPrintLatencyHistogram(t *testing.T) {
+	buckets := []stats.HistogramBucket{
+		{MinSec: 0, MaxSec: 0.005, Count: 850},
+		{MinSec: 0.005, MaxSec: 0.010, Count: 425},
+	}
+
+	var buf bytes.Buffer
+	PrintLatencyHistogram(&buf, buckets)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+
+	if len(lines) != 3 || lines[0] != "Latency histogram" {
+		t.Fatalf("unexpected histogram output:\n%s", buf.String())
+	}
+	if !strings.HasPrefix(strings.TrimSpace(lines[1]), "0.00-5.0ms |") || !strings.HasSuffix(lines[1], "| 850") {
+		t.Errorf("unexpected first bucket line: %q", lines[1])
+	}
+	if got := strings.Count(lines[1], "█"); got != 60 {
+		t.Errorf("largest bucket bar = %d chars, want 60", got)
+	}
+	if got := strings.Count(lines[2], "█"); got != 30 {
+		t.Errorf("half bucket bar = %d chars, want 30", got)
+	}
+}
+
+func TestPrintThroughputTimeline(t *testing.T) {
+	var buf bytes.Buffer
+	printThroughputTimeline(newColorWriter(&buf), []stats.ThroughputEntry{
+		{Second: 1, Requests: 100, AvgLatency: 0.012},
+		{Second: 2},
+		{Second: 3, Requests: 50, Errors: 12, AvgLatency: 0.25},
+	})
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+
+	if len(lines) != 4 || lines[0] != "Timeline (req/s, errors, avg latency)" {
+		t.Fatalf("unexpected timeline output:\n%s", buf.String())
+	}
+	wantSuffix := []string{"100   0 err  12.0ms", "  0   0 err  -", " 50  12 err  250ms"}
+	for i, want := range wantSuffix {
+		if !strings.HasSuffix(lines[i+1], want) {
+			t.Errorf("line %d = %q, want suffix %q", i+1, lines[i+1], want)
+		}
+	}
+	if got := strings.Count(lines[3], "█"); got != 15 {
+		t.Errorf("half-rate bar = %d chars, want 15", got)
+	}
+}
+
+func TestPrintFailureBreakdown(t *testing.T) {
+	var buf bytes.Buffer
+	PrintTextResult(&buf, stats.Statistics{
+		Total:    10,
+		Failures: 4,
+		FailureBreakdown: []stats.FailureKindCount{
+			{Kind: "timeout", Count: 3},
+			{Kind: "connection_refused", Count: 1},
+		},
+		StatusCount: map[int]int{},
+	}, 1, 10)
+	out := buf.String()
+	for _, want := range []string{
+		"Failure breakdown     :",
+		"  Timeout                     3   75.0%",
+		"  Connection refused          1   25.0%",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+}
+
+func TestPrintProtocols(t *testing.T) {
+	var buf bytes.Buffer
+	PrintTextResult(&buf, stats.Statistics{
+		Total:       4,
+		Successes:   4,
+		Protocols:   []stats.ProtocolCount{{Proto: "HTTP/2.0", Count: 3}, {Proto: "HTTP/1.1", Count: 1}},
+		StatusCount: map[int]int{200: 4},
+	}, 1, 4)
+	out := buf.String()
+	for _, want := range []string{
+		"Protocols             :",
+		"  HTTP/2.0        3 (75.0%)",
+		"  HTTP/1.1        1 (25.0%)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+}
+
+func TestPrintTimingBreakdown(t *testing.T) {
+	var buf bytes.Buffer
+	PrintTimingBreakdown(&buf, nil)
+	if buf.Len() != 0 {
+		t.Fatalf("expected no output without timings, got %q", buf.String())
+	}
+
+	PrintTimingBreakdown(&buf, &stats.TimingBreakdown{
+		Phases: []stats.PhaseStats{
+			{Name: "dns", Count: 0},
+			{Name: "ttfb", Count: 10, Avg: 0.0125, P50: 0.012, P90: 0.02, P99: 0.1},
+		},
+		NewConnections:    2,
+		ReusedConnections: 8,
+	})
+	out := buf.String()
+	for _, want := range []string{
+		"Timing breakdown (ms)",
+		"  DNS lookup                  0        -",
+		"  Time to first byte         10     12.5     12.0     20.0      100",
+		"  Connection reused    : 8 (new: 2)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+}
+
 func TestPrintJSONResult(t *testing.T) {
 	output := JSONOutput{
 		Config: TestConfig{
@@ -220,3 +347,38 @@ func TestRenderBar(t *testing.T) {
 		})
 	}
 }
+
+func TestPrintResponseSamples(t *testing.T) {
+	samples := []request.ResponseSample{
+		{StatusCode: 500, Body: "line one\nline two\n"},
+		{StatusCode: 404},
+	}
+
+	var buf bytes.Buffer
+	PrintResponseSamples(&buf, samples)
+	out := buf.String()
+
+	for _, want := range []string{"Response samples", "[1] HTTP 500 (18 bytes)", "      line two", "[2] HTTP 404 (0 bytes)", "(empty body)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+}
+
+func TestPrintRateResult(t *testing.T) {
+	var buf bytes.Buffer
+	PrintRateResult(&buf, &stats.RateResult{TargetRPS: 100, AchievedRPS: 38.5, CapacityRPS: 40, NeededConcurrency: 10})
+	out := buf.String()
+
+	for _, want := range []string{"Target              : 100.00 req/s", "Achieved            : 38.50 req/s", "WARNING: target rate of 100.00 req/s not reached", "use --concurrency 10 or more"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	PrintRateResult(&buf, &stats.RateResult{TargetRPS: 50, AchievedRPS: 49, Met: true})
+	if strings.Contains(buf.String(), "WARNING") {
+		t.Errorf("met target printed a warning:\n%s", buf.String())
+	}
+}
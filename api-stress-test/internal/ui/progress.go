@@ -3,22 +3,34 @@ package ui
 import (
 	"fmt"
 	"io"
+	"os"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"api-stress-test/internal/stats"
 )
 
+// progressInterval is how often the progress line is redrawn and a window
+// sample is taken.
+const progressInterval = time.Second
+
 // Progress tracks and displays live progress during a stress test.
 type Progress struct {
-	w              io.Writer
-	total          int64 // 0 for duration mode (unknown total)
+	w              io.Writer // nil: take window samples without drawing
+	total          int64     // 0 for duration mode (unknown total)
 	completed      atomic.Int64
 	startTime      time.Time
 	done           chan struct{}
 	wg             sync.WaitGroup
 	isDurationMode bool
 	duration       time.Duration
+
+	// window, when set, closes the current stats window each tick (see
+	// stats.Collector.TakeWindow); the line then shows that window's rate,
+	// success percentage and median instead of a plain request rate.
+	window func(now time.Time) stats.WindowSample
 }
 
 // NewProgress creates a live progress display.
@@ -34,6 +46,19 @@ func NewProgress(w io.Writer, total int64, isDurationMode bool, duration time.Du
 	}
 }
 
+// SetWindowSource makes each tick take a window sample with fn. Call it
+// before Start.
+func (p *Progress) SetWindowSource(fn func(now time.Time) stats.WindowSample) {
+	p.window = fn
+}
+
+// IsTerminal reports whether f is an interactive terminal, where the
+// progress line can redraw itself.
+func IsTerminal(f *os.File) bool {
+	stat, err := f.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
+
 // Add adds n to the completed count. Thread-safe.
 func (p *Progress) Add(n int64) {
 	p.completed.Add(n)
@@ -44,7 +69,7 @@ func (p *Progress) Start() {
 	p.wg.Add(1)
 	go func() {
 		defer p.wg.Done()
-		ticker := time.NewTicker(500 * time.Millisecond)
+		ticker := time.NewTicker(progressInterval)
 		defer ticker.Stop()
 
 		var lastCount int64
@@ -52,12 +77,20 @@ func (p *Progress) Start() {
 
 		for {
 			select {
-			case <-ticker.C:
-				now := time.Now()
+			case now := <-ticker.C:
 				current := p.completed.Load()
 				elapsed := now.Sub(p.startTime)
 
-				// Calculate instant req/s from last 500ms window
+				var sample *stats.WindowSample
+				if p.window != nil {
+					s := p.window(now)
+					sample = &s
+				}
+				if p.w == nil {
+					continue
+				}
+
+				// Without a window source, the rate comes from the completed count
 				dt := now.Sub(lastTime).Seconds()
 				var instantRPS float64
 				if dt > 0 {
@@ -66,23 +99,41 @@ func (p *Progress) Start() {
 				lastCount = current
 				lastTime = now
 
-				p.render(current, elapsed, instantRPS)
+				p.render(current, elapsed, instantRPS, sample)
 			case <-p.done:
+				// Close the last, partial window so its results reach the
+				// timeline; a run under a second gets its only sample here
+				if p.window != nil {
+					p.window(time.Now())
+				}
 				// Clear the progress line
-				fmt.Fprintf(p.w, "\r%s\r", strings.Repeat(" ", 80))
+				if p.w != nil {
+					fmt.Fprintf(p.w, "\r%s\r", strings.Repeat(" ", 100))
+				}
 				return
 			}
 		}
 	}()
 }
 
-// Stop ends the progress display and waits for the goroutine to exit.
+// Stop takes a final window sample, ends the progress display and waits for
+// the goroutine to exit.
 func (p *Progress) Stop() {
 	close(p.done)
 	p.wg.Wait()
 }
 
-func (p *Progress) render(completed int64, elapsed time.Duration, rps float64) {
+func (p *Progress) render(completed int64, elapsed time.Duration, rps float64, sample *stats.WindowSample) {
+	window := fmt.Sprintf("%.0f req/s", rps)
+	if sample != nil {
+		window = fmt.Sprintf("%.0f req/s", sample.RequestsPerSec)
+		if sample.Requests > 0 {
+			window += fmt.Sprintf(" | %.1f%% ok | p50 %sms", sample.SuccessRate, formatMillis(sample.P50Latency))
+		} else {
+			window += " | - ok | p50 -"
+		}
+	}
+
 	if p.isDurationMode {
 		// Duration mode: show elapsed/total time
 		pct := float64(elapsed) / float64(p.duration) * 100
@@ -90,8 +141,8 @@ func (p *Progress) render(completed int64, elapsed time.Duration, rps float64) {
 			pct = 100
 		}
 		bar := renderBar(pct, 20)
-		fmt.Fprintf(p.w, "\r%s %3.0f%% | %d reqs | %.0f req/s | %.1fs/%.1fs",
-			bar, pct, completed, rps, elapsed.Seconds(), p.duration.Seconds())
+		fmt.Fprintf(p.w, "\r%s %3.0f%% | %d reqs | %s | %.1fs/%.1fs\033[K",
+			bar, pct, completed, window, elapsed.Seconds(), p.duration.Seconds())
 	} else {
 		// Fixed request mode
 		pct := float64(completed) / float64(p.total) * 100
@@ -99,8 +150,8 @@ func (p *Progress) render(completed int64, elapsed time.Duration, rps float64) {
 			pct = 100
 		}
 		bar := renderBar(pct, 20)
-		fmt.Fprintf(p.w, "\r%s %3.0f%% | %d/%d | %.0f req/s | %.1fs elapsed",
-			bar, pct, completed, p.total, rps, elapsed.Seconds())
+		fmt.Fprintf(p.w, "\r%s %3.0f%% | %d/%d | %s | %.1fs elapsed\033[K",
+			bar, pct, completed, p.total, window, elapsed.Seconds())
 	}
 }
 
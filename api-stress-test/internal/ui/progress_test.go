@@ -3,8 +3,11 @@ package ui
 import (
 	"bytes"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"api-stress-test/internal/stats"
 )
 
 func TestProgressStartStop(t *testing.T) {
@@ -33,7 +36,7 @@ func TestProgressStopClearsLine(t *testing.T) {
 	p := NewProgress(&buf, 10, false, 0)
 	p.Start()
 	p.Add(5)
-	time.Sleep(600 * time.Millisecond) // Wait for at least one render
+	time.Sleep(progressInterval + 100*time.Millisecond) // Wait for at least one render
 	p.Stop()
 	out := buf.String()
 	// After stop, the output should contain \r (carriage return for clearing)
@@ -41,3 +44,49 @@ func TestProgressStopClearsLine(t *testing.T) {
 		t.Error("expected \\r in output after Stop()")
 	}
 }
+
+func TestProgressRenderWindow(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewProgress(&buf, 1000, false, 0)
+	p.render(450, 3*time.Second, 0, &stats.WindowSample{Requests: 120, RequestsPerSec: 120, SuccessRate: 98.5, P50Latency: 0.0123})
+	out := buf.String()
+	for _, want := range []string{"450/1000", "120 req/s", "98.5% ok", "p50 12.3ms"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in progress line %q", want, out)
+		}
+	}
+
+	buf.Reset()
+	p.render(450, 4*time.Second, 0, &stats.WindowSample{})
+	if !strings.Contains(buf.String(), "0 req/s | - ok | p50 -") {
+		t.Errorf("empty window rendered as %q", buf.String())
+	}
+}
+
+func TestProgressWindowSamplesWithoutWriter(t *testing.T) {
+	var taken atomic.Int32
+	p := NewProgress(nil, 10, false, 0)
+	p.SetWindowSource(func(now time.Time) stats.WindowSample {
+		taken.Add(1)
+		return stats.WindowSample{}
+	})
+	p.Start()
+	time.Sleep(progressInterval + 200*time.Millisecond)
+	p.Stop()
+	if taken.Load() == 0 {
+		t.Error("expected a window sample with no writer to draw on")
+	}
+}
+
+func TestProgressStopTakesFinalWindow(t *testing.T) {
+	collector := stats.NewCollector(1)
+	p := NewProgress(nil, 1, false, 0)
+	p.SetWindowSource(collector.TakeWindow)
+	p.Start()
+	collector.Record(time.Now(), 200, 0.01, true, "", "", 0)
+	p.Stop()
+	windows := collector.Windows()
+	if len(windows) != 1 || windows[0].Requests != 1 {
+		t.Errorf("windows after a short run = %+v, want one sample with 1 request", windows)
+	}
+}
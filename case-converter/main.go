@@ -1,380 +1,383 @@
 package main
 
 import (
+	"bytes"
+	"common-module/caseconv"
 	"common-module/utils"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
-	"unicode"
+	"unicode/utf8"
 
 	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v3"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 )
 
-// CaseConverter contains all text transformation methods
-type CaseConverter struct{}
-
 // Global instances to avoid repeated allocations
 var (
-	globalCaseConverter = &CaseConverter{}
+	globalCaseConverter = caseconv.NewCaseConverter()
 	globalColorOutput   = &ColorOutput{}
-	titleCaser          = cases.Title(language.English)
+	titleCaser          = cases.Title(language.English) // follows --locale
 )
 
-// RemoveNonAlpha removes non-alphabetic characters from a string, keeping whitespace and alphanumeric
-func (cc *CaseConverter) RemoveNonAlpha(s string) string {
-	var result strings.Builder
-	result.Grow(len(s)) // Pre-allocate capacity
-	for _, char := range s {
-		if unicode.IsLetter(char) || unicode.IsSpace(char) || unicode.IsNumber(char) {
-			result.WriteRune(char)
-		}
-	}
-	return result.String()
+// ColorOutput provides colored terminal output
+type ColorOutput struct {
+	disabled bool // plain text for --no-color, NO_COLOR or redirected output
 }
 
-// ToSnakeCase converts string to snake_case
-func (cc *CaseConverter) ToSnakeCase(s string) string {
-	return strings.ToLower(strings.ReplaceAll(s, " ", "_"))
+// Green returns green colored text
+func (co *ColorOutput) Green(msg string) string {
+	if co.disabled {
+		return msg
+	}
+	return fmt.Sprintf("\033[42m\033[1;30m %s \033[0m", msg)
 }
 
-// ToPascalCase converts string to PascalCase
-func (cc *CaseConverter) ToPascalCase(s string) string {
-	words := strings.Fields(s)
-	if len(words) == 0 {
-		return s
+// Blue returns blue colored text
+func (co *ColorOutput) Blue(msg string) string {
+	if co.disabled {
+		return msg
 	}
+	return fmt.Sprintf("\033[44m\033[1;30m %s \033[0m", msg)
+}
 
-	var result strings.Builder
-	result.Grow(len(s)) // Pre-allocate capacity
+// PrintConversions writes all case conversions for a given line to w
+func PrintConversions(w io.Writer, line string) {
+	fmt.Fprintf(w, "\n%s: %s\n", globalColorOutput.Blue("Original"), line)
+	conversions := globalCaseConverter.Process(line)
 
-	for _, word := range words {
-		if len(word) > 0 {
-			if result.Len() > 0 {
-				result.WriteString(strings.ToUpper(word[:1]))
-				result.WriteString(strings.ToLower(word[1:]))
-			} else {
-				result.WriteString(strings.ToUpper(word[:1]))
-				result.WriteString(strings.ToLower(word[1:]))
-			}
+	for _, formatName := range caseconv.Formats() {
+		if converted, exists := conversions[formatName]; exists {
+			displayName := strings.ReplaceAll(formatName, "_", " ")
+			displayName = titleCaser.String(displayName)
+			fmt.Fprintf(w, "%s: %s\n", globalColorOutput.Green(displayName), converted)
 		}
 	}
-	return result.String()
 }
 
-// ToKebabCase converts string to kebab-case
-func (cc *CaseConverter) ToKebabCase(s string) string {
-	return strings.ToLower(strings.ReplaceAll(s, " ", "-"))
+// renameOp is one file rename planned by --rename
+type renameOp struct {
+	from, to string
 }
 
-// ToConstantCase converts string to CONSTANT_CASE
-func (cc *CaseConverter) ToConstantCase(s string) string {
-	return strings.ToUpper(strings.ReplaceAll(s, " ", "_"))
-}
-
-// ToPathCase converts string to path/case
-func (cc *CaseConverter) ToPathCase(s string) string {
-	return strings.ToLower(strings.ReplaceAll(s, " ", "/"))
-}
-
-// ToCamelCase converts string to camelCase
-func (cc *CaseConverter) ToCamelCase(s string) string {
-	words := strings.Fields(s)
-	if len(words) == 0 {
-		return s
-	}
-
-	var result strings.Builder
-	result.Grow(len(s)) // Pre-allocate capacity
-
-	// First word in lowercase
-	if len(words[0]) > 0 {
-		result.WriteString(strings.ToLower(words[0]))
-	}
-
-	// Subsequent words with first letter uppercase
-	for i := 1; i < len(words); i++ {
-		if len(words[i]) > 0 {
-			result.WriteString(strings.ToUpper(words[i][:1]))
-			result.WriteString(strings.ToLower(words[i][1:]))
+// planRenames converts the name of every file in dir (below it too with
+// recursive) to the format key, keeping the extension. Files already in that
+// format are left out, and so are hidden files and directories such as .git
+// unless hidden is set. A target used by two files, or taken by another
+// existing file, is returned in conflicts instead; the plan is only safe to
+// apply when there are none.
+func planRenames(dir, key string, recursive, hidden bool) (ops []renameOp, conflicts []string, err error) {
+	sources := map[string][]string{} // target -> files renamed to it
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
 		}
-	}
-	return result.String()
-}
-
-// ToTitleCase converts string to Title Case
-func (cc *CaseConverter) ToTitleCase(s string) string {
-	words := strings.Fields(s)
-	if len(words) == 0 {
-		return s
-	}
-
-	var result strings.Builder
-	result.Grow(len(s)) // Pre-allocate capacity
-
-	for i, word := range words {
-		if i > 0 {
-			result.WriteByte(' ')
+		name := d.Name()
+		if path != dir && !hidden && strings.HasPrefix(name, ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if path != dir && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
 		}
-		if len(word) > 0 {
-			result.WriteString(strings.ToUpper(word[:1]))
-			result.WriteString(strings.ToLower(word[1:]))
+		ext := filepath.Ext(name)
+		base := strings.TrimSuffix(name, ext)
+		if strings.TrimSpace(base) == "" {
+			return nil // dotfiles such as .gitignore have no name to convert
 		}
-	}
-	return result.String()
-}
-
-// ToDotCase converts string to dot.case
-func (cc *CaseConverter) ToDotCase(s string) string {
-	return strings.Join(strings.Fields(s), ".")
-}
-
-// FromSnakeCase converts snake_case to normal text
-func (cc *CaseConverter) FromSnakeCase(s string) string {
-	words := strings.Split(s, "_")
-	if len(words) == 0 {
-		return s
+		converted := globalCaseConverter.Process(base)[key]
+		if converted == "" || converted+ext == name {
+			return nil
+		}
+		target := filepath.Join(filepath.Dir(path), converted+ext)
+		sources[target] = append(sources[target], path)
+		ops = append(ops, renameOp{from: path, to: target})
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
 	}
 
-	var result strings.Builder
-	result.Grow(len(s)) // Pre-allocate capacity
-
-	for i, word := range words {
-		if i > 0 {
-			result.WriteByte(' ')
+	for _, op := range ops {
+		if froms := sources[op.to]; len(froms) > 1 {
+			if froms[0] == op.from {
+				conflicts = append(conflicts, fmt.Sprintf("%s: would be the new name of %s", op.to, strings.Join(froms, ", ")))
+			}
+			continue
 		}
-		if len(word) > 0 {
-			result.WriteString(strings.ToUpper(word[:1]))
-			result.WriteString(strings.ToLower(word[1:]))
+		// A case-only rename finds its own file on case-insensitive file systems
+		if info, err := os.Stat(op.to); err == nil {
+			if src, err := os.Stat(op.from); err != nil || !os.SameFile(info, src) {
+				conflicts = append(conflicts, fmt.Sprintf("%s: already exists (new name of %s)", op.to, op.from))
+			}
 		}
 	}
-	return result.String()
+	return ops, conflicts, nil
 }
 
-// FromPascalCase converts PascalCase to normal text
-func (cc *CaseConverter) FromPascalCase(s string) string {
-	if len(s) == 0 {
-		return s
+// renameFiles implements --rename: it prints the old -> new mapping and,
+// unless dryRun, renames the files once the whole plan is known to be free of
+// conflicts
+func renameFiles(dir, format string, recursive, hidden, dryRun bool) error {
+	key, ok := caseconv.FormatKey(format)
+	if !ok {
+		return fmt.Errorf("--rename needs --format with a known format, got %q", format)
 	}
-
-	var result strings.Builder
-	result.Grow(len(s) + 10) // Pre-allocate capacity with some extra space
-
-	for i, char := range s {
-		if i > 0 && unicode.IsUpper(char) {
-			result.WriteByte(' ')
+	ops, conflicts, err := planRenames(dir, key, recursive, hidden)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		for _, c := range conflicts {
+			fmt.Printf("Conflict: %s\n", c)
 		}
-		result.WriteRune(char)
+		return fmt.Errorf("%d conflict(s), nothing renamed", len(conflicts))
 	}
-	return result.String()
-}
-
-// FromCamelCase converts camelCase to normal text
-func (cc *CaseConverter) FromCamelCase(s string) string {
-	if len(s) == 0 {
-		return s
+	if len(ops) == 0 {
+		fmt.Println("Nothing to rename")
+		return nil
 	}
 
-	var result strings.Builder
-	result.Grow(len(s) + 10) // Pre-allocate capacity with some extra space
-
-	for i, char := range s {
-		if i > 0 && unicode.IsUpper(char) {
-			result.WriteByte(' ')
+	for _, op := range ops {
+		from, _ := filepath.Rel(dir, op.from)
+		to, _ := filepath.Rel(dir, op.to)
+		fmt.Printf("%s -> %s\n", from, to)
+		if dryRun {
+			continue
+		}
+		if err := os.Rename(op.from, op.to); err != nil {
+			return err
 		}
-		result.WriteRune(char)
 	}
-	return result.String()
-}
-
-// FromKebabCase converts kebab-case to normal text
-func (cc *CaseConverter) FromKebabCase(s string) string {
-	words := strings.Split(s, "-")
-	if len(words) == 0 {
-		return s
+	if dryRun {
+		fmt.Printf("%d file(s) would be renamed (dry run)\n", len(ops))
+	} else {
+		fmt.Printf("Renamed %d file(s)\n", len(ops))
 	}
+	return nil
+}
 
-	var result strings.Builder
-	result.Grow(len(s)) // Pre-allocate capacity
-
-	for i, word := range words {
-		if i > 0 {
-			result.WriteByte(' ')
+// yamlIndent guesses the indentation step of a YAML document: the smallest
+// indent of a line that has one. yaml.v3 re-indents everything it writes, so
+// when a file has to be re-encoded this keeps a 4-space file at 4 spaces.
+func yamlIndent(data []byte) int {
+	indent := 0
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		n := len(line) - len(trimmed)
+		if n == 0 || trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
 		}
-		if len(word) > 0 {
-			result.WriteString(strings.ToUpper(word[:1]))
-			result.WriteString(strings.ToLower(word[1:]))
+		if indent == 0 || n < indent {
+			indent = n
 		}
 	}
-	return result.String()
-}
-
-// ColorOutput provides colored terminal output
-type ColorOutput struct{}
-
-// Green returns green colored text
-func (co *ColorOutput) Green(msg string) string {
-	return fmt.Sprintf("\033[42m\033[1;30m %s \033[0m", msg)
+	if indent < 2 {
+		return 2
+	}
+	return indent
 }
 
-// Blue returns blue colored text
-func (co *ColorOutput) Blue(msg string) string {
-	return fmt.Sprintf("\033[44m\033[1;30m %s \033[0m", msg)
+// yamlKeyEdit is a key renamed by convertYAMLKeys, at its place in the source
+type yamlKeyEdit struct {
+	line, column int // 1-based, as yaml.v3 reports them; the column counts runes
+	style        yaml.Style
+	from, to     string
 }
 
-// detectCaseType detects the input case type to avoid unnecessary conversions
-func detectCaseType(text string) string {
-	if strings.Contains(text, " ") {
-		return "normal"
-	}
-	if strings.Contains(text, "_") {
-		return "snake"
+// convertYAMLKeys renames every mapping key below node to the format key and
+// records each rename in edits. Merge keys (<<) and non-scalar keys are left
+// alone, and nodes reached again through an alias are converted once. Two
+// keys of one mapping that end up with the same name are an error.
+func convertYAMLKeys(node *yaml.Node, key string, seen map[*yaml.Node]bool, edits *[]yamlKeyEdit) error {
+	if node == nil || seen[node] {
+		return nil
 	}
-	if strings.Contains(text, "-") {
-		return "kebab"
-	}
-	if strings.Contains(text, ".") {
-		return "dot"
-	}
-	if strings.Contains(text, "/") {
-		return "path"
+	seen[node] = true
+
+	if node.Kind == yaml.MappingNode {
+		renamed := make(map[string]string, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k := node.Content[i]
+			if k.Kind != yaml.ScalarNode {
+				continue
+			}
+			if k.Tag == "!!merge" {
+				k.Tag = "" // or the encoder writes it out as "!!merge <<"
+				continue
+			}
+			if converted := globalCaseConverter.Process(k.Value)[key]; converted != "" {
+				if prev, dup := renamed[converted]; dup && prev != k.Value {
+					return fmt.Errorf("line %d: keys %q and %q both become %q", k.Line, prev, k.Value, converted)
+				}
+				renamed[converted] = k.Value
+				if converted != k.Value {
+					*edits = append(*edits, yamlKeyEdit{line: k.Line, column: k.Column, style: k.Style, from: k.Value, to: converted})
+				}
+				k.Value = converted
+			}
+		}
+		for i := 1; i < len(node.Content); i += 2 {
+			if err := convertYAMLKeys(node.Content[i], key, seen, edits); err != nil {
+				return err
+			}
+		}
+		return nil
 	}
-	// Check for camelCase or PascalCase
-	for i, char := range text {
-		if i > 0 && unicode.IsUpper(char) {
-			return "camel_or_pascal"
+	for _, child := range node.Content {
+		if err := convertYAMLKeys(child, key, seen, edits); err != nil {
+			return err
 		}
 	}
-	return "unknown"
+	return nil
 }
 
-// normalizeText optimizes text normalization based on detected case type
-func normalizeText(text string) string {
-	caseType := detectCaseType(text)
-
-	switch caseType {
-	case "normal":
-		return text
-	case "snake":
-		return globalCaseConverter.FromSnakeCase(text)
-	case "kebab":
-		return globalCaseConverter.FromKebabCase(text)
-	case "dot":
-		return strings.ReplaceAll(text, ".", " ")
-	case "path":
-		return strings.ReplaceAll(text, "/", " ")
-	case "camel_or_pascal":
-		// Try camel case first, then pascal
-		result := globalCaseConverter.FromCamelCase(text)
-		if result != text {
-			return result
-		}
-		return globalCaseConverter.FromPascalCase(text)
-	default:
-		// Try all conversions as fallback
-		result := globalCaseConverter.FromCamelCase(text)
-		if result != text {
-			return result
-		}
-		result = globalCaseConverter.FromSnakeCase(text)
-		if result != text {
-			return result
-		}
-		result = globalCaseConverter.FromKebabCase(text)
-		if result != text {
-			return result
-		}
-		return globalCaseConverter.FromPascalCase(text)
+// yamlKeySource returns how a key of the given style and value is written in
+// the source, or false for styles whose text cannot be told from the value
+func yamlKeySource(style yaml.Style, value string) (string, bool) {
+	switch style {
+	case 0:
+		return value, true
+	case yaml.SingleQuotedStyle:
+		return "'" + strings.ReplaceAll(value, "'", "''") + "'", true
+	case yaml.DoubleQuotedStyle:
+		// Only found when the key has no escape sequences
+		return `"` + value + `"`, true
 	}
+	return "", false
 }
 
-// ProcessCaseConversions processes text and returns all case conversions
-func ProcessCaseConversions(text string) map[string]string {
-	// Normalize text efficiently
-	normalized := normalizeText(text)
-
-	// Clean up the text
-	words := strings.Fields(strings.TrimSpace(normalized))
-	cleanText := globalCaseConverter.RemoveNonAlpha(strings.Join(words, " "))
-	cleanText = strings.ToLower(cleanText)
-
-	if len(cleanText) == 0 {
-		cleanText = strings.ToLower(strings.TrimSpace(text))
+// renderYAMLKey writes value as a key in the given style; a plain key that
+// would read as something else (a number, true) gets quoted
+func renderYAMLKey(style yaml.Style, value string) string {
+	switch style {
+	case yaml.SingleQuotedStyle:
+		return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+	case yaml.DoubleQuotedStyle:
+		return strconv.Quote(value)
 	}
-
-	// Pre-allocate the result map
-	result := make(map[string]string, 13)
-
-	// Use cached instances and avoid repeated allocations
-	result["normal"] = cleanText
-	result["upper"] = strings.ToUpper(cleanText)
-	result["lower"] = strings.ToLower(cleanText)
-
-	if len(cleanText) > 0 {
-		result["capitalized"] = strings.ToUpper(cleanText[:1]) + strings.ToLower(cleanText[1:])
-	} else {
-		result["capitalized"] = cleanText
+	out, err := yaml.Marshal(value)
+	if err != nil {
+		return strconv.Quote(value)
 	}
-
-	result["swapped"] = swapCase(cleanText)
-	result["snake_case"] = globalCaseConverter.ToSnakeCase(cleanText)
-	result["kebab_case"] = globalCaseConverter.ToKebabCase(cleanText)
-	result["camel_case"] = globalCaseConverter.ToCamelCase(cleanText)
-	result["pascal_case"] = globalCaseConverter.ToPascalCase(cleanText)
-	result["constant_case"] = globalCaseConverter.ToConstantCase(cleanText)
-	result["title_case"] = globalCaseConverter.ToTitleCase(cleanText)
-	result["dot_case"] = globalCaseConverter.ToDotCase(cleanText)
-	result["path_case"] = globalCaseConverter.ToPathCase(cleanText)
-	result["pascal_kebab"] = strings.ReplaceAll(globalCaseConverter.ToTitleCase(cleanText), " ", "-")
-
-	return result
+	return strings.TrimSuffix(string(out), "\n")
 }
 
-// swapCase swaps the case of each character
-func swapCase(s string) string {
-	var result strings.Builder
-	result.Grow(len(s)) // Pre-allocate capacity
-	for _, char := range s {
-		if unicode.IsUpper(char) {
-			result.WriteRune(unicode.ToLower(char))
-		} else if unicode.IsLower(char) {
-			result.WriteRune(unicode.ToUpper(char))
-		} else {
-			result.WriteRune(char)
+// replaceYAMLKeys applies edits to the text of data, leaving everything else
+// byte for byte as it was. It reports false when a key is not found where
+// yaml.v3 placed it (e.g. behind an anchor or tag, or with escapes).
+func replaceYAMLKeys(data []byte, edits []yamlKeyEdit) ([]byte, bool) {
+	lines := strings.SplitAfter(string(data), "\n")
+	byLine := map[int][]yamlKeyEdit{}
+	for _, e := range edits {
+		if e.line < 1 || e.line > len(lines) {
+			return nil, false
 		}
+		byLine[e.line] = append(byLine[e.line], e)
 	}
-	return result.String()
+	for line, edits := range byLine {
+		// Right to left, so the columns of the keys still to do stay valid
+		sort.Slice(edits, func(i, j int) bool { return edits[i].column > edits[j].column })
+		runes := []rune(lines[line-1])
+		for _, e := range edits {
+			src, ok := yamlKeySource(e.style, e.from)
+			start, end := e.column-1, e.column-1+utf8.RuneCountInString(src)
+			if !ok || start < 0 || end > len(runes) || string(runes[start:end]) != src {
+				return nil, false
+			}
+			runes = slices.Concat(runes[:start], []rune(renderYAMLKey(e.style, e.to)), runes[end:])
+		}
+		lines[line-1] = string(runes)
+	}
+	return []byte(strings.Join(lines, "")), true
 }
 
-// Pre-defined sorted keys to avoid sorting every time
-var sortedKeys = []string{
-	"normal", "upper", "lower", "capitalized", "swapped",
-	"snake_case", "kebab_case", "camel_case", "pascal_case",
-	"constant_case", "title_case", "dot_case", "path_case", "pascal_kebab",
-}
+// convertYAMLFile implements --yaml-keys: it writes path to w with every
+// mapping key converted to format. Only the keys are rewritten, so values,
+// comments and layout are kept as they are. A file whose keys cannot all be
+// found in the text (anchored, tagged or escaped keys) is re-encoded by
+// yaml.v3 instead, which keeps values, comments and the indentation step but
+// normalizes sequence indentation and comment spacing.
+func convertYAMLFile(w io.Writer, path, format string) error {
+	key, ok := caseconv.FormatKey(format)
+	if !ok {
+		return fmt.Errorf("--yaml-keys needs --format with a known format, got %q", format)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
 
-// PrintConversions prints all case conversions for a given line
-func PrintConversions(line string) {
-	fmt.Printf("\n%s: %s\n", globalColorOutput.Blue("Original"), line)
-	conversions := ProcessCaseConversions(line)
+	var docs []*yaml.Node
+	var edits []yamlKeyEdit
+	dec := yaml.NewDecoder(strings.NewReader(string(data)))
+	for {
+		var doc yaml.Node
+		if err := dec.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("parsing %s: %w", path, err)
+		}
+		if err := convertYAMLKeys(&doc, key, map[*yaml.Node]bool{}, &edits); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		docs = append(docs, &doc)
+	}
 
-	for _, formatName := range sortedKeys {
-		if converted, exists := conversions[formatName]; exists {
-			displayName := strings.ReplaceAll(formatName, "_", " ")
-			displayName = titleCaser.String(displayName)
-			fmt.Printf("%s: %s\n", globalColorOutput.Green(displayName), converted)
+	if out, ok := replaceYAMLKeys(data, edits); ok {
+		_, err := w.Write(out)
+		return err
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(yamlIndent(data))
+	for _, doc := range docs {
+		if err := enc.Encode(doc); err != nil {
+			return err
 		}
 	}
+	return enc.Close()
 }
 
 var (
-	file   string
-	all    bool
-	format string
+	file         string
+	all          bool
+	format       string
+	noColor      bool
+	acronyms     string
+	acronymsFile string
+	stdAcronyms  bool
+	renameDir    string
+	recursive    bool
+	renameHidden bool
+	dryRun       bool
+	yamlKeys     string
+	delimiters   []string
+	stripNumbers bool
+	smartNumbers bool
+	outputFile   string
+	locale       string
 )
 
-func main() {
+// newRootCmd builds the command with its flags. Each command starts from a
+// fresh converter, which its flags then configure.
+func newRootCmd() *cobra.Command {
+	globalCaseConverter = caseconv.NewCaseConverter()
+
 	var rootCmd = &cobra.Command{
 		Use:   "case-converter",
 		Short: "Case Converter CLI Tool - A text case conversion utility",
@@ -391,10 +394,94 @@ Examples:
   case-converter "hello world" --all
 
   # Output specific format only
-  case-converter "hello world" --format snake`,
+  case-converter "hello world" --format snake
+
+  # Keep common and domain abbreviations all caps in PascalCase and camelCase
+  case-converter "user id" --default-acronyms --format pascal_case
+  case-converter "order sku eta" --acronyms SKU,ETA --format pascal_case
+
+  # Rename every file in a directory tree to kebab-case, previewing first
+  case-converter --rename assets --format kebab-case --recursive --dry-run
+
+  # Treat C++ namespace separators as word boundaries
+  case-converter "std::unordered_map" --delimiter :: --format pascal
+
+  # Convert the keys of a YAML file, keeping values and comments
+  case-converter --yaml-keys config.yaml --format camel > config.camel.yaml
+
+  # Write the conversions of a long list to a file instead of the terminal
+  case-converter -f fields.txt --all --output-file results.txt
+
+  # Use Turkish casing rules (i -> İ, I -> ı)
+  case-converter "istanbul" --format constant --locale tr`,
 		Run: func(cmd *cobra.Command, args []string) {
-			// Clear screen
-			utils.CLS()
+			globalColorOutput.disabled = !utils.ColorEnabled(os.Stdout, noColor)
+
+			// The built-in list comes first, then the file, so --acronyms can
+			// re-add a word the file removed
+			if stdAcronyms {
+				globalCaseConverter.AddDefaultAcronyms()
+			}
+			if acronymsFile != "" {
+				if err := globalCaseConverter.LoadAcronymsFile(acronymsFile); err != nil {
+					fmt.Printf("Error reading acronyms file: %v\n", err)
+					os.Exit(1)
+				}
+			}
+			if acronyms != "" {
+				globalCaseConverter.AddAcronyms(strings.Split(acronyms, ",")...)
+			}
+			for _, d := range delimiters {
+				globalCaseConverter.AddDelimiter(d)
+			}
+			globalCaseConverter.StripNumbers = stripNumbers
+			globalCaseConverter.SmartNumbers = smartNumbers
+
+			tag, err := language.Parse(locale)
+			if err != nil {
+				fmt.Printf("Error: invalid --locale %q: %v\n", locale, err)
+				os.Exit(1)
+			}
+			globalCaseConverter.Locale = tag
+			titleCaser = cases.Title(tag)
+
+			// Conversions go to --output-file instead of stdout when set; files
+			// are not terminals, so they get no colors. The file is written
+			// once everything is converted: it may be the input itself, and a
+			// failed run leaves it as it was.
+			var out io.Writer = os.Stdout
+			if outputFile != "" {
+				var buf bytes.Buffer
+				defer func() {
+					if err := os.WriteFile(outputFile, buf.Bytes(), 0o644); err != nil {
+						fmt.Printf("Error writing output file: %v\n", err)
+						os.Exit(1)
+					}
+				}()
+				out = &buf
+				globalColorOutput.disabled = true
+			}
+
+			if yamlKeys != "" {
+				if err := convertYAMLFile(out, yamlKeys, format); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+				return
+			}
+
+			if renameDir != "" {
+				if err := renameFiles(renameDir, format, recursive, renameHidden, dryRun); err != nil {
+					fmt.Printf("Error: %v\n", err)
+					os.Exit(1)
+				}
+				return
+			}
+
+			// Clear screen, unless the results go to a file
+			if outputFile == "" {
+				utils.CLS()
+			}
 
 			var inputText string
 			if file != "" {
@@ -418,11 +505,11 @@ Examples:
 				// Output specific format
 				for _, line := range lines {
 					if strings.TrimSpace(line) != "" {
-						conversions := ProcessCaseConversions(line)
-						if result, exists := conversions[format]; exists {
-							fmt.Println(result)
+						conversions := globalCaseConverter.Process(line)
+						if key, ok := caseconv.FormatKey(format); ok {
+							fmt.Fprintln(out, conversions[key])
 						} else {
-							fmt.Println(line)
+							fmt.Fprintln(out, line)
 						}
 					}
 				}
@@ -430,7 +517,7 @@ Examples:
 				// Output all formats
 				for _, line := range lines {
 					if strings.TrimSpace(line) != "" {
-						PrintConversions(line)
+						PrintConversions(out, line)
 					}
 				}
 			} else {
@@ -438,7 +525,7 @@ Examples:
 				if len(lines) > 0 {
 					line := strings.TrimSpace(lines[0])
 					if line != "" {
-						PrintConversions(line)
+						PrintConversions(out, line)
 					}
 				}
 			}
@@ -447,9 +534,27 @@ Examples:
 
 	rootCmd.Flags().StringVarP(&file, "file", "f", "", "Input file containing text to convert")
 	rootCmd.Flags().BoolVar(&all, "all", false, "Show all case conversions")
-	rootCmd.Flags().StringVar(&format, "format", "", "Specific format to output (normal, upper, lower, snake, kebab, camel, pascal, constant, title, dot, path)")
+	rootCmd.Flags().BoolVar(&noColor, "no-color", false, "Disable ANSI colors (also off when NO_COLOR is set or stdout is not a terminal)")
+	rootCmd.Flags().StringVar(&acronyms, "acronyms", "", "Comma-separated extra acronyms kept all caps in PascalCase and camelCase (e.g. SKU,ETA)")
+	rootCmd.Flags().StringVar(&acronymsFile, "acronyms-file", "", "File with one acronym per line (# comments, \"- WORD\" removes an acronym, e.g. a default one)")
+	rootCmd.Flags().BoolVar(&stdAcronyms, "default-acronyms", false, "Keep common abbreviations (API, HTTP, ID, URL, ...) all caps in PascalCase and camelCase")
+	rootCmd.Flags().StringVar(&format, "format", "", "Specific format to output (normal, upper, lower, snake, kebab, camel, pascal, constant, cobol, title, dot, path, train, ada)")
+	rootCmd.Flags().StringVar(&renameDir, "rename", "", "Rename the files in this directory to --format (extensions are kept)")
+	rootCmd.Flags().BoolVar(&recursive, "recursive", false, "With --rename, also rename files in subdirectories")
+	rootCmd.Flags().BoolVar(&renameHidden, "hidden", false, "With --rename, also rename hidden files and enter hidden directories such as .git")
+	rootCmd.Flags().BoolVar(&dryRun, "dry-run", false, "With --rename, print the old -> new names without renaming")
+	rootCmd.Flags().StringArrayVar(&delimiters, "delimiter", nil, "Extra word boundary, e.g. :: or -> or | (repeatable)")
+	rootCmd.Flags().BoolVar(&stripNumbers, "strip-numbers", false, "Remove digits from the input before converting (all formats)")
+	rootCmd.Flags().BoolVar(&smartNumbers, "smart-numbers", false, "Treat digits in camelCase/PascalCase input as separate words (test2Result -> test_2_result)")
+	rootCmd.Flags().StringVar(&locale, "locale", "en", "BCP-47 language tag for upper/lower-case rules, e.g. tr or az for dotted and dotless i")
+	rootCmd.Flags().StringVar(&yamlKeys, "yaml-keys", "", "Print this YAML file with every mapping key converted to --format")
+	rootCmd.Flags().StringVar(&outputFile, "output-file", "", "Write the conversions to this file instead of stdout (without colors)")
+	rootCmd.MarkFlagsMutuallyExclusive("output-file", "rename")
+	return rootCmd
+}
 
-	if err := rootCmd.Execute(); err != nil {
+func main() {
+	if err := newRootCmd().Execute(); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
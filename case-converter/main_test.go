@@ -0,0 +1,276 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// runCLI executes a fresh root command with args, writing the conversions to
+// a temporary --output-file, and returns what was written there
+func runCLI(t *testing.T, args ...string) string {
+	t.Helper()
+
+	out := filepath.Join(t.TempDir(), "out.txt")
+	cmd := newRootCmd()
+	cmd.SetArgs(append(args, "--output-file", out))
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute(%q) returned error: %v", args, err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("read output file: %v", err)
+	}
+	return string(data)
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("create parent of %s: %v", path, err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func TestCLIAcronyms(t *testing.T) {
+	acronymsFile := filepath.Join(t.TempDir(), "acronyms.txt")
+	writeFile(t, acronymsFile, "# domain words\nSKU\n- ID\n")
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"none by default", []string{"user id url"}, "UserIdUrl\n"},
+		{"default list", []string{"user id url", "--default-acronyms"}, "UserIDURL\n"},
+		{"own acronyms only", []string{"user id sku", "--acronyms", "sku"}, "UserIdSKU\n"},
+		{"file removes a default", []string{"user id sku url", "--default-acronyms", "--acronyms-file", acronymsFile}, "UserIdSKUURL\n"},
+		{"flag re-adds what the file removed", []string{"user id", "--default-acronyms", "--acronyms-file", acronymsFile, "--acronyms", "id"}, "UserID\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := runCLI(t, append(tt.args, "--format", "pascal")...); got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// Each command starts over: acronyms added by an earlier run are gone
+	if got := runCLI(t, "user id", "--format", "camel"); got != "userId\n" {
+		t.Errorf("after other runs: output = %q, want %q", got, "userId\n")
+	}
+}
+
+func TestCLIConversions(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"to cobol", []string{"hello world", "--format", "cobol"}, "HELLO-WORLD\n"},
+		{"to cobol by full name", []string{"order_item_id", "--format", "cobol-case"}, "ORDER-ITEM-ID\n"},
+		{"from cobol to camel", []string{"HELLO-WORLD", "--format", "camel"}, "helloWorld\n"},
+		{"from cobol to kebab", []string{"HELLO-WORLD", "--format", "kebab"}, "hello-world\n"},
+		{"without delimiter", []string{"std::unordered_map", "--format", "pascal"}, "StdunorderedMap\n"},
+		{"delimiter", []string{"std::unordered_map", "--delimiter", "::", "--format", "pascal"}, "StdUnorderedMap\n"},
+		{"repeated delimiter", []string{"a->b|c", "--delimiter", "->", "--delimiter", "|", "--format", "snake"}, "a_b_c\n"},
+		{"keeps numbers", []string{"user2_name3", "--format", "snake"}, "user2_name3\n"},
+		{"strip numbers", []string{"user2_name3", "--strip-numbers", "--format", "snake"}, "user_name\n"},
+		{"strip numbers with a leading version", []string{"v2 api", "--strip-numbers", "--format", "constant"}, "V_API\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := runCLI(t, tt.args...); got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCLIAllListsCobol(t *testing.T) {
+	got := runCLI(t, "HELLO-WORLD", "--all")
+	for _, want := range []string{"Original: HELLO-WORLD\n", "Kebab Case: hello-world\n", "Cobol Case: HELLO-WORLD\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("--all output missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestCLIYAMLKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			"layout kept",
+			"# Service config\nserver_config:\n  listen_port: 8080   # port\n  allowed_hosts:\n  - alpha.example\n  - beta.example\ndb_settings:\n    max_conns: 4\n",
+			"# Service config\nserverConfig:\n  listenPort: 8080   # port\n  allowedHosts:\n  - alpha.example\n  - beta.example\ndbSettings:\n    maxConns: 4\n",
+		},
+		{
+			"quoted and flow keys",
+			"'user''s_name': a\n\"last_name\": b\nflow_map: {first_key: 1, second_key: 2}\n",
+			"'usersName': a\n\"lastName\": b\nflowMap: {firstKey: 1, secondKey: 2}\n",
+		},
+		{
+			"values and merge keys left alone",
+			"base: &base\n  time_out: 5\nprod_env:\n  <<: *base\n  host_name: some_host\n",
+			"base: &base\n  timeOut: 5\nprodEnv:\n  <<: *base\n  hostName: some_host\n",
+		},
+		{
+			"multiple documents",
+			"first_doc: 1\n---\nsecond_doc:   2\n",
+			"firstDoc: 1\n---\nsecondDoc:   2\n",
+		},
+		{
+			"escaped key is re-encoded",
+			"\"user\\u005fname\": a\nsome_list:\n- x\n",
+			"\"userName\": a\nsomeList:\n  - x\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "in.yaml")
+			writeFile(t, path, tt.in)
+			if got := runCLI(t, "--yaml-keys", path, "--format", "camel"); got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCLIOutputFileIsInput(t *testing.T) {
+	dir := t.TempDir()
+	lines := filepath.Join(dir, "in.txt")
+	writeFile(t, lines, "user_name\norder_id\n")
+	config := filepath.Join(dir, "in.yaml")
+	writeFile(t, config, "server_config:\n  listen_port: 8080   # port\n")
+
+	tests := []struct {
+		path string
+		args []string
+		want string
+	}{
+		{lines, []string{"-f", lines, "--format", "camel"}, "userName\norderId\n"},
+		{config, []string{"--yaml-keys", config, "--format", "camel"}, "serverConfig:\n  listenPort: 8080   # port\n"},
+	}
+	for _, tt := range tests {
+		cmd := newRootCmd()
+		cmd.SetArgs(append(tt.args, "--output-file", tt.path))
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("Execute(%q) returned error: %v", tt.args, err)
+		}
+		data, err := os.ReadFile(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != tt.want {
+			t.Errorf("%s after converting in place = %q, want %q", filepath.Base(tt.path), data, tt.want)
+		}
+	}
+}
+
+func TestConvertYAMLFileDuplicateKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "in.yaml")
+	writeFile(t, path, "user_id: 1\nuserId: 2\n")
+	err := convertYAMLFile(io.Discard, path, "camel")
+	if err == nil || !strings.Contains(err.Error(), "userId") {
+		t.Errorf("convertYAMLFile() error = %v, want a duplicate userId error", err)
+	}
+}
+
+// planned returns the renames of planRenames as "from -> to" relative to dir
+func planned(t *testing.T, dir string, ops []renameOp) []string {
+	t.Helper()
+
+	var got []string
+	for _, op := range ops {
+		from, _ := filepath.Rel(dir, op.from)
+		to, _ := filepath.Rel(dir, op.to)
+		got = append(got, filepath.ToSlash(from)+" -> "+filepath.ToSlash(to))
+	}
+	return got
+}
+
+func TestPlanRenames(t *testing.T) {
+	dir := t.TempDir()
+	for _, rel := range []string{"MyFile.txt", "already-kebab.md", "other_file.go", "sub/InnerFile.txt", ".git/HEAD", ".github/CodeOwners", ".eslintrc.json"} {
+		writeFile(t, filepath.Join(dir, filepath.FromSlash(rel)), "")
+	}
+
+	tests := []struct {
+		name              string
+		recursive, hidden bool
+		want              []string
+	}{
+		{
+			name: "top level only",
+			want: []string{"MyFile.txt -> my-file.txt", "other_file.go -> other-file.go"},
+		},
+		{
+			name:      "recursive skips hidden directories",
+			recursive: true,
+			want:      []string{"MyFile.txt -> my-file.txt", "other_file.go -> other-file.go", "sub/InnerFile.txt -> sub/inner-file.txt"},
+		},
+		{
+			name:      "hidden opts in",
+			recursive: true,
+			hidden:    true,
+			want: []string{
+				".eslintrc.json -> eslintrc.json",
+				".git/HEAD -> .git/h-e-a-d",
+				".github/CodeOwners -> .github/code-owners",
+				"MyFile.txt -> my-file.txt",
+				"other_file.go -> other-file.go",
+				"sub/InnerFile.txt -> sub/inner-file.txt",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops, conflicts, err := planRenames(dir, "kebab_case", tt.recursive, tt.hidden)
+			if err != nil {
+				t.Fatalf("planRenames returned error: %v", err)
+			}
+			if len(conflicts) != 0 {
+				t.Errorf("conflicts = %q, want none", conflicts)
+			}
+			if got := planned(t, dir, ops); !slices.Equal(got, tt.want) {
+				t.Errorf("plan = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanRenamesConflicts(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"MyFile.txt", "my_file.txt", "FooBar.txt", "foo-bar.txt"} {
+		writeFile(t, filepath.Join(dir, name), "")
+	}
+
+	_, conflicts, err := planRenames(dir, "kebab_case", false, false)
+	if err != nil {
+		t.Fatalf("planRenames returned error: %v", err)
+	}
+	want := []string{
+		filepath.Join(dir, "foo-bar.txt") + ": already exists (new name of " + filepath.Join(dir, "FooBar.txt") + ")",
+		filepath.Join(dir, "my-file.txt") + ": would be the new name of " + filepath.Join(dir, "MyFile.txt") + ", " + filepath.Join(dir, "my_file.txt"),
+	}
+	if !slices.Equal(conflicts, want) {
+		t.Errorf("conflicts = %q, want %q", conflicts, want)
+	}
+}
@@ -2,45 +2,112 @@ package cmd
 
 import (
 	"check-folder-size/internal/scanner"
+	"check-folder-size/internal/tui"
 	"check-folder-size/internal/ui"
 	"common-module/utils"
 	"context"
 	"encoding/json"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"sort"
-	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
 )
 
 var (
-	sortBy      string
-	asc         bool
-	progress    bool
-	noClear     bool
-	excludeDirs string
-	timeout     int
-	maxDepth    int
-	jsonOutput  bool
-	minSize     string
-	maxSize     string
+	sortBy       string
+	asc          bool
+	progress     bool
+	noClear      bool
+	excludeDirs  string
+	timeout      int
+	maxDepth     int
+	jsonOutput   bool
+	minSize      string
+	maxSize      string
+	alert        string
+	alertItem    string
+	groupByExt   bool
+	topExt       int
+	noChart      bool
+	noPct        bool
+	largestN     int
+	htmlOutput   string
+	ignoreHidden bool
+	readIgnore   bool
+	watch        bool
+	interval     time.Duration
+	interactive  bool
+	noColor      bool
 )
 
+// alertExitCode is returned when a --alert or --alert-per-item threshold is
+// exceeded, so scripts can tell it apart from a usage error (exit 1)
+const alertExitCode = 2
+
 var RootCmd = &cobra.Command{
 	Use:   "check-folder-size [path]",
 	Short: "Calculate folder sizes with improved features",
 	Long:  `A tool to analyze folder sizes with progress tracking, exclusion lists, and colored output.`,
 	Args:  cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
+		ui.SetColor(utils.ColorEnabled(os.Stdout, noColor))
+
 		// Validate sort flag
 		if sortBy != "size" && sortBy != "name" {
 			fmt.Fprintf(os.Stderr, "Error: --sort must be 'size' or 'name', got '%s'\n", sortBy)
 			os.Exit(1)
 		}
+		if topExt < 0 {
+			fmt.Fprintf(os.Stderr, "Error: --top-ext must not be negative, got %d\n", topExt)
+			os.Exit(1)
+		}
+		if largestN < 0 {
+			fmt.Fprintf(os.Stderr, "Error: --find-largest-files must not be negative, got %d\n", largestN)
+			os.Exit(1)
+		}
+		if watch && htmlOutput != "" {
+			fmt.Fprintln(os.Stderr, "Error: --watch cannot be combined with --output-html")
+			os.Exit(1)
+		}
+		if watch && jsonOutput {
+			fmt.Fprintln(os.Stderr, "Error: --watch cannot be combined with --json")
+			os.Exit(1)
+		}
+		if watch && interval <= 0 {
+			fmt.Fprintf(os.Stderr, "Error: --interval must be positive, got %s\n", interval)
+			os.Exit(1)
+		}
+		if interactive {
+			conflicts := []struct {
+				flag string
+				set  bool
+			}{
+				{"--watch", watch},
+				{"--json", jsonOutput},
+				{"--output-html", htmlOutput != ""},
+				{"--group-by-ext", groupByExt || topExt > 0},
+			}
+			for _, c := range conflicts {
+				if c.set {
+					fmt.Fprintf(os.Stderr, "Error: --interactive cannot be combined with %s\n", c.flag)
+					os.Exit(1)
+				}
+			}
+		}
+		// A progress line would be wiped by every redraw
+		if watch || interactive {
+			progress = false
+		}
+		// --top-ext only makes sense for extension groups
+		if topExt > 0 {
+			groupByExt = true
+		}
 
 		// Parse exclude list
 		var excludeList []string
@@ -55,7 +122,7 @@ var RootCmd = &cobra.Command{
 		var minSizeBytes, maxSizeBytes int64
 		if minSize != "" {
 			var err error
-			minSizeBytes, err = parseSize(minSize)
+			minSizeBytes, err = utils.ParseSize(minSize)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error: invalid --min-size value '%s': %v\n", minSize, err)
 				os.Exit(1)
@@ -63,7 +130,7 @@ var RootCmd = &cobra.Command{
 		}
 		if maxSize != "" {
 			var err error
-			maxSizeBytes, err = parseSize(maxSize)
+			maxSizeBytes, err = utils.ParseSize(maxSize)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error: invalid --max-size value '%s': %v\n", maxSize, err)
 				os.Exit(1)
@@ -72,6 +139,25 @@ var RootCmd = &cobra.Command{
 			maxSizeBytes = 1<<63 - 1
 		}
 
+		// Parse alert thresholds (0 = disabled)
+		var alertBytes, alertItemBytes int64
+		if alert != "" {
+			var err error
+			alertBytes, err = utils.ParseSize(alert)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: invalid --alert value '%s': %v\n", alert, err)
+				os.Exit(1)
+			}
+		}
+		if alertItem != "" {
+			var err error
+			alertItemBytes, err = utils.ParseSize(alertItem)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: invalid --alert-per-item value '%s': %v\n", alertItem, err)
+				os.Exit(1)
+			}
+		}
+
 		// Determine path to analyze
 		path := "."
 		if len(args) > 0 {
@@ -95,6 +181,94 @@ var RootCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
+		scanOpts := scanner.ScanOptions{
+			ShowProgress:  progress,
+			ExcludeList:   excludeList,
+			MaxDepth:      maxDepth,
+			GroupByExt:    groupByExt,
+			LargestFiles:  largestN,
+			IgnoreHidden:  ignoreHidden,
+			ReadGitignore: readIgnore,
+		}
+
+		if interactive {
+			if err := tui.Run(parentFolder, scanOpts); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		// display prints one scan result; previous holds the sizes of the
+		// last refresh in watch mode and is nil otherwise
+		display := func(result scanner.ScanResult, previous map[string]int64) {
+			if result.WarningCount > 0 {
+				fmt.Fprintf(os.Stderr, "Warning: %d files/folders could not be accessed\n", result.WarningCount)
+			}
+
+			// Apply size filters
+			filteredItems := result.Items
+			var filteredCount int
+			var filteredSize int64
+			if minSizeBytes > 0 || maxSizeBytes < (1<<63-1) {
+				filteredItems = make([]scanner.ItemInfo, 0, len(result.Items))
+				for _, item := range result.Items {
+					if item.Size >= minSizeBytes && item.Size <= maxSizeBytes {
+						filteredItems = append(filteredItems, item)
+					} else {
+						filteredCount++
+						filteredSize += item.Size
+					}
+				}
+			}
+
+			if topExt > 0 {
+				filteredItems = largestItems(filteredItems, topExt)
+			}
+
+			// Output results
+			if jsonOutput {
+				sort.Slice(filteredItems, func(i, j int) bool {
+					return filteredItems[i].Name < filteredItems[j].Name
+				})
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(jsonReport(filteredItems, result.LargestFiles, largestN > 0)); err != nil {
+					fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+					os.Exit(1)
+				}
+			} else {
+				printOpts := ui.PrintOptions{
+					SortBy:        sortBy,
+					Reverse:       !asc,
+					Previous:      previous,
+					NoPct:         noPct,
+					FilteredCount: filteredCount,
+					FilteredSize:  filteredSize,
+				}
+				if !noChart {
+					printOpts.ChartWidth = ui.ChartWidth()
+				}
+				ui.PrintResults(filteredItems, parentFolder, printOpts)
+				ui.PrintLargestFiles(result.LargestFiles)
+			}
+		}
+
+		if watch {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			watchSizes(ctx, parentFolder, scanOpts, interval, func(result scanner.ScanResult, previous map[string]int64) {
+				fmt.Print(clearScreen)
+				fmt.Printf("Watching: %s (every %s, Ctrl+C to stop)\n", parentFolder, interval)
+				display(result, previous)
+				// Alerts are reported on every refresh but do not end the watch
+				for _, msg := range checkAlerts(result.Items, alertBytes, alertItemBytes) {
+					fmt.Fprintf(os.Stderr, "Alert: %s\n", msg)
+				}
+			})
+			return
+		}
+
 		fmt.Printf("Analyzing: %s\n", parentFolder)
 		if len(excludeList) > 0 {
 			fmt.Printf("Excluding: %s\n", strings.Join(excludeList, ", "))
@@ -116,12 +290,8 @@ var RootCmd = &cobra.Command{
 		startTime := time.Now()
 
 		// Get folder sizes
-		result := scanner.GetSizesOfSubfolders(parentFolder, scanner.ScanOptions{
-			ShowProgress: progress,
-			ExcludeList:  excludeList,
-			Ctx:          ctx,
-			MaxDepth:     maxDepth,
-		})
+		scanOpts.Ctx = ctx
+		result := scanner.GetSizesOfSubfolders(parentFolder, scanOpts)
 
 		elapsed := time.Since(startTime)
 
@@ -129,34 +299,22 @@ var RootCmd = &cobra.Command{
 			fmt.Printf("\nAnalysis completed in %.2f seconds\n", elapsed.Seconds())
 		}
 
-		if result.WarningCount > 0 {
-			fmt.Fprintf(os.Stderr, "Warning: %d files/folders could not be accessed\n", result.WarningCount)
-		}
+		display(result, nil)
 
-		// Apply size filters
-		filteredItems := result.Items
-		if minSizeBytes > 0 || maxSizeBytes < (1<<63-1) {
-			filteredItems = make([]scanner.ItemInfo, 0, len(result.Items))
-			for _, item := range result.Items {
-				if item.Size >= minSizeBytes && item.Size <= maxSizeBytes {
-					filteredItems = append(filteredItems, item)
-				}
+		if htmlOutput != "" {
+			if err := writeHTMLFile(htmlOutput, result.Items, parentFolder, startTime); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing HTML report: %v\n", err)
+				os.Exit(1)
 			}
+			fmt.Fprintf(os.Stderr, "HTML report written to %s\n", htmlOutput)
 		}
 
-		// Output results
-		if jsonOutput {
-			sort.Slice(filteredItems, func(i, j int) bool {
-				return filteredItems[i].Name < filteredItems[j].Name
-			})
-			enc := json.NewEncoder(os.Stdout)
-			enc.SetIndent("", "  ")
-			if err := enc.Encode(filteredItems); err != nil {
-				fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
-				os.Exit(1)
+		// Alerts look at every scanned item, not just the filtered view
+		if alerts := checkAlerts(result.Items, alertBytes, alertItemBytes); len(alerts) > 0 {
+			for _, msg := range alerts {
+				fmt.Fprintf(os.Stderr, "Alert: %s\n", msg)
 			}
-		} else {
-			ui.PrintResults(filteredItems, parentFolder, sortBy, !asc)
+			os.Exit(alertExitCode)
 		}
 	},
 }
@@ -174,42 +332,106 @@ func init() {
 	RootCmd.Flags().BoolVarP(&progress, "progress", "p", false, "Show progress during calculation")
 	RootCmd.Flags().BoolVarP(&noClear, "no-clear", "n", false, "Don't clear screen before output")
 	RootCmd.Flags().StringVarP(&excludeDirs, "exclude-dirs", "e", "", "Comma-separated list of folders/files to exclude (e.g., node_modules,.git,target)")
+	RootCmd.Flags().BoolVar(&ignoreHidden, "ignore-hidden", false, "Skip every file and folder whose name starts with '.' (covers --exclude-dirs .git,.idea,.vscode)")
+	RootCmd.Flags().BoolVar(&readIgnore, "read-gitignore", false, "Leave out files and folders ignored by the analyzed folder's .gitignore (negations respected)")
 	RootCmd.Flags().IntVar(&timeout, "timeout", 0, "Timeout in seconds (0 = no timeout)")
 	RootCmd.Flags().IntVar(&maxDepth, "depth", 0, "Maximum recursion depth (0 = unlimited)")
 	RootCmd.Flags().BoolVar(&jsonOutput, "json", false, "Output results in JSON format")
-	RootCmd.Flags().StringVar(&minSize, "min-size", "", "Minimum size filter (e.g., 1KB, 10MB, 1GB)")
-	RootCmd.Flags().StringVar(&maxSize, "max-size", "", "Maximum size filter (e.g., 100MB, 1GB)")
+	RootCmd.Flags().StringVar(&minSize, "min-size", "", "Hide entries smaller than this size (e.g., 1KB, 10MB, 1GB); the total still counts them")
+	RootCmd.Flags().StringVar(&maxSize, "max-size", "", "Hide entries larger than this size (e.g., 100MB, 10GB); the total still counts them")
+	RootCmd.Flags().IntVar(&largestN, "find-largest-files", 0, "Also list the N largest individual files in the whole tree")
+	RootCmd.Flags().StringVar(&htmlOutput, "output-html", "", "Also write a self-contained HTML report to this file")
+	RootCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Browse the folder tree in a terminal UI; sizes are computed per folder on first visit")
+	RootCmd.Flags().BoolVar(&watch, "watch", false, "Re-scan every --interval and redraw the table with size changes (Ctrl+C to stop)")
+	RootCmd.Flags().DurationVar(&interval, "interval", 5*time.Second, "Refresh interval for --watch (e.g., 5s, 1m)")
+	RootCmd.Flags().BoolVar(&noPct, "no-pct", false, "Hide the percentage-of-total column")
+	RootCmd.Flags().BoolVar(&noColor, "no-color", false, "Disable ANSI colors (also off when NO_COLOR is set or stdout is not a terminal)")
+	RootCmd.Flags().BoolVar(&noChart, "no-chart", false, "Don't draw the size bar chart")
+	RootCmd.Flags().StringVar(&alert, "alert", "", "Exit with code 2 if the total size exceeds this threshold (e.g., 10GB)")
+	RootCmd.Flags().StringVar(&alertItem, "alert-per-item", "", "Exit with code 2 if any immediate child, or extension group under --group-by-ext, exceeds this threshold (e.g., 1GB)")
+	RootCmd.Flags().BoolVar(&groupByExt, "group-by-ext", false, "Report total size per file extension instead of per folder")
+	RootCmd.Flags().IntVar(&topExt, "top-ext", 0, "Show only the N largest extension groups (implies --group-by-ext)")
 }
 
-func parseSize(sizeStr string) (int64, error) {
-	if strings.ToLower(sizeStr) == "inf" {
-		return 1<<63 - 1, nil
+// writeHTMLFile writes the HTML report for items to path
+func writeHTMLFile(path string, items []scanner.ItemInfo, parentFolder string, scannedAt time.Time) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
 	}
+	if err := ui.WriteHTMLReport(f, items, parentFolder, scannedAt); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
 
-	sizeStr = strings.ToUpper(sizeStr)
-
-	// Ordered from longest suffix to shortest to avoid "KB" matching "B" first
-	units := []struct {
-		suffix     string
-		multiplier int64
-	}{
-		{"TB", 1024 * 1024 * 1024 * 1024},
-		{"GB", 1024 * 1024 * 1024},
-		{"MB", 1024 * 1024},
-		{"KB", 1024},
-		{"B", 1},
+// jsonReport is the --json document: the bare items array, or an object that
+// also carries the largest files when --find-largest-files is set.
+func jsonReport(items []scanner.ItemInfo, largest []scanner.FileEntry, withLargest bool) any {
+	if !withLargest {
+		return items
 	}
+	if largest == nil {
+		largest = []scanner.FileEntry{}
+	}
+	return struct {
+		Items        []scanner.ItemInfo  `json:"items"`
+		LargestFiles []scanner.FileEntry `json:"largest_files"`
+	}{items, largest}
+}
 
-	for _, u := range units {
-		if strings.HasSuffix(sizeStr, u.suffix) {
-			numStr := strings.TrimSuffix(sizeStr, u.suffix)
-			num, err := strconv.ParseFloat(numStr, 64)
-			if err != nil {
-				return 0, err
+// largestItems returns the n largest items, leaving display order to the
+// caller's sort settings
+func largestItems(items []scanner.ItemInfo, n int) []scanner.ItemInfo {
+	if len(items) <= n {
+		return items
+	}
+	largest := make([]scanner.ItemInfo, len(items))
+	copy(largest, items)
+	sort.Slice(largest, func(i, j int) bool {
+		return largest[i].Size > largest[j].Size
+	})
+	return largest[:n]
+}
+
+// checkAlerts returns one message per exceeded threshold: the total size of
+// items against totalLimit and each item against itemLimit. A limit of 0 is
+// disabled.
+func checkAlerts(items []scanner.ItemInfo, totalLimit, itemLimit int64) []string {
+	var alerts []string
+	if totalLimit > 0 {
+		var total int64
+		for _, item := range items {
+			total += item.Size
+		}
+		if total > totalLimit {
+			alerts = append(alerts, fmt.Sprintf("total size %s exceeds threshold %s", ui.HumanSize(total), ui.HumanSize(totalLimit)))
+		}
+	}
+	if itemLimit > 0 {
+		var over []scanner.ItemInfo
+		for _, item := range items {
+			if item.Size > itemLimit {
+				over = append(over, item)
 			}
-			return int64(num * float64(u.multiplier)), nil
+		}
+		// Largest offenders first; scan order is not deterministic
+		sort.Slice(over, func(i, j int) bool {
+			return over[i].Size > over[j].Size
+		})
+		for _, item := range over {
+			alerts = append(alerts, fmt.Sprintf("%s is %s, exceeds per-item threshold %s", alertSubject(item), ui.HumanSize(item.Size), ui.HumanSize(itemLimit)))
 		}
 	}
+	return alerts
+}
 
-	return strconv.ParseInt(sizeStr, 10, 64)
+// alertSubject names an item in an alert message. Under --group-by-ext the
+// items are extension groups rather than children of the analyzed folder.
+func alertSubject(item scanner.ItemInfo) string {
+	if item.Type == "extension" {
+		return "extension group " + item.Name
+	}
+	return item.Type + " " + item.Name
 }
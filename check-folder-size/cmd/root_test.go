@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"check-folder-size/internal/scanner"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCheckAlerts(t *testing.T) {
+	items := []scanner.ItemInfo{
+		{Name: "small.txt", Size: 100, Type: "file"},
+		{Name: "build", Size: 3000, Type: "directory"},
+		{Name: "cache", Size: 2000, Type: "directory"},
+	}
+
+	tests := []struct {
+		name       string
+		totalLimit int64
+		itemLimit  int64
+		want       []string
+	}{
+		{name: "disabled", want: nil},
+		{name: "total under limit", totalLimit: 5100, want: nil},
+		{name: "total over limit", totalLimit: 5000, want: []string{"total size 4.98 KB exceeds threshold 4.88 KB"}},
+		{name: "per item", itemLimit: 1024, want: []string{"directory build is 2.93 KB, exceeds per-item threshold 1.00 KB", "directory cache is 1.95 KB"}},
+		{name: "both", totalLimit: 1, itemLimit: 2500, want: []string{"total size", "directory build"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checkAlerts(items, tt.totalLimit, tt.itemLimit)
+			if len(got) != len(tt.want) {
+				t.Fatalf("checkAlerts() = %q, want %d alert(s)", got, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(got[i], want) {
+					t.Errorf("alert %d = %q, want it to contain %q", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckAlertsExtensionGroups(t *testing.T) {
+	items := []scanner.ItemInfo{
+		{Name: ".mp4", Size: 9000, Type: "extension"},
+		{Name: scanner.NoExtension, Size: 5000, Type: "extension"},
+		{Name: ".txt", Size: 10, Type: "extension"},
+	}
+
+	got := checkAlerts(items, 0, 1024)
+	want := []string{
+		"extension group .mp4 is 8.79 KB, exceeds per-item threshold 1.00 KB",
+		"extension group (none) is 4.88 KB, exceeds per-item threshold 1.00 KB",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("checkAlerts() = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("alert %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestLargestItems(t *testing.T) {
+	items := []scanner.ItemInfo{
+		{Name: ".txt", Size: 10},
+		{Name: ".mp4", Size: 900},
+		{Name: ".log", Size: 300},
+	}
+
+	got := largestItems(items, 2)
+	if len(got) != 2 || got[0].Name != ".mp4" || got[1].Name != ".log" {
+		t.Fatalf("largestItems(2) = %#v, want .mp4 and .log", got)
+	}
+	if items[0].Name != ".txt" {
+		t.Errorf("largestItems reordered its input: %#v", items)
+	}
+	if got := largestItems(items, 5); len(got) != 3 {
+		t.Errorf("largestItems(5) returned %d items, want all 3", len(got))
+	}
+}
+
+func TestJSONReport(t *testing.T) {
+	items := []scanner.ItemInfo{{Name: "a", Size: 10, Type: "file"}}
+	largest := []scanner.FileEntry{{Path: "/t/a", Size: 10}}
+
+	data, err := json.Marshal(jsonReport(items, largest, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "[") {
+		t.Errorf("without --find-largest-files got %s, want the bare items array", data)
+	}
+
+	data, err = json.Marshal(jsonReport(items, largest, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Items        []scanner.ItemInfo  `json:"items"`
+		LargestFiles []scanner.FileEntry `json:"largest_files"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("unmarshal %s: %v", data, err)
+	}
+	if len(got.Items) != 1 || len(got.LargestFiles) != 1 || got.LargestFiles[0].Path != "/t/a" {
+		t.Errorf("with --find-largest-files got %s", data)
+	}
+
+	if data, _ := json.Marshal(jsonReport(items, nil, true)); !strings.Contains(string(data), `"largest_files":[]`) {
+		t.Errorf("empty largest files = %s, want an empty array", data)
+	}
+}
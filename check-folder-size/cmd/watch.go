@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"check-folder-size/internal/scanner"
+	"context"
+	"time"
+)
+
+// clearScreen moves the cursor home and clears the terminal before a redraw
+const clearScreen = "\033[2J\033[H"
+
+// watchSizes scans parentFolder immediately and then every interval until ctx
+// is cancelled, handing each result to render together with the item sizes
+// of the previous scan (nil on the first one). --timeout bounds each scan.
+func watchSizes(ctx context.Context, parentFolder string, opts scanner.ScanOptions, interval time.Duration, render func(scanner.ScanResult, map[string]int64)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var previous map[string]int64
+	for {
+		scanCtx, cancel := ctx, context.CancelFunc(func() {})
+		if timeout > 0 {
+			scanCtx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+		}
+		opts.Ctx = scanCtx
+		result := scanner.GetSizesOfSubfolders(parentFolder, opts)
+		cancel()
+		if ctx.Err() != nil {
+			return // interrupted mid-scan: keep the last complete table
+		}
+
+		render(result, previous)
+		previous = sizesByName(result.Items)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sizesByName indexes item sizes by name for the next refresh's deltas
+func sizesByName(items []scanner.ItemInfo) map[string]int64 {
+	sizes := make(map[string]int64, len(items))
+	for _, item := range items {
+		sizes[item.Name] = item.Size
+	}
+	return sizes
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"check-folder-size/internal/scanner"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchSizesPassesPreviousSizes(t *testing.T) {
+	parent := t.TempDir()
+	if err := os.WriteFile(filepath.Join(parent, "grow.log"), []byte("12345"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var previous []map[string]int64
+	watchSizes(ctx, parent, scanner.ScanOptions{}, 10*time.Millisecond, func(result scanner.ScanResult, prev map[string]int64) {
+		previous = append(previous, prev)
+		if len(previous) == 1 {
+			if err := os.WriteFile(filepath.Join(parent, "grow.log"), []byte("1234567890"), 0o644); err != nil {
+				t.Errorf("grow file: %v", err)
+			}
+			return
+		}
+		cancel()
+	})
+
+	if len(previous) != 2 {
+		t.Fatalf("render called %d times, want 2", len(previous))
+	}
+	if previous[0] != nil {
+		t.Errorf("first refresh previous = %v, want nil", previous[0])
+	}
+	if got := previous[1]["grow.log"]; got != 5 {
+		t.Errorf("second refresh previous size = %d, want 5", got)
+	}
+}
@@ -0,0 +1,67 @@
+package scanner
+
+import (
+	"container/heap"
+	"sort"
+	"sync"
+)
+
+// FileEntry is a single file reported by --find-largest-files
+type FileEntry struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// fileHeap is a min-heap by size, so the smallest of the kept files is the
+// one evicted when a larger file turns up
+type fileHeap []FileEntry
+
+func (h fileHeap) Len() int           { return len(h) }
+func (h fileHeap) Less(i, j int) bool { return h[i].Size < h[j].Size }
+func (h fileHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *fileHeap) Push(x any)        { *h = append(*h, x.(FileEntry)) }
+func (h *fileHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
+
+// largestFiles keeps the n largest files offered to it; safe for concurrent use
+type largestFiles struct {
+	n  int
+	mu sync.Mutex
+	h  fileHeap
+}
+
+func newLargestFiles(n int) *largestFiles {
+	return &largestFiles{n: n, h: make(fileHeap, 0, n)}
+}
+
+// offer records the file if it is among the n largest seen so far
+func (lf *largestFiles) offer(path string, size int64) {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+	if len(lf.h) < lf.n {
+		heap.Push(&lf.h, FileEntry{Path: path, Size: size})
+	} else if size > lf.h[0].Size {
+		lf.h[0] = FileEntry{Path: path, Size: size}
+		heap.Fix(&lf.h, 0)
+	}
+}
+
+// sorted returns the kept files, largest first
+func (lf *largestFiles) sorted() []FileEntry {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+	files := make([]FileEntry, len(lf.h))
+	copy(files, lf.h)
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].Size != files[j].Size {
+			return files[i].Size > files[j].Size
+		}
+		return files[i].Path < files[j].Path
+	})
+	return files
+}
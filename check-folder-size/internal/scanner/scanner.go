@@ -6,10 +6,12 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"sync"
 	"sync/atomic"
 
-	"golang.org/x/term"
+	"common-module/gitignore"
+	"common-module/terminal"
 )
 
 // walkTask is a unit of work for the parallel walker.
@@ -24,9 +26,18 @@ type ScanOptions struct {
 	ShowProgress bool
 	ExcludeList  []string
 	Ctx          context.Context
-	MaxDepth     int // 0 = unlimited
+	MaxDepth     int  // 0 = unlimited
+	GroupByExt   bool // report total bytes per file extension instead of per child
+	LargestFiles int  // also collect the N largest individual files (0 = off)
+	IgnoreHidden bool // skip every file and folder whose name starts with "."
+	// ReadGitignore skips paths ignored by the .gitignore files of the
+	// analyzed folder (and any nested ones), negations included
+	ReadGitignore bool
 }
 
+// NoExtension is the group name for files without an extension
+const NoExtension = "(none)"
+
 type ItemInfo struct {
 	Name string `json:"name"`
 	Size int64  `json:"size"`
@@ -36,13 +47,16 @@ type ItemInfo struct {
 type ScanResult struct {
 	Items        []ItemInfo
 	WarningCount int64
+	LargestFiles []FileEntry // largest first; only with ScanOptions.LargestFiles
 }
 
 type parallelWalker struct {
-	excludeMap map[string]struct{}
-	ctx        context.Context
-	maxDepth   int
-	numWorkers int
+	excludeMap   map[string]struct{}
+	ignoreHidden bool
+	gitignore    *gitignore.Matcher // nil unless ScanOptions.ReadGitignore
+	ctx          context.Context
+	maxDepth     int
+	numWorkers   int
 
 	taskCh   chan walkTask
 	sizes    map[string]*int64 // topLevelName -> atomic size accumulator
@@ -51,6 +65,12 @@ type parallelWalker struct {
 
 	warningCount int64 // atomic
 
+	// Extension grouping (nil unless ScanOptions.GroupByExt)
+	extSizes map[string]int64
+	extMu    sync.Mutex
+
+	largest *largestFiles // nil unless ScanOptions.LargestFiles
+
 	// Progress tracking
 	showProgress      bool
 	termWidth         int
@@ -60,14 +80,6 @@ type parallelWalker struct {
 	progressMu        sync.Mutex
 }
 
-// getTerminalWidth returns the width of the terminal
-func getTerminalWidth() int {
-	if width, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && width > 0 {
-		return width
-	}
-	return 80
-}
-
 func newParallelWalker(excludeMap map[string]struct{}, opts ScanOptions, numWorkers, topLevelDirCount int) *parallelWalker {
 	bufSize := numWorkers * 4
 	if bufSize < 64 {
@@ -76,6 +88,7 @@ func newParallelWalker(excludeMap map[string]struct{}, opts ScanOptions, numWork
 
 	pw := &parallelWalker{
 		excludeMap:    excludeMap,
+		ignoreHidden:  opts.IgnoreHidden,
 		ctx:           opts.Ctx,
 		maxDepth:      opts.MaxDepth,
 		numWorkers:    numWorkers,
@@ -87,7 +100,10 @@ func newParallelWalker(excludeMap map[string]struct{}, opts ScanOptions, numWork
 	}
 
 	if opts.ShowProgress {
-		pw.termWidth = getTerminalWidth()
+		pw.termWidth = terminal.Width(os.Stdout)
+	}
+	if opts.GroupByExt {
+		pw.extSizes = make(map[string]int64)
 	}
 
 	return pw
@@ -107,11 +123,24 @@ func (pw *parallelWalker) processDirectory(task walkTask) {
 
 	sizePtr := pw.sizes[task.topLevelName]
 
+	// Per-directory extension totals, merged once to keep the lock cold
+	var extSizes map[string]int64
+	if pw.extSizes != nil {
+		extSizes = make(map[string]int64)
+		defer pw.addExtSizes(extSizes)
+	}
+
 	for _, entry := range entries {
 		// Exclusion check first: O(1) map lookup, skip entire subtrees early
 		if _, excluded := pw.excludeMap[entry.Name()]; excluded {
 			continue
 		}
+		if pw.ignoreHidden && isHidden(entry.Name()) {
+			continue
+		}
+		if pw.gitignore != nil && pw.gitignore.Ignored(filepath.Join(task.dirPath, entry.Name()), entry.IsDir()) {
+			continue
+		}
 
 		// Skip symlinks to avoid loops
 		if entry.Type()&os.ModeSymlink != 0 {
@@ -142,10 +171,50 @@ func (pw *parallelWalker) processDirectory(task walkTask) {
 				continue
 			}
 			atomic.AddInt64(sizePtr, info.Size())
+			if extSizes != nil {
+				extSizes[extensionOf(entry.Name())] += info.Size()
+			}
+			if pw.largest != nil {
+				pw.largest.offer(filepath.Join(task.dirPath, entry.Name()), info.Size())
+			}
 		}
 	}
 }
 
+// isHidden reports whether name is a dot-file or dot-folder
+func isHidden(name string) bool {
+	return strings.HasPrefix(name, ".")
+}
+
+// addExtSizes merges per-directory extension totals into the walker's totals
+func (pw *parallelWalker) addExtSizes(sizes map[string]int64) {
+	pw.extMu.Lock()
+	defer pw.extMu.Unlock()
+	for ext, size := range sizes {
+		pw.extSizes[ext] += size
+	}
+}
+
+// extensionOf returns the lowercased extension of a file name including the
+// dot (".log"), or NoExtension. A leading dot alone (".bashrc") is not an
+// extension.
+func extensionOf(name string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext == "" || ext == strings.ToLower(name) {
+		return NoExtension
+	}
+	return ext
+}
+
+// extensionItems converts extension totals into result items
+func extensionItems(sizes map[string]int64) []ItemInfo {
+	items := make([]ItemInfo, 0, len(sizes))
+	for ext, size := range sizes {
+		items = append(items, ItemInfo{Name: ext, Size: size, Type: "extension"})
+	}
+	return items
+}
+
 // enqueueOrProcess tries to send the task to the channel.
 // If the channel is full, it processes inline to avoid deadlock.
 // The inline fallback may recurse if child directories also can't be enqueued,
@@ -232,7 +301,9 @@ func (pw *parallelWalker) run(initialTasks []walkTask) {
 	pw.workerWg.Wait()
 }
 
-// GetSizesOfSubfolders calculates sizes of immediate subfolders/files
+// GetSizesOfSubfolders calculates sizes of immediate subfolders/files, or,
+// with opts.GroupByExt, the total size of every file extension below
+// parentFolder
 func GetSizesOfSubfolders(parentFolder string, opts ScanOptions) ScanResult {
 	var items []ItemInfo
 
@@ -249,15 +320,31 @@ func GetSizesOfSubfolders(parentFolder string, opts ScanOptions) ScanResult {
 	}
 
 	// Separate top-level files (stat directly) and directories (parallel walk)
+	var ignore *gitignore.Matcher
+	if opts.ReadGitignore {
+		ignore = gitignore.New(parentFolder)
+	}
+
 	var initialTasks []walkTask
 	var fileWarnings int64
+	topLevelExt := make(map[string]int64)
+	var largest *largestFiles
+	if opts.LargestFiles > 0 {
+		largest = newLargestFiles(opts.LargestFiles)
+	}
 
 	for _, entry := range entries {
 		if _, excluded := excludeMap[entry.Name()]; excluded {
 			continue
 		}
+		if opts.IgnoreHidden && isHidden(entry.Name()) {
+			continue
+		}
 
 		fullPath := filepath.Join(parentFolder, entry.Name())
+		if ignore != nil && ignore.Ignored(fullPath, entry.IsDir()) {
+			continue
+		}
 
 		if entry.IsDir() {
 			initialTasks = append(initialTasks, walkTask{
@@ -269,6 +356,10 @@ func GetSizesOfSubfolders(parentFolder string, opts ScanOptions) ScanResult {
 			if info, err := os.Stat(fullPath); err == nil {
 				name := entry.Name()
 				items = append(items, ItemInfo{Name: name, Size: info.Size(), Type: "file"})
+				topLevelExt[extensionOf(name)] += info.Size()
+				if largest != nil {
+					largest.offer(fullPath, info.Size())
+				}
 			} else {
 				fileWarnings++
 			}
@@ -276,13 +367,22 @@ func GetSizesOfSubfolders(parentFolder string, opts ScanOptions) ScanResult {
 	}
 
 	if len(initialTasks) == 0 {
-		return ScanResult{Items: items, WarningCount: fileWarnings}
+		if opts.GroupByExt {
+			items = extensionItems(topLevelExt)
+		}
+		result := ScanResult{Items: items, WarningCount: fileWarnings}
+		if largest != nil {
+			result.LargestFiles = largest.sorted()
+		}
+		return result
 	}
 
 	// Create parallel walker — NumCPU workers regardless of top-level count,
 	// because subdirectories become tasks that benefit from more workers.
 	numWorkers := runtime.NumCPU()
 	pw := newParallelWalker(excludeMap, opts, numWorkers, len(initialTasks))
+	pw.largest = largest
+	pw.gitignore = ignore
 
 	// Allocate atomic size accumulators for each top-level directory
 	for _, task := range initialTasks {
@@ -297,9 +397,14 @@ func GetSizesOfSubfolders(parentFolder string, opts ScanOptions) ScanResult {
 	// Run the parallel walker (blocks until complete)
 	pw.run(initialTasks)
 
-	// Collect directory sizes into result
-	for name, sizePtr := range pw.sizes {
-		items = append(items, ItemInfo{Name: name, Size: atomic.LoadInt64(sizePtr), Type: "directory"})
+	if opts.GroupByExt {
+		pw.addExtSizes(topLevelExt)
+		items = extensionItems(pw.extSizes)
+	} else {
+		// Collect directory sizes into result
+		for name, sizePtr := range pw.sizes {
+			items = append(items, ItemInfo{Name: name, Size: atomic.LoadInt64(sizePtr), Type: "directory"})
+		}
 	}
 
 	if opts.ShowProgress {
@@ -312,8 +417,12 @@ func GetSizesOfSubfolders(parentFolder string, opts ScanOptions) ScanResult {
 		fmt.Fprintf(os.Stderr, "\nScan cancelled: %v (partial results returned)\n", opts.Ctx.Err())
 	}
 
-	return ScanResult{
+	result := ScanResult{
 		Items:        items,
 		WarningCount: totalWarnings,
 	}
+	if largest != nil {
+		result.LargestFiles = largest.sorted()
+	}
+	return result
 }
@@ -54,6 +54,161 @@ func TestGetSizesOfSubfoldersReturnsItemTypes(t *testing.T) {
 	}
 }
 
+func TestGetSizesOfSubfoldersGroupByExt(t *testing.T) {
+	parent := t.TempDir()
+	files := map[string]int{
+		"top.LOG":               10,
+		"README":                3,
+		"logs/app.log":          20,
+		"logs/old/app.log.gz":   7,
+		"src/main.go":           5,
+		"src/.gitignore":        2,
+		"node_modules/x/lib.js": 100,
+	}
+	for rel, size := range files {
+		path := filepath.Join(parent, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("create dir for %s: %v", rel, err)
+		}
+		if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+
+	result := GetSizesOfSubfolders(parent, ScanOptions{
+		Ctx:         context.Background(),
+		ExcludeList: []string{"node_modules"},
+		GroupByExt:  true,
+	})
+
+	want := map[string]int64{".log": 30, ".gz": 7, ".go": 5, NoExtension: 5}
+	if len(result.Items) != len(want) {
+		t.Fatalf("Items = %#v, want %d extension groups", result.Items, len(want))
+	}
+	for ext, size := range want {
+		item := findItem(t, result.Items, ext)
+		if item.Size != size || item.Type != "extension" {
+			t.Errorf("item %q = %#v, want size %d, type extension", ext, item, size)
+		}
+	}
+}
+
+func TestGetSizesOfSubfoldersLargestFiles(t *testing.T) {
+	parent := t.TempDir()
+	files := map[string]int{
+		"top.bin":           40,
+		"a/small.txt":       1,
+		"a/b/model.bin":     100,
+		"a/b/c/deep.log":    60,
+		"cache/blob":        80,
+		"skip/huge.iso":     500,
+		"cache/another.tmp": 5,
+	}
+	for rel, size := range files {
+		path := filepath.Join(parent, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("create dir for %s: %v", rel, err)
+		}
+		if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+
+	result := GetSizesOfSubfolders(parent, ScanOptions{
+		Ctx:          context.Background(),
+		ExcludeList:  []string{"skip"},
+		LargestFiles: 3,
+	})
+
+	want := []FileEntry{
+		{Path: filepath.Join(parent, "a", "b", "model.bin"), Size: 100},
+		{Path: filepath.Join(parent, "cache", "blob"), Size: 80},
+		{Path: filepath.Join(parent, "a", "b", "c", "deep.log"), Size: 60},
+	}
+	if len(result.LargestFiles) != len(want) {
+		t.Fatalf("LargestFiles = %#v, want %#v", result.LargestFiles, want)
+	}
+	for i := range want {
+		if result.LargestFiles[i] != want[i] {
+			t.Errorf("LargestFiles[%d] = %#v, want %#v", i, result.LargestFiles[i], want[i])
+		}
+	}
+
+	if result := GetSizesOfSubfolders(parent, ScanOptions{Ctx: context.Background()}); result.LargestFiles != nil {
+		t.Errorf("LargestFiles collected without the option: %#v", result.LargestFiles)
+	}
+}
+
+func TestGetSizesOfSubfoldersIgnoreHidden(t *testing.T) {
+	parent := t.TempDir()
+	files := map[string]int{
+		".git/objects/pack": 100,
+		".env":              7,
+		"src/main.go":       5,
+		"src/.cache/blob":   50,
+	}
+	for rel, size := range files {
+		path := filepath.Join(parent, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("create dir for %s: %v", rel, err)
+		}
+		if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+
+	result := GetSizesOfSubfolders(parent, ScanOptions{Ctx: context.Background(), IgnoreHidden: true})
+	if len(result.Items) != 1 {
+		t.Fatalf("Items = %#v, want only src", result.Items)
+	}
+	if src := findItem(t, result.Items, "src"); src.Size != 5 {
+		t.Errorf("src size = %d, want 5 (nested hidden folder skipped)", src.Size)
+	}
+
+	result = GetSizesOfSubfolders(parent, ScanOptions{Ctx: context.Background()})
+	if len(result.Items) != 3 {
+		t.Errorf("without IgnoreHidden: Items = %#v, want 3 entries", result.Items)
+	}
+}
+
+func TestGetSizesOfSubfoldersReadGitignore(t *testing.T) {
+	parent := t.TempDir()
+	files := map[string]int{
+		".gitignore":         0,
+		"build/out.bin":      100,
+		"logs/app.log":       30,
+		"logs/important.log": 7,
+		"src/main.go":        5,
+		"debug.log":          11,
+	}
+	for rel, size := range files {
+		path := filepath.Join(parent, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("create dir for %s: %v", rel, err)
+		}
+		if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(parent, ".gitignore"), []byte("build/\n*.log\n!important.log\n"), 0o644); err != nil {
+		t.Fatalf("write .gitignore: %v", err)
+	}
+
+	result := GetSizesOfSubfolders(parent, ScanOptions{Ctx: context.Background(), ReadGitignore: true})
+
+	for _, item := range result.Items {
+		if item.Name == "build" || item.Name == "debug.log" {
+			t.Errorf("ignored entry %q reported", item.Name)
+		}
+	}
+	if logs := findItem(t, result.Items, "logs"); logs.Size != 7 {
+		t.Errorf("logs size = %d, want 7 (only the negated important.log)", logs.Size)
+	}
+	if src := findItem(t, result.Items, "src"); src.Size != 5 {
+		t.Errorf("src size = %d, want 5", src.Size)
+	}
+}
+
 func findItem(t *testing.T, items []ItemInfo, name string) ItemInfo {
 	t.Helper()
 
@@ -0,0 +1,206 @@
+// Package tui implements the ncdu-like folder browser behind --interactive.
+package tui
+
+import (
+	"check-folder-size/internal/scanner"
+	"check-folder-size/internal/ui"
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// scanFunc returns the children of a folder with their sizes
+type scanFunc func(path string) []scanner.ItemInfo
+
+// level is one folder on the navigation stack
+type level struct {
+	path   string
+	cursor int
+	offset int // first visible row when the list is taller than the screen
+}
+
+// scannedMsg delivers the children of a folder scanned in the background
+type scannedMsg struct {
+	path  string
+	items []scanner.ItemInfo
+}
+
+// model is the bubbletea model of the browser
+type model struct {
+	root   string
+	scan   scanFunc
+	stack  []level                       // root first, current folder last
+	sizes  map[string][]scanner.ItemInfo // folders scanned so far, largest child first
+	height int                           // terminal rows; 0 until the first WindowSizeMsg
+}
+
+func newModel(root string, scan scanFunc) model {
+	return model{
+		root:  root,
+		scan:  scan,
+		stack: []level{{path: root}},
+		sizes: make(map[string][]scanner.ItemInfo),
+	}
+}
+
+// Run browses root until the user quits. Each folder is scanned with opts the
+// first time it is entered.
+func Run(root string, opts scanner.ScanOptions) error {
+	scan := func(path string) []scanner.ItemInfo {
+		scanOpts := opts
+		scanOpts.Ctx = context.Background()
+		scanOpts.GroupByExt = false
+		scanOpts.LargestFiles = 0
+		return scanner.GetSizesOfSubfolders(path, scanOpts).Items
+	}
+	_, err := tea.NewProgram(newModel(root, scan), tea.WithAltScreen()).Run()
+	return err
+}
+
+func (m model) Init() tea.Cmd {
+	return m.scanCmd(m.root)
+}
+
+// scanCmd computes the sizes of path off the UI goroutine
+func (m model) scanCmd(path string) tea.Cmd {
+	return func() tea.Msg {
+		items := m.scan(path)
+		sort.Slice(items, func(i, j int) bool {
+			if items[i].Size != items[j].Size {
+				return items[i].Size > items[j].Size
+			}
+			return items[i].Name < items[j].Name
+		})
+		return scannedMsg{path: path, items: items}
+	}
+}
+
+func (m model) current() *level {
+	return &m.stack[len(m.stack)-1]
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+	case scannedMsg:
+		m.sizes[msg.path] = msg.items
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+	return m, nil
+}
+
+func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// Copy the stack so earlier model values stay untouched
+	m.stack = append([]level(nil), m.stack...)
+	cur := m.current()
+	items, loaded := m.sizes[cur.path]
+
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return m, tea.Quit
+	case "up", "k":
+		if cur.cursor > 0 {
+			cur.cursor--
+		}
+	case "down", "j":
+		if cur.cursor < len(items)-1 {
+			cur.cursor++
+		}
+	case "home", "g":
+		cur.cursor = 0
+	case "end", "G":
+		cur.cursor = max(len(items)-1, 0)
+	case "enter", "right", "l":
+		if !loaded || len(items) == 0 || items[cur.cursor].Type != "directory" {
+			break
+		}
+		child := filepath.Join(cur.path, items[cur.cursor].Name)
+		m.stack = append(m.stack, level{path: child})
+		if _, ok := m.sizes[child]; !ok {
+			return m, m.scanCmd(child) // sizes are computed lazily on first visit
+		}
+	case "backspace", "esc", "left", "h":
+		if len(m.stack) > 1 {
+			m.stack = m.stack[:len(m.stack)-1]
+		}
+	}
+	m.scroll()
+	return m, nil
+}
+
+// listHeight is the number of rows available for entries
+func (m model) listHeight() int {
+	const chrome = 4 // breadcrumb, total, blank line, help
+	if m.height <= chrome {
+		return 20
+	}
+	return m.height - chrome
+}
+
+// scroll keeps the cursor of the current level on screen
+func (m model) scroll() {
+	cur := m.current()
+	rows := m.listHeight()
+	if cur.cursor < cur.offset {
+		cur.offset = cur.cursor
+	} else if cur.cursor >= cur.offset+rows {
+		cur.offset = cur.cursor - rows + 1
+	}
+}
+
+// breadcrumb renders the current folder as root › child › grandchild
+func (m model) breadcrumb() string {
+	parts := []string{m.root}
+	for _, l := range m.stack[1:] {
+		parts = append(parts, filepath.Base(l.path))
+	}
+	return strings.Join(parts, " › ")
+}
+
+func (m model) View() string {
+	var b strings.Builder
+	cur := m.current()
+	fmt.Fprintf(&b, "📁 %s\n", m.breadcrumb())
+
+	items, loaded := m.sizes[cur.path]
+	if !loaded {
+		b.WriteString("Scanning...\n")
+		return b.String()
+	}
+
+	var total int64
+	for _, item := range items {
+		total += item.Size
+	}
+	fmt.Fprintf(&b, "📊 Total: %s in %d entries\n\n", ui.HumanSize(total), len(items))
+	if len(items) == 0 {
+		b.WriteString("  (empty)\n")
+	}
+
+	end := min(cur.offset+m.listHeight(), len(items))
+	for i := cur.offset; i < end; i++ {
+		item := items[i]
+		name := item.Name
+		if item.Type == "directory" {
+			name += string(filepath.Separator)
+		}
+		pct := 0.0
+		if total > 0 {
+			pct = float64(item.Size) / float64(total) * 100
+		}
+		line := fmt.Sprintf(" %12s %5.1f%%  %s", ui.HumanSize(item.Size), pct, name)
+		if i == cur.cursor {
+			line = "\033[7m" + line + " \033[0m" // reverse video
+		}
+		b.WriteString(line + "\n")
+	}
+
+	b.WriteString("↑/↓ move  enter open  esc/backspace up  q quit")
+	return b.String()
+}
@@ -0,0 +1,101 @@
+package tui
+
+import (
+	"check-folder-size/internal/scanner"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// press sends key to m and runs the returned command, if any, feeding its
+// message back into the model
+func press(t *testing.T, m model, key string) model {
+	t.Helper()
+	var msg tea.KeyMsg
+	switch key {
+	case "enter":
+		msg = tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		msg = tea.KeyMsg{Type: tea.KeyEsc}
+	case "down":
+		msg = tea.KeyMsg{Type: tea.KeyDown}
+	default:
+		msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+	}
+	next, cmd := m.Update(msg)
+	m = next.(model)
+	if cmd != nil {
+		next, _ = m.Update(cmd())
+		m = next.(model)
+	}
+	return m
+}
+
+func TestBrowserNavigation(t *testing.T) {
+	tree := map[string][]scanner.ItemInfo{
+		"root": {
+			{Name: "small.txt", Size: 10, Type: "file"},
+			{Name: "big", Size: 90, Type: "directory"},
+		},
+		filepath.Join("root", "big"): {
+			{Name: "data.bin", Size: 90, Type: "file"},
+		},
+	}
+	scans := map[string]int{}
+	m := newModel("root", func(path string) []scanner.ItemInfo {
+		scans[path]++
+		return append([]scanner.ItemInfo(nil), tree[path]...)
+	})
+
+	if !strings.Contains(m.View(), "Scanning...") {
+		t.Errorf("view before the first scan = %q, want a scanning note", m.View())
+	}
+	next, _ := m.Update(m.Init()())
+	m = next.(model)
+
+	// Children are sorted by size, so the cursor starts on the folder
+	view := m.View()
+	if strings.Index(view, "big") > strings.Index(view, "small.txt") {
+		t.Errorf("view not sorted by size:\n%s", view)
+	}
+
+	m = press(t, m, "enter")
+	if got := m.current().path; got != filepath.Join("root", "big") {
+		t.Fatalf("after enter, path = %q, want root/big", got)
+	}
+	if view := m.View(); !strings.Contains(view, "root › big") || !strings.Contains(view, "data.bin") {
+		t.Errorf("child view missing breadcrumb or entries:\n%s", view)
+	}
+
+	// Files cannot be entered and the root cannot be left
+	m = press(t, m, "enter")
+	m = press(t, m, "esc")
+	m = press(t, m, "esc")
+	if got := m.current().path; got != "root" {
+		t.Fatalf("after going up twice, path = %q, want root", got)
+	}
+
+	// Revisiting a folder reuses its sizes
+	m = press(t, m, "enter")
+	if scans[filepath.Join("root", "big")] != 1 {
+		t.Errorf("root/big scanned %d times, want 1", scans[filepath.Join("root", "big")])
+	}
+
+	m = press(t, m, "esc")
+	m = press(t, m, "down")
+	if m.current().cursor != 1 {
+		t.Errorf("cursor = %d after down, want 1", m.current().cursor)
+	}
+	m = press(t, m, "down")
+	if m.current().cursor != 1 {
+		t.Errorf("cursor = %d after down on the last entry, want 1", m.current().cursor)
+	}
+
+	if _, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")}); cmd == nil {
+		t.Error("q did not return a quit command")
+	} else if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Error("q did not quit")
+	}
+}
@@ -0,0 +1,78 @@
+package ui
+
+import (
+	"check-folder-size/internal/scanner"
+	"embed"
+	"html/template"
+	"io"
+	"sort"
+	"time"
+)
+
+//go:embed templates/report.html
+var templateFS embed.FS
+
+var reportTemplate = template.Must(template.ParseFS(templateFS, "templates/report.html"))
+
+// htmlRow is one table row of the HTML report
+type htmlRow struct {
+	Name       string
+	Type       string
+	Size       int64
+	Human      string
+	Percent    float64 // share of the total size
+	BarPercent float64 // bar width relative to the largest item
+	Class      string  // green/yellow/red, as in the terminal output
+}
+
+// htmlReport is the data rendered by templates/report.html
+type htmlReport struct {
+	Path      string
+	Total     string
+	ScannedAt string
+	Rows      []htmlRow
+}
+
+// sizeClasses maps formatSize colors to the report's CSS classes
+var sizeClasses = map[int]string{42: "green", 43: "yellow", 41: "red"}
+
+// WriteHTMLReport writes a self-contained HTML page listing items, largest
+// first, with a sortable table and a bar per item
+func WriteHTMLReport(w io.Writer, items []scanner.ItemInfo, parentFolder string, scannedAt time.Time) error {
+	sorted := make([]scanner.ItemInfo, len(items))
+	copy(sorted, items)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Size > sorted[j].Size
+	})
+
+	var total, largest int64
+	for _, item := range sorted {
+		total += item.Size
+		largest = max(largest, item.Size)
+	}
+
+	report := htmlReport{
+		Path:      parentFolder,
+		Total:     HumanSize(total),
+		ScannedAt: scannedAt.Format("2006-01-02 15:04:05 MST"),
+		Rows:      make([]htmlRow, 0, len(sorted)),
+	}
+	for _, item := range sorted {
+		row := htmlRow{
+			Name:  item.Name,
+			Type:  item.Type,
+			Size:  item.Size,
+			Human: HumanSize(item.Size),
+			Class: sizeClasses[formatSize(item.Size).Color],
+		}
+		if total > 0 {
+			row.Percent = float64(item.Size) / float64(total) * 100
+		}
+		if largest > 0 {
+			row.BarPercent = float64(item.Size) / float64(largest) * 100
+		}
+		report.Rows = append(report.Rows, row)
+	}
+
+	return reportTemplate.Execute(w, report)
+}
@@ -0,0 +1,41 @@
+package ui
+
+import (
+	"check-folder-size/internal/scanner"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteHTMLReport(t *testing.T) {
+	var sb strings.Builder
+	scannedAt := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	err := WriteHTMLReport(&sb, []scanner.ItemInfo{
+		{Name: "small.txt", Size: 512, Type: "file"},
+		{Name: "<media>", Size: 3 * 1024 * 1024 * 1024, Type: "directory"},
+		{Name: "logs", Size: 5 * 1024 * 1024, Type: "directory"},
+	}, "/data", scannedAt)
+	if err != nil {
+		t.Fatalf("WriteHTMLReport returned error: %v", err)
+	}
+	html := sb.String()
+
+	for _, want := range []string{
+		"<title>/data — 3.00 GB — 2024-05-01 12:30:00 UTC</title>",
+		"&lt;media&gt;",                          // names are escaped
+		`class="bar red" style="width: 100.00%"`, // largest item gets the full bar
+		`class="bar yellow"`,
+		`class="bar green"`,
+		"<script>",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("report does not contain %q", want)
+		}
+	}
+
+	// Rows are written largest first
+	media, logs, small := strings.Index(html, "&lt;media&gt;"), strings.Index(html, ">logs<"), strings.Index(html, ">small.txt<")
+	if !(media < logs && logs < small) {
+		t.Errorf("rows not ordered by size: media=%d logs=%d small=%d", media, logs, small)
+	}
+}
@@ -3,21 +3,80 @@ package ui
 import (
 	"check-folder-size/internal/scanner"
 	"fmt"
+	"os"
 	"sort"
 	"strings"
+
+	"golang.org/x/term"
 )
 
+// chartLabelWidth is the room left of each bar for the name and percentage
+const chartLabelWidth = 30
+
+// minChartWidth is the narrowest bar area worth drawing
+const minChartWidth = 10
+
+// PrintOptions controls how PrintResults orders and decorates the table
+type PrintOptions struct {
+	SortBy     string // "size" or "name"
+	Reverse    bool   // largest/last name first
+	ChartWidth int    // bar width of the largest item; 0 = no chart
+	NoPct      bool   // hide the percentage-of-total column
+	// Previous maps item names to their size at the previous refresh
+	// (--watch); when set, each changed entry is annotated with its delta
+	Previous map[string]int64
+	// Entries hidden by --min-size/--max-size; they still count towards the
+	// total size in the header
+	FilteredCount int
+	FilteredSize  int64
+}
+
 type FormatResult struct {
 	Size  float64
 	Unit  string
 	Color int
 }
 
-// color formats text with ANSI colors
+// colorEnabled turns the ANSI codes of every printer on or off
+var colorEnabled = true
+
+// SetColor enables or disables ANSI colors in the printed output
+func SetColor(enabled bool) {
+	colorEnabled = enabled
+}
+
+// color formats text with ANSI colors. The padding is kept without colors so
+// columns stay aligned.
 func color(msg string, bg int) string {
+	if !colorEnabled {
+		return " " + msg + " "
+	}
 	return fmt.Sprintf("\033[%dm\033[1;30m %s \033[0m", bg, msg)
 }
 
+// barColor colors a bar with the foreground variant of a formatSize
+// background color, so the bar glyphs themselves carry the color
+func barColor(bar string, bg int) string {
+	if !colorEnabled {
+		return bar
+	}
+	return fmt.Sprintf("\033[%dm%s\033[0m", bg-10, bar)
+}
+
+// ChartWidth returns the bar width for the largest item on the current
+// terminal, or 0 when stdout is not a terminal or too narrow for a chart
+func ChartWidth() int {
+	fd := int(os.Stdout.Fd())
+	if !term.IsTerminal(fd) {
+		return 0
+	}
+	width, _, err := term.GetSize(fd)
+	if err != nil || width-chartLabelWidth < minChartWidth {
+		return 0
+	}
+	return width - chartLabelWidth
+}
+
 // formatSize converts bytes to human readable format
 func formatSize(size int64) FormatResult {
 	if size == 0 {
@@ -46,9 +105,21 @@ func formatSize(size int64) FormatResult {
 	return FormatResult{sizeFloat, units[unitIndex], msgColor}
 }
 
-// PrintResults displays the folder analysis results
-func PrintResults(items []scanner.ItemInfo, parentFolder, sortBy string, reverse bool) {
+// HumanSize formats a byte count as plain text, e.g. "1.50 GB"
+func HumanSize(size int64) string {
+	formatted := formatSize(size)
+	return fmt.Sprintf("%.2f %s", formatted.Size, formatted.Unit)
+}
+
+// PrintResults displays the folder analysis results. A ChartWidth > 0 adds a
+// bar chart in which the largest item gets a bar of ChartWidth characters.
+func PrintResults(items []scanner.ItemInfo, parentFolder string, opts PrintOptions) {
+	sortBy, reverse := opts.SortBy, opts.Reverse
 	if len(items) == 0 {
+		if opts.FilteredCount > 0 {
+			fmt.Printf("No entries within the size limits (%d entries filtered by size).\n", opts.FilteredCount)
+			return
+		}
 		fmt.Println("No accessible folders or files found.")
 		return
 	}
@@ -71,25 +142,35 @@ func PrintResults(items []scanner.ItemInfo, parentFolder, sortBy string, reverse
 		})
 	}
 
-	// Calculate total size
-	var totalSize int64
+	// Calculate total size, including entries hidden by the size filters
+	totalSize := opts.FilteredSize
 	for _, item := range items {
 		totalSize += item.Size
 	}
 	totalFormatted := formatSize(totalSize)
+	filteredNote := ""
+	if opts.FilteredCount > 0 {
+		filteredNote = fmt.Sprintf(" (%d entries filtered by size)", opts.FilteredCount)
+	}
 
 	// Print header
 	fmt.Printf("\n%s\n", strings.Repeat("=", 80))
 	fmt.Printf("📁 Parent Folder: %s\n", parentFolder)
-	fmt.Printf("📊 Total Size: %.2f %s\n", totalFormatted.Size, color(totalFormatted.Unit, totalFormatted.Color))
+	fmt.Printf("📊 Total Size: %.2f %s%s\n", totalFormatted.Size, color(totalFormatted.Unit, totalFormatted.Color), filteredNote)
 	fmt.Printf("📈 Items Found: %d\n", len(items))
 	fmt.Printf("%s\n", strings.Repeat("=", 80))
 
 	// Print table header
 	const unitColWidth = 7 // max visible width: " bytes " = 7
 	const typeColWidth = 9 // "directory" = 9
-	fmt.Printf("%10s  %-*s  %-*s  %s\n", "Size", unitColWidth, "Unit", typeColWidth, "Type", "Name")
-	fmt.Printf("%10s  %-*s  %-*s  %s\n", "----", unitColWidth, "----", typeColWidth, "----", "----")
+	const pctColWidth = 6  // "100.0%" = 6
+	pctHeader, pctRule := "", ""
+	if !opts.NoPct {
+		pctHeader = fmt.Sprintf("%*s  ", pctColWidth, "Pct")
+		pctRule = fmt.Sprintf("%*s  ", pctColWidth, "---")
+	}
+	fmt.Printf("%10s  %-*s  %s%-*s  %s\n", "Size", unitColWidth, "Unit", pctHeader, typeColWidth, "Type", "Name")
+	fmt.Printf("%10s  %-*s  %s%-*s  %s\n", "----", unitColWidth, "----", pctRule, typeColWidth, "----", "----")
 
 	// Print items
 	for _, item := range items {
@@ -105,8 +186,91 @@ func PrintResults(items []scanner.ItemInfo, parentFolder, sortBy string, reverse
 			padding = strings.Repeat(" ", padCount)
 		}
 
-		fmt.Printf("%s  %s%s  %-*s  %s\n", sizeStr, unitStr, padding, typeColWidth, item.Type, item.Name)
+		pctStr := ""
+		if !opts.NoPct {
+			pctStr = fmt.Sprintf("%*.1f%%  ", pctColWidth-1, percentOf(item.Size, totalSize))
+		}
+
+		delta := ""
+		if opts.Previous != nil {
+			delta = formatDelta(item.Size - opts.Previous[item.Name])
+		}
+
+		fmt.Printf("%s  %s%s  %s%-*s  %s%s\n", sizeStr, unitStr, padding, pctStr, typeColWidth, item.Type, item.Name, delta)
+	}
+
+	fmt.Println(strings.Repeat("-", 80))
+
+	if opts.ChartWidth > 0 {
+		printChart(items, totalSize, opts.ChartWidth)
+	}
+}
+
+// percentOf returns size as a percentage of total (0 when total is 0)
+func percentOf(size, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(size) / float64(total) * 100
+}
+
+// formatDelta renders a size change as "  +12.3 MB" in green or "  -5.1 MB"
+// in red; no change renders as nothing
+func formatDelta(delta int64) string {
+	if delta == 0 {
+		return ""
+	}
+	sign, code := "+", 32 // green
+	if delta < 0 {
+		sign, code, delta = "-", 31, -delta // red
+	}
+	formatted := formatSize(delta)
+	if !colorEnabled {
+		return fmt.Sprintf("  %s%.1f %s", sign, formatted.Size, formatted.Unit)
+	}
+	return fmt.Sprintf("  \033[%dm%s%.1f %s\033[0m", code, sign, formatted.Size, formatted.Unit)
+}
+
+// printChart draws one horizontal bar per item, scaled to the largest item
+func printChart(items []scanner.ItemInfo, totalSize int64, width int) {
+	var largest int64
+	for _, item := range items {
+		if item.Size > largest {
+			largest = item.Size
+		}
+	}
+	if largest == 0 {
+		return
+	}
+
+	const nameWidth = chartLabelWidth - 10 // leaves room for " 100.0% "
+	for _, item := range items {
+		name := []rune(item.Name)
+		if len(name) > nameWidth {
+			name = append(name[:nameWidth-1], '…')
+		}
+		percent := percentOf(item.Size, totalSize)
+
+		barLen := int(float64(item.Size) / float64(largest) * float64(width))
+		if barLen == 0 && item.Size > 0 {
+			barLen = 1 // keep non-empty items visible
+		}
+		bar := barColor(strings.Repeat("█", barLen), formatSize(item.Size).Color)
+
+		fmt.Printf("%-*s %6.1f%% %s\n", nameWidth, string(name), percent, bar)
 	}
+}
 
+// PrintLargestFiles lists the largest individual files below the results table
+func PrintLargestFiles(files []scanner.FileEntry) {
+	if len(files) == 0 {
+		return
+	}
+
+	fmt.Printf("\n🗂  Largest Files (%d)\n", len(files))
+	for i, file := range files {
+		formatted := formatSize(file.Size)
+		fmt.Printf("%3d. %10.2f %s  %s\n", i+1, formatted.Size, color(formatted.Unit, formatted.Color), file.Path)
+	}
 	fmt.Println(strings.Repeat("-", 80))
 }
@@ -16,7 +16,7 @@ func TestPrintResultsShowsTypeAndFullNames(t *testing.T) {
 		PrintResults([]scanner.ItemInfo{
 			{Name: longFileName, Size: 5, Type: "file"},
 			{Name: longDirName, Size: 0, Type: "directory"},
-		}, "/tmp/example", "name", false)
+		}, "/tmp/example", PrintOptions{SortBy: "name"})
 	})
 
 	for _, want := range []string{"Type", "file", "directory", longFileName, longDirName} {
@@ -30,6 +30,148 @@ func TestPrintResultsShowsTypeAndFullNames(t *testing.T) {
 	}
 }
 
+func TestPrintResultsFilteredNote(t *testing.T) {
+	output := captureStdout(t, func() {
+		PrintResults([]scanner.ItemInfo{
+			{Name: "big", Size: 2 * 1024 * 1024, Type: "directory"},
+		}, "/tmp/example", PrintOptions{SortBy: "size", FilteredCount: 3, FilteredSize: 1024 * 1024})
+	})
+	if line := findLine(t, output, "Total Size"); !strings.Contains(line, "3.00") || !strings.Contains(line, "(3 entries filtered by size)") {
+		t.Errorf("total line = %q, want the size of all entries and the filtered note", line)
+	}
+	if line := findLine(t, output, "Items Found"); !strings.Contains(line, "1") {
+		t.Errorf("items line = %q, want 1 shown entry", line)
+	}
+
+	output = captureStdout(t, func() {
+		PrintResults(nil, "/tmp/example", PrintOptions{SortBy: "size", FilteredCount: 2})
+	})
+	if !strings.Contains(output, "2 entries filtered by size") {
+		t.Errorf("empty result output = %q, want the filtered note", output)
+	}
+}
+
+func TestPrintResultsPctColumn(t *testing.T) {
+	items := func() []scanner.ItemInfo {
+		return []scanner.ItemInfo{
+			{Name: "big", Size: 3000, Type: "directory"},
+			{Name: "small", Size: 1000, Type: "file"},
+		}
+	}
+
+	output := captureStdout(t, func() {
+		PrintResults(items(), "/tmp/example", PrintOptions{SortBy: "size", Reverse: true})
+	})
+	if line := findLine(t, output, "Type"); !strings.Contains(line, "Pct") {
+		t.Errorf("header = %q, want a Pct column", line)
+	}
+	if line := findLine(t, output, "  big"); !strings.Contains(line, " 75.0%") {
+		t.Errorf("big = %q, want 75.0%%", line)
+	}
+	if line := findLine(t, output, "  small"); !strings.Contains(line, " 25.0%") {
+		t.Errorf("small = %q, want 25.0%%", line)
+	}
+
+	output = captureStdout(t, func() {
+		PrintResults(items(), "/tmp/example", PrintOptions{SortBy: "size", Reverse: true, NoPct: true})
+	})
+	if strings.Contains(output, "Pct") || strings.Contains(output, "%") {
+		t.Errorf("NoPct output still has percentages:\n%s", output)
+	}
+}
+
+func TestPrintResultsChart(t *testing.T) {
+	output := captureStdout(t, func() {
+		PrintResults([]scanner.ItemInfo{
+			{Name: "big", Size: 3000, Type: "directory"},
+			{Name: "half", Size: 1500, Type: "directory"},
+			{Name: "tiny", Size: 1, Type: "file"},
+			{Name: "empty", Size: 0, Type: "file"},
+		}, "/tmp/example", PrintOptions{SortBy: "size", Reverse: true, ChartWidth: 40})
+	})
+
+	bars := map[string]int{}
+	for _, line := range strings.Split(output, "\n") {
+		if strings.Contains(line, "%") {
+			bars[strings.Fields(line)[0]] = strings.Count(line, "█")
+		}
+	}
+	want := map[string]int{"big": 40, "half": 20, "tiny": 1, "empty": 0}
+	for name, n := range want {
+		if bars[name] != n {
+			t.Errorf("bar for %q has %d blocks, want %d\n%s", name, bars[name], n, output)
+		}
+	}
+
+	noChart := captureStdout(t, func() {
+		PrintResults([]scanner.ItemInfo{{Name: "big", Size: 3000, Type: "directory"}}, "/tmp/example", PrintOptions{SortBy: "size", Reverse: true})
+	})
+	if strings.Contains(noChart, "█") {
+		t.Errorf("chart drawn with chartWidth 0:\n%s", noChart)
+	}
+}
+
+func TestPrintResultsDeltas(t *testing.T) {
+	output := captureStdout(t, func() {
+		PrintResults([]scanner.ItemInfo{
+			{Name: "grew", Size: 3 * 1024 * 1024, Type: "directory"},
+			{Name: "shrank", Size: 1024, Type: "directory"},
+			{Name: "same", Size: 10, Type: "file"},
+			{Name: "new", Size: 5, Type: "file"},
+		}, "/tmp/example", PrintOptions{SortBy: "name", Previous: map[string]int64{
+			"grew":   1024 * 1024,
+			"shrank": 2048,
+			"same":   10,
+		}})
+	})
+
+	want := map[string]string{
+		"grew":   "\033[32m+2.0 MB",
+		"shrank": "\033[31m-1.0 KB",
+		"new":    "\033[32m+5.0 bytes",
+		"same":   "",
+	}
+	for name, delta := range want {
+		line := findLine(t, output, "  "+name)
+		if delta == "" {
+			if strings.Contains(line, "+") || strings.Contains(line, "-") {
+				t.Errorf("unchanged entry annotated: %q", line)
+			}
+		} else if !strings.Contains(line, delta) {
+			t.Errorf("line for %q = %q, want it to contain %q", name, line, delta)
+		}
+	}
+}
+
+func TestPrintResultsNoColor(t *testing.T) {
+	SetColor(false)
+	t.Cleanup(func() { SetColor(true) })
+
+	output := captureStdout(t, func() {
+		PrintResults([]scanner.ItemInfo{
+			{Name: "grew", Size: 3 * 1024 * 1024, Type: "directory"},
+		}, "/tmp/example", PrintOptions{SortBy: "size", ChartWidth: 20, Previous: map[string]int64{"grew": 1024 * 1024}})
+	})
+	if strings.Contains(output, "\033[") {
+		t.Errorf("output contains escape codes:\n%q", output)
+	}
+	if line := findLine(t, output, "  grew"); !strings.Contains(line, "+2.0 MB") {
+		t.Errorf("line = %q, want the plain delta", line)
+	}
+}
+
+func findLine(t *testing.T, output, substr string) string {
+	t.Helper()
+
+	for _, line := range strings.Split(output, "\n") {
+		if strings.Contains(line, substr) {
+			return line
+		}
+	}
+	t.Fatalf("no line contains %q:\n%s", substr, output)
+	return ""
+}
+
 func captureStdout(t *testing.T, fn func()) string {
 	t.Helper()
 
@@ -0,0 +1,646 @@
+// Package caseconv converts identifiers and phrases between naming
+// conventions: snake_case, kebab-case, camelCase, PascalCase, CONSTANT_CASE
+// and more. The input style is detected, so any of them can be converted to
+// any other.
+package caseconv
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// CaseConverter holds the conversion settings: the acronym set, extra word
+// delimiters and how digits are treated. Create it with NewCaseConverter;
+// it must not be changed while other goroutines use it.
+type CaseConverter struct {
+	acronyms   map[string]bool // upper-cased words kept all caps by ToPascalCase and ToCamelCase
+	delimiters []string        // extra word boundaries, e.g. "::" or "->"
+
+	// StripNumbers drops digits from the input of Process, for naming
+	// conventions that do not allow them
+	StripNumbers bool
+
+	// SmartNumbers makes a switch between letters and digits a word
+	// boundary in camelCase and PascalCase input (test2Result -> test 2 Result)
+	SmartNumbers bool
+
+	// Locale selects the upper- and lower-case rules, e.g. language.Turkish
+	// for dotted and dotless i (i -> İ, I -> ı). The zero value (language.Und)
+	// and English use the language-neutral Unicode mappings.
+	Locale language.Tag
+
+	casers sync.Pool // *localeCasers, reused by upper, lower and swapCase
+}
+
+// localeCasers are the Casers of one locale. A Caser keeps state, so a set
+// is used by one goroutine at a time, taken from CaseConverter.casers.
+type localeCasers struct {
+	tag          language.Tag
+	upper, lower cases.Caser
+}
+
+var englishBase = language.MustParseBase("en")
+
+// neutralLocale reports whether tag uses the language-neutral Unicode case
+// mappings, which strings.ToUpper and strings.ToLower apply far faster than
+// a Caser.
+func neutralLocale(tag language.Tag) bool {
+	if tag == language.Und {
+		return true
+	}
+	base, _ := tag.Base()
+	return base == englishBase
+}
+
+// getCasers returns Casers for cc.Locale; put them back in cc.casers after use
+func (cc *CaseConverter) getCasers() *localeCasers {
+	c, _ := cc.casers.Get().(*localeCasers)
+	if c == nil || c.tag != cc.Locale {
+		c = &localeCasers{tag: cc.Locale, upper: cases.Upper(cc.Locale), lower: cases.Lower(cc.Locale)}
+	}
+	return c
+}
+
+// defaultAcronyms are the common abbreviations added by AddDefaultAcronyms,
+// to write them all caps in PascalCase and camelCase as in Go identifiers
+// (UserID, HTTPServer)
+var defaultAcronyms = []string{
+	"API", "ASCII", "CPU", "CSS", "CSV", "DNS", "EOF", "GUID", "HTML", "HTTP",
+	"HTTPS", "ID", "IP", "JSON", "JWT", "OS", "RAM", "RPC", "SQL", "SSH",
+	"TCP", "TLS", "TTL", "UDP", "UI", "URI", "URL", "UUID", "VM", "XML", "YAML",
+}
+
+// defaultConverter backs ProcessCaseConversions; it is never modified
+var defaultConverter = NewCaseConverter()
+
+// NewCaseConverter returns a converter with no acronyms, so every word is
+// capitalized alike ("user id" -> UserId) until some are added
+func NewCaseConverter() *CaseConverter {
+	return &CaseConverter{acronyms: make(map[string]bool)}
+}
+
+// AddDefaultAcronyms adds the built-in list of common abbreviations (API,
+// HTTP, ID, URL, ...) to the acronym set
+func (cc *CaseConverter) AddDefaultAcronyms() {
+	cc.AddAcronyms(defaultAcronyms...)
+}
+
+// AddAcronyms adds words to the acronym set; case does not matter
+func (cc *CaseConverter) AddAcronyms(words ...string) {
+	for _, w := range words {
+		if w = strings.TrimSpace(w); w != "" {
+			cc.acronyms[strings.ToUpper(w)] = true
+		}
+	}
+}
+
+// RemoveAcronyms drops words from the acronym set; case does not matter
+func (cc *CaseConverter) RemoveAcronyms(words ...string) {
+	for _, w := range words {
+		delete(cc.acronyms, strings.ToUpper(strings.TrimSpace(w)))
+	}
+}
+
+// LoadAcronymsFile applies an acronym list to the set. The file has one
+// acronym per line; "- WORD" removes WORD instead (e.g. a default acronym
+// that is a real word in the domain), and blank lines and lines starting with # are
+// ignored.
+func (cc *CaseConverter) LoadAcronymsFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if word, ok := strings.CutPrefix(line, "-"); ok {
+			if word = strings.TrimSpace(word); word == "" {
+				return fmt.Errorf("%s:%d: expected an acronym after -", path, lineNum)
+			}
+			cc.RemoveAcronyms(word)
+			continue
+		}
+		if strings.ContainsFunc(line, unicode.IsSpace) {
+			return fmt.Errorf("%s:%d: expected one acronym per line, got %q", path, lineNum, line)
+		}
+		cc.AddAcronyms(line)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+	return nil
+}
+
+// AddDelimiter makes s a word boundary in addition to the standard ones
+// (space, _, -, . and /). Multi-character delimiters such as "::" match as
+// a whole, so a lone ":" is left alone.
+func (cc *CaseConverter) AddDelimiter(s string) {
+	if s != "" {
+		cc.delimiters = append(cc.delimiters, s)
+	}
+}
+
+// capitalize writes word with an upper-case first letter, or all caps when it
+// is a known acronym
+func (cc *CaseConverter) capitalize(result *strings.Builder, word string) {
+	if cc.acronyms[strings.ToUpper(word)] {
+		result.WriteString(strings.ToUpper(word))
+		return
+	}
+	result.WriteString(cc.upperFirst(word))
+}
+
+// upper upper-cases s by the rules of cc.Locale
+func (cc *CaseConverter) upper(s string) string {
+	if neutralLocale(cc.Locale) {
+		return strings.ToUpper(s)
+	}
+	c := cc.getCasers()
+	defer cc.casers.Put(c)
+	return c.upper.String(s)
+}
+
+// lower lower-cases s by the rules of cc.Locale
+func (cc *CaseConverter) lower(s string) string {
+	if neutralLocale(cc.Locale) {
+		return strings.ToLower(s)
+	}
+	c := cc.getCasers()
+	defer cc.casers.Put(c)
+	return c.lower.String(s)
+}
+
+// upperFirst upper-cases the first letter of word and lower-cases the rest
+func (cc *CaseConverter) upperFirst(word string) string {
+	_, size := utf8.DecodeRuneInString(word)
+	return cc.upper(word[:size]) + cc.lower(word[size:])
+}
+
+// RemoveNonAlpha removes non-alphabetic characters from a string, keeping whitespace and alphanumeric
+func (cc *CaseConverter) RemoveNonAlpha(s string) string {
+	var result strings.Builder
+	result.Grow(len(s)) // Pre-allocate capacity
+	for _, char := range s {
+		if unicode.IsLetter(char) || unicode.IsSpace(char) || unicode.IsNumber(char) {
+			result.WriteRune(char)
+		}
+	}
+	return result.String()
+}
+
+// RemoveNonAlphaStrict is RemoveNonAlpha that also removes numbers, keeping
+// only letters and whitespace
+func (cc *CaseConverter) RemoveNonAlphaStrict(s string) string {
+	var result strings.Builder
+	result.Grow(len(s)) // Pre-allocate capacity
+	for _, char := range s {
+		if unicode.IsLetter(char) || unicode.IsSpace(char) {
+			result.WriteRune(char)
+		}
+	}
+	return result.String()
+}
+
+// ToSnakeCase converts string to snake_case
+func (cc *CaseConverter) ToSnakeCase(s string) string {
+	return cc.lower(strings.ReplaceAll(s, " ", "_"))
+}
+
+// ToPascalCase converts string to PascalCase
+func (cc *CaseConverter) ToPascalCase(s string) string {
+	words := strings.Fields(s)
+	if len(words) == 0 {
+		return s
+	}
+
+	var result strings.Builder
+	result.Grow(len(s)) // Pre-allocate capacity
+
+	for _, word := range words {
+		if len(word) > 0 {
+			cc.capitalize(&result, word)
+		}
+	}
+	return result.String()
+}
+
+// ToKebabCase converts string to kebab-case
+func (cc *CaseConverter) ToKebabCase(s string) string {
+	return cc.lower(strings.ReplaceAll(s, " ", "-"))
+}
+
+// ToConstantCase converts string to CONSTANT_CASE
+func (cc *CaseConverter) ToConstantCase(s string) string {
+	return cc.upper(strings.ReplaceAll(s, " ", "_"))
+}
+
+// ToCobolCase converts string to COBOL-CASE
+func (cc *CaseConverter) ToCobolCase(s string) string {
+	return cc.upper(strings.ReplaceAll(s, " ", "-"))
+}
+
+// ToPathCase converts string to path/case
+func (cc *CaseConverter) ToPathCase(s string) string {
+	return cc.lower(strings.ReplaceAll(s, " ", "/"))
+}
+
+// ToCamelCase converts string to camelCase
+func (cc *CaseConverter) ToCamelCase(s string) string {
+	words := strings.Fields(s)
+	if len(words) == 0 {
+		return s
+	}
+
+	var result strings.Builder
+	result.Grow(len(s)) // Pre-allocate capacity
+
+	// First word in lowercase, even an acronym (idValue, httpServer)
+	if len(words[0]) > 0 {
+		result.WriteString(cc.lower(words[0]))
+	}
+
+	// Subsequent words with first letter uppercase, acronyms all caps
+	for i := 1; i < len(words); i++ {
+		if len(words[i]) > 0 {
+			cc.capitalize(&result, words[i])
+		}
+	}
+	return result.String()
+}
+
+// ToTitleCase converts string to Title Case
+func (cc *CaseConverter) ToTitleCase(s string) string {
+	words := strings.Fields(s)
+	if len(words) == 0 {
+		return s
+	}
+
+	var result strings.Builder
+	result.Grow(len(s)) // Pre-allocate capacity
+
+	for i, word := range words {
+		if i > 0 {
+			result.WriteByte(' ')
+		}
+		if len(word) > 0 {
+			result.WriteString(cc.upperFirst(word))
+		}
+	}
+	return result.String()
+}
+
+// ToTrainCase converts string to Train-Case: Title Case words joined by
+// hyphens. Unlike kebab-case it keeps the capitals.
+func (cc *CaseConverter) ToTrainCase(s string) string {
+	return strings.ReplaceAll(cc.ToTitleCase(s), " ", "-")
+}
+
+// ToAdaCase converts string to Ada_Case: Title Case words joined by
+// underscores (Mixed_Case_With_Underscores)
+func (cc *CaseConverter) ToAdaCase(s string) string {
+	return strings.ReplaceAll(cc.ToTitleCase(s), " ", "_")
+}
+
+// ToDotCase converts string to dot.case
+func (cc *CaseConverter) ToDotCase(s string) string {
+	return strings.Join(strings.Fields(s), ".")
+}
+
+// FromSnakeCase converts snake_case to normal text
+func (cc *CaseConverter) FromSnakeCase(s string) string {
+	words := strings.Split(s, "_")
+	if len(words) == 0 {
+		return s
+	}
+
+	var result strings.Builder
+	result.Grow(len(s)) // Pre-allocate capacity
+
+	for i, word := range words {
+		if i > 0 {
+			result.WriteByte(' ')
+		}
+		if len(word) > 0 {
+			result.WriteString(cc.upperFirst(word))
+		}
+	}
+	return result.String()
+}
+
+// numberBoundary reports whether SmartNumbers splits between prev and char:
+// a digit after a letter or a letter after a digit
+func (cc *CaseConverter) numberBoundary(prev, char rune) bool {
+	if !cc.SmartNumbers {
+		return false
+	}
+	return (unicode.IsDigit(char) && unicode.IsLetter(prev)) || (unicode.IsLetter(char) && unicode.IsDigit(prev))
+}
+
+// FromPascalCase converts PascalCase to normal text
+func (cc *CaseConverter) FromPascalCase(s string) string {
+	if len(s) == 0 {
+		return s
+	}
+
+	var result strings.Builder
+	result.Grow(len(s) + 10) // Pre-allocate capacity with some extra space
+
+	var prev rune
+	for i, char := range s {
+		if i > 0 && (unicode.IsUpper(char) || cc.numberBoundary(prev, char)) {
+			result.WriteByte(' ')
+		}
+		result.WriteRune(char)
+		prev = char
+	}
+	return result.String()
+}
+
+// FromCamelCase converts camelCase to normal text
+func (cc *CaseConverter) FromCamelCase(s string) string {
+	if len(s) == 0 {
+		return s
+	}
+
+	var result strings.Builder
+	result.Grow(len(s) + 10) // Pre-allocate capacity with some extra space
+
+	var prev rune
+	for i, char := range s {
+		if i > 0 && (unicode.IsUpper(char) || cc.numberBoundary(prev, char)) {
+			result.WriteByte(' ')
+		}
+		result.WriteRune(char)
+		prev = char
+	}
+	return result.String()
+}
+
+// FromKebabCase converts kebab-case to normal text
+func (cc *CaseConverter) FromKebabCase(s string) string {
+	words := strings.Split(s, "-")
+	if len(words) == 0 {
+		return s
+	}
+
+	var result strings.Builder
+	result.Grow(len(s)) // Pre-allocate capacity
+
+	for i, word := range words {
+		if i > 0 {
+			result.WriteByte(' ')
+		}
+		if len(word) > 0 {
+			result.WriteString(cc.upperFirst(word))
+		}
+	}
+	return result.String()
+}
+
+// DetectCaseType names the case style of text: "normal" (has spaces), "snake",
+// "ada" (underscores with mixed capitals), "cobol" (all caps with hyphens),
+// "kebab", "dot", "path", "camel_or_pascal" or "unknown". The first separator
+// found decides.
+func DetectCaseType(text string) string {
+	if strings.Contains(text, " ") {
+		return "normal"
+	}
+	if strings.Contains(text, "_") {
+		if strings.ContainsFunc(text, unicode.IsUpper) && strings.ContainsFunc(text, unicode.IsLower) {
+			return "ada"
+		}
+		return "snake"
+	}
+	if strings.Contains(text, "-") {
+		if strings.ToUpper(text) == text && strings.ContainsFunc(text, unicode.IsLetter) {
+			return "cobol"
+		}
+		return "kebab"
+	}
+	if strings.Contains(text, ".") {
+		return "dot"
+	}
+	if strings.Contains(text, "/") {
+		return "path"
+	}
+	// Check for camelCase or PascalCase
+	for i, char := range text {
+		if i > 0 && unicode.IsUpper(char) {
+			return "camel_or_pascal"
+		}
+	}
+	return "unknown"
+}
+
+// normalize splits text into space-separated words according to its
+// detected case type
+func (cc *CaseConverter) normalize(text string) string {
+	caseType := DetectCaseType(text)
+
+	switch caseType {
+	case "normal":
+		return text
+	case "snake", "ada":
+		return cc.FromSnakeCase(text)
+	case "kebab", "cobol":
+		return cc.FromKebabCase(text)
+	case "dot":
+		return strings.ReplaceAll(text, ".", " ")
+	case "path":
+		return strings.ReplaceAll(text, "/", " ")
+	case "camel_or_pascal":
+		// Try camel case first, then pascal
+		result := cc.FromCamelCase(text)
+		if result != text {
+			return result
+		}
+		return cc.FromPascalCase(text)
+	default:
+		// Try all conversions as fallback
+		result := cc.FromCamelCase(text)
+		if result != text {
+			return result
+		}
+		result = cc.FromSnakeCase(text)
+		if result != text {
+			return result
+		}
+		result = cc.FromKebabCase(text)
+		if result != text {
+			return result
+		}
+		return cc.FromPascalCase(text)
+	}
+}
+
+// Normalize splits text into space-separated words. It first splits on the
+// extra delimiters and then normalizes each part on its own, so the case
+// style of one part does not decide how the others are split
+// (MyApp::HttpClient -> My App Http Client).
+func (cc *CaseConverter) Normalize(text string) string {
+	parts := []string{text}
+	for _, d := range cc.delimiters {
+		var split []string
+		for _, p := range parts {
+			split = append(split, strings.Split(p, d)...)
+		}
+		parts = split
+	}
+	if len(parts) == 1 {
+		return cc.normalize(text)
+	}
+
+	normalized := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			normalized = append(normalized, cc.normalize(p))
+		}
+	}
+	return strings.Join(normalized, " ")
+}
+
+// ProcessCaseConversions converts text to every format in Formats, using the
+// default settings of NewCaseConverter.
+func ProcessCaseConversions(text string) map[string]string {
+	return defaultConverter.Process(text)
+}
+
+// Process converts text to every format in Formats. The result maps each
+// format name to the converted text. The deprecated pascal_kebab key is also
+// set, to the train_case text, for callers written before the rename; it is
+// not listed in Formats.
+func (cc *CaseConverter) Process(text string) map[string]string {
+	// Normalize text efficiently
+	normalized := cc.Normalize(text)
+
+	// Clean up the text
+	words := strings.Fields(strings.TrimSpace(normalized))
+	var cleanText string
+	if cc.StripNumbers {
+		// A word of digits only is gone now, so split again to drop its space
+		cleanText = cc.RemoveNonAlphaStrict(strings.Join(words, " "))
+		cleanText = strings.Join(strings.Fields(cleanText), " ")
+	} else {
+		cleanText = cc.RemoveNonAlpha(strings.Join(words, " "))
+	}
+	cleanText = cc.lower(cleanText)
+
+	// The raw input is the fallback, unless it would bring the numbers back
+	if len(cleanText) == 0 && !cc.StripNumbers {
+		cleanText = cc.lower(strings.TrimSpace(text))
+	}
+
+	// Pre-allocate the result map
+	result := make(map[string]string, len(formats)+len(formatAliases))
+
+	// Use cached instances and avoid repeated allocations
+	result["normal"] = cleanText
+	result["upper"] = cc.upper(cleanText)
+	result["lower"] = cc.lower(cleanText)
+
+	if len(cleanText) > 0 {
+		result["capitalized"] = cc.upperFirst(cleanText)
+	} else {
+		result["capitalized"] = cleanText
+	}
+
+	result["swapped"] = cc.swapCase(cleanText)
+	result["snake_case"] = cc.ToSnakeCase(cleanText)
+	result["kebab_case"] = cc.ToKebabCase(cleanText)
+	result["camel_case"] = cc.ToCamelCase(cleanText)
+	result["pascal_case"] = cc.ToPascalCase(cleanText)
+	result["constant_case"] = cc.ToConstantCase(cleanText)
+	result["cobol_case"] = cc.ToCobolCase(cleanText)
+	result["title_case"] = cc.ToTitleCase(cleanText)
+	result["dot_case"] = cc.ToDotCase(cleanText)
+	result["path_case"] = cc.ToPathCase(cleanText)
+	result["train_case"] = cc.ToTrainCase(cleanText)
+	result["ada_case"] = cc.ToAdaCase(cleanText)
+
+	// Retired names keep returning the text of the format they became
+	for alias, key := range formatAliases {
+		result[alias] = result[key]
+	}
+
+	return result
+}
+
+// swapCase swaps the case of each character
+func (cc *CaseConverter) swapCase(s string) string {
+	var result strings.Builder
+	result.Grow(len(s)) // Pre-allocate capacity
+	if neutralLocale(cc.Locale) {
+		for _, char := range s {
+			if unicode.IsUpper(char) {
+				result.WriteRune(unicode.ToLower(char))
+			} else if unicode.IsLower(char) {
+				result.WriteRune(unicode.ToUpper(char))
+			} else {
+				result.WriteRune(char)
+			}
+		}
+		return result.String()
+	}
+
+	c := cc.getCasers()
+	defer cc.casers.Put(c)
+	for _, char := range s {
+		if unicode.IsUpper(char) {
+			result.WriteString(c.lower.String(string(char)))
+		} else if unicode.IsLower(char) {
+			result.WriteString(c.upper.String(string(char)))
+		} else {
+			result.WriteRune(char)
+		}
+	}
+	return result.String()
+}
+
+// formats lists the keys of a Process result in display order.
+var formats = []string{
+	"normal", "upper", "lower", "capitalized", "swapped",
+	"snake_case", "kebab_case", "camel_case", "pascal_case",
+	"constant_case", "cobol_case", "title_case", "dot_case", "path_case", "train_case",
+	"ada_case",
+}
+
+// Formats returns the keys of a Process result in display order. The slice
+// is a copy the caller may change.
+func Formats() []string {
+	return slices.Clone(formats)
+}
+
+// formatAliases maps retired format names to their key in Formats.
+var formatAliases = map[string]string{
+	"pascal_kebab": "train_case", // deprecated: the old name of train_case
+}
+
+// FormatKey maps a user-supplied format name to its key in Formats. Dashes
+// may stand for underscores and "_case" may be left out (kebab-case, snake).
+// The deprecated pascal_kebab is still accepted for train_case.
+func FormatKey(format string) (string, bool) {
+	key := strings.ReplaceAll(format, "-", "_")
+	if k, ok := formatAliases[key]; ok {
+		return k, true
+	}
+	for _, k := range formats {
+		if k == key || k == key+"_case" {
+			return k, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,222 @@
+package caseconv
+
+import (
+	"slices"
+	"sync"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func TestProcessCaseConversions(t *testing.T) {
+	tests := []struct {
+		input  string
+		format string
+		want   string
+	}{
+		{"hello world", "snake_case", "hello_world"},
+		{"helloWorld", "kebab_case", "hello-world"},
+		{"hello_world", "camel_case", "helloWorld"},
+		{"hello-world", "pascal_case", "HelloWorld"},
+		{"HELLO-WORLD", "snake_case", "hello_world"}, // COBOL-CASE input
+		{"hello.world", "constant_case", "HELLO_WORLD"},
+		{"hello/world", "cobol_case", "HELLO-WORLD"},
+		{"user id url", "pascal_case", "UserIdUrl"}, // no acronyms by default
+		{"user id url", "camel_case", "userIdUrl"},
+		{"hello world", "path_case", "hello/world"},
+		{"hello world", "train_case", "Hello-World"},
+		{"hello_world_example", "train_case", "Hello-World-Example"},
+		{"hello world", "pascal_kebab", "Hello-World"}, // deprecated alias of train_case
+		{"hello world example", "ada_case", "Hello_World_Example"},
+		{"Hello_World_Example", "kebab_case", "hello-world-example"}, // Ada_Case input
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input+"/"+tt.format, func(t *testing.T) {
+			if got := ProcessCaseConversions(tt.input)[tt.format]; got != tt.want {
+				t.Errorf("ProcessCaseConversions(%q)[%q] = %q, want %q", tt.input, tt.format, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessReturnsEveryFormat(t *testing.T) {
+	got := ProcessCaseConversions("some text")
+	// Every format plus the deprecated pascal_kebab duplicate
+	if len(got) != len(Formats())+1 {
+		t.Errorf("got %d conversions, want %d", len(got), len(Formats())+1)
+	}
+	for _, f := range Formats() {
+		if _, ok := got[f]; !ok {
+			t.Errorf("missing format %q", f)
+		}
+	}
+	if got["pascal_kebab"] != got["train_case"] {
+		t.Errorf("pascal_kebab = %q, want the train_case text %q", got["pascal_kebab"], got["train_case"])
+	}
+	if slices.Contains(Formats(), "pascal_kebab") {
+		t.Error("Formats() lists the deprecated pascal_kebab")
+	}
+}
+
+func TestFormatsReturnsCopy(t *testing.T) {
+	Formats()[0] = "changed"
+	if got := Formats()[0]; got != "normal" {
+		t.Errorf("Formats()[0] = %q after changing a returned slice, want %q", got, "normal")
+	}
+}
+
+func TestConverterSettings(t *testing.T) {
+	cc := NewCaseConverter()
+	cc.AddDelimiter("::")
+	cc.AddAcronyms("db")
+	cc.StripNumbers = true
+
+	if got := cc.Process("MyApp::Db2Client")["snake_case"]; got != "my_app_db_client" {
+		t.Errorf("snake_case = %q, want %q", got, "my_app_db_client")
+	}
+	if got := cc.Process("my db client")["pascal_case"]; got != "MyDBClient" {
+		t.Errorf("pascal_case = %q, want %q", got, "MyDBClient")
+	}
+	// the package-level function keeps the defaults
+	if got := ProcessCaseConversions("my db client")["pascal_case"]; got != "MyDbClient" {
+		t.Errorf("default pascal_case = %q, want %q", got, "MyDbClient")
+	}
+}
+
+func TestDefaultAcronyms(t *testing.T) {
+	cc := NewCaseConverter()
+	cc.AddDefaultAcronyms()
+	tests := map[string]string{
+		"user id url":    "UserIDURL",
+		"http server":    "HTTPServer",
+		"json api token": "JSONAPIToken",
+		"order sku":      "OrderSku", // not in the built-in list
+	}
+	for input, want := range tests {
+		if got := cc.Process(input)["pascal_case"]; got != want {
+			t.Errorf("pascal_case(%q) = %q, want %q", input, got, want)
+		}
+	}
+	if got := cc.Process("user id")["camel_case"]; got != "userID" {
+		t.Errorf("camel_case = %q, want %q", got, "userID")
+	}
+
+	cc.RemoveAcronyms("id")
+	if got := cc.Process("user id")["pascal_case"]; got != "UserId" {
+		t.Errorf("after RemoveAcronyms: pascal_case = %q, want %q", got, "UserId")
+	}
+}
+
+func TestSmartNumbers(t *testing.T) {
+	cc := NewCaseConverter()
+	if got := cc.Process("test2Result")["snake_case"]; got != "test2_result" {
+		t.Errorf("without SmartNumbers: snake_case = %q, want %q", got, "test2_result")
+	}
+
+	cc.SmartNumbers = true
+	tests := map[string]string{
+		"test2Result": "test_2_result",
+		"Base64Url":   "base_64_url",
+		"md5":         "md_5",
+		"v2":          "v_2",
+		"user_2fa":    "user_2fa", // only camelCase and PascalCase input is split
+	}
+	for input, want := range tests {
+		if got := cc.Process(input)["snake_case"]; got != want {
+			t.Errorf("SmartNumbers: snake_case(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestLocale(t *testing.T) {
+	cc := NewCaseConverter()
+	cc.Locale = language.Turkish
+	got := cc.Process("istanbul ılık")
+	tests := map[string]string{
+		"upper":         "İSTANBUL ILIK",
+		"capitalized":   "İstanbul ılık",
+		"swapped":       "İSTANBUL ILIK",
+		"pascal_case":   "İstanbulIlık",
+		"constant_case": "İSTANBUL_ILIK",
+		"title_case":    "İstanbul Ilık",
+	}
+	for format, want := range tests {
+		if got[format] != want {
+			t.Errorf("tr %s = %q, want %q", format, got[format], want)
+		}
+	}
+	if got := cc.Process("ILIK SU")["lower"]; got != "ılık su" {
+		t.Errorf("tr lower = %q, want %q", got, "ılık su")
+	}
+
+	// without a locale, in English or after switching away from Turkish, i
+	// and I map to each other
+	for _, tag := range []language.Tag{language.Und, language.English, language.AmericanEnglish, language.German} {
+		cc.Locale = tag
+		if got := cc.Process("istanbul")["swapped"]; got != "ISTANBUL" {
+			t.Errorf("%s swapped = %q, want %q", tag, got, "ISTANBUL")
+		}
+	}
+}
+
+func TestLocaleConcurrent(t *testing.T) {
+	cc := NewCaseConverter()
+	cc.Locale = language.Turkish
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				if got := cc.Process("istanbul ılık")["swapped"]; got != "İSTANBUL ILIK" {
+					t.Errorf("tr swapped = %q, want %q", got, "İSTANBUL ILIK")
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestDetectCaseType(t *testing.T) {
+	tests := map[string]string{
+		"hello world": "normal",
+		"hello_world": "snake",
+		"Hello_World": "ada",
+		"HELLO_WORLD": "snake", // CONSTANT_CASE
+		"hello-world": "kebab",
+		"HELLO-WORLD": "cobol",
+		"hello.world": "dot",
+		"hello/world": "path",
+		"helloWorld":  "camel_or_pascal",
+		"hello":       "unknown",
+	}
+	for input, want := range tests {
+		if got := DetectCaseType(input); got != want {
+			t.Errorf("DetectCaseType(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestFormatKey(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+		ok     bool
+	}{
+		{"snake_case", "snake_case", true},
+		{"kebab-case", "kebab_case", true},
+		{"snake", "snake_case", true},
+		{"train", "train_case", true},
+		{"ada", "ada_case", true},
+		{"pascal-kebab", "train_case", true}, // deprecated alias
+		{"shout", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := FormatKey(tt.format)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("FormatKey(%q) = %q, %v, want %q, %v", tt.format, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+// Package gitignore evaluates .gitignore files the way git does: nested
+// files, negation, directory-only and anchored patterns, and "**".
+package gitignore
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ignoreRule is a single parsed .gitignore pattern
+type ignoreRule struct {
+	segments []string // pattern split on '/', may contain "**"
+	negate   bool     // "!pattern" re-includes a previously ignored path
+	dirOnly  bool     // "pattern/" only matches directories
+	anchored bool     // pattern contains '/', so it is relative to the .gitignore directory
+}
+
+// Matcher evaluates the .gitignore files found below a root directory.
+// Rules are loaded lazily per directory and cached; a Matcher is safe for
+// concurrent use.
+type Matcher struct {
+	root  string
+	mu    sync.Mutex
+	rules map[string][]ignoreRule // slash-separated dir relative to root ("." for root)
+}
+
+// New returns a Matcher for the tree at root
+func New(root string) *Matcher {
+	return &Matcher{root: root, rules: make(map[string][]ignoreRule)}
+}
+
+// Ignored reports whether path is ignored. Every .gitignore from the root down
+// to the path's parent is consulted; deeper files and later rules win.
+func (g *Matcher) Ignored(filePath string, isDir bool) bool {
+	rel, err := filepath.Rel(g.root, filePath)
+	if err != nil || rel == "." {
+		return false
+	}
+	segs := strings.Split(filepath.ToSlash(rel), "/")
+
+	ignored := false
+	for i := range segs {
+		dir := "."
+		if i > 0 {
+			dir = strings.Join(segs[:i], "/")
+		}
+		for _, rule := range g.load(dir) {
+			if rule.matches(segs[i:], isDir) {
+				ignored = !rule.negate
+			}
+		}
+	}
+	return ignored
+}
+
+// load returns the rules of dir/.gitignore, reading the file on first use
+func (g *Matcher) load(dir string) []ignoreRule {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if rules, ok := g.rules[dir]; ok {
+		return rules
+	}
+
+	var rules []ignoreRule
+	if f, err := os.Open(filepath.Join(g.root, filepath.FromSlash(dir), ".gitignore")); err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			if rule, ok := parseIgnoreLine(scanner.Text()); ok {
+				rules = append(rules, rule)
+			}
+		}
+		f.Close()
+	}
+	g.rules[dir] = rules
+	return rules
+}
+
+// parseIgnoreLine parses one .gitignore line; ok is false for blanks and comments
+func parseIgnoreLine(line string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	var rule ignoreRule
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		line = line[1:] // escaped leading '#' or '!'
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		rule.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+	rule.segments = strings.Split(line, "/")
+	return rule, true
+}
+
+// matches reports whether the rule matches rel, the path segments relative
+// to the directory holding the .gitignore
+func (r ignoreRule) matches(rel []string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if !r.anchored {
+		ok, _ := path.Match(r.segments[0], rel[len(rel)-1])
+		return ok
+	}
+	return matchSegments(r.segments, rel)
+}
+
+// matchSegments matches glob segments against path segments, where "**"
+// spans zero or more directories (one or more when it ends the pattern)
+func matchSegments(pattern, segs []string) bool {
+	if len(pattern) == 0 {
+		return len(segs) == 0
+	}
+	if pattern[0] == "**" {
+		if len(pattern) == 1 {
+			return len(segs) > 0
+		}
+		for i := 0; i <= len(segs); i++ {
+			if matchSegments(pattern[1:], segs[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segs) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], segs[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], segs[1:])
+}
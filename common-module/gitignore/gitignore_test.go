@@ -0,0 +1,85 @@
+package gitignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGitignoreNestedAndNegation(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".gitignore"), "# build output\n*.log\n!keep.log\nbuild/\n/secret.txt\ndocs/**/draft.md\n")
+	writeFile(t, filepath.Join(root, "sub", ".gitignore"), "!debug.log\ntmp\n")
+	writeFile(t, filepath.Join(root, "sub", "deeper", ".gitignore"), "*.log\n")
+
+	g := New(root)
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"app.log", false, true},
+		{"keep.log", false, false},            // negated in the same file
+		{"sub/app.log", false, true},          // unanchored pattern applies at any depth
+		{"sub/debug.log", false, false},       // re-included by a nested .gitignore
+		{"sub/deeper/debug.log", false, true}, // ignored again by an even deeper file
+		{"build", true, true},                 // dir-only pattern
+		{"build", false, false},               // ...does not match a file named build
+		{"sub/build", true, true},
+		{"secret.txt", false, true}, // anchored to the root
+		{"sub/secret.txt", false, false},
+		{"sub/tmp", false, true},           // nested rule without slash
+		{"tmp", false, false},              // nested rules do not apply above their dir
+		{"docs/draft.md", false, true},     // "**" matches zero directories
+		{"docs/a/b/draft.md", false, true}, // ...or several
+		{"main.go", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := g.Ignored(filepath.Join(root, filepath.FromSlash(tt.path)), tt.isDir); got != tt.want {
+				t.Errorf("Ignored(%q, dir=%v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseIgnoreLine(t *testing.T) {
+	tests := []struct {
+		line string
+		ok   bool
+		want ignoreRule
+	}{
+		{line: "", ok: false},
+		{line: "# comment", ok: false},
+		{line: `\#literal`, ok: true, want: ignoreRule{segments: []string{"#literal"}}},
+		{line: "!keep/", ok: true, want: ignoreRule{segments: []string{"keep"}, negate: true, dirOnly: true}},
+		{line: "/a/b  ", ok: true, want: ignoreRule{segments: []string{"a", "b"}, anchored: true}},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseIgnoreLine(tt.line)
+		if ok != tt.ok {
+			t.Errorf("parseIgnoreLine(%q) ok = %v, want %v", tt.line, ok, tt.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if got.negate != tt.want.negate || got.dirOnly != tt.want.dirOnly || got.anchored != tt.want.anchored ||
+			filepath.Join(got.segments...) != filepath.Join(tt.want.segments...) {
+			t.Errorf("parseIgnoreLine(%q) = %+v, want %+v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("create dir for %s: %v", path, err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
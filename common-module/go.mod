@@ -1,3 +1,9 @@
 module common-module
 
-go 1.24.4
+go 1.25.0
+
+require golang.org/x/term v0.44.0
+
+require golang.org/x/sys v0.46.0
+
+require golang.org/x/text v0.38.0
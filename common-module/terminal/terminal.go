@@ -0,0 +1,21 @@
+// Package terminal holds small helpers shared by the CLIs that draw
+// terminal-sized output.
+package terminal
+
+import (
+	"os"
+
+	"golang.org/x/term"
+)
+
+// DefaultWidth is assumed when the width of a terminal cannot be read
+const DefaultWidth = 80
+
+// Width returns the width of the terminal f is attached to, or DefaultWidth
+// if f is not a terminal
+func Width(f *os.File) int {
+	if width, _, err := term.GetSize(int(f.Fd())); err == nil && width > 0 {
+		return width
+	}
+	return DefaultWidth
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package terminal
+
+import "os"
+
+// EnableVirtualTerminal reports whether ANSI escape sequences written to f
+// are interpreted. Only Windows consoles need them switched on.
+func EnableVirtualTerminal(f *os.File) bool {
+	return true
+}
@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"os"
+
+	"common-module/terminal"
+)
+
+// ColorRequested reports whether the user allows ANSI colors: neither noColor
+// (the --no-color flag) nor the NO_COLOR environment variable
+// (https://no-color.org) is set
+func ColorRequested(noColor bool) bool {
+	return !noColor && os.Getenv("NO_COLOR") == ""
+}
+
+// ColorEnabled reports whether ANSI colors should be written to out. Colors
+// are off when ColorRequested is false, when out is redirected to a file or
+// pipe, or when out is a Windows console that cannot interpret them.
+func ColorEnabled(out *os.File, noColor bool) bool {
+	if !ColorRequested(noColor) {
+		return false
+	}
+	stat, err := out.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0 && terminal.EnableVirtualTerminal(out)
+}
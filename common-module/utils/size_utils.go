@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseSize parses a human-readable size such as "512KB", "1.5GB" or "100"
+// (bytes) into a byte count. Units are binary (1KB = 1024 bytes) and
+// case-insensitive; "inf" means no limit.
+func ParseSize(sizeStr string) (int64, error) {
+	if strings.ToLower(sizeStr) == "inf" {
+		return 1<<63 - 1, nil // Max int64
+	}
+
+	sizeStr = strings.ToUpper(sizeStr)
+
+	// Ordered from longest suffix to shortest to avoid ambiguous matching
+	// (e.g., "1KB" matching "B" before "KB")
+	units := []struct {
+		suffix     string
+		multiplier int64
+	}{
+		{"TB", 1024 * 1024 * 1024 * 1024},
+		{"GB", 1024 * 1024 * 1024},
+		{"MB", 1024 * 1024},
+		{"KB", 1024},
+		{"B", 1},
+	}
+
+	for _, u := range units {
+		if strings.HasSuffix(sizeStr, u.suffix) {
+			numStr := strings.TrimSuffix(sizeStr, u.suffix)
+			num, err := strconv.ParseFloat(numStr, 64)
+			if err != nil {
+				return 0, err
+			}
+			return int64(num * float64(u.multiplier)), nil
+		}
+	}
+
+	// No unit specified, assume bytes
+	return strconv.ParseInt(sizeStr, 10, 64)
+}
+
+// FormatSize formats a byte count for display, e.g. "512 B" or "1.5 MB";
+// units are binary like ParseSize's
+func FormatSize(sizeBytes int64) string {
+	const unit = 1024
+	if sizeBytes < unit {
+		return fmt.Sprintf("%d B", sizeBytes)
+	}
+	div, exp := int64(unit), 0
+	for n := sizeBytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(sizeBytes)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,46 @@
+package utils
+
+import "testing"
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int64
+	}{
+		{"0", 0},
+		{"100", 100},
+		{"100B", 100},
+		{"1KB", 1024},
+		{"1.5KB", 1536},
+		{"10MB", 10 * 1024 * 1024},
+		{"2GB", 2 * 1024 * 1024 * 1024},
+		{"1TB", 1024 * 1024 * 1024 * 1024},
+		{"1kb", 1024}, // units are case-insensitive
+		{"1Mb", 1024 * 1024},
+		{"0.5gb", 512 * 1024 * 1024},
+		{"inf", 1<<63 - 1},
+		{"INF", 1<<63 - 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseSize(tt.input)
+			if err != nil {
+				t.Fatalf("ParseSize(%q) returned error: %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSize(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSizeInvalid(t *testing.T) {
+	for _, input := range []string{"", "KB", "abc", "1XB", "1.5", "1 KB", "one MB"} {
+		t.Run(input, func(t *testing.T) {
+			if got, err := ParseSize(input); err == nil {
+				t.Errorf("ParseSize(%q) = %d, want an error", input, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// configFileName holds persistent flag defaults. It is read from the user
+// config directory (e.g. ~/.config/find-content/) and then from the search
+// root, so a project file overrides the personal one.
+const configFileName = ".findcontentrc"
+
+// fileConfig is the merged content of the config files found; a nil field was
+// not set by any of them
+type fileConfig struct {
+	extensions    *string
+	excludeDirs   *string
+	excludeFiles  *string
+	caseSensitive *bool
+	maxResults    *int
+	loaded        []string // files read, lowest precedence first
+	unreadable    []error  // files skipped because they could not be opened
+}
+
+// configPaths returns the config files to read for searchRoot, lowest
+// precedence first. searchRoot is empty when no directory is searched.
+func configPaths(searchRoot string) []string {
+	var paths []string
+	if dir, err := os.UserConfigDir(); err == nil {
+		paths = append(paths, filepath.Join(dir, "find-content", configFileName))
+	}
+	if searchRoot != "" {
+		paths = append(paths, filepath.Join(searchRoot, configFileName))
+	}
+	return paths
+}
+
+// loadConfig reads every existing file of configPaths(searchRoot); later
+// files override the keys set by earlier ones. A file that cannot be opened
+// for lack of permission is skipped and recorded in cfg.unreadable, so only a
+// malformed config stops the search.
+func loadConfig(searchRoot string) (fileConfig, error) {
+	var cfg fileConfig
+	for _, path := range configPaths(searchRoot) {
+		if err := cfg.readFile(path); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			if errors.Is(err, fs.ErrPermission) {
+				cfg.unreadable = append(cfg.unreadable, err)
+				continue
+			}
+			return cfg, err
+		}
+		cfg.loaded = append(cfg.loaded, path)
+	}
+	return cfg, nil
+}
+
+// readFile merges one config file into cfg. Each line is "key = value" or the
+// YAML form "key: value"; blank lines and lines starting with # are ignored.
+// List values are comma-separated and may be wrapped in [ ] as in YAML.
+func (cfg *fileConfig) readFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		sep := strings.IndexAny(line, "=:")
+		if sep < 0 {
+			return fmt.Errorf("%s:%d: expected key = value", path, lineNum)
+		}
+		key := strings.TrimSpace(line[:sep])
+		value := unquote(strings.TrimSpace(line[sep+1:]))
+
+		switch key {
+		case "extensions", "exclude_dirs", "exclude_files":
+			list := listValue(value)
+			switch key {
+			case "extensions":
+				cfg.extensions = &list
+			case "exclude_dirs":
+				cfg.excludeDirs = &list
+			default:
+				cfg.excludeFiles = &list
+			}
+		case "case_sensitive":
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("%s:%d: case_sensitive must be true or false, got %q", path, lineNum, value)
+			}
+			cfg.caseSensitive = &b
+		case "max_results":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return fmt.Errorf("%s:%d: max_results must be a non-negative integer, got %q", path, lineNum, value)
+			}
+			cfg.maxResults = &n
+		default:
+			return fmt.Errorf("%s:%d: unknown key %q", path, lineNum, key)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+	return nil
+}
+
+// listValue turns "go, py" or "[go, py]" into the comma-separated form the
+// matching flag takes
+func listValue(value string) string {
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = unquote(strings.TrimSpace(item)); item != "" {
+			items = append(items, item)
+		}
+	}
+	return strings.Join(items, ",")
+}
+
+// unquote strips one pair of matching single or double quotes
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestConfigReadFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, configFileName)
+	writeFile(t, path, `# comment
+extensions: [go, "py"]
+exclude_dirs = vendor, node_modules
+
+case_sensitive: yes-ish
+`)
+	var cfg fileConfig
+	err := cfg.readFile(path)
+	if err == nil || !strings.Contains(err.Error(), ":5: case_sensitive must be true or false") {
+		t.Fatalf("err = %v, want a case_sensitive error on line 5", err)
+	}
+	if cfg.extensions == nil || *cfg.extensions != "go,py" {
+		t.Errorf("extensions = %v, want go,py", cfg.extensions)
+	}
+	if cfg.excludeDirs == nil || *cfg.excludeDirs != "vendor,node_modules" {
+		t.Errorf("exclude_dirs = %v, want vendor,node_modules", cfg.excludeDirs)
+	}
+
+	writeFile(t, path, "colour = always\n")
+	if err := cfg.readFile(path); err == nil || !strings.Contains(err.Error(), `unknown key "colour"`) {
+		t.Errorf("err = %v, want an unknown key error", err)
+	}
+}
+
+func TestLoadConfigRootOverridesHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	homeFile := filepath.Join(home, "find-content", configFileName)
+	writeFile(t, homeFile, "max_results = 10\ncase_sensitive = true\n")
+
+	root := t.TempDir()
+	rootFile := filepath.Join(root, configFileName)
+	writeFile(t, rootFile, "max_results = 5\n")
+
+	cfg, err := loadConfig(root)
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	if cfg.maxResults == nil || *cfg.maxResults != 5 {
+		t.Errorf("max_results = %v, want 5 from the search root", cfg.maxResults)
+	}
+	if cfg.caseSensitive == nil || !*cfg.caseSensitive {
+		t.Errorf("case_sensitive = %v, want true from the home config", cfg.caseSensitive)
+	}
+	if len(cfg.loaded) != 2 || cfg.loaded[0] != homeFile || cfg.loaded[1] != rootFile {
+		t.Errorf("loaded = %v, want [%s %s]", cfg.loaded, homeFile, rootFile)
+	}
+
+	// Without a search directory only the home file applies
+	if cfg, err = loadConfig(""); err != nil || *cfg.maxResults != 10 {
+		t.Errorf("loadConfig(\"\") = %v, %v; want max_results 10", cfg.maxResults, err)
+	}
+}
+
+func TestLoadConfigSkipsUnreadable(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("needs files the current user cannot read")
+	}
+	// A config directory that cannot be entered, as with an inaccessible HOME
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	homeDir := filepath.Join(home, "find-content")
+	if err := os.Mkdir(homeDir, 0); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(homeDir, 0o755) })
+
+	root := t.TempDir()
+	rootFile := filepath.Join(root, configFileName)
+	writeFile(t, rootFile, "max_results = 5\n")
+	if err := os.Chmod(rootFile, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := loadConfig(root)
+	if err != nil {
+		t.Fatalf("loadConfig: %v, want unreadable files skipped", err)
+	}
+	if len(cfg.loaded) != 0 || cfg.maxResults != nil {
+		t.Errorf("loaded = %v, max_results = %v; want nothing read", cfg.loaded, cfg.maxResults)
+	}
+	if len(cfg.unreadable) != 2 {
+		t.Errorf("unreadable = %v, want both files", cfg.unreadable)
+	}
+
+	// A malformed file is still an error
+	os.Chmod(rootFile, 0o644)
+	writeFile(t, rootFile, "max_results = many\n")
+	if _, err := loadConfig(root); err == nil {
+		t.Error("loadConfig accepted a malformed config")
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxDedupeLines bounds the distinct lines --dedupe holds until the end of the
+// search; new lines found after that are printed as usual
+const maxDedupeLines = 10000
+
+// dedupeGroup is one distinct (trimmed) matched line and where it was seen
+type dedupeGroup struct {
+	first       matchResult // first occurrence, content trimmed
+	firstPath   string
+	lastPath    string // for counting files: a file's matches arrive together
+	files       int
+	occurrences int
+}
+
+// dedupeSet collects the matches of a --dedupe search in first-seen order.
+// It is not safe for concurrent use; grepRecursive calls it under its lock.
+type dedupeSet struct {
+	groups   []*dedupeGroup
+	index    map[string]*dedupeGroup
+	overflow int // new lines printed ungrouped once maxDedupeLines was reached
+}
+
+func newDedupeSet() *dedupeSet {
+	return &dedupeSet{index: make(map[string]*dedupeGroup)}
+}
+
+// add records a match found in path. It returns false when the line is new
+// but the set is full, so the caller prints it right away.
+func (d *dedupeSet) add(path string, match matchResult) bool {
+	key := strings.TrimSpace(match.content)
+	g := d.index[key]
+	if g == nil {
+		if len(d.groups) >= maxDedupeLines {
+			d.overflow++
+			return false
+		}
+		match.content = key
+		match.truncated = false
+		g = &dedupeGroup{first: match, firstPath: path}
+		d.index[key] = g
+		d.groups = append(d.groups, g)
+	}
+	if g.lastPath != path {
+		g.files++
+		g.lastPath = path
+	}
+	g.occurrences++
+	return true
+}
+
+// write prints every distinct line once, followed by where it was seen:
+//
+//	return nil, err
+//	  … seen 12 times in 9 files (first: pkg/a.go:40)
+func (d *dedupeSet) write(out *bufio.Writer, matcher *searchMatcher, opts searchOptions) {
+	lineOpts := opts
+	lineOpts.showFilePath, lineOpts.showLineNumbers, lineOpts.column, lineOpts.group = false, false, false, false
+	for _, g := range d.groups {
+		writeTextMatch(out, "", g.first, matcher, lineOpts)
+
+		first := strconv.Itoa(g.first.lineNum)
+		if opts.showFilePath {
+			first = g.firstPath + ":" + first
+		} else {
+			first = "line " + first
+		}
+		out.WriteString("  … seen ")
+		if g.occurrences != g.files {
+			fmt.Fprintf(out, "%d times ", g.occurrences)
+		}
+		fmt.Fprintf(out, "in %d %s (first: %s)\n", g.files, plural(g.files, "file", "files"), first)
+	}
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
@@ -1,10 +1,18 @@
 module find-content
 
-go 1.24.4
+go 1.25.0
 
-require github.com/spf13/cobra v1.10.2
+require (
+	common-module v0.0.0
+	github.com/fsnotify/fsnotify v1.9.0
+	github.com/spf13/cobra v1.10.2
+)
 
 require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.10 // indirect
+	golang.org/x/sys v0.46.0 // indirect
+	golang.org/x/term v0.44.0 // indirect
 )
+
+replace common-module => ../common-module
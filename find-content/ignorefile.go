@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreFileName is the per-project exclude list read from the search root
+const ignoreFileName = ".findcontentignore"
+
+// ignoreRules are the entries of one root's ignore file. They are applied on
+// top of the command-line filters, and only while walking that root.
+type ignoreRules struct {
+	extensions map[string]bool // "!" entries
+	dirs       map[string]bool
+	files      map[string]bool
+	globs      []string
+}
+
+// skipDir reports whether the directory name is excluded; nil rules exclude nothing
+func (r *ignoreRules) skipDir(name string) bool {
+	return r != nil && r.dirs[name]
+}
+
+// skipFile reports whether the file name is excluded by name or glob
+func (r *ignoreRules) skipFile(name string) bool {
+	return r != nil && (r.files[name] || matchesAnyGlob(r.globs, name))
+}
+
+// loadIgnoreFile reads root/.findcontentignore, if it exists, into the rules
+// of root; other roots are not affected by it. Each line is one of:
+//
+//	# comment
+//	!py         extension to search (like --extensions)
+//	*.min.js    glob matched against file names (like --exclude)
+//	dist/       directory name to skip
+//	secrets     name skipped both as a directory and as a file
+//
+// Flags given on the command line take precedence: directory entries are
+// dropped when --exclude-dirs was set, and "!" entries when --extensions was.
+func (fs *FileSearcher) loadIgnoreFile(root string, cliExcludeDirs, cliExtensions bool) error {
+	path := filepath.Join(root, ignoreFileName)
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	rules := &ignoreRules{extensions: map[string]bool{}, dirs: map[string]bool{}, files: map[string]bool{}}
+	scanner := bufio.NewScanner(f)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "!"):
+			if cliExtensions {
+				continue
+			}
+			ext := strings.ToLower(strings.TrimSpace(line[1:]))
+			if !strings.HasPrefix(ext, ".") {
+				ext = "." + ext
+			}
+			rules.extensions[ext] = true
+		case strings.ContainsAny(line, "*?["):
+			if _, err := filepath.Match(line, ""); err != nil {
+				return fmt.Errorf("%s:%d: invalid glob %q: %w", path, lineNum, line, err)
+			}
+			rules.globs = append(rules.globs, line)
+		case strings.HasSuffix(line, "/"):
+			if !cliExcludeDirs {
+				rules.dirs[strings.TrimRight(line, "/")] = true
+			}
+		default:
+			if !cliExcludeDirs {
+				rules.dirs[line] = true
+			}
+			rules.files[line] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if fs.ignoreFiles == nil {
+		fs.ignoreFiles = make(map[string]*ignoreRules)
+	}
+	fs.ignoreFiles[root] = rules
+	return nil
+}
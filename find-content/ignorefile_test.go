@@ -0,0 +1,113 @@
+package main
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestLoadIgnoreFile(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ignoreFileName), "# project excludes\n!py\n!.TXT\nvendor/\nsecrets\n*.min.js\n\n")
+	for _, rel := range []string{"a.py", "b.txt", "c.go", "d.min.js", "vendor/v.py", "secrets", "sub/secrets/s.py", "sub/e.py"} {
+		writeFile(t, filepath.Join(root, filepath.FromSlash(rel)), "needle\n")
+	}
+
+	tests := []struct {
+		name           string
+		cliExcludeDirs bool
+		cliExtensions  bool
+		want           []string
+	}{
+		{
+			name: "file rules",
+			want: []string{"a.py", "b.txt", "sub/e.py"},
+		},
+		{
+			name:           "--exclude-dirs wins over directory entries",
+			cliExcludeDirs: true,
+			want:           []string{"a.py", "b.txt", "sub/e.py", "sub/secrets/s.py", "vendor/v.py"},
+		},
+		{
+			name:          "--extensions wins over ! entries",
+			cliExtensions: true,
+			want:          []string{"a.py", "b.txt", "c.go", "sub/e.py"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := NewFileSearcher(false, true, false, nil, nil, nil)
+			if err := fs.loadIgnoreFile(root, tt.cliExcludeDirs, tt.cliExtensions); err != nil {
+				t.Fatalf("loadIgnoreFile returned error: %v", err)
+			}
+			output, _ := runGrep(t, fs, root, "needle", searchOptions{count: true, showFilePath: true})
+
+			var got []string
+			for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+				if path, _, ok := strings.Cut(line, ":"); ok && !strings.HasPrefix(line, "Total") {
+					rel, _ := filepath.Rel(root, path)
+					got = append(got, filepath.ToSlash(rel))
+				}
+			}
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("searched %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadIgnoreFilePerRoot(t *testing.T) {
+	base := t.TempDir()
+	a, b := filepath.Join(base, "a"), filepath.Join(base, "b")
+	writeFile(t, filepath.Join(a, ignoreFileName), "!py\nsecrets\n*.min.js\n")
+	for _, root := range []string{a, b} {
+		for _, rel := range []string{"x.py", "x.txt", "x.min.js", "secrets/s.txt"} {
+			writeFile(t, filepath.Join(root, filepath.FromSlash(rel)), "needle\n")
+		}
+	}
+
+	fs := NewFileSearcher(false, true, false, nil, nil, nil)
+	for _, root := range []string{a, b} {
+		if err := fs.loadIgnoreFile(root, false, false); err != nil {
+			t.Fatalf("loadIgnoreFile(%s) returned error: %v", root, err)
+		}
+	}
+	var err error
+	output := captureStdout(t, func() {
+		_, err = fs.grepRecursive([]string{a, b}, []string{"needle"}, searchOptions{count: true, showFilePath: true})
+	})
+	if err != nil {
+		t.Fatalf("grepRecursive returned error: %v", err)
+	}
+
+	var got []string
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if path, _, ok := strings.Cut(line, ":"); ok && !strings.HasPrefix(line, "Total") {
+			rel, _ := filepath.Rel(base, path)
+			got = append(got, filepath.ToSlash(rel))
+		}
+	}
+	sort.Strings(got)
+	// a's entries leave b searched with the default filters
+	want := []string{"a/x.py", "b/secrets/s.txt", "b/x.min.js", "b/x.py", "b/x.txt"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("searched %v, want %v", got, want)
+	}
+}
+
+func TestLoadIgnoreFileErrors(t *testing.T) {
+	fs := NewFileSearcher(false, true, false, nil, nil, nil)
+	if err := fs.loadIgnoreFile(t.TempDir(), false, false); err != nil {
+		t.Fatalf("missing file should be ignored, got %v", err)
+	}
+
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ignoreFileName), "ok\n[unclosed\n")
+	err := fs.loadIgnoreFile(root, false, false)
+	if err == nil || !strings.Contains(err.Error(), ":2: invalid glob") {
+		t.Fatalf("err = %v, want invalid glob error on line 2", err)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"math/bits"
+	"sync/atomic"
+)
+
+// maxKeywordStats is the most keywords whose hits fit in a match's bitmask
+const maxKeywordStats = 64
+
+// keywordStat counts the reported matches of one keyword of a multi-pattern
+// search and the files they were found in. Workers update it concurrently.
+type keywordStat struct {
+	keyword string
+	matcher *searchMatcher
+	matches atomic.Int64
+	files   atomic.Int64
+}
+
+// jsonKeywordStat is a keywordStat in the --json summary and --output json document
+type jsonKeywordStat struct {
+	Keyword string `json:"keyword"`
+	Matches int64  `json:"matches"`
+	Files   int64  `json:"files"`
+}
+
+// newKeywordStats returns one zeroed counter per keyword of matcher
+func newKeywordStats(matcher *searchMatcher) []*keywordStat {
+	stats := []*keywordStat{{keyword: matcher.keyword, matcher: matcher}}
+	for _, p := range matcher.extra {
+		stats = append(stats, &keywordStat{keyword: p.keyword, matcher: p})
+	}
+	return stats
+}
+
+// keywordHits returns, for each match, a bitmask of the keywords found in its
+// content (bit i for stats[i]); context lines get 0
+func keywordHits(stats []*keywordStat, matches []matchResult, multiline bool) []uint64 {
+	hits := make([]uint64, len(matches))
+	for i, match := range matches {
+		if match.isContext {
+			continue
+		}
+		for k, stat := range stats {
+			var found bool
+			if multiline {
+				found = len(stat.matcher.multilineSpans(match.content)) > 0
+			} else {
+				found = stat.matcher.matchKeyword(match.content)
+			}
+			if found {
+				hits[i] |= 1 << k
+			}
+		}
+	}
+	return hits
+}
+
+// recordKeywordHits counts the reported matches of one file from their keyword bitmasks
+func recordKeywordHits(stats []*keywordStat, reported []uint64) {
+	var fileMask uint64
+	for _, mask := range reported {
+		fileMask |= mask
+		for m := mask; m != 0; m &= m - 1 {
+			stats[bits.TrailingZeros64(m)].matches.Add(1)
+		}
+	}
+	for m := fileMask; m != 0; m &= m - 1 {
+		stats[bits.TrailingZeros64(m)].files.Add(1)
+	}
+}
+
+// jsonKeywordStats converts stats for the JSON outputs
+func jsonKeywordStats(stats []*keywordStat) []jsonKeywordStat {
+	var out []jsonKeywordStat
+	for _, stat := range stats {
+		out = append(out, jsonKeywordStat{Keyword: stat.keyword, Matches: stat.matches.Load(), Files: stat.files.Load()})
+	}
+	return out
+}
+
+// writeKeywordTable prints the per-keyword breakdown, keywords without hits
+// included so a misspelled one stands out
+func writeKeywordTable(out *bufio.Writer, stats []*keywordStat) {
+	width := len("Keyword")
+	for _, stat := range stats {
+		width = max(width, len(stat.keyword))
+	}
+	fmt.Fprintf(out, "\n%-*s  %7s  %5s\n", width, "Keyword", "Matches", "Files")
+	for _, stat := range stats {
+		fmt.Fprintf(out, "%-*s  %7d  %5d\n", width, stat.keyword, stat.matches.Load(), stat.files.Load())
+	}
+}
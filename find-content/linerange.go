@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// lineRange is a 1-based inclusive range of line numbers (--line-range)
+type lineRange struct {
+	start, end int
+}
+
+// parseLineRanges parses --line-range values such as "1-50" or "120"
+// (a single line)
+func parseLineRanges(values []string) ([]lineRange, error) {
+	var ranges []lineRange
+	for _, value := range values {
+		startStr, endStr, isRange := strings.Cut(strings.TrimSpace(value), "-")
+		if !isRange {
+			endStr = startStr
+		}
+		start, err1 := strconv.Atoi(startStr)
+		end, err2 := strconv.Atoi(endStr)
+		if err1 != nil || err2 != nil || start < 1 {
+			return nil, fmt.Errorf("invalid --line-range %q: expected START-END with 1-based line numbers, e.g. 1-50", value)
+		}
+		if start > end {
+			return nil, fmt.Errorf("invalid --line-range %q: start is after end", value)
+		}
+		ranges = append(ranges, lineRange{start, end})
+	}
+	return ranges, nil
+}
+
+// inLineRanges reports whether line lies in one of ranges
+func inLineRanges(ranges []lineRange, line int) bool {
+	for _, r := range ranges {
+		if line >= r.start && line <= r.end {
+			return true
+		}
+	}
+	return false
+}
+
+// lastRangeLine returns the last line covered by ranges, or 0 when there are none
+func lastRangeLine(ranges []lineRange) int {
+	last := 0
+	for _, r := range ranges {
+		last = max(last, r.end)
+	}
+	return last
+}
@@ -1,14 +1,36 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"math"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
+
+	"common-module/terminal"
+	"common-module/utils"
 
 	"github.com/spf13/cobra"
 )
 
 func main() {
+	// Writing to a closed pipe then fails with EPIPE, which the search handles,
+	// instead of killing the process
+	signal.Ignore(syscall.SIGPIPE)
+	if err := newRootCmd().Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// newRootCmd builds the find-content command; flag state lives in its closures
+// so every call returns an independent command (tests rely on this)
+func newRootCmd() *cobra.Command {
 	var (
 		useRegex         bool
 		caseSensitive    bool
@@ -19,29 +41,286 @@ func main() {
 		noLineNumbers    bool
 		noFilePath       bool
 		maxResults       int
+		maxPerFile       int
+		maxPerDir        int
+		sortOutput       bool
 		listMode         bool
+		tree             bool
+		dirsOnly         bool
 		showHidden       bool
 		suppressWarnings bool
 		searchAll        bool
+		jsonOutput       bool
+		outputFormat     string
+		noColor          bool
+		invertMatch      bool
+		wholeWord        bool
+		contextLines     int
+		beforeLines      int
+		afterLines       int
+		includeGlobs     []string
+		excludeGlobs     []string
+		respectGitignore bool
+		label            string
+		onlyMatching     bool
+		matchMode        string
+		countOnly        bool
+		includeZeros     bool
+		maxFileSize      string
+		maxLineLength    string
+		maxFileBytes     int64
+		maxLineBytes     int64
+		noIgnoreFile     bool
+		groupByFile      bool
+		fuzzy            bool
+		fuzzyDistance    int
+		showColumn       bool
+		showScope        bool
+		dedupe           bool
+		watch            bool
+		debounce         time.Duration
+		verbose          bool
+		maxDepth         int
+		followSymlinks   bool
+		noSniff          bool
+		readMode         string
+		hidden           bool
+		noDefaults       bool
+		outputFile       string
+		tee              bool
+		noProgress       bool
+		noConfig         bool
+		first            bool
+		lineRangeArgs    []string
+		fileTimeout      time.Duration
+		lineRanges       []lineRange
+		paths            []string
+		keywords         []string
 	)
 
 	rootCmd := &cobra.Command{
-		Use:   "find-content [directory] [keyword]",
+		Use:   "find-content path keyword... | path... -- keyword...",
 		Short: "Improved file content search utility",
 		Long: `A powerful file content search utility that supports recursive search with various options.
 
 Examples:
   find-content /path/to/search "keyword"
+  find-content ./config.yaml "port"
+  find-content /path/to/search "TODO" "FIXME"
+  find-content src tests main.go -- "TODO"
   find-content /path/to/search "pattern" --regex
   find-content /path/to/search "text" --extensions py,js,txt
   find-content /path/to/search "version" --case-sensitive
   find-content /path/to/search "error" --exclude-dirs node_modules,.git
-  find-content /path/to/search "line1\nline2\nline3" --multiline`,
-		Args: cobra.ExactArgs(2),
+  find-content /path/to/search "line1\nline2\nline3" --multiline
+  find-content /path/to/search "TODO" --json | jq .path
+  find-content /path/to/search "TODO" --output json | jq '.matches[].path'
+  find-content /path/to/logs "DEBUG" --invert-match
+  find-content /path/to/search "err" --word
+  find-content /path/to/search "recieve" --fuzzy --fuzzy-distance 1
+  find-content /path/to/search "panic" --context 3
+  find-content /path/to/logs "ERROR" "FATAL" "panic"
+  find-content /path/to/logs "user_id" "failed" --match-mode all
+  find-content /path/to/search "TODO" --count
+  find-content /path/to/search "TODO" --group
+  find-content /path/to/src "db.Query" --show-scope
+  find-content /path/to/search "Code generated" --dedupe
+  find-content /path/to/project --tree --max-depth 2
+  find-content /var/log/myapp "ERROR" --watch
+  find-content /var/log "timeout" --all --max-file-size 100MB
+  find-content /path/to/logs "[\w.]+@[\w.]+" --regex --only-matching
+  find-content /path/to/search "[0-9a-f]{8}-[0-9a-f]{4}" --regex -o --column --json
+  find-content /path/to/repo "TODO" --respect-gitignore --include '*.go' --exclude '*_test.go'
+  kubectl logs my-pod | find-content - "error" --label my-pod`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			// Listing takes no keyword
+			if listMode || tree {
+				return cobra.MinimumNArgs(1)(cmd, args)
+			}
+			return cobra.MinimumNArgs(2)(cmd, args)
+		},
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			if tree {
+				listMode = true
+			}
+			if dirsOnly && !listMode {
+				return fmt.Errorf("--dirs-only only applies to --list and --tree")
+			}
+			if listMode {
+				// A keyword after the path is accepted and ignored, as it always
+				// was; several directories are listed when "--" ends them
+				if dash := cmd.ArgsLenAtDash(); dash > 0 {
+					paths = args[:dash]
+				} else {
+					paths = args[:1]
+				}
+			} else if paths, keywords, err = splitArgs(args, cmd.ArgsLenAtDash()); err != nil {
+				return err
+			}
+			if !noConfig {
+				cfg, err := loadConfig(configRoot(paths))
+				if err != nil {
+					return err
+				}
+				if !suppressWarnings {
+					for _, err := range cfg.unreadable {
+						fmt.Fprintf(os.Stderr, "Warning: Skipping config file: %v\n", err)
+					}
+				}
+				if verbose {
+					for _, path := range cfg.loaded {
+						fmt.Fprintf(os.Stderr, "Loaded config from %s\n", path)
+					}
+				}
+				// Flags given on the command line win over the config files
+				flags := cmd.Flags()
+				if cfg.extensions != nil && !flags.Changed("extensions") {
+					extensions = *cfg.extensions
+				}
+				if cfg.excludeDirs != nil && !flags.Changed("exclude-dirs") {
+					excludeDirs = *cfg.excludeDirs
+				}
+				if cfg.excludeFiles != nil && !flags.Changed("exclude-files") {
+					excludeFiles = *cfg.excludeFiles
+				}
+				if cfg.caseSensitive != nil && !flags.Changed("case-sensitive") {
+					caseSensitive = *cfg.caseSensitive
+				}
+				if cfg.maxResults != nil && !flags.Changed("max-results") {
+					maxResults = *cfg.maxResults
+				}
+			}
+			if invertMatch && multiline {
+				return fmt.Errorf("--invert-match cannot be combined with --multiline: an inverted multiline match has no well-defined line range")
+			}
+			if fuzzy && useRegex {
+				return fmt.Errorf("--fuzzy cannot be combined with --regex")
+			}
+			if fuzzy && multiline {
+				return fmt.Errorf("--fuzzy compares single tokens and cannot be combined with --multiline")
+			}
+			if tee && outputFile == "" {
+				return fmt.Errorf("--tee requires --output-file")
+			}
+			if outputFile != "" && (watch || listMode) {
+				return fmt.Errorf("--output-file cannot be combined with --watch or --list")
+			}
+			if maxDepth < -1 {
+				return fmt.Errorf("--max-depth must be -1 (unlimited) or greater")
+			}
+			if fileTimeout < 0 {
+				return fmt.Errorf("--file-timeout must not be negative")
+			}
+			if maxPerFile < 0 {
+				return fmt.Errorf("--max-per-file must not be negative")
+			}
+			if lineRanges, err = parseLineRanges(lineRangeArgs); err != nil {
+				return err
+			}
+			if lineRanges != nil && multiline {
+				return fmt.Errorf("--line-range is not supported with --multiline")
+			}
+			if maxPerDir < 0 {
+				return fmt.Errorf("--max-per-dir must not be negative")
+			}
+			if fuzzyDistance < 0 {
+				return fmt.Errorf("--fuzzy-distance must not be negative")
+			}
+			if outputFormat != "text" && outputFormat != "json" {
+				return fmt.Errorf("unsupported output format: %s (supported: text, json)", outputFormat)
+			}
+			if readMode != readModeAuto && readMode != readModeScan && readMode != readModeFull {
+				return fmt.Errorf("unsupported read mode: %s (supported: auto, scan, full)", readMode)
+			}
+			if matchMode != "any" && matchMode != "all" {
+				return fmt.Errorf("unsupported match mode: %s (supported: any, all)", matchMode)
+			}
+			if matchMode == "all" && multiline {
+				return fmt.Errorf("--match-mode all is not supported with --multiline")
+			}
+			if contextLines < 0 || beforeLines < 0 || afterLines < 0 {
+				return fmt.Errorf("--context, --before and --after must not be negative")
+			}
+			// --context is shorthand for both; explicit --before/--after win
+			if !cmd.Flags().Changed("before") {
+				beforeLines = contextLines
+			}
+			if !cmd.Flags().Changed("after") {
+				afterLines = contextLines
+			}
+			if showScope && multiline {
+				return fmt.Errorf("--show-scope is not supported with --multiline")
+			}
+			if multiline && (beforeLines > 0 || afterLines > 0) {
+				return fmt.Errorf("--context, --before and --after are not supported with --multiline")
+			}
+			if onlyMatching && invertMatch {
+				return fmt.Errorf("--only-matching cannot be combined with --invert-match: non-matching lines have no matched part")
+			}
+			if onlyMatching && (beforeLines > 0 || afterLines > 0) {
+				return fmt.Errorf("--context, --before and --after are not supported with --only-matching")
+			}
+			if countOnly && onlyMatching {
+				return fmt.Errorf("--count cannot be combined with --only-matching")
+			}
+			if countOnly && (beforeLines > 0 || afterLines > 0) {
+				return fmt.Errorf("--context, --before and --after are not supported with --count")
+			}
+			if countOnly && (jsonOutput || outputFormat == "json") {
+				return fmt.Errorf("--count is a text summary and cannot be combined with --json or --output json")
+			}
+			if groupByFile && (countOnly || jsonOutput || outputFormat == "json") {
+				return fmt.Errorf("--group only applies to the plain text output; it cannot be combined with --count, --json or --output json")
+			}
+			if dedupe && (countOnly || jsonOutput || outputFormat == "json" || groupByFile || watch || beforeLines > 0 || afterLines > 0) {
+				return fmt.Errorf("--dedupe cannot be combined with --count, --json, --output json, --group, --watch or context lines")
+			}
+			if watch {
+				if len(paths) != 1 || paths[0] == stdinPath {
+					return fmt.Errorf("--watch needs a single directory, not stdin or several paths")
+				}
+				if countOnly || outputFormat == "json" || beforeLines > 0 || afterLines > 0 {
+					return fmt.Errorf("--watch prints new matches as they appear; it cannot be combined with --count, --output json or context lines")
+				}
+				if debounce <= 0 {
+					return fmt.Errorf("--debounce must be positive")
+				}
+			}
+			if first && (countOnly || sortOutput || watch) {
+				return fmt.Errorf("--first stops at the first match found; it cannot be combined with --count, --sort or --watch")
+			}
+			if first {
+				// Only the match itself is printed, so it can be used as is
+				maxResults = 1
+				suppressWarnings = true
+			}
+			if maxPerDir > 0 && (countOnly || beforeLines > 0 || afterLines > 0) {
+				return fmt.Errorf("--max-per-dir cannot be combined with --count or context lines")
+			}
+			if includeZeros && !countOnly {
+				return fmt.Errorf("--include-zeros requires --count")
+			}
+			if maxFileSize != "" {
+				size, err := utils.ParseSize(maxFileSize)
+				if err != nil || size <= 0 {
+					return fmt.Errorf("invalid --max-file-size %q: expected a positive size such as 10MB", maxFileSize)
+				}
+				maxFileBytes = size
+			}
+			size, err := utils.ParseSize(maxLineLength)
+			if err != nil || size <= 0 || size > math.MaxInt32 {
+				return fmt.Errorf("invalid --max-line-length %q: expected a positive size such as 1MB", maxLineLength)
+			}
+			maxLineBytes = size
+			for _, glob := range append(append([]string{}, includeGlobs...), excludeGlobs...) {
+				if _, err := filepath.Match(glob, ""); err != nil {
+					return fmt.Errorf("invalid glob %q: %w", glob, err)
+				}
+			}
+			return nil
+		},
 		Run: func(cmd *cobra.Command, args []string) {
-			directory := args[0]
-			keyword := args[1]
-
 			// Parse comma-separated arguments
 			var fileExtensions, excludeDirsList, excludeFilesList []string
 			if extensions != "" {
@@ -55,31 +334,164 @@ Examples:
 			}
 
 			searcher := NewFileSearcher(caseSensitive, suppressWarnings, searchAll, fileExtensions, excludeDirsList, excludeFilesList)
+			searcher.setDirDefaults(hidden, !noDefaults, excludeDirsList)
+			searcher.setPathFilters(includeGlobs, excludeGlobs, respectGitignore)
+			searcher.setLimits(maxFileBytes, int(maxLineBytes))
+			searcher.verbose = verbose
+			searcher.setWalkOptions(maxDepth, followSymlinks)
+			searcher.noSniff = noSniff
+			searcher.readMode = readMode
+			searcher.fileTimeout = fileTimeout
+			// The watch loop reports each run itself
+			// The progress line is redrawn with escape sequences, though not colored
+			searcher.showProgress = !noProgress && !watch && !first && isTerminal(os.Stderr) && terminal.EnableVirtualTerminal(os.Stderr)
+			if !noIgnoreFile && !listMode {
+				// Each directory searched contributes its own ignore file
+				for _, path := range paths {
+					if info, err := os.Stat(path); err != nil || !info.IsDir() {
+						continue
+					}
+					if err := searcher.loadIgnoreFile(path, cmd.Flags().Changed("exclude-dirs"), cmd.Flags().Changed("extensions")); err != nil {
+						fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+						os.Exit(1)
+					}
+				}
+			}
+			if verbose && !listMode {
+				searcher.writeExcludes(os.Stderr)
+			}
 
 			if listMode {
-				if err := searcher.listDirectoryContents(directory, showHidden); err != nil {
-					os.Exit(1)
+				for _, path := range paths {
+					if err := searcher.listDirectoryContents(path, listOptions{showHidden: showHidden, tree: tree, dirsOnly: dirsOnly}); err != nil {
+						os.Exit(1)
+					}
 				}
 			} else {
-				var maxResultsPtr *int
-				if maxResults > 0 {
-					maxResultsPtr = &maxResults
-				}
-
-				matches := searcher.grepRecursive(
-					directory,
-					keyword,
-					useRegex,
-					multiline,
-					!noLineNumbers,
-					!noFilePath,
-					maxResultsPtr,
-				)
-
-				if matches == 0 {
-					fmt.Println("No matches found")
-				} else {
-					fmt.Printf("\nFound %d match(es)\n", matches)
+				if onlyMatching && !useRegex && !suppressWarnings {
+					fmt.Fprintln(os.Stderr, "Warning: --only-matching without --regex prints the keyword itself for every match")
+				}
+
+				structured := jsonOutput || outputFormat == "json"
+				// A report file gets plain, untruncated lines even with --tee
+				styled := !structured && outputFile == "" && isTerminal(os.Stdout)
+				displayWidth := 0
+				if styled {
+					displayWidth = terminal.Width(os.Stdout)
+				}
+				opts := searchOptions{
+					useRegex:        useRegex,
+					multiline:       multiline,
+					showLineNumbers: !noLineNumbers,
+					showFilePath:    !noFilePath,
+					maxResults:      maxResults,
+					maxPerFile:      maxPerFile,
+					maxPerDir:       maxPerDir,
+					lineRanges:      lineRanges,
+					sorted:          sortOutput,
+					jsonOutput:      jsonOutput,
+					jsonDocument:    outputFormat == "json",
+					color:           styled && canColor(os.Stdout, noColor),
+					invertMatch:     invertMatch,
+					wholeWord:       wholeWord,
+					before:          beforeLines,
+					after:           afterLines,
+					label:           label,
+					onlyMatching:    onlyMatching,
+					matchAll:        matchMode == "all",
+					count:           countOnly,
+					includeZeros:    includeZeros,
+					displayWidth:    displayWidth,
+					group:           groupByFile,
+					fuzzy:           fuzzy,
+					fuzzyDistance:   fuzzyDistance,
+					column:          showColumn,
+					showScope:       showScope,
+					dedupe:          dedupe,
+					first:           first,
+					// Inverted matches contain no keyword to count
+					keywordStats: len(keywords) > 1 && !invertMatch && !countOnly && !first,
+				}
+
+				if watch {
+					ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+					defer stop()
+
+					started := false
+					err := searcher.watch(ctx, paths[0], keywords, opts, debounce, func(n int) {
+						if !started {
+							started = true
+							fmt.Fprintf(os.Stderr, "Found %d match(es); watching %s for changes (Ctrl+C to stop)\n", n, paths[0])
+						} else if n > 0 {
+							fmt.Fprintf(os.Stderr, "[%s] %d new match(es)\n", time.Now().Format("15:04:05"), n)
+						}
+					})
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+						os.Exit(1)
+					}
+					return
+				}
+
+				var report *atomicFile
+				if outputFile != "" {
+					var err error
+					if report, err = createAtomic(outputFile); err != nil {
+						fmt.Fprintf(os.Stderr, "Error: creating output file: %v\n", err)
+						os.Exit(1)
+					}
+					searcher.out = report
+					if tee {
+						searcher.out = io.MultiWriter(report, os.Stdout)
+					}
+				}
+
+				matches, err := searcher.grepRecursive(paths, keywords, opts)
+				if err != nil {
+					if report != nil {
+						report.abort()
+					}
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+				if report != nil {
+					if err := report.commit(); err != nil {
+						fmt.Fprintf(os.Stderr, "Error: writing %s: %v\n", outputFile, err)
+						os.Exit(1)
+					}
+				}
+				if searcher.brokenPipe.Load() {
+					// The reader (head, a pager) has all it wanted
+					return
+				}
+
+				if first {
+					// Like grep -q: the exit status tells whether anything matched
+					if matches == 0 {
+						os.Exit(1)
+					}
+					return
+				}
+
+				// The summary goes to stderr, so stdout holds only results and a
+				// pipe reader never sees it mixed in with them
+				summaryOut := os.Stderr
+				// --count already ended with its own "Total:" line
+				if !countOnly {
+					if matches == 0 {
+						fmt.Fprintln(summaryOut, "No matches found")
+					} else {
+						fmt.Fprintf(summaryOut, "\nFound %d match(es) in %d file(s) scanned\n", matches, searcher.filesScanned)
+					}
+				}
+				if skipped := searcher.skippedForSize.Load(); skipped > 0 {
+					fmt.Fprintf(summaryOut, "Skipped %d file(s) larger than %s\n", skipped, maxFileSize)
+				}
+				if skipped := searcher.skippedForDepth.Load(); skipped > 0 {
+					fmt.Fprintf(summaryOut, "Skipped %d dir(s) below --max-depth %d\n", skipped, maxDepth)
+				}
+				if timedOut := searcher.timedOut.Load(); timedOut > 0 {
+					fmt.Fprintf(summaryOut, "Gave up on %d file(s) after --file-timeout %s\n", timedOut, fileTimeout)
 				}
 			}
 		},
@@ -95,13 +507,104 @@ Examples:
 	rootCmd.Flags().BoolVar(&noLineNumbers, "no-line-numbers", false, "Hide line numbers in output")
 	rootCmd.Flags().BoolVar(&noFilePath, "no-file-path", false, "Hide file paths in output")
 	rootCmd.Flags().IntVarP(&maxResults, "max-results", "m", 0, "Maximum number of results to show")
+	rootCmd.Flags().BoolVar(&first, "first", false, "Print the first match found and stop at once; implies --quiet-warnings, no summary, exit status 1 when nothing matches")
+	rootCmd.Flags().IntVar(&maxPerFile, "max-per-file", 0, "Stop reading a file after N matches (0 = unlimited)")
+	rootCmd.Flags().StringSliceVar(&lineRangeArgs, "line-range", nil, "Only match lines in this 1-based inclusive range, e.g. 1-50 (repeatable)")
+	rootCmd.Flags().IntVar(&maxPerDir, "max-per-dir", 0, "Report at most N matches under each immediate subdirectory of the search path, then one suppression notice (0 = unlimited)")
+	rootCmd.Flags().BoolVar(&sortOutput, "sort", false, "Print results ordered by path and line once the search completes (buffers all matches)")
 	rootCmd.Flags().BoolVarP(&listMode, "list", "l", false, "List directory contents instead of searching")
+	rootCmd.Flags().BoolVar(&tree, "tree", false, "List the whole tree below the path (implies --list; honors --exclude-dirs and --max-depth)")
+	rootCmd.Flags().BoolVar(&dirsOnly, "dirs-only", false, "With --list or --tree, show directories only")
 	rootCmd.Flags().BoolVar(&showHidden, "show-hidden", false, "Show hidden files when listing")
 	rootCmd.Flags().BoolVar(&suppressWarnings, "suppress-warnings", false, "Suppress warning messages")
+	rootCmd.Flags().BoolVar(&suppressWarnings, "quiet-warnings", false, "Alias for --suppress-warnings")
+	rootCmd.Flags().BoolVar(&noProgress, "no-progress", false, "Don't show the progress line on stderr (it is also hidden when stderr is not a terminal)")
+	rootCmd.Flags().BoolVar(&verbose, "verbose", false, "Report the config files loaded, the excluded directories, each file skipped by --max-file-size and each symlink loop on stderr")
+	rootCmd.Flags().IntVar(&maxDepth, "max-depth", -1, "Maximum directory depth to search (0 = only files directly in the directory, -1 = unlimited)")
+	rootCmd.Flags().BoolVar(&followSymlinks, "follow-symlinks", false, "Descend into symlinked directories (loops are detected and skipped)")
+	rootCmd.Flags().BoolVar(&hidden, "hidden", false, "Also search directories whose name starts with a dot, such as .github or .config (and .vscode/.idea, excluded by default)")
+	rootCmd.Flags().BoolVar(&noDefaults, "no-default-excludes", false, "Do not skip the default directories (.git, node_modules, build, dist, ...); --exclude-dirs still applies")
+	rootCmd.Flags().BoolVar(&noSniff, "no-sniff", false, "Only search known text extensions: skip well-known names like Dockerfile and shebang scripts")
 	rootCmd.Flags().BoolVar(&searchAll, "all", false, "Search in all files (not limited by extension)")
+	rootCmd.Flags().BoolVar(&noColor, "no-color", false, "Disable colored match highlighting (also off when NO_COLOR is set or stdout is not a terminal)")
+	rootCmd.Flags().BoolVar(&jsonOutput, "json", false, "Output matches as JSON lines (one object per match plus a summary)")
+	rootCmd.Flags().StringVar(&outputFormat, "output", "text", "Output format: text or json (a single JSON document written when the search ends)")
+	rootCmd.MarkFlagsMutuallyExclusive("json", "output")
+	rootCmd.Flags().StringVar(&outputFile, "output-file", "", "Write the results to this file (replaced atomically when the search ends)")
+	rootCmd.Flags().BoolVar(&tee, "tee", false, "With --output-file, also print the results to stdout")
+	rootCmd.Flags().BoolVarP(&invertMatch, "invert-match", "v", false, "Select lines that do not match the keyword")
+	rootCmd.Flags().BoolVarP(&wholeWord, "word", "w", false, "Match the keyword only as a whole word")
+	rootCmd.Flags().BoolVar(&fuzzy, "fuzzy", false, "Match words within --fuzzy-distance edits of the keyword (Levenshtein)")
+	rootCmd.Flags().IntVar(&fuzzyDistance, "fuzzy-distance", 2, "Maximum edit distance for --fuzzy")
+	rootCmd.Flags().IntVarP(&contextLines, "context", "C", 0, "Show N lines of context around each match (sets --before and --after)")
+	rootCmd.Flags().IntVarP(&beforeLines, "before", "B", 0, "Show N lines of context before each match")
+	rootCmd.Flags().IntVarP(&afterLines, "after", "A", 0, "Show N lines of context after each match")
+	rootCmd.Flags().StringSliceVar(&includeGlobs, "include", nil, "Only search files whose name matches a glob (repeatable or comma-separated, e.g. '*.go')")
+	rootCmd.Flags().StringSliceVar(&excludeGlobs, "exclude", nil, "Skip files whose name matches a glob (takes precedence over --include)")
+	rootCmd.Flags().BoolVar(&respectGitignore, "respect-gitignore", false, "Skip paths ignored by .gitignore files found during the walk")
+	rootCmd.Flags().BoolVar(&noIgnoreFile, "no-ignore-file", false, "Do not read .findcontentignore from the search directory")
+	rootCmd.Flags().BoolVar(&noConfig, "no-config", false, "Do not read defaults from .findcontentrc files")
+	rootCmd.Flags().BoolVarP(&onlyMatching, "only-matching", "o", false, "Print only the matched part of each line, one match per output line")
+	rootCmd.Flags().StringVar(&matchMode, "match-mode", "any", "With several keywords: any (a line matches one of them) or all (a line contains every one)")
+	rootCmd.Flags().BoolVar(&groupByFile, "group", false, "Print each file path once as a header followed by its indented matches")
+	rootCmd.Flags().BoolVar(&countOnly, "count", false, "Print the number of matches per file (path:N) instead of the matches")
+	rootCmd.Flags().BoolVar(&includeZeros, "include-zeros", false, "With --count, also list files that have no matches")
+	rootCmd.Flags().StringVar(&maxFileSize, "max-file-size", "", "Skip files larger than this size (e.g., 10MB, 1GB)")
+	rootCmd.Flags().DurationVar(&fileTimeout, "file-timeout", 30*time.Second, "Give up on a file that takes longer than this to open and search, e.g. on a hung mount (0 = wait forever)")
+	rootCmd.Flags().StringVar(&maxLineLength, "max-line-length", "10MB", "Longest line that can be searched (e.g., 512KB, 10MB)")
+	rootCmd.Flags().StringVar(&readMode, "read-mode", readModeAuto, "How files are read: scan (stream line by line), full (read whole files into memory), or auto (full for files up to 256KB); results are the same")
+	rootCmd.Flags().BoolVar(&dedupe, "dedupe", false, "Print each distinct matched line once (ignoring surrounding whitespace) with the number of files it was seen in; output waits for the end of the search")
+	rootCmd.Flags().BoolVar(&showScope, "show-scope", false, "Prefix each match with the function, class or Markdown section it is in (Go, Python, Java, C/C++, JS/TS, Rust and more)")
+	rootCmd.Flags().BoolVar(&showColumn, "column", false, "Show the 1-based byte column where each match starts (also added to JSON output)")
+	rootCmd.Flags().BoolVar(&watch, "watch", false, "Keep running and print new matches whenever files are created or modified")
+	rootCmd.Flags().DurationVar(&debounce, "debounce", 200*time.Millisecond, "With --watch, wait this long after the last change before searching again")
+	rootCmd.Flags().StringVar(&label, "label", "", "Name shown as the file path when searching stdin (path \"-\")")
 
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+	return rootCmd
+}
+
+// configRoot returns the first searched directory, whose .findcontentrc is
+// read, or "" when only files or stdin are searched
+func configRoot(paths []string) string {
+	for _, path := range paths {
+		if path == "-" {
+			continue
+		}
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// splitArgs separates the paths of args from the keywords. Arguments before a
+// "--" (at index dash) are all paths; without one only the first argument is.
+// What exists on disk plays no part, so a command line means the same thing
+// in every directory.
+func splitArgs(args []string, dash int) (paths, keywords []string, err error) {
+	if dash < 0 {
+		return args[:1], args[1:], nil
 	}
+	if dash == 0 || dash == len(args) {
+		return nil, nil, fmt.Errorf("expected paths before \"--\" and keywords after it")
+	}
+	return args[:dash], args[dash:], nil
+}
+
+// isTerminal reports whether f is an interactive terminal (not a pipe or
+// file); tests replace it to exercise the terminal output
+var isTerminal = func(f *os.File) bool {
+	stat, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
+
+// canColor reports whether ANSI color sequences may be written to f: it must
+// be a terminal, neither --no-color nor NO_COLOR may be set, and a Windows
+// console must accept virtual terminal sequences. Every colored write is
+// gated by it.
+func canColor(f *os.File, noColor bool) bool {
+	return isTerminal(f) && utils.ColorRequested(noColor) && terminal.EnableVirtualTerminal(f)
 }
@@ -0,0 +1,447 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// runCLI executes a fresh root command with args and returns its stdout and
+// the error returned by Execute
+func runCLI(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	cmd := newRootCmd()
+	cmd.SetArgs(args)
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+
+	var err error
+	output := captureStdout(t, func() {
+		err = cmd.Execute()
+	})
+	return output, err
+}
+
+// runCLIStderr is runCLI that also returns what was written to stderr, where
+// the summary and warnings go
+func runCLIStderr(t *testing.T, args ...string) (stdout, stderr string, err error) {
+	t.Helper()
+
+	oldStderr := os.Stderr
+	r, w, pipeErr := os.Pipe()
+	if pipeErr != nil {
+		t.Fatalf("pipe stderr: %v", pipeErr)
+	}
+	os.Stderr = w
+	stdout, err = runCLI(t, args...)
+	os.Stderr = oldStderr
+	w.Close()
+	b, _ := io.ReadAll(r)
+	return stdout, string(b), err
+}
+
+func TestCLIMultiline(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.txt"), "foo\nbar\nbaz\n")
+
+	output, stderr, err := runCLIStderr(t, root, `foo\nbar`, "--regex", "--multiline", "--no-color")
+	if err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+	if !strings.Contains(output, `a.txt:1..2:foo\nbar`) {
+		t.Errorf("output missing multiline match range, got:\n%s", output)
+	}
+	if !strings.Contains(stderr, "Found 1 match(es)") {
+		t.Errorf("stderr missing summary, got:\n%s", stderr)
+	}
+}
+
+func TestCLIQuietWarnings(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	for _, flag := range []string{"--suppress-warnings", "--quiet-warnings"} {
+		t.Run(flag, func(t *testing.T) {
+			_, stderr, err := runCLIStderr(t, missing, "needle", flag)
+			if err != nil {
+				t.Fatalf("Execute returned error: %v", err)
+			}
+			if stderr != "No matches found\n" {
+				t.Errorf("stderr = %q, want only the summary", stderr)
+			}
+		})
+	}
+}
+
+func TestCLIOutputFile(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.txt"), "needle here\nhay\n")
+	report := filepath.Join(t.TempDir(), "report.txt")
+
+	for _, tee := range []bool{false, true} {
+		args := []string{root, "needle", "--output-file", report, "--no-file-path"}
+		if tee {
+			args = append(args, "--tee")
+		}
+		output, stderr, err := runCLIStderr(t, args...)
+		if err != nil {
+			t.Fatalf("tee=%v: Execute returned error: %v", tee, err)
+		}
+
+		content, err := os.ReadFile(report)
+		if err != nil {
+			t.Fatalf("tee=%v: read report: %v", tee, err)
+		}
+		if string(content) != "1:needle here\n" {
+			t.Errorf("tee=%v: report = %q, want only the match line", tee, content)
+		}
+		if got := strings.Contains(output, "1:needle here"); got != tee {
+			t.Errorf("tee=%v: match on stdout = %v, output:\n%s", tee, got, output)
+		}
+		if !strings.Contains(stderr, "Found 1 match(es)") {
+			t.Errorf("tee=%v: summary missing from stderr:\n%s", tee, stderr)
+		}
+	}
+
+	// Nothing but the report is left in its directory
+	entries, err := os.ReadDir(filepath.Dir(report))
+	if err != nil || len(entries) != 1 {
+		t.Errorf("output dir entries = %v (err %v), want only the report", entries, err)
+	}
+}
+
+func TestCLIBrokenPipe(t *testing.T) {
+	root := t.TempDir()
+	for i := range 50 {
+		writeFile(t, filepath.Join(root, fmt.Sprintf("f%02d.txt", i)), "needle\n")
+	}
+
+	// Stdout is a pipe whose reader has gone away, as after "| head -1"
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe stdout: %v", err)
+	}
+	r.Close()
+	oldStdout, oldStderr := os.Stdout, os.Stderr
+	stderrR, stderrW, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe stderr: %v", err)
+	}
+	os.Stdout, os.Stderr = w, stderrW
+	cmd := newRootCmd()
+	cmd.SetArgs([]string{root, "needle", "--no-color"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	execErr := cmd.Execute()
+	os.Stdout, os.Stderr = oldStdout, oldStderr
+	w.Close()
+	stderrW.Close()
+	stderr, _ := io.ReadAll(stderrR)
+
+	// The search stops quietly: no error, no summary, exit status 0
+	if execErr != nil {
+		t.Fatalf("Execute returned error: %v", execErr)
+	}
+	if len(stderr) != 0 {
+		t.Errorf("stderr = %q, want nothing", stderr)
+	}
+}
+
+func TestCLIFlagValidation(t *testing.T) {
+	root := t.TempDir()
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"invert with multiline", []string{"--invert-match", "--multiline"}, "--invert-match cannot be combined with --multiline"},
+		{"fuzzy with regex", []string{"--fuzzy", "--regex"}, "--fuzzy cannot be combined with --regex"},
+		{"unknown match mode", []string{"--match-mode", "some"}, "unsupported match mode: some"},
+		{"negative context", []string{"--context", "-1"}, "must not be negative"},
+		{"tee without output file", []string{"--tee"}, "--tee requires --output-file"},
+		{"line range backwards", []string{"--line-range", "50-1"}, "start is after end"},
+		{"line range not numeric", []string{"--line-range", "top"}, "invalid --line-range"},
+		{"line range with multiline", []string{"--line-range", "1-5", "--multiline"}, "--line-range is not supported with --multiline"},
+		{"first with count", []string{"--first", "--count"}, "--first stops at the first match found"},
+		{"dedupe with json", []string{"--dedupe", "--json"}, "--dedupe cannot be combined with --count, --json"},
+		{"watch several paths", []string{"--watch", root, "--", "needle"}, "--watch needs a single directory"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := runCLI(t, append([]string{root, "needle"}, tt.args...)...)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCLIPaths(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "src", "a.go"), "// TODO src\n")
+	writeFile(t, filepath.Join(root, "docs", "b.md"), "TODO docs\n")
+	writeFile(t, filepath.Join(root, "config.weird"), "port: 8080\nTODO config\n")
+	src, docs, config := filepath.Join(root, "src"), filepath.Join(root, "docs"), filepath.Join(root, "config.weird")
+
+	t.Run("single file", func(t *testing.T) {
+		// An explicit file is searched whatever its extension
+		output, stderr, err := runCLIStderr(t, config, "port", "--no-color")
+		if err != nil {
+			t.Fatalf("Execute returned error: %v", err)
+		}
+		if !strings.Contains(output, config+":1:port: 8080") {
+			t.Errorf("output missing file match, got:\n%s", output)
+		}
+		if !strings.Contains(stderr, "Found 1 match(es) in 1 file(s) scanned") {
+			t.Errorf("stderr missing summary, got:\n%s", stderr)
+		}
+	})
+
+	t.Run("files and directories", func(t *testing.T) {
+		output, stderr, err := runCLIStderr(t, src, docs, config, "--no-color", "--sort", "--", "TODO")
+		if err != nil {
+			t.Fatalf("Execute returned error: %v", err)
+		}
+		for _, want := range []string{"TODO src", "TODO docs", "TODO config"} {
+			if !strings.Contains(output, want) {
+				t.Errorf("output missing %q, got:\n%s", want, output)
+			}
+		}
+		if !strings.Contains(stderr, "Found 3 match(es) in 3 file(s) scanned") {
+			t.Errorf("stderr missing summary, got:\n%s", stderr)
+		}
+	})
+
+	t.Run("without dash only the first argument is a path", func(t *testing.T) {
+		// docs exists, but is searched for as a second keyword
+		output, stderr, err := runCLIStderr(t, "--no-color", src, docs, "TODO")
+		if err != nil {
+			t.Fatalf("Execute returned error: %v", err)
+		}
+		if !strings.Contains(output, "TODO src") || strings.Contains(output, "TODO docs") || !strings.Contains(stderr, "in 1 file(s) scanned") {
+			t.Errorf("output = %q, stderr = %q, want only the src match", output, stderr)
+		}
+	})
+}
+
+func TestCLIHidden(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".github", "workflows", "ci.yml"), "run: go test ./... # needle\n")
+	writeFile(t, filepath.Join(root, ".vscode", "settings.json"), `{"needle": true}`+"\n")
+	writeFile(t, filepath.Join(root, "node_modules", "x", "a.js"), "// needle\n")
+	writeFile(t, filepath.Join(root, "main.go"), "// needle\n")
+
+	tests := []struct {
+		name string
+		args []string
+		want []string // files with a match
+	}{
+		{"default", nil, []string{"main.go"}},
+		{"hidden", []string{"--hidden"}, []string{"ci.yml", "main.go", "settings.json"}},
+		{"no default excludes", []string{"--no-default-excludes"}, []string{"a.js", "main.go"}},
+		{"both", []string{"--hidden", "--no-default-excludes"}, []string{"a.js", "ci.yml", "main.go", "settings.json"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := runCLI(t, append([]string{root, "needle", "--no-color", "--sort"}, tt.args...)...)
+			if err != nil {
+				t.Fatalf("Execute returned error: %v", err)
+			}
+			var got []string
+			for _, line := range strings.Split(output, "\n") {
+				if path, _, ok := strings.Cut(line, ":"); ok && strings.HasPrefix(path, root) {
+					got = append(got, filepath.Base(path))
+				}
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("matched %v, want %v:\n%s", got, tt.want, output)
+			}
+		})
+	}
+}
+
+func TestCLIConfigPrecedence(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	writeFile(t, filepath.Join(home, "find-content", configFileName), "# personal defaults\ncase_sensitive = true\nmax_results = 1\nextensions = txt\n")
+
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, configFileName), "max_results: 2\n")
+	writeFile(t, filepath.Join(root, "a.txt"), "Needle 1\nNeedle 2\nNeedle 3\nneedle 4\n")
+	writeFile(t, filepath.Join(root, "b.md"), "Needle md\n")
+
+	countLines := func(output, substr string) int {
+		n := 0
+		for _, line := range strings.Split(output, "\n") {
+			if strings.Contains(line, substr) {
+				n++
+			}
+		}
+		return n
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		matches int // lines of a.txt printed
+		md      bool
+	}{
+		// Home sets case sensitivity and extensions, the search root overrides max_results
+		{"config files", nil, 2, false},
+		{"flag overrides config", []string{"--max-results", "3"}, 3, false},
+		{"flag set to its default still wins", []string{"--max-results", "0", "--case-sensitive=false"}, 4, false},
+		{"no config", []string{"--no-config"}, 4, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := runCLI(t, append([]string{root, "Needle", "--no-color", "--sort"}, tt.args...)...)
+			if err != nil {
+				t.Fatalf("Execute returned error: %v", err)
+			}
+			if got := countLines(output, "a.txt:"); got != tt.matches {
+				t.Errorf("printed %d a.txt match(es), want %d:\n%s", got, tt.matches, output)
+			}
+			if got := strings.Contains(output, "Needle md"); got != tt.md {
+				t.Errorf("b.md searched = %v, want %v:\n%s", got, tt.md, output)
+			}
+		})
+	}
+
+	t.Run("bad config", func(t *testing.T) {
+		writeFile(t, filepath.Join(root, configFileName), "max_results: lots\n")
+		_, err := runCLI(t, root, "Needle")
+		if err == nil || !strings.Contains(err.Error(), "max_results must be a non-negative integer") {
+			t.Errorf("err = %v, want a max_results error", err)
+		}
+	})
+}
+
+func TestCLIFirst(t *testing.T) {
+	root := t.TempDir()
+	for i := 0; i < 50; i++ {
+		writeFile(t, filepath.Join(root, fmt.Sprintf("d%d", i%5), fmt.Sprintf("f%d.txt", i)), "needle one\nneedle two\n")
+	}
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	oldStderr := os.Stderr
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe stderr: %v", err)
+	}
+	os.Stderr = w
+	output, execErr := runCLI(t, missing, root, "--first", "--no-color", "--", "needle")
+	os.Stderr = oldStderr
+	w.Close()
+	stderr, _ := io.ReadAll(r)
+
+	if execErr != nil {
+		t.Fatalf("Execute returned error: %v", execErr)
+	}
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 1 || !strings.HasSuffix(lines[0], ":1:needle one") {
+		t.Errorf("output = %q, want exactly one match and no summary", output)
+	}
+	if len(stderr) != 0 {
+		t.Errorf("stderr = %q, want the missing path warning suppressed", stderr)
+	}
+}
+
+func TestCLITree(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "README.md"), "hello\n")
+	writeFile(t, filepath.Join(root, "src", "main.go"), strings.Repeat("x", 2048))
+	writeFile(t, filepath.Join(root, "src", "util", "deep.go"), "")
+	writeFile(t, filepath.Join(root, "node_modules", "pkg", "index.js"), "")
+	writeFile(t, filepath.Join(root, ".hidden", "secret"), "")
+
+	t.Run("tree", func(t *testing.T) {
+		output, err := runCLI(t, root, "--tree")
+		if err != nil {
+			t.Fatalf("Execute returned error: %v", err)
+		}
+		want := root + `
+├── README.md (6 B)
+└── src/
+    ├── main.go (2.0 KB)
+    └── util/
+        └── deep.go (0 B)
+
+2 directories, 3 files
+`
+		if output != want {
+			t.Errorf("output =\n%s\nwant\n%s", output, want)
+		}
+	})
+
+	t.Run("dirs only with max depth", func(t *testing.T) {
+		output, err := runCLI(t, root, "--tree", "--dirs-only", "--max-depth", "0", "--show-hidden")
+		if err != nil {
+			t.Fatalf("Execute returned error: %v", err)
+		}
+		want := root + `
+├── .hidden/
+└── src/
+
+2 directories
+`
+		if output != want {
+			t.Errorf("output =\n%s\nwant\n%s", output, want)
+		}
+	})
+
+	t.Run("keyword after the path is ignored", func(t *testing.T) {
+		// "find-content /path kw -l" lists /path, as before listings took several paths
+		for _, flag := range []string{"-l", "--tree"} {
+			output, err := runCLI(t, root, "needle", flag, "--dirs-only")
+			if err != nil {
+				t.Fatalf("%s: Execute returned error: %v", flag, err)
+			}
+			if !strings.Contains(output, "src") || strings.Contains(output, "needle") {
+				t.Errorf("%s: output = %q, want only the listing of root", flag, output)
+			}
+		}
+	})
+
+	t.Run("dirs only needs a listing", func(t *testing.T) {
+		_, err := runCLI(t, root, "needle", "--dirs-only")
+		if err == nil || !strings.Contains(err.Error(), "--dirs-only only applies to --list and --tree") {
+			t.Errorf("err = %v, want a --dirs-only error", err)
+		}
+	})
+}
+
+func TestCLINoColorEnv(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.txt"), "needle here\n")
+
+	// Pretend stdout is a terminal so only the color settings decide
+	oldIsTerminal := isTerminal
+	isTerminal = func(*os.File) bool { return true }
+	t.Cleanup(func() { isTerminal = oldIsTerminal })
+
+	output, err := runCLI(t, root, "needle", "--no-progress")
+	if err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+	if !strings.Contains(output, "\x1b[") {
+		t.Fatalf("output = %q, want highlighted output on a terminal", output)
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	output, err = runCLI(t, root, "needle", "--no-progress")
+	if err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+	if strings.Contains(output, "\x1b") {
+		t.Errorf("output = %q, want no escape bytes with NO_COLOR set", output)
+	}
+	if !strings.Contains(output, "needle here") {
+		t.Errorf("output = %q, want the match", output)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// atomicFile is written under a temporary name next to its destination and
+// renamed into place by commit, so an interrupted run never leaves a
+// half-written report behind
+type atomicFile struct {
+	*os.File
+	path string
+	err  error // first write error; commit refuses to publish after one
+}
+
+// createAtomic opens a temporary file in the directory of path. Unlike
+// os.CreateTemp it asks for mode 0644, so after the umask the report gets
+// the same permissions as any other file the user creates.
+func createAtomic(path string) (*atomicFile, error) {
+	dir, base := filepath.Dir(path), filepath.Base(path)
+	for range 10000 {
+		name := filepath.Join(dir, "."+base+"."+strconv.FormatUint(uint64(rand.Uint32()), 10)+".tmp")
+		tmp, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &atomicFile{File: tmp, path: path}, nil
+	}
+	return nil, &os.PathError{Op: "create", Path: path, Err: os.ErrExist}
+}
+
+// Write remembers the first error, since the buffered writers in front of
+// the report do not always check theirs
+func (f *atomicFile) Write(p []byte) (int, error) {
+	n, err := f.File.Write(p)
+	if err != nil && f.err == nil {
+		f.err = err
+	}
+	return n, err
+}
+
+// WriteString keeps the embedded File's WriteString from bypassing Write
+func (f *atomicFile) WriteString(s string) (int, error) {
+	return f.Write([]byte(s))
+}
+
+// commit closes the temporary file and moves it to its destination. After
+// a failed write or close the temporary file is removed and the
+// destination is left untouched.
+func (f *atomicFile) commit() error {
+	err := f.Close()
+	if f.err != nil {
+		err = f.err
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), f.path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// abort discards the temporary file
+func (f *atomicFile) abort() {
+	f.Close()
+	os.Remove(f.Name())
+}
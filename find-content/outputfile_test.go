@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAtomicFileWriteError(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.txt")
+	f, err := createAtomic(path)
+	if err != nil {
+		t.Fatalf("createAtomic: %v", err)
+	}
+	f.WriteString("partial\n")
+	// Swap in a read-only handle so later writes fail, like on a full disk,
+	// while closing still succeeds
+	writable := f.File
+	defer writable.Close()
+	if f.File, err = os.Open(writable.Name()); err != nil {
+		t.Fatalf("reopen temporary file: %v", err)
+	}
+	if _, err := f.WriteString("rest\n"); err == nil {
+		t.Fatal("write to a read-only file succeeded")
+	}
+
+	if err := f.commit(); err == nil {
+		t.Error("commit succeeded after a failed write")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("dir entries = %v, want no report and no temporary file", entries)
+	}
+}
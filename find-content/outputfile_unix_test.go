@@ -0,0 +1,33 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestAtomicFileMode(t *testing.T) {
+	old := syscall.Umask(0o022)
+	defer syscall.Umask(old)
+
+	path := filepath.Join(t.TempDir(), "report.txt")
+	f, err := createAtomic(path)
+	if err != nil {
+		t.Fatalf("createAtomic: %v", err)
+	}
+	f.WriteString("x\n")
+	if err := f.commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat report: %v", err)
+	}
+	if mode := info.Mode().Perm(); mode != 0o644 {
+		t.Errorf("report mode = %v, want -rw-r--r--", mode)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// progressInterval is the minimum time between two redraws of the progress line
+const progressInterval = 200 * time.Millisecond
+
+// progressLine keeps a one-line status ("Scanned N files, M matches: path")
+// on stderr while a search runs. Redraws take the search's output lock, and
+// the search clears the line (with the lock held) before writing results, so
+// the two never interleave.
+type progressLine struct {
+	w       io.Writer
+	mu      *sync.Mutex   // the output lock of the search
+	width   int           // terminal columns; the line is kept one shorter
+	files   *atomic.Int64 // files scanned so far
+	matches *atomic.Int64 // matches reported so far
+	current atomic.Pointer[string]
+	shown   bool // a line is on screen and must be cleared before output
+}
+
+func newProgressLine(w io.Writer, mu *sync.Mutex, width int, files, matches *atomic.Int64) *progressLine {
+	return &progressLine{w: w, mu: mu, width: width, files: files, matches: matches}
+}
+
+// setCurrent records the file being searched
+func (p *progressLine) setCurrent(path string) {
+	p.current.Store(&path)
+}
+
+// draw replaces the progress line with the current counts; the path is
+// shortened to what fits on the line
+func (p *progressLine) draw() {
+	line := fmt.Sprintf("Scanned %d files, %d matches", p.files.Load(), p.matches.Load())
+	if path := p.current.Load(); path != nil {
+		if room := p.width - 1 - len(line) - len(": "); room > len("...") {
+			line += ": " + shortenLeft(*path, room)
+		}
+	}
+	fmt.Fprint(p.w, "\r"+line+"\033[K")
+	p.shown = true
+}
+
+// clear erases the progress line if one is on screen. The caller holds the
+// output lock.
+func (p *progressLine) clear() {
+	if p.shown {
+		fmt.Fprint(p.w, "\r\033[K")
+		p.shown = false
+	}
+}
+
+// printf writes a message such as a warning to the progress line's writer
+// on a line of its own; the next redraw puts the progress line back below it
+func (p *progressLine) printf(format string, args ...any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clear()
+	fmt.Fprintf(p.w, format, args...)
+}
+
+// start redraws the line every progressInterval until the returned function
+// is called; that function clears the line and returns once it is gone
+func (p *progressLine) start() (stop func()) {
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.mu.Lock()
+				p.draw()
+				p.mu.Unlock()
+			case <-done:
+				p.mu.Lock()
+				p.clear()
+				p.mu.Unlock()
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-finished
+	}
+}
+
+// shortenLeft fits s into width runes (width > 3) by replacing its start
+// with "...", keeping the file name at the end of a long path visible
+func shortenLeft(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	return "..." + string(runes[len(runes)-width+3:])
+}
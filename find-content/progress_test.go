@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestProgressLine(t *testing.T) {
+	var buf bytes.Buffer
+	var mu sync.Mutex
+	var files, matches atomic.Int64
+	files.Store(12)
+	matches.Store(3)
+
+	p := newProgressLine(&buf, &mu, 50, &files, &matches)
+	p.setCurrent("/very/long/path/to/some/deeply/nested/file.go")
+	p.draw()
+	line := strings.TrimSuffix(strings.TrimPrefix(buf.String(), "\r"), "\033[K")
+	if !strings.HasPrefix(line, "Scanned 12 files, 3 matches: ...") || !strings.HasSuffix(line, "nested/file.go") {
+		t.Errorf("progress line = %q, want counts and the shortened path", line)
+	}
+	if len(line) != 49 {
+		t.Errorf("progress line is %d columns, want 49 on a 50-column terminal", len(line))
+	}
+
+	buf.Reset()
+	p.clear()
+	p.clear()
+	if buf.String() != "\r\033[K" {
+		t.Errorf("clear wrote %q, want one erase", buf.String())
+	}
+}
+
+func TestProgressLinePrintf(t *testing.T) {
+	var buf bytes.Buffer
+	var mu sync.Mutex
+	var files, matches atomic.Int64
+
+	p := newProgressLine(&buf, &mu, 80, &files, &matches)
+	p.draw()
+	buf.Reset()
+	p.printf("Warning: %s\n", "oops")
+	if buf.String() != "\r\033[KWarning: oops\n" {
+		t.Errorf("printf wrote %q, want the line erased before the warning", buf.String())
+	}
+
+	// Nothing to erase when no line is shown
+	buf.Reset()
+	p.printf("Warning: again\n")
+	if buf.String() != "Warning: again\n" {
+		t.Errorf("printf wrote %q, want only the warning", buf.String())
+	}
+}
+
+func TestProgressLineStop(t *testing.T) {
+	var buf bytes.Buffer
+	var mu sync.Mutex
+	var files, matches atomic.Int64
+
+	p := newProgressLine(&buf, &mu, 80, &files, &matches)
+	stop := p.start()
+	time.Sleep(progressInterval + 50*time.Millisecond)
+	stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !strings.Contains(buf.String(), "Scanned 0 files") {
+		t.Errorf("no progress drawn: %q", buf.String())
+	}
+	if !strings.HasSuffix(buf.String(), "\r\033[K") {
+		t.Errorf("line not cleared on stop: %q", buf.String())
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// scopeRules recognize the lines that open a definition (function, class,
+// section) per file extension for --show-scope. The scope name is the
+// non-empty capture groups joined with ".", e.g. "Type.Method" for a Go method.
+// The end of a definition is not tracked: a line keeps the scope of the last
+// definition above it, as in `git diff` hunk headers.
+var scopeRules = map[string][]*regexp.Regexp{}
+
+func init() {
+	cFamily := []string{
+		// A declarator at column 0 that is not a prototype: "int main(void) {"
+		`^(?:[A-Za-z_][\w\s\*&:<>,]*?[\s\*&])?([A-Za-z_]\w*(?:::~?\w+)?)\s*\([^;]*$`,
+	}
+	javaLike := []string{
+		`^\s*(?:(?:public|private|protected|internal|static|final|abstract|sealed|partial|open|data)\s+)*(?:class|interface|enum|record|struct|object)\s+(\w+)`,
+		`^\s*(?:(?:public|private|protected|internal|static|final|abstract|synchronized|native|override|virtual|async)\s+)+[\w<>\[\],.?]+\s+(\w+)\s*\(`,
+	}
+	jsLike := []string{
+		`^\s*(?:export\s+)?(?:default\s+)?(?:async\s+)?function\*?\s+(\w+)`,
+		`^\s*(?:export\s+)?(?:default\s+)?(?:abstract\s+)?class\s+(\w+)`,
+		`^\s*(?:export\s+)?(?:const|let|var)\s+(\w+)\s*=\s*(?:async\s+)?(?:function\b|\([^)]*\)\s*=>|\w+\s*=>)`,
+	}
+	rules := map[string][]string{
+		".go": {
+			`^func\s+(?:\(\s*(?:\w+\s+)?\*?(\w+)[^)]*\)\s*)?(\w+)`,
+			`^type\s+(\w+)\s+(?:struct|interface)\b`,
+		},
+		".py":    {`^\s*(?:async\s+)?(?:def|class)\s+(\w+)`},
+		".rb":    {`^\s*(?:def|class|module)\s+(?:self\.)?([\w:]+[?!=]?)`},
+		".rs":    {`^\s*(?:pub(?:\([^)]*\))?\s+)?(?:(?:async|const|unsafe|extern\s+"\w+")\s+)*(?:fn|struct|enum|trait|mod)\s+(\w+)`, `^\s*impl(?:<[^>]*>)?\s+(?:[\w:<>]+\s+for\s+)?(\w+)`},
+		".php":   {`^\s*(?:(?:public|private|protected|static|abstract|final)\s+)*(?:function|class|interface|trait)\s+(\w+)`},
+		".kt":    {`^\s*(?:(?:public|private|protected|internal|override|open|suspend|inline|data|sealed|abstract)\s+)*(?:fun|class|object|interface)\s+(?:<[^>]*>\s*)?(?:\w+\.)?(\w+)`},
+		".swift": {`^\s*(?:(?:public|private|fileprivate|internal|open|static|final|override|mutating)\s+)*(?:func|class|struct|enum|protocol|extension)\s+(\w+)`},
+		".sh":    {`^\s*(?:function\s+)?([\w-]+)\s*\(\)`, `^\s*function\s+([\w-]+)`},
+		".lua":   {`^\s*(?:local\s+)?function\s+([\w.:]+)`},
+		".md":    {`^#{1,6}\s+(.+?)\s*#*$`},
+	}
+	for _, ext := range []string{".java", ".cs", ".scala"} {
+		rules[ext] = javaLike
+	}
+	rules[".scala"] = append([]string{`^\s*(?:(?:private|protected|override|final|implicit)\s+)*(?:def|class|object|trait)\s+(\w+)`}, javaLike...)
+	for _, ext := range []string{".js", ".jsx", ".ts", ".tsx", ".vue"} {
+		rules[ext] = jsLike
+	}
+	for _, ext := range []string{".c", ".h", ".cpp", ".hpp", ".cc", ".cxx", ".hh"} {
+		rules[ext] = cFamily
+	}
+	rules[".bash"] = rules[".sh"]
+	rules[".zsh"] = rules[".sh"]
+	rules[".markdown"] = rules[".md"]
+
+	for ext, patterns := range rules {
+		for _, pattern := range patterns {
+			scopeRules[ext] = append(scopeRules[ext], regexp.MustCompile(pattern))
+		}
+	}
+}
+
+// controlKeywords look like a C function name before "(" but open no scope
+var controlKeywords = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "return": true, "sizeof": true, "catch": true,
+}
+
+// scopeRulesFor returns the definition patterns for the language of name, or
+// nil when its extension is not recognized
+func scopeRulesFor(name string) []*regexp.Regexp {
+	return scopeRules[strings.ToLower(filepath.Ext(name))]
+}
+
+// scopeName returns the definition opened by line, if any
+func scopeName(rules []*regexp.Regexp, line string) (string, bool) {
+	for _, re := range rules {
+		m := re.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		var parts []string
+		for _, group := range m[1:] {
+			if group != "" {
+				parts = append(parts, group)
+			}
+		}
+		if len(parts) > 0 && !controlKeywords[parts[0]] {
+			return strings.Join(parts, "."), true
+		}
+	}
+	return "", false
+}
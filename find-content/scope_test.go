@@ -0,0 +1,41 @@
+package main
+
+import "testing"
+
+func TestScopeName(t *testing.T) {
+	tests := []struct {
+		file string
+		line string
+		want string // empty: the line opens no scope
+	}{
+		{"a.go", "func main() {", "main"},
+		{"a.go", "func (s *Server) Serve(l net.Listener) error {", "Server.Serve"},
+		{"a.go", "func (Server) Close() {", "Server.Close"},
+		{"a.go", "type Config struct {", "Config"},
+		{"a.go", "\tfoo := func() {", ""},
+		{"a.py", "    async def fetch(self, url):", "fetch"},
+		{"a.py", "class Parser(Base):", "Parser"},
+		{"A.java", "    public static void main(String[] args) {", "main"},
+		{"A.java", "public final class Main {", "Main"},
+		{"A.java", "        return compute(x);", ""},
+		{"a.c", "int main(int argc, char **argv)", "main"},
+		{"a.c", "static const char *name_of(struct node *n) {", "name_of"},
+		{"a.c", "int helper(void);", ""},
+		{"a.c", "if (x) {", ""},
+		{"a.cpp", "void Widget::draw() const {", "Widget::draw"},
+		{"a.ts", "export async function load(id: string) {", "load"},
+		{"a.js", "const handler = async (req, res) => {", "handler"},
+		{"a.rs", "pub(crate) async fn run(cfg: Config) -> Result<()> {", "run"},
+		{"a.rs", "impl<T> Display for Wrapper<T> {", "Wrapper"},
+		{"a.sh", "deploy() {", "deploy"},
+		{"README.md", "## Getting started", "Getting started"},
+		{"notes.txt", "func main() {", ""},
+	}
+
+	for _, tt := range tests {
+		got, ok := scopeName(scopeRulesFor(tt.file), tt.line)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("scopeName(%s, %q) = %q, %v; want %q", tt.file, tt.line, got, ok, tt.want)
+		}
+	}
+}
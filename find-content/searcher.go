@@ -3,23 +3,156 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"syscall"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"common-module/gitignore"
+	"common-module/terminal"
+	"common-module/utils"
 )
 
 // matchResult represents a single search match
 type matchResult struct {
-	lineNum int
-	endLine int
-	content string
+	lineNum   int
+	endLine   int
+	content   string
+	isContext bool // surrounding line emitted by --before/--after, not a match
+	newGroup  bool // first line of a contiguous block (preceded by "--" in text output)
+	column    int  // 1-based byte column of the match start; 0 when not reported
+	truncated bool // last match reported for its file; --max-per-file dropped later ones
+
+	// scope is the function, class or section the match is in (--show-scope);
+	// empty for context lines and unrecognized languages
+	scope string
+}
+
+// searchOptions controls how grepRecursive matches and reports results
+type searchOptions struct {
+	useRegex        bool
+	multiline       bool
+	showLineNumbers bool
+	showFilePath    bool
+	maxResults      int    // 0 = unlimited
+	jsonOutput      bool   // emit one JSON object per match plus a summary (NDJSON)
+	jsonDocument    bool   // buffer all matches and emit a single JSON document at exit
+	color           bool   // highlight matches and prefixes with ANSI colors
+	invertMatch     bool   // report lines that do NOT match (single-line mode only)
+	wholeWord       bool   // only match the keyword as a whole word
+	before          int    // lines of context to show before each match
+	after           int    // lines of context to show after each match
+	label           string // name shown as the path of stdin matches; empty hides it
+	onlyMatching    bool   // report each matched substring instead of the whole line
+	matchAll        bool   // with several keywords, require all of them on a line (single-line mode only)
+	count           bool   // print "path:N" per file instead of the matches (text output only)
+	includeZeros    bool   // with count, also print files without matches
+	displayWidth    int    // terminal width; longer text lines are shortened with an ellipsis (0 = never)
+	group           bool   // print each file's path once as a header above its indented matches
+	fuzzy           bool   // match whitespace-delimited tokens within fuzzyDistance edits of the keyword
+	fuzzyDistance   int    // maximum Levenshtein distance in fuzzy mode
+	column          bool   // report the 1-based byte column where each match starts
+	maxPerFile      int    // stop reading a file after this many matches (0 = unlimited)
+	sorted          bool   // buffer every file's matches and report them ordered by path
+	maxPerDir       int    // matches reported under each immediate subdirectory of a root (0 = unlimited)
+
+	// filter, when set, sees every searched file's matches (even none) and
+	// returns the ones to report; --watch uses it to drop already printed matches.
+	// Calls are serialized.
+	filter func(path string, matches []matchResult) []matchResult
+
+	// reported, when set, is called after filter with the matches of a file
+	// that were actually written (not those cut by --max-results or
+	// --max-per-dir); context lines are left out. Calls are serialized.
+	reported func(path string, matches []matchResult)
+
+	// lineRanges, when set, limits matching to these lines of every file
+	// (--line-range, single-line mode only)
+	lineRanges []lineRange
+
+	// first stops the whole search as soon as one match has been reported
+	// (--first); use it with maxResults 1
+	first bool
+
+	// keywordStats counts the reported matches and files of each keyword and
+	// prints them after the results (or adds them to the JSON summary). It
+	// needs at least two and at most maxKeywordStats keywords, and no filter.
+	keywordStats bool
+
+	// showScope prefixes each match with the name of the enclosing definition
+	// (see scopeRules); single-line mode only
+	showScope bool
+
+	// dedupe buffers the text output and prints each distinct trimmed matched
+	// line once, with the number of files it was seen in (see dedupeSet).
+	// Match counts still include every occurrence.
+	dedupe bool
+}
+
+// suppressedNote is appended to the last text match of a file cut short by --max-per-file
+const suppressedNote = " (more matches suppressed)"
+
+// dirSuppressedNote reports the matches hidden under one directory by --max-per-dir
+const dirSuppressedNote = "(suppressed %d more under %s)\n"
+
+// stdinPath is the directory argument that makes grepRecursive read os.Stdin
+const stdinPath = "-"
+
+// ANSI colors for highlighted text output
+const (
+	colorReset  = "\033[0m"
+	colorMatch  = "\033[1;31m" // bold red
+	colorPath   = "\033[35m"   // magenta
+	colorLineNo = "\033[32m"   // green
+	colorScope  = "\033[36m"   // cyan
+)
+
+// patternColors highlights each keyword of a multi-pattern search distinctly;
+// the first keyword keeps the usual match color
+var patternColors = []string{colorMatch, "\033[1;33m", "\033[1;34m", "\033[1;36m", "\033[1;32m", "\033[1;35m"}
+
+// jsonMatch is the NDJSON record emitted for each match in --json mode
+type jsonMatch struct {
+	Path    string `json:"path"`
+	Line    int    `json:"line"`
+	EndLine int    `json:"end_line"`
+	Content string `json:"content"`
+	Context bool   `json:"context,omitempty"`         // true for --before/--after lines
+	Column  int    `json:"column,omitempty"`          // 1-based byte column of the match start (--column)
+	More    bool   `json:"more_suppressed,omitempty"` // last match of a file cut short by --max-per-file
+	Scope   string `json:"scope,omitempty"`           // enclosing definition (--show-scope)
+}
+
+// jsonDocument is the single object written by --output json
+type jsonDocument struct {
+	Matches      []jsonMatch `json:"matches"`
+	TotalMatches int64       `json:"total_matches"`
+	FilesScanned int64       `json:"files_scanned"`
+	Truncated    bool        `json:"truncated"` // --max-results stopped the search early
+
+	Keywords []jsonKeywordStat `json:"keywords,omitempty"` // with keywordStats
+}
+
+// jsonSummary is the trailing NDJSON record emitted in --json mode
+type jsonSummary struct {
+	TotalMatches int64             `json:"total_matches"`
+	FilesScanned int64             `json:"files_scanned"`
+	Keywords     []jsonKeywordStat `json:"keywords,omitempty"` // with keywordStats
 }
 
 // searchMatcher holds pre-compiled search state to avoid per-line/per-file recomputation
@@ -30,12 +163,33 @@ type searchMatcher struct {
 	searchPattern string // multiline: \n converted to actual newlines
 	lowerPattern  string // multiline case-insensitive
 	caseSensitive bool
+	foldRegex     *regexp.Regexp   // case-insensitive literal, used only to locate highlight spans
+	invert        bool             // select non-matching lines
+	wholeWord     bool             // literal matches must not touch other word characters
+	before        int              // context lines kept before a match (single-line mode)
+	after         int              // context lines emitted after a match (single-line mode)
+	onlyMatching  bool             // emit matched substrings, one result per occurrence
+	extra         []*searchMatcher // further keywords of a multi-pattern search
+	matchAll      bool             // a line must match every keyword, not just one
+	fuzzy         bool             // approximate token matching instead of substring search
+	fuzzyKeyword  []rune           // fuzzy mode: keyword runes (lowercased unless case-sensitive)
+	fuzzyDistance int              // fuzzy mode: maximum edit distance of a matching token
+	column        bool             // record where each match starts (--column)
+	maxPerFile    int              // matches reported per file before reading stops (0 = unlimited)
+	lineRanges    []lineRange      // only lines in these ranges can match; nil = all
+	showScope     bool             // track the enclosing definition of matches (--show-scope)
 }
 
-func newSearchMatcher(keyword string, useRegex, caseSensitive, multiline bool) (*searchMatcher, error) {
+// textSpan is a [start, end) byte range of a match in multiline content
+type textSpan struct {
+	start, end int
+}
+
+func newSearchMatcher(keyword string, useRegex, caseSensitive, multiline, wholeWord bool) (*searchMatcher, error) {
 	sm := &searchMatcher{
 		keyword:       keyword,
 		caseSensitive: caseSensitive,
+		wholeWord:     wholeWord && !useRegex, // regex patterns get \b anchors instead
 	}
 
 	if multiline {
@@ -48,7 +202,7 @@ func newSearchMatcher(keyword string, useRegex, caseSensitive, multiline bool) (
 			if !caseSensitive {
 				flags = "(?i)"
 			}
-			re, err := regexp.Compile(flags + sm.searchPattern)
+			re, err := regexp.Compile(flags + wordAnchored(sm.searchPattern, wholeWord))
 			if err != nil {
 				return nil, err
 			}
@@ -60,19 +214,239 @@ func newSearchMatcher(keyword string, useRegex, caseSensitive, multiline bool) (
 			if !caseSensitive {
 				flags = "(?i)"
 			}
-			re, err := regexp.Compile(flags + keyword)
+			re, err := regexp.Compile(flags + wordAnchored(keyword, wholeWord))
 			if err != nil {
 				return nil, err
 			}
 			sm.regex = re
 		} else if !caseSensitive {
 			sm.lowerKeyword = strings.ToLower(keyword)
+			// Lowercasing can change byte lengths, so offsets found in the lowered
+			// copy may not line up with the original line; match it directly instead.
+			sm.foldRegex = regexp.MustCompile("(?i)" + regexp.QuoteMeta(keyword))
 		}
 	}
 
 	return sm, nil
 }
 
+// setFuzzy switches the matcher to approximate token matching
+func (sm *searchMatcher) setFuzzy(distance int) {
+	keyword := sm.keyword
+	if !sm.caseSensitive {
+		keyword = strings.ToLower(keyword)
+	}
+	sm.fuzzy = true
+	sm.fuzzyKeyword = []rune(keyword)
+	sm.fuzzyDistance = distance
+}
+
+// fuzzySpans returns the whitespace-delimited tokens of line whose edit
+// distance to the keyword is within fuzzyDistance, up to limit (0 = all)
+func (sm *searchMatcher) fuzzySpans(line string, limit int) [][]int {
+	var spans [][]int
+	for start := 0; start < len(line) && (limit <= 0 || len(spans) < limit); {
+		r, size := utf8.DecodeRuneInString(line[start:])
+		if unicode.IsSpace(r) {
+			start += size
+			continue
+		}
+		end := start + size
+		for end < len(line) {
+			r, size := utf8.DecodeRuneInString(line[end:])
+			if unicode.IsSpace(r) {
+				break
+			}
+			end += size
+		}
+
+		token := line[start:end]
+		// Cheap length check first: most tokens are ruled out without allocating
+		if abs(utf8.RuneCountInString(token)-len(sm.fuzzyKeyword)) <= sm.fuzzyDistance {
+			if !sm.caseSensitive {
+				token = strings.ToLower(token)
+			}
+			if levenshtein(sm.fuzzyKeyword, []rune(token), sm.fuzzyDistance) <= sm.fuzzyDistance {
+				spans = append(spans, []int{start, end})
+			}
+		}
+		start = end
+	}
+	return spans
+}
+
+// levenshtein returns the edit distance between a and b. It stops early and
+// returns limit+1 once the distance is certain to exceed limit.
+func levenshtein(a, b []rune, limit int) int {
+	if abs(len(a)-len(b)) > limit {
+		return limit + 1
+	}
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// wordAnchored wraps a regex pattern in \b anchors when whole-word matching is on.
+// RE2's \b is ASCII-only; literal searches use the Unicode-aware isWholeWord instead.
+func wordAnchored(pattern string, wholeWord bool) string {
+	if !wholeWord {
+		return pattern
+	}
+	return `\b(?:` + pattern + `)\b`
+}
+
+// isWordRune reports whether r is a word character (letter, digit or underscore)
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// isWholeWord reports whether s[start:end] is not adjacent to other word characters
+func isWholeWord(s string, start, end int) bool {
+	if start > 0 {
+		if r, _ := utf8.DecodeLastRuneInString(s[:start]); isWordRune(r) {
+			return false
+		}
+	}
+	if end < len(s) {
+		if r, _ := utf8.DecodeRuneInString(s[end:]); isWordRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// literalWordSpans returns whole-word occurrences of the literal keyword in line.
+// A rejected candidate only advances one rune so overlapping occurrences are still found.
+func (sm *searchMatcher) literalWordSpans(line string, limit int) [][]int {
+	var spans [][]int
+	offset := 0
+	for offset <= len(line) && (limit <= 0 || len(spans) < limit) {
+		var start, end int
+		if sm.foldRegex != nil {
+			loc := sm.foldRegex.FindStringIndex(line[offset:])
+			if loc == nil {
+				break
+			}
+			start, end = offset+loc[0], offset+loc[1]
+		} else {
+			idx := strings.Index(line[offset:], sm.keyword)
+			if idx == -1 {
+				break
+			}
+			start, end = offset+idx, offset+idx+len(sm.keyword)
+		}
+
+		if start < end && isWholeWord(line, start, end) {
+			spans = append(spans, []int{start, end})
+			offset = end
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(line[start:])
+		offset = start + max(size, 1)
+	}
+	return spans
+}
+
+// matchLine reports whether line matches any keyword, or every keyword when matchAll is set
+func (sm *searchMatcher) matchLine(line string) bool {
+	matched := sm.matchKeyword(line)
+	for _, p := range sm.extra {
+		if matched != sm.matchAll {
+			break // "any" already matched or "all" already failed
+		}
+		matched = p.matchKeyword(line)
+	}
+	return matched
+}
+
+// matchKeyword reports whether line contains this matcher's own keyword
+func (sm *searchMatcher) matchKeyword(line string) bool {
+	switch {
+	case sm.fuzzy:
+		return len(sm.fuzzySpans(line, 1)) > 0
+	case sm.regex != nil:
+		return sm.regex.MatchString(line)
+	case sm.wholeWord:
+		return len(sm.literalWordSpans(line, 1)) > 0
+	case sm.caseSensitive:
+		return strings.Contains(line, sm.keyword)
+	default:
+		return strings.Contains(strings.ToLower(line), sm.lowerKeyword)
+	}
+}
+
+// matchSpans returns the [start, end) byte offsets of every match in line,
+// positioned on the original text so highlighting preserves its case.
+// Spans of extra keywords carry the keyword index as a third element and
+// are merged in order of their start offset.
+func (sm *searchMatcher) matchSpans(line string) [][]int {
+	spans := sm.keywordSpans(line)
+	if len(sm.extra) == 0 {
+		return spans
+	}
+	for i, p := range sm.extra {
+		for _, span := range p.keywordSpans(line) {
+			spans = append(spans, []int{span[0], span[1], i + 1})
+		}
+	}
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+	return spans
+}
+
+// keywordSpans returns the spans of this matcher's own keyword in line
+func (sm *searchMatcher) keywordSpans(line string) [][]int {
+	switch {
+	case sm.fuzzy:
+		return sm.fuzzySpans(line, 0)
+	case sm.regex != nil:
+		return sm.regex.FindAllStringIndex(line, -1)
+	case sm.wholeWord:
+		return sm.literalWordSpans(line, 0)
+	case sm.foldRegex != nil:
+		return sm.foldRegex.FindAllStringIndex(line, -1)
+	case sm.keyword == "":
+		return nil
+	}
+
+	var spans [][]int
+	offset := 0
+	for {
+		idx := strings.Index(line[offset:], sm.keyword)
+		if idx == -1 {
+			return spans
+		}
+		start := offset + idx
+		spans = append(spans, []int{start, start + len(sm.keyword)})
+		offset = start + len(sm.keyword)
+	}
+}
+
 // FileSearcher handles file content searching operations
 type FileSearcher struct {
 	caseSensitive    bool
@@ -82,11 +456,100 @@ type FileSearcher struct {
 	textExtensions   map[string]bool
 	suppressWarnings bool
 	searchAll        bool
+	includeGlobs     []string // basename globs; when set, only matching files are searched
+	excludeGlobs     []string // basename globs that are never searched
+	respectGitignore bool
+	stdin            io.Reader // searched when the directory argument is "-"
+	out              io.Writer // where results are written; nil means os.Stdout
+	maxFileSize      int64     // files larger than this are skipped (0 = unlimited)
+	maxLineLength    int       // longest line the scanner accepts, in bytes
+	skippedForSize   atomic.Int64
+	verbose          bool // report each file skipped for size on stderr
+	maxDepth         int  // deepest directory level searched; 0 = only the root's files, -1 = unlimited
+	followSymlinks   bool // descend into symlinked directories
+	skippedForDepth  atomic.Int64
+	noSniff          bool          // only trust extensions: no well-known names, no shebang check
+	filesScanned     int64         // files read by the last grepRecursive call
+	showProgress     bool          // draw a progress line on stderr during grepRecursive
+	progress         *progressLine // non-nil while a search with showProgress runs
+
+	// fileTimeout abandons a file whose open or read takes longer (0 = wait
+	// forever). An abandoned read cannot be interrupted and keeps its file
+	// descriptor until the kernel returns, so abandonedReads counts the ones
+	// still running and no file is opened while maxAbandonedReads of them are.
+	fileTimeout    time.Duration
+	timedOut       atomic.Int64 // files abandoned or skipped because of fileTimeout
+	abandonedReads atomic.Int64
+
+	// readMode picks how regular files are read in single-line mode
+	// (--read-mode): readModeScan streams them through a bufio.Scanner,
+	// readModeFull reads them into memory and splits the lines there, and
+	// readModeAuto (or "") does the latter for files up to fullReadMaxSize.
+	// Both give the same matches.
+	readMode string
+
+	// hidden makes the walk enter directories whose name starts with a dot
+	// (--hidden); they are skipped by default. Explicit roots always are searched.
+	hidden bool
+
+	// brokenPipe is set when the reader of the output went away (e.g. the
+	// output was piped into head or a pager that quit); the search stops there.
+	brokenPipe atomic.Bool
+
+	// ignoreFiles holds each search root's .findcontentignore entries, which
+	// only apply below that root
+	ignoreFiles map[string]*ignoreRules
+}
+
+// --read-mode values
+const (
+	readModeAuto = "auto"
+	readModeScan = "scan"
+	readModeFull = "full"
+)
+
+// fullReadMaxSize is the largest file readModeAuto reads in one go. Small
+// files are the common case in source trees, where setting up a scanner and
+// allocating every line costs more than the search itself.
+const fullReadMaxSize = 256 << 10
+
+// maxAbandonedReads bounds the goroutines and file descriptors held by reads
+// abandoned after --file-timeout, e.g. on a hung network mount: at most this
+// many plus one per worker
+const maxAbandonedReads = 64
+
+// specialFileTypes are the DirEntry type bits of files that are never searched
+// during a walk: reading a FIFO, socket or device can block forever
+const specialFileTypes = os.ModeNamedPipe | os.ModeSocket | os.ModeDevice | os.ModeCharDevice | os.ModeIrregular
+
+// textFileNames are extension-less file names that are always text
+var textFileNames = map[string]bool{
+	"Dockerfile": true, "Containerfile": true, "Makefile": true, "GNUmakefile": true,
+	"Jenkinsfile": true, "Vagrantfile": true, "Gemfile": true, "Rakefile": true,
+	"Procfile": true, "Brewfile": true, "Pipfile": true, "Justfile": true, "Tiltfile": true,
+	"LICENSE": true, "LICENCE": true, "COPYING": true, "NOTICE": true, "AUTHORS": true,
+	"README": true, "CHANGELOG": true, "CODEOWNERS": true, "VERSION": true,
+	".gitignore": true, ".gitattributes": true, ".dockerignore": true, ".editorconfig": true,
+	".env": true, ".bashrc": true, ".zshrc": true, ".profile": true,
 }
 
+// defaultExcludeDirs are skipped unless --no-default-excludes is given
+var defaultExcludeDirs = []string{".git", "__pycache__", "node_modules", ".vscode", ".idea", "target", "build", "dist"}
+
+// editorDirs are the default excludes that --hidden searches after all: editor
+// settings are worth grepping, unlike the objects in .git
+var editorDirs = []string{".vscode", ".idea"}
+
+// defaultMaxLineLength is the scanner limit used unless --max-line-length is set
+const defaultMaxLineLength = 10 << 20
+
+// minDisplayWidth keeps shortened lines readable when the prefix is long
+const minDisplayWidth = 20
+
 // NewFileSearcher creates a new FileSearcher instance
 func NewFileSearcher(caseSensitive, suppressWarnings, searchAll bool, fileExtensions, excludeDirs, excludeFiles []string) *FileSearcher {
 	fs := &FileSearcher{
+		maxDepth:         -1,
 		caseSensitive:    caseSensitive,
 		suppressWarnings: suppressWarnings,
 		searchAll:        searchAll,
@@ -94,10 +557,11 @@ func NewFileSearcher(caseSensitive, suppressWarnings, searchAll bool, fileExtens
 		excludeDirs:      make(map[string]bool),
 		excludeFiles:     make(map[string]bool),
 		textExtensions:   make(map[string]bool),
+		stdin:            os.Stdin,
+		maxLineLength:    defaultMaxLineLength,
 	}
 
 	// Set default excluded directories
-	defaultExcludeDirs := []string{".git", "__pycache__", "node_modules", ".vscode", ".idea", "target", "build", "dist"}
 	for _, dir := range defaultExcludeDirs {
 		fs.excludeDirs[dir] = true
 	}
@@ -132,6 +596,56 @@ func NewFileSearcher(caseSensitive, suppressWarnings, searchAll bool, fileExtens
 	return fs
 }
 
+// setPathFilters configures --include/--exclude globs and .gitignore handling
+func (fs *FileSearcher) setPathFilters(include, exclude []string, respectGitignore bool) {
+	fs.includeGlobs = include
+	fs.excludeGlobs = exclude
+	fs.respectGitignore = respectGitignore
+}
+
+// setLimits configures the --max-file-size and --max-line-length guards
+func (fs *FileSearcher) setLimits(maxFileSize int64, maxLineLength int) {
+	fs.maxFileSize = maxFileSize
+	fs.maxLineLength = maxLineLength
+}
+
+// setWalkOptions configures --max-depth and --follow-symlinks
+func (fs *FileSearcher) setWalkOptions(maxDepth int, followSymlinks bool) {
+	fs.maxDepth = maxDepth
+	fs.followSymlinks = followSymlinks
+}
+
+// shouldSearchFile applies the file filters in precedence order:
+// --exclude globs > --include globs > .gitignore > built-in extension filter.
+// The "!" entries of the root's ignore file add to --extensions.
+func (fs *FileSearcher) shouldSearchFile(filePath string, ignore *gitignore.Matcher, rules *ignoreRules) bool {
+	name := filepath.Base(filePath)
+	if matchesAnyGlob(fs.excludeGlobs, name) {
+		return false
+	}
+	if len(fs.includeGlobs) > 0 {
+		return matchesAnyGlob(fs.includeGlobs, name)
+	}
+	if ignore != nil && ignore.Ignored(filePath, false) {
+		return false
+	}
+	if rules != nil && len(rules.extensions) > 0 && !fs.searchAll {
+		ext := strings.ToLower(filepath.Ext(filePath))
+		return fs.fileExtensions[ext] || rules.extensions[ext]
+	}
+	return fs.isTextFile(filePath)
+}
+
+// matchesAnyGlob reports whether name matches one of the glob patterns
+func matchesAnyGlob(globs []string, name string) bool {
+	for _, g := range globs {
+		if ok, _ := filepath.Match(g, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
 // isTextFile checks if a file is likely a text file
 func (fs *FileSearcher) isTextFile(filePath string) bool {
 	if fs.searchAll {
@@ -146,7 +660,30 @@ func (fs *FileSearcher) isTextFile(filePath string) bool {
 	}
 
 	// Otherwise fall back to known text extensions
-	return fs.textExtensions[ext]
+	if fs.textExtensions[ext] {
+		return true
+	}
+	if fs.noSniff {
+		return false
+	}
+	name := filepath.Base(filePath)
+	if textFileNames[name] {
+		return true
+	}
+	// Extension-less scripts announce themselves with a shebang
+	return ext == "" && hasShebang(filePath)
+}
+
+// hasShebang reports whether the file starts with "#!"
+func hasShebang(filePath string) bool {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	var magic [2]byte
+	n, _ := io.ReadFull(f, magic[:])
+	return n == 2 && magic == [2]byte{'#', '!'}
 }
 
 // shouldSkipDirectory checks if directory should be skipped
@@ -154,78 +691,421 @@ func (fs *FileSearcher) shouldSkipDirectory(dirName string) bool {
 	return fs.excludeDirs[dirName]
 }
 
+// skipWalkDir reports whether a search or watch must not enter the directory
+// dirName: it is excluded, or hidden and --hidden is off. Listings show hidden
+// entries by --show-hidden instead.
+func (fs *FileSearcher) skipWalkDir(dirName string) bool {
+	return fs.shouldSkipDirectory(dirName) || (!fs.hidden && isHiddenName(dirName))
+}
+
+// isHiddenName reports whether name is a dot-file or dot-directory
+func isHiddenName(name string) bool {
+	return strings.HasPrefix(name, ".") && name != "." && name != ".."
+}
+
+// setDirDefaults applies --hidden and --no-default-excludes. excludeDirs are
+// the --exclude-dirs values, which stay excluded either way.
+func (fs *FileSearcher) setDirDefaults(hidden, useDefaults bool, excludeDirs []string) {
+	fs.hidden = hidden
+	for _, dir := range defaultExcludeDirs {
+		if !useDefaults || (hidden && slices.Contains(editorDirs, dir)) {
+			delete(fs.excludeDirs, dir)
+		}
+	}
+	for _, dir := range excludeDirs {
+		fs.excludeDirs[dir] = true
+	}
+}
+
+// writeExcludes prints the directories the walk skips, for --verbose
+func (fs *FileSearcher) writeExcludes(w io.Writer) {
+	dirs := make([]string, 0, len(fs.excludeDirs))
+	for dir := range fs.excludeDirs {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	if len(dirs) == 0 {
+		dirs = []string{"(none)"}
+	}
+	fmt.Fprintf(w, "Excluded directories: %s\n", strings.Join(dirs, ", "))
+	roots := make([]string, 0, len(fs.ignoreFiles))
+	for root := range fs.ignoreFiles {
+		roots = append(roots, root)
+	}
+	sort.Strings(roots)
+	for _, root := range roots {
+		dirs := make([]string, 0, len(fs.ignoreFiles[root].dirs))
+		for dir := range fs.ignoreFiles[root].dirs {
+			dirs = append(dirs, dir)
+		}
+		if len(dirs) > 0 {
+			sort.Strings(dirs)
+			fmt.Fprintf(w, "Also excluded below %s (%s): %s\n", root, ignoreFileName, strings.Join(dirs, ", "))
+		}
+	}
+	if !fs.hidden {
+		fmt.Fprintln(w, "Hidden directories are skipped (use --hidden to search them)")
+	}
+}
+
+// warnf writes a warning or --verbose note to stderr, clearing the progress
+// line first when one is shown. The caller must not hold the output lock.
+func (fs *FileSearcher) warnf(format string, args ...any) {
+	if p := fs.progress; p != nil {
+		p.printf(format, args...)
+		return
+	}
+	fmt.Fprintf(os.Stderr, format, args...)
+}
+
 // shouldSkipFile checks if file should be skipped
 func (fs *FileSearcher) shouldSkipFile(fileName string) bool {
 	return fs.excludeFiles[fileName]
 }
 
-// searchInFile searches for keyword in a single file using a pre-compiled matcher
+// searchInFile searches for keyword in a single file using a pre-compiled
+// matcher, giving up on it after fs.fileTimeout
 func (fs *FileSearcher) searchInFile(filePath string, matcher *searchMatcher, multiline bool) []matchResult {
+	if fs.fileTimeout <= 0 {
+		return fs.readAndSearch(context.Background(), filePath, matcher, multiline)
+	}
+	if fs.abandonedReads.Load() >= maxAbandonedReads {
+		fs.timedOut.Add(1)
+		if !fs.suppressWarnings {
+			fs.warnf("Warning: Skipping %s: %d earlier reads are still stuck past --file-timeout\n", filePath, maxAbandonedReads)
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), fs.fileTimeout)
+	defer cancel()
+
+	const (
+		reading = iota
+		finished
+		abandoned
+	)
+	var state atomic.Int32
+	done := make(chan []matchResult, 1)
+	go func() {
+		done <- fs.readAndSearch(ctx, filePath, matcher, multiline)
+		if !state.CompareAndSwap(reading, finished) {
+			fs.abandonedReads.Add(-1)
+		}
+	}()
+
+	select {
+	case matches := <-done:
+		return matches
+	case <-ctx.Done():
+		if !state.CompareAndSwap(reading, abandoned) {
+			return <-done // finished just in time
+		}
+		fs.abandonedReads.Add(1)
+		fs.timedOut.Add(1)
+		if !fs.suppressWarnings {
+			fs.warnf("Warning: Gave up on %s after --file-timeout %s\n", filePath, fs.fileTimeout)
+		}
+		return nil
+	}
+}
+
+// readAndSearch opens filePath and searches it. A read that returns after ctx
+// is done stops the search, so an abandoned file that is merely slow is let go.
+func (fs *FileSearcher) readAndSearch(ctx context.Context, filePath string, matcher *searchMatcher, multiline bool) []matchResult {
 	file, err := os.Open(filePath)
 	if err != nil {
 		if !fs.suppressWarnings {
-			fmt.Fprintf(os.Stderr, "Warning: Could not read %s: %v\n", filePath, err)
+			fs.warnf("Warning: Could not read %s: %v\n", filePath, err)
 		}
 		return nil
 	}
 	defer file.Close()
 
+	var r io.Reader = file
+	if ctx.Done() != nil {
+		r = ctxReader{ctx, file}
+	}
+	if !multiline {
+		if size, ok := fs.readWhole(file); ok {
+			data, err := readSized(r, size)
+			if err != nil {
+				if !fs.suppressWarnings && !errors.Is(err, errAbandoned) {
+					fs.warnf("Warning: Error reading %s: %v\n", filePath, err)
+				}
+				return nil
+			}
+			return fs.searchBytes(filePath, data, matcher)
+		}
+	}
+	return fs.searchReader(filePath, r, matcher, multiline)
+}
+
+// readWhole reports whether file is to be read into memory at once under
+// fs.readMode, and its size. Only regular files qualify: a FIFO or device
+// has no size and may never end.
+func (fs *FileSearcher) readWhole(file *os.File) (int64, bool) {
+	if fs.readMode == readModeScan {
+		return 0, false
+	}
+	info, err := file.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return 0, false
+	}
+	return info.Size(), fs.readMode == readModeFull || info.Size() <= fullReadMaxSize
+}
+
+// readSized reads all of r, expected to hold size bytes, with a single
+// allocation unless the file grew in the meantime
+func readSized(r io.Reader, size int64) ([]byte, error) {
+	buf := make([]byte, size+1) // one spare byte tells a grown file from one of the expected size
+	n, err := io.ReadFull(r, buf)
+	switch err {
+	case io.EOF, io.ErrUnexpectedEOF:
+		return buf[:n], nil
+	case nil:
+		rest, err := io.ReadAll(r)
+		return append(buf, rest...), err
+	default:
+		return nil, err
+	}
+}
+
+// errAbandoned fails the reads of a file given up on after --file-timeout.
+// searchInFile has warned about the file already, so it is not reported as
+// a read error too.
+var errAbandoned = errors.New("read abandoned after --file-timeout")
+
+// ctxReader fails reads with errAbandoned once ctx is done
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr ctxReader) Read(p []byte) (int, error) {
+	if cr.ctx.Err() != nil {
+		return 0, errAbandoned
+	}
+	return cr.r.Read(p)
+}
+
+// searchReader searches the content of r; name is only used in warnings
+func (fs *FileSearcher) searchReader(name string, r io.Reader, matcher *searchMatcher, multiline bool) []matchResult {
 	if multiline {
-		return fs.searchInFileMultiline(filePath, file, matcher)
+		return fs.searchReaderMultiline(name, r, matcher)
 	}
 
-	// Binary file detection for --all mode (stack-allocated buffer)
+	reader := bufio.NewReader(r)
+
+	// Binary file detection for --all mode (peeked bytes stay in the buffer)
 	if fs.searchAll {
-		var preview [512]byte
-		n, err := file.Read(preview[:])
-		if err != nil && err != io.EOF {
+		preview, err := reader.Peek(512)
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
 			return nil
 		}
-		if bytes.IndexByte(preview[:n], 0) != -1 {
+		if bytes.IndexByte(preview, 0) != -1 {
 			return nil // binary file, skip
 		}
-		if _, err := file.Seek(0, io.SeekStart); err != nil {
-			return nil
+	}
+
+	scanner := bufio.NewScanner(reader)
+	// Minified files easily exceed the default 64 KB token limit
+	scanner.Buffer(make([]byte, 0, 64*1024), fs.lineLimit())
+	matches := fs.searchLines(name, matcher, func() (string, bool) {
+		if !scanner.Scan() {
+			return "", false
+		}
+		return scanner.Text(), true
+	})
+
+	if err := scanner.Err(); err != nil && !fs.suppressWarnings {
+		if errors.Is(err, bufio.ErrTooLong) {
+			fs.warnLineTooLong(name)
+		} else if !errors.Is(err, errAbandoned) {
+			fs.warnf("Warning: Error reading %s: %v\n", name, err)
 		}
 	}
 
-	var matches []matchResult
-	scanner := bufio.NewScanner(file)
-	lineNum := 1
+	return matches
+}
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		var matched bool
+// searchBytes searches data, the whole content of a file, line by line
+// exactly like searchReader: the lines are cut from a single string, so they
+// are not allocated one by one, but binary detection, CRLF handling and the
+// line length limit behave the same.
+func (fs *FileSearcher) searchBytes(name string, data []byte, matcher *searchMatcher) []matchResult {
+	if fs.searchAll && bytes.IndexByte(data[:min(len(data), 512)], 0) != -1 {
+		return nil // binary file, skip
+	}
 
-		if matcher.regex != nil {
-			matched = matcher.regex.MatchString(line)
-		} else if matcher.caseSensitive {
-			matched = strings.Contains(line, matcher.keyword)
+	content := string(data)
+	limit := fs.lineLimit()
+	tooLong := false
+	matches := fs.searchLines(name, matcher, func() (string, bool) {
+		if content == "" {
+			return "", false
+		}
+		line := content
+		if i := strings.IndexByte(content, '\n'); i >= 0 {
+			line, content = content[:i], content[i+1:]
 		} else {
-			matched = strings.Contains(strings.ToLower(line), matcher.lowerKeyword)
+			content = ""
 		}
-
-		if matched {
-			matches = append(matches, matchResult{lineNum, lineNum, line})
+		// bufio.Scanner gives up on a line that fills its whole buffer
+		if len(line) >= limit {
+			tooLong = true
+			return "", false
 		}
-		lineNum++
+		return strings.TrimSuffix(line, "\r"), true // as bufio.ScanLines does
+	})
+
+	if tooLong && !fs.suppressWarnings {
+		fs.warnLineTooLong(name)
 	}
+	// The lines are slices of content; copy the few that are kept, so buffered
+	// results (--sort, --output json) do not hold on to whole files
+	for i := range matches {
+		matches[i].content = strings.Clone(matches[i].content)
+		matches[i].scope = strings.Clone(matches[i].scope)
+	}
+	return matches
+}
 
-	if err := scanner.Err(); err != nil {
-		if !fs.suppressWarnings {
-			fmt.Fprintf(os.Stderr, "Warning: Error reading %s: %v\n", filePath, err)
-		}
+// lineLimit is the buffer size of the line scanner: lines of this many bytes
+// or more stop the search of their file
+func (fs *FileSearcher) lineLimit() int {
+	return max(fs.maxLineLength, 64*1024)
+}
+
+func (fs *FileSearcher) warnLineTooLong(name string) {
+	fs.warnf("Warning: %s has a line longer than %d bytes (see --max-line-length); the rest of the file was not searched\n", name, fs.maxLineLength)
+}
+
+// searchLines matches the lines returned by next, which reports false after
+// the last one, and returns the matches and context lines to report
+func (fs *FileSearcher) searchLines(name string, matcher *searchMatcher, next func() (string, bool)) []matchResult {
+	var matches []matchResult
+	lineNum := 1
+
+	// Context tracking: ring holds up to `before` lines preceding the current
+	// one that have not been emitted yet; afterLeft counts pending trailing lines.
+	var ring []string
+	if matcher.before > 0 {
+		ring = make([]string, matcher.before)
+	}
+	ringStart, ringLen := 0, 0
+	afterLeft := 0
+	lastEmitted := 0
+	found := 0 // non-context results, for --max-per-file
+	emit := func(num int, text string, isContext bool) {
+		matches = append(matches, matchResult{
+			lineNum:   num,
+			endLine:   num,
+			content:   text,
+			isContext: isContext,
+			newGroup:  lastEmitted == 0 || num != lastEmitted+1,
+		})
+		lastEmitted = num
+	}
+
+	// --show-scope: the last definition line seen, for languages with scopeRules
+	var scopes []*regexp.Regexp
+	if matcher.showScope {
+		scopes = scopeRulesFor(name)
 	}
+	scope := ""
+
+	lastLine := lastRangeLine(matcher.lineRanges)
+	for {
+		line, ok := next()
+		if !ok {
+			break
+		}
+		// Nothing past the last --line-range can match; stop once its trailing context is out
+		if lastLine > 0 && lineNum > lastLine && afterLeft == 0 {
+			break
+		}
+		if scopes != nil {
+			if name, ok := scopeName(scopes, line); ok {
+				scope = name
+			}
+		}
+		matched := matcher.matchLine(line)
+		if matcher.invert {
+			matched = !matched
+		}
+		if matched && matcher.lineRanges != nil && !inLineRanges(matcher.lineRanges, lineNum) {
+			matched = false
+		}
+		if matched && matcher.maxPerFile > 0 && found >= matcher.maxPerFile {
+			// Stop at the first match over the limit and flag the last reported one
+			for i := len(matches) - 1; i >= 0; i-- {
+				if !matches[i].isContext {
+					matches[i].truncated = true
+					break
+				}
+			}
+			break
+		}
 
+		switch {
+		case matched && matcher.onlyMatching:
+			last := 0
+			for _, span := range matcher.matchSpans(line) {
+				// Skip zero-width regex matches and overlaps between keywords
+				if span[0] >= last && span[0] < span[1] {
+					if matcher.maxPerFile > 0 && found >= matcher.maxPerFile {
+						matches[len(matches)-1].truncated = true
+						break
+					}
+					match := matchResult{lineNum: lineNum, endLine: lineNum, content: line[span[0]:span[1]], scope: scope}
+					if matcher.column {
+						match.column = span[0] + 1
+					}
+					matches = append(matches, match)
+					found++
+					last = span[1]
+				}
+			}
+		case matched:
+			// Overlapping context merges naturally: lines already emitted as
+			// trailing context never enter the ring, so nothing is repeated.
+			for i := 0; i < ringLen; i++ {
+				emit(lineNum-ringLen+i, ring[(ringStart+i)%len(ring)], true)
+			}
+			ringStart, ringLen = 0, 0
+			emit(lineNum, line, false)
+			matches[len(matches)-1].scope = scope
+			found++
+			if matcher.column && !matcher.invert {
+				if spans := matcher.matchSpans(line); len(spans) > 0 {
+					matches[len(matches)-1].column = spans[0][0] + 1
+				}
+			}
+			afterLeft = matcher.after
+		case afterLeft > 0:
+			emit(lineNum, line, true)
+			afterLeft--
+		case ring != nil:
+			if ringLen < len(ring) {
+				ring[(ringStart+ringLen)%len(ring)] = line
+				ringLen++
+			} else {
+				ring[ringStart] = line
+				ringStart = (ringStart + 1) % len(ring)
+			}
+		}
+		lineNum++
+	}
 	return matches
 }
 
-// searchInFileMultiline searches for multiline keyword in a single file
-func (fs *FileSearcher) searchInFileMultiline(filePath string, file *os.File, matcher *searchMatcher) []matchResult {
-	contentBytes, err := io.ReadAll(file)
+// searchReaderMultiline searches for a multiline keyword in the whole content of r
+func (fs *FileSearcher) searchReaderMultiline(name string, r io.Reader, matcher *searchMatcher) []matchResult {
+	contentBytes, err := io.ReadAll(r)
 	if err != nil {
-		if !fs.suppressWarnings {
-			fmt.Fprintf(os.Stderr, "Warning: Could not read %s: %v\n", filePath, err)
+		if !fs.suppressWarnings && !errors.Is(err, errAbandoned) {
+			fs.warnf("Warning: Could not read %s: %v\n", name, err)
 		}
 		return nil
 	}
@@ -238,41 +1118,23 @@ func (fs *FileSearcher) searchInFileMultiline(filePath string, file *os.File, ma
 	// Normalize Windows line endings to Unix line endings
 	content := strings.ReplaceAll(string(contentBytes), "\r\n", "\n")
 
-	type position struct {
-		start, end int
-	}
-	var foundPositions []position
-
-	if matcher.regex != nil {
-		for _, m := range matcher.regex.FindAllStringIndex(content, -1) {
-			foundPositions = append(foundPositions, position{m[0], m[1]})
-		}
-	} else {
-		searchContent := content
-		pattern := matcher.searchPattern
-		if !matcher.caseSensitive {
-			searchContent = strings.ToLower(content)
-			pattern = matcher.lowerPattern
-		}
-		patternLen := len(pattern)
-		idx := strings.Index(searchContent, pattern)
-		for idx != -1 {
-			foundPositions = append(foundPositions, position{idx, idx + patternLen})
-			nextStart := idx + patternLen
-			if nextStart >= len(searchContent) {
-				break
-			}
-			nextIdx := strings.Index(searchContent[nextStart:], pattern)
-			if nextIdx == -1 {
-				break
-			}
-			idx = nextStart + nextIdx
-		}
+	foundPositions := matcher.multilineSpans(content)
+	if len(matcher.extra) > 0 {
+		for _, p := range matcher.extra {
+			foundPositions = append(foundPositions, p.multilineSpans(content)...)
+		}
+		// Line numbers are computed incrementally, so spans must be in order
+		sort.SliceStable(foundPositions, func(i, j int) bool { return foundPositions[i].start < foundPositions[j].start })
 	}
 
 	if len(foundPositions) == 0 {
 		return nil
 	}
+	truncated := false
+	if matcher.maxPerFile > 0 && len(foundPositions) > matcher.maxPerFile {
+		foundPositions = foundPositions[:matcher.maxPerFile]
+		truncated = true
+	}
 
 	// Incremental line number calculation: O(n) total instead of O(n*m)
 	matches := make([]matchResult, 0, len(foundPositions))
@@ -282,48 +1144,475 @@ func (fs *FileSearcher) searchInFileMultiline(filePath string, file *os.File, ma
 		lastLine += strings.Count(content[lastPos:pos.start], "\n")
 		startLineNum := lastLine
 		endLineNum := startLineNum + strings.Count(content[pos.start:pos.end], "\n")
-		matchedContent := strings.ReplaceAll(content[pos.start:pos.end], "\n", "\\n")
-		matches = append(matches, matchResult{startLineNum, endLineNum, matchedContent})
+		match := matchResult{lineNum: startLineNum, endLine: endLineNum, content: content[pos.start:pos.end]}
+		if matcher.column {
+			match.column = pos.start - strings.LastIndexByte(content[:pos.start], '\n')
+		}
+		matches = append(matches, match)
 		lastPos = pos.start
 	}
+	matches[len(matches)-1].truncated = truncated
 
 	return matches
 }
 
-// grepRecursive recursively searches for keyword in files using parallel workers
-func (fs *FileSearcher) grepRecursive(rootDir, keyword string, useRegex, multiline bool, showLineNumbers, showFilePath bool, maxResults *int) int {
-	info, err := os.Stat(rootDir)
-	if err != nil {
-		if !fs.suppressWarnings {
-			fmt.Fprintf(os.Stderr, "Error: Directory does not exist: %s\n", rootDir)
+// multilineSpans returns every match of the multiline keyword in content
+func (sm *searchMatcher) multilineSpans(content string) []textSpan {
+	var spans []textSpan
+
+	if sm.regex != nil {
+		for _, m := range sm.regex.FindAllStringIndex(content, -1) {
+			spans = append(spans, textSpan{m[0], m[1]})
 		}
-		return 0
+		return spans
 	}
 
-	if !info.IsDir() {
-		if !fs.suppressWarnings {
-			fmt.Fprintf(os.Stderr, "Error: Path is not a directory: %s\n", rootDir)
+	searchContent := content
+	pattern := sm.searchPattern
+	if !sm.caseSensitive {
+		searchContent = strings.ToLower(content)
+		pattern = sm.lowerPattern
+	}
+	patternLen := len(pattern)
+	idx := strings.Index(searchContent, pattern)
+	for idx != -1 {
+		nextStart := idx + patternLen
+		if sm.wholeWord && !isWholeWord(searchContent, idx, nextStart) {
+			nextStart = idx + 1 // retry just past a rejected candidate
+		} else {
+			spans = append(spans, textSpan{idx, idx + patternLen})
+		}
+		if nextStart >= len(searchContent) {
+			break
+		}
+		nextIdx := strings.Index(searchContent[nextStart:], pattern)
+		if nextIdx == -1 {
+			break
+		}
+		idx = nextStart + nextIdx
+	}
+	return spans
+}
+
+// grepRecursive searches for keywords in roots using parallel workers.
+// Directories are walked recursively, a file root is searched as is (whatever
+// its extension) and a root of "-" searches stdin.
+// With several keywords a line matches when any of them does (or all of them,
+// with matchAll). The patterns are compiled once up front; an invalid regex is returned as an
+// error before any file is read.
+func (fs *FileSearcher) grepRecursive(roots []string, keywords []string, opts searchOptions) (int, error) {
+	var matcher *searchMatcher
+	for _, keyword := range keywords {
+		sm, err := newSearchMatcher(keyword, opts.useRegex, fs.caseSensitive, opts.multiline, opts.wholeWord)
+		if err != nil {
+			return 0, fmt.Errorf("invalid regex pattern: %w", err)
+		}
+		if opts.fuzzy {
+			sm.setFuzzy(opts.fuzzyDistance)
+		}
+		if matcher == nil {
+			matcher = sm
+		} else {
+			matcher.extra = append(matcher.extra, sm)
 		}
-		return 0
 	}
+	matcher.matchAll = opts.matchAll
+	matcher.column = opts.column
+	matcher.invert = opts.invertMatch
+	matcher.before = opts.before
+	matcher.after = opts.after
+	matcher.onlyMatching = opts.onlyMatching
+	matcher.maxPerFile = opts.maxPerFile
+	matcher.lineRanges = opts.lineRanges
+	matcher.showScope = opts.showScope
+	showContext := opts.before > 0 || opts.after > 0
 
-	// Pre-compile search matcher once (regex + lowercase keyword)
-	matcher, err := newSearchMatcher(keyword, useRegex, fs.caseSensitive, multiline)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: Invalid regex pattern: %v\n", err)
-		return 0
+	var dedupe *dedupeSet // guarded by mu
+	if opts.dedupe {
+		dedupe = newDedupeSet()
+	}
+
+	var kwStats []*keywordStat
+	if opts.keywordStats && opts.filter == nil && len(keywords) > 1 && len(keywords) <= maxKeywordStats {
+		kwStats = newKeywordStats(matcher)
 	}
 
 	// Buffered output to reduce syscalls
-	out := bufio.NewWriterSize(os.Stdout, 64*1024)
-	defer out.Flush()
+	var dest io.Writer = os.Stdout
+	if fs.out != nil {
+		dest = fs.out
+	}
+	out := bufio.NewWriterSize(dest, 64*1024)
 
-	// Parallel search with worker pool
-	numWorkers := runtime.NumCPU()
-	paths := make(chan string, numWorkers*4)
 	var totalMatches atomic.Int64
+	var filesScanned atomic.Int64
 	var maxReached atomic.Bool
+
+	// flush pushes out what was written so far, so matches show up file by
+	// file when stdout is a pipe. Once the reader is gone nothing more can be
+	// shown, and the search stops as if --max-results had been reached. Any
+	// other write error stops it too, and is returned.
+	fs.brokenPipe.Store(false)
+	var writeErr error // first failed write other than a broken pipe (guarded by mu)
+	flush := func() {
+		err := out.Flush()
+		switch {
+		case err == nil:
+		case isBrokenPipe(err):
+			fs.brokenPipe.Store(true)
+			maxReached.Store(true)
+		default:
+			if writeErr == nil {
+				writeErr = err
+			}
+			maxReached.Store(true)
+		}
+	}
+	defer flush()
+	var groupWritten bool          // a context group has been written (guarded by mu)
+	collected := []jsonMatch{}     // --output json buffer (guarded by mu)
+	var filesMatched int           // files with at least one counted match (guarded by mu)
+	var headersWritten int         // --group file headers written so far (guarded by mu)
+	perDir := map[string]int{}     // --max-per-dir matches reported per directory (guarded by mu)
+	suppressed := map[string]int{} // --max-per-dir matches hidden per directory (guarded by mu)
 	var mu sync.Mutex
+
+	stopProgress := func() {}
+	if fs.showProgress {
+		fs.progress = newProgressLine(os.Stderr, &mu, terminal.Width(os.Stderr), &filesScanned, &totalMatches)
+		stop := fs.progress.start()
+		stopProgress = func() {
+			if fs.progress != nil {
+				stop()
+				fs.progress = nil
+			}
+		}
+		defer stopProgress()
+	}
+
+	// report writes the matches of one file in the selected output mode
+	report := func(path string, matches []matchResult) {
+		if len(matches) == 0 && !(opts.count && opts.includeZeros) && opts.filter == nil {
+			return
+		}
+		// Keywords are told apart before taking the lock, while other files are read
+		var hits, reportedHits []uint64
+		if kwStats != nil {
+			hits = keywordHits(kwStats, matches, opts.multiline)
+			defer func() { recordKeywordHits(kwStats, reportedHits) }()
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if fs.brokenPipe.Load() {
+			return
+		}
+		if fs.progress != nil {
+			// Keep the progress line out of the way of the results
+			fs.progress.clear()
+		}
+		defer flush()
+		if opts.filter != nil {
+			matches = opts.filter(path, matches)
+		}
+		if opts.count {
+			n := len(matches)
+			if opts.maxResults > 0 && int(totalMatches.Load())+n >= opts.maxResults {
+				n = max(opts.maxResults-int(totalMatches.Load()), 0)
+				maxReached.Store(true)
+			}
+			if n > 0 || opts.includeZeros {
+				writeCount(out, path, n, opts)
+			}
+			if n > 0 {
+				filesMatched++
+				if opts.reported != nil {
+					opts.reported(path, matches[:n])
+				}
+			}
+			totalMatches.Add(int64(n))
+			return
+		}
+		dir := ""
+		if opts.maxPerDir > 0 {
+			dir = topDir(roots, path)
+		}
+		keep, cut := selectReported(matches, int(totalMatches.Load()), dir, perDir, suppressed, opts)
+		if cut < len(matches) {
+			maxReached.Store(true)
+		}
+		headerWritten := false
+		var written []matchResult
+		if opts.reported != nil {
+			defer func() { opts.reported(path, written) }()
+		}
+		for i, match := range matches[:cut] {
+			if !keep[i] {
+				continue
+			}
+
+			// The header is written lazily so a file cut off by --max-results gets none
+			if opts.group && !headerWritten && opts.showFilePath {
+				if headersWritten > 0 {
+					out.WriteByte('\n')
+				}
+				writeColored(out, path, colorPath, opts.color)
+				out.WriteByte('\n')
+				headersWritten++
+				headerWritten = true
+				groupWritten = false // the header already separates this file's blocks
+			}
+
+			record := jsonMatch{Path: path, Line: match.lineNum, EndLine: match.endLine, Content: match.content, Context: match.isContext, Column: match.column, More: match.truncated, Scope: match.scope}
+			if opts.jsonDocument {
+				collected = append(collected, record)
+			} else if opts.jsonOutput {
+				writeJSONLine(out, record)
+			} else {
+				if showContext && match.newGroup {
+					if groupWritten {
+						out.WriteString("--\n")
+					}
+					groupWritten = true
+				}
+				if dedupe == nil || match.isContext || !dedupe.add(path, match) {
+					writeTextMatch(out, path, match, matcher, opts)
+				}
+			}
+			if !match.isContext {
+				totalMatches.Add(1)
+				if opts.reported != nil {
+					written = append(written, match)
+				}
+				if hits != nil {
+					reportedHits = append(reportedHits, hits[i])
+				}
+				if opts.first {
+					// Stop the walk now rather than when the next match turns up;
+					// the rest of this file's matches are only trailing context
+					maxReached.Store(true)
+				}
+			}
+		}
+	}
+
+	// search reads every root in turn, handing each file's matches to emit
+	search := func(emit func(string, []matchResult)) {
+		for _, root := range roots {
+			if maxReached.Load() {
+				return
+			}
+			if root == stdinPath {
+				// A stream has no path of its own: only --label puts one in the output
+				name := opts.label
+				if name == "" {
+					name = stdinPath
+					if len(roots) == 1 {
+						opts.showFilePath = false
+					}
+				}
+				matches := fs.searchReader(name, fs.stdin, matcher, opts.multiline)
+				filesScanned.Add(1)
+				emit(name, matches)
+				continue
+			}
+
+			info, err := os.Stat(root)
+			if err != nil {
+				if !fs.suppressWarnings {
+					fs.warnf("Error: Path does not exist: %s\n", root)
+				}
+				continue
+			}
+			if !info.IsDir() {
+				if fs.progress != nil {
+					fs.progress.setCurrent(root)
+				}
+				var matches []matchResult
+				if info.Mode().IsRegular() {
+					matches = fs.searchInFile(root, matcher, opts.multiline)
+				} else {
+					// A FIFO named explicitly, e.g. <(cmd), is read like stdin: for as long as it takes
+					matches = fs.readAndSearch(context.Background(), root, matcher, opts.multiline)
+				}
+				filesScanned.Add(1)
+				emit(root, matches)
+				continue
+			}
+			fs.walkAndSearch(root, matcher, opts.multiline, &filesScanned, &maxReached, emit)
+		}
+	}
+
+	if !opts.sorted {
+		search(report)
+		stopProgress()
+	} else {
+		// Nothing is reported during the walk, so --max-results cannot stop
+		// it early; the limit applies when the sorted results are replayed
+		type fileMatches struct {
+			path    string
+			matches []matchResult
+		}
+		var buffered []fileMatches
+		var bufferMu sync.Mutex
+		search(func(path string, matches []matchResult) {
+			bufferMu.Lock()
+			buffered = append(buffered, fileMatches{path, matches})
+			bufferMu.Unlock()
+		})
+		stopProgress()
+		sort.Slice(buffered, func(i, j int) bool { return buffered[i].path < buffered[j].path })
+		for _, f := range buffered {
+			report(f.path, f.matches)
+		}
+	}
+	fs.filesScanned = filesScanned.Load()
+
+	if dedupe != nil {
+		dedupe.write(out, matcher, opts)
+		if dedupe.overflow > 0 && !fs.suppressWarnings {
+			flush()
+			fmt.Fprintf(os.Stderr, "Warning: --dedupe groups at most %d distinct lines; %d more were printed as found\n", maxDedupeLines, dedupe.overflow)
+		}
+	}
+
+	if len(suppressed) > 0 {
+		dirs := make([]string, 0, len(suppressed))
+		for dir := range suppressed {
+			dirs = append(dirs, dir)
+		}
+		sort.Strings(dirs)
+		for _, dir := range dirs {
+			// Keep stdout pure JSON in the structured modes
+			if opts.jsonOutput || opts.jsonDocument {
+				fmt.Fprintf(os.Stderr, dirSuppressedNote, suppressed[dir], dir)
+			} else {
+				fmt.Fprintf(out, dirSuppressedNote, suppressed[dir], dir)
+			}
+		}
+	}
+
+	if opts.count {
+		fmt.Fprintf(out, "Total: %d matches in %d files\n", totalMatches.Load(), filesMatched)
+	}
+	if kwStats != nil && !opts.jsonOutput && !opts.jsonDocument && !opts.count {
+		writeKeywordTable(out, kwStats)
+	}
+	if opts.jsonOutput {
+		writeJSONLine(out, jsonSummary{TotalMatches: totalMatches.Load(), FilesScanned: filesScanned.Load(), Keywords: jsonKeywordStats(kwStats)})
+	}
+	if opts.jsonDocument {
+		// Buffered output can be ordered, unlike the streamed modes
+		sort.SliceStable(collected, func(i, j int) bool {
+			if collected[i].Path != collected[j].Path {
+				return collected[i].Path < collected[j].Path
+			}
+			return collected[i].Line < collected[j].Line
+		})
+		writeJSONLine(out, jsonDocument{
+			Matches:      collected,
+			TotalMatches: totalMatches.Load(),
+			FilesScanned: filesScanned.Load(),
+			Truncated:    maxReached.Load(),
+			Keywords:     jsonKeywordStats(kwStats),
+		})
+	}
+
+	flush()
+	if writeErr != nil {
+		return int(totalMatches.Load()), fmt.Errorf("writing results: %w", writeErr)
+	}
+	return int(totalMatches.Load()), nil
+}
+
+// isBrokenPipe reports whether err comes from writing to a pipe whose reader
+// has exited
+func isBrokenPipe(err error) bool {
+	if errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	// ERROR_BROKEN_PIPE and ERROR_NO_DATA
+	var errno syscall.Errno
+	return runtime.GOOS == "windows" && errors.As(err, &errno) && (errno == 109 || errno == 232)
+}
+
+// selectReported decides which of a file's matches are written, before any
+// is: matches past --max-results (reported counts those already written)
+// and over the --max-per-dir limit of dir are dropped, and so are context
+// lines that are not within --before/--after of a kept match. cut is the
+// index of the first match over --max-results, or len(matches); nothing
+// from there on is written.
+func selectReported(matches []matchResult, reported int, dir string, perDir, suppressed map[string]int, opts searchOptions) (keep []bool, cut int) {
+	keep = make([]bool, len(matches))
+	cut = len(matches)
+	for i, m := range matches {
+		if m.isContext {
+			continue
+		}
+		if opts.maxResults > 0 && reported >= opts.maxResults {
+			cut = i
+			break
+		}
+		if dir != "" {
+			if perDir[dir] >= opts.maxPerDir {
+				suppressed[dir]++
+				continue
+			}
+			perDir[dir]++
+		}
+		keep[i] = true
+		reported++
+	}
+
+	// Context lines go with the nearest kept match on either side
+	prevEnd := -1 // last line of the previous kept match; -1 for none
+	for i, m := range matches[:cut] {
+		if !m.isContext {
+			if keep[i] {
+				prevEnd = max(m.lineNum, m.endLine)
+			}
+			continue
+		}
+		if prevEnd >= 0 && m.lineNum <= prevEnd+opts.after {
+			keep[i] = true
+		}
+	}
+	nextStart := -1 // first line of the next kept match; -1 for none
+	for i := cut - 1; i >= 0; i-- {
+		m := matches[i]
+		if !m.isContext {
+			if keep[i] {
+				nextStart = m.lineNum
+			}
+			continue
+		}
+		if nextStart >= 0 && m.lineNum >= nextStart-opts.before {
+			keep[i] = true
+		}
+	}
+	return keep, cut
+}
+
+// topDir returns the immediate subdirectory of a directory in roots that
+// contains path, or "" for a file directly inside a root (or a root itself)
+func topDir(roots []string, path string) string {
+	for _, root := range roots {
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if i := strings.IndexRune(rel, filepath.Separator); i >= 0 {
+			return filepath.Join(root, rel[:i])
+		}
+		return ""
+	}
+	return ""
+}
+
+// walkAndSearch walks rootDir and searches every eligible file with a pool of
+// workers, handing each file's matches to report
+func (fs *FileSearcher) walkAndSearch(rootDir string, matcher *searchMatcher, multiline bool, filesScanned *atomic.Int64, maxReached *atomic.Bool, report func(string, []matchResult)) {
+	numWorkers := runtime.NumCPU()
+	paths := make(chan string, numWorkers*4)
 	var wg sync.WaitGroup
 
 	for i := 0; i < numWorkers; i++ {
@@ -335,94 +1624,349 @@ func (fs *FileSearcher) grepRecursive(rootDir, keyword string, useRegex, multili
 					continue // drain channel
 				}
 
-				matches := fs.searchInFile(path, matcher, multiline)
-				if len(matches) == 0 {
-					continue
+				if fs.progress != nil {
+					fs.progress.setCurrent(path)
 				}
+				matches := fs.searchInFile(path, matcher, multiline)
+				filesScanned.Add(1)
+				report(path, matches)
+			}
+		}()
+	}
 
-				mu.Lock()
-				for _, match := range matches {
-					if maxResults != nil && int(totalMatches.Load()) >= *maxResults {
-						maxReached.Store(true)
-						break
+	var ignore *gitignore.Matcher
+	if fs.respectGitignore {
+		ignore = gitignore.New(rootDir)
+	}
+	rules := fs.ignoreFiles[rootDir]
+
+	// Real paths of the directories walked so far; with --follow-symlinks a
+	// link back to one of them would otherwise loop forever
+	visited := make(map[string]bool)
+
+	// walk searches the tree at realDir, reporting its paths below shownDir:
+	// the two differ when realDir was reached through a symlink
+	var walk func(realDir, shownDir string)
+	walk = func(realDir, shownDir string) {
+		filepath.WalkDir(realDir, func(realPath string, d os.DirEntry, err error) error {
+			// WalkDir cleans the paths below an unclean root such as "." or
+			// "src/", so only a symlinked directory's paths are rewritten
+			path := realPath
+			if realDir != shownDir {
+				rel, _ := filepath.Rel(realDir, realPath)
+				path = filepath.Join(shownDir, rel)
+			}
+			if err != nil {
+				if os.IsPermission(err) {
+					if !fs.suppressWarnings {
+						fs.warnf("Warning: Permission denied: %s\n", path)
 					}
+					return nil
+				}
+				if !fs.suppressWarnings {
+					fs.warnf("Warning: Error accessing %s: %v\n", path, err)
+				}
+				return nil
+			}
 
-					if showFilePath {
-						out.WriteString(path)
-						out.WriteByte(':')
+			if maxReached.Load() {
+				return filepath.SkipAll
+			}
+
+			if d.IsDir() {
+				if path != rootDir {
+					if fs.skipWalkDir(d.Name()) || rules.skipDir(d.Name()) {
+						return filepath.SkipDir
+					}
+					// Ignored directories are pruned outright, so --include cannot reach into them
+					if ignore != nil && ignore.Ignored(path, true) {
+						return filepath.SkipDir
+					}
+					if fs.tooDeep(rootDir, path) {
+						fs.skippedForDepth.Add(1)
+						return filepath.SkipDir
 					}
-					if showLineNumbers {
-						if multiline && match.lineNum != match.endLine {
-							out.WriteString(strconv.Itoa(match.lineNum))
-							out.WriteString("..")
-							out.WriteString(strconv.Itoa(match.endLine))
-						} else {
-							out.WriteString(strconv.Itoa(match.lineNum))
+				}
+				if fs.followSymlinks {
+					visited[realPath] = true
+				}
+				return nil
+			}
+
+			if fs.followSymlinks && d.Type()&os.ModeSymlink != 0 {
+				if target, err := filepath.EvalSymlinks(realPath); err == nil {
+					if info, err := os.Stat(target); err == nil && info.IsDir() {
+						if fs.skipWalkDir(d.Name()) || rules.skipDir(d.Name()) || (ignore != nil && ignore.Ignored(path, true)) {
+							return nil
+						}
+						if fs.tooDeep(rootDir, path) {
+							fs.skippedForDepth.Add(1)
+							return nil
 						}
-						out.WriteByte(':')
+						if visited[target] {
+							if fs.verbose {
+								fs.warnf("Skipping %s: symlink loop back to %s\n", path, target)
+							}
+							return nil
+						}
+						walk(target, path)
+						return nil
 					}
-					out.WriteString(match.content)
-					out.WriteByte('\n')
-					totalMatches.Add(1)
 				}
-				mu.Unlock()
 			}
-		}()
-	}
 
-	// Walk directory tree and dispatch file paths to workers
-	filepath.WalkDir(rootDir, func(path string, d os.DirEntry, err error) error {
-		if err != nil {
-			if os.IsPermission(err) {
-				if !fs.suppressWarnings {
-					fmt.Fprintf(os.Stderr, "Warning: Permission denied: %s\n", path)
+			if fs.shouldSkipFile(d.Name()) || rules.skipFile(d.Name()) {
+				return nil
+			}
+
+			special := d.Type()&specialFileTypes != 0
+			if d.Type()&os.ModeSymlink != 0 {
+				if info, err := os.Stat(realPath); err == nil {
+					special = info.Mode()&specialFileTypes != 0
 				}
+			}
+			if special {
+				if fs.verbose {
+					fs.warnf("Skipping %s: not a regular file\n", path)
+				}
+				return nil
+			}
+
+			if !fs.shouldSearchFile(path, ignore, rules) {
 				return nil
 			}
-			if !fs.suppressWarnings {
-				fmt.Fprintf(os.Stderr, "Warning: Error accessing %s: %v\n", path, err)
+
+			if fs.maxFileSize > 0 {
+				if info, err := d.Info(); err == nil && info.Size() > fs.maxFileSize {
+					fs.skippedForSize.Add(1)
+					if fs.verbose {
+						fs.warnf("Skipping %s: %d bytes exceeds --max-file-size\n", path, info.Size())
+					}
+					return nil
+				}
 			}
+
+			paths <- path
 			return nil
+		})
+	}
+
+	// Walk directory tree and dispatch file paths to workers
+	realRoot := rootDir
+	if fs.followSymlinks {
+		if resolved, err := filepath.EvalSymlinks(rootDir); err == nil {
+			realRoot = resolved
 		}
+	}
+	walk(realRoot, rootDir)
+	close(paths)
+	wg.Wait()
+}
+
+// tooDeep reports whether the files of directory dir lie below --max-depth.
+// Files directly inside rootDir have depth 0.
+func (fs *FileSearcher) tooDeep(rootDir, dir string) bool {
+	if fs.maxDepth < 0 {
+		return false
+	}
+	rel, err := filepath.Rel(rootDir, dir)
+	if err != nil {
+		return false
+	}
+	return strings.Count(rel, string(filepath.Separator))+1 > fs.maxDepth
+}
 
-		if maxReached.Load() {
-			return filepath.SkipAll
+// writeTextMatch writes a match in the "path:line[:column]:content" text format,
+// or as an indented "  line: content" row below a --group header
+func writeTextMatch(out *bufio.Writer, path string, match matchResult, matcher *searchMatcher, opts searchOptions) {
+	// grep convention: ':' after the prefix of matches, '-' for context lines
+	sep := byte(':')
+	if match.isContext {
+		sep = '-'
+	}
+	prefixWidth := 0
+	if opts.group {
+		out.WriteString("  ")
+		prefixWidth += 2
+	} else if opts.showFilePath {
+		writeColored(out, path, colorPath, opts.color)
+		out.WriteByte(sep)
+		prefixWidth += utf8.RuneCountInString(path) + 1
+	}
+	if opts.showLineNumbers {
+		lineNo := strconv.Itoa(match.lineNum)
+		if opts.multiline && match.lineNum != match.endLine {
+			lineNo += ".." + strconv.Itoa(match.endLine)
+		}
+		writeColored(out, lineNo, colorLineNo, opts.color)
+		out.WriteByte(sep)
+		prefixWidth += len(lineNo) + 1
+	}
+	if opts.column && match.column > 0 {
+		col := strconv.Itoa(match.column)
+		out.WriteString(col)
+		out.WriteByte(sep)
+		prefixWidth += len(col) + 1
+	}
+	if opts.group && (opts.showLineNumbers || opts.column) {
+		out.WriteByte(' ')
+		prefixWidth++
+	}
+	if match.scope != "" {
+		writeColored(out, "["+match.scope+"]", colorScope, opts.color)
+		out.WriteByte(' ')
+		prefixWidth += utf8.RuneCountInString(match.scope) + 3
+	}
+	if (opts.multiline || opts.onlyMatching) && !match.isContext {
+		// The whole content is the match
+		writeColored(out, strings.ReplaceAll(match.content, "\n", "\\n"), colorMatch, opts.color)
+		if match.truncated {
+			out.WriteString(suppressedNote)
 		}
+		out.WriteByte('\n')
+		return
+	}
 
-		if d.IsDir() {
-			if fs.shouldSkipDirectory(d.Name()) {
-				return filepath.SkipDir
-			}
-			return nil
+	content := match.content
+	var spans [][]int
+	if !match.isContext && !opts.invertMatch && (opts.color || opts.displayWidth > 0) {
+		spans = matcher.matchSpans(content)
+	}
+	if opts.displayWidth > 0 {
+		content, spans = truncateLine(content, spans, max(opts.displayWidth-prefixWidth, minDisplayWidth))
+	}
+	if opts.color {
+		writeHighlighted(out, content, spans)
+	} else {
+		out.WriteString(content)
+	}
+	if match.truncated {
+		out.WriteString(suppressedNote)
+	}
+	out.WriteByte('\n')
+}
+
+// truncateLine shortens line to at most width runes for display, marking cut
+// ends with an ellipsis. When the first match would fall outside the visible
+// part, the window starts shortly before it. Spans are shifted and clipped to
+// the returned text.
+func truncateLine(line string, spans [][]int, width int) (string, [][]int) {
+	if utf8.RuneCountInString(line) <= width {
+		return line, spans
+	}
+	const ellipsis = "…"
+
+	start := 0
+	if len(spans) > 0 && utf8.RuneCountInString(line[:spans[0][1]]) > width-1 {
+		start = spans[0][0]
+		for back := width / 4; back > 0 && start > 0; back-- {
+			_, size := utf8.DecodeLastRuneInString(line[:start])
+			start -= size
 		}
+	}
 
-		if fs.shouldSkipFile(d.Name()) {
-			return nil
+	prefix := ""
+	avail := width - 1 // room for the trailing ellipsis
+	if start > 0 {
+		prefix = ellipsis
+		avail--
+	}
+	end := start
+	for n := 0; n < avail && end < len(line); n++ {
+		_, size := utf8.DecodeRuneInString(line[end:])
+		end += size
+	}
+	suffix := ""
+	if end < len(line) {
+		suffix = ellipsis
+	}
+
+	shifted := make([][]int, 0, len(spans))
+	for _, span := range spans {
+		a, b := max(span[0], start), min(span[1], end)
+		if a >= b {
+			continue
 		}
+		clipped := append([]int{a - start + len(prefix), b - start + len(prefix)}, span[2:]...)
+		shifted = append(shifted, clipped)
+	}
+	return prefix + line[start:end] + suffix, shifted
+}
 
-		if !fs.isTextFile(path) {
-			return nil
+// writeCount writes the "path:N" line of --count mode
+func writeCount(out *bufio.Writer, path string, n int, opts searchOptions) {
+	if opts.showFilePath {
+		writeColored(out, path, colorPath, opts.color)
+		out.WriteByte(':')
+	}
+	out.WriteString(strconv.Itoa(n))
+	out.WriteByte('\n')
+}
+
+// writeColored writes text wrapped in the given color when enabled
+func writeColored(out *bufio.Writer, text, color string, enabled bool) {
+	if !enabled {
+		out.WriteString(text)
+		return
+	}
+	out.WriteString(color)
+	out.WriteString(text)
+	out.WriteString(colorReset)
+}
+
+// writeHighlighted writes line with each span wrapped in its keyword's match color
+func writeHighlighted(out *bufio.Writer, line string, spans [][]int) {
+	last := 0
+	for _, span := range spans {
+		if span[0] < last || span[0] == span[1] {
+			continue // skip overlapping or empty (zero-width regex) matches
 		}
+		color := colorMatch
+		if len(span) > 2 {
+			color = patternColors[span[2]%len(patternColors)]
+		}
+		out.WriteString(line[last:span[0]])
+		writeColored(out, line[span[0]:span[1]], color, true)
+		last = span[1]
+	}
+	out.WriteString(line[last:])
+}
 
-		paths <- path
-		return nil
-	})
-	close(paths)
-	wg.Wait()
+// writeJSONLine writes v as a single NDJSON line
+func writeJSONLine(out *bufio.Writer, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	out.Write(data)
+	out.WriteByte('\n')
+}
 
-	return int(totalMatches.Load())
+// listOptions controls the --list output
+type listOptions struct {
+	showHidden bool
+	tree       bool // recurse into subdirectories, drawn as a tree (--tree)
+	dirsOnly   bool // leave files out (--dirs-only)
 }
 
-// listDirectoryContents lists directory contents
-func (fs *FileSearcher) listDirectoryContents(path string, showHidden bool) error {
+// listDirectoryContents lists directory contents, or the whole tree below
+// path with opts.tree
+func (fs *FileSearcher) listDirectoryContents(path string, opts listOptions) error {
 	entries, err := os.ReadDir(path)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		return err
 	}
+	if opts.tree {
+		fs.listTree(path, entries, opts)
+		return nil
+	}
 
 	for _, entry := range entries {
-		if !showHidden && strings.HasPrefix(entry.Name(), ".") {
+		if !opts.showHidden && strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if opts.dirsOnly && !entry.IsDir() {
 			continue
 		}
 
@@ -438,7 +1982,7 @@ func (fs *FileSearcher) listDirectoryContents(path string, showHidden bool) erro
 
 		sizeStr := ""
 		if entryType == "file" {
-			sizeStr = fmt.Sprintf(" (%d bytes)", info.Size())
+			sizeStr = fmt.Sprintf(" (%s)", utils.FormatSize(info.Size()))
 		}
 
 		fmt.Printf("%10s %s%s\n", entryType, entry.Name(), sizeStr)
@@ -446,3 +1990,69 @@ func (fs *FileSearcher) listDirectoryContents(path string, showHidden bool) erro
 
 	return nil
 }
+
+// listTree prints root and everything below it with box-drawing characters,
+// then the number of directories and files shown. entries are root's own.
+// Excluded and (without showHidden) hidden directories are left out, and
+// directories deeper than --max-depth are shown but not expanded.
+func (fs *FileSearcher) listTree(root string, entries []os.DirEntry, opts listOptions) {
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	var dirs, files int
+	// walk prints the entries of dir, which lies depth levels below root
+	var walk func(dir string, entries []os.DirEntry, prefix string, depth int)
+	walk = func(dir string, entries []os.DirEntry, prefix string, depth int) {
+		shown := entries[:0:0]
+		for _, entry := range entries {
+			name := entry.Name()
+			switch {
+			case !opts.showHidden && strings.HasPrefix(name, "."):
+			case entry.IsDir() && fs.shouldSkipDirectory(name):
+			case opts.dirsOnly && !entry.IsDir():
+			default:
+				shown = append(shown, entry)
+			}
+		}
+
+		for i, entry := range shown {
+			branch, indent := "├── ", "│   "
+			if i == len(shown)-1 {
+				branch, indent = "└── ", "    "
+			}
+			name := entry.Name()
+			if !entry.IsDir() {
+				files++
+				size := ""
+				if info, err := entry.Info(); err == nil {
+					size = " (" + utils.FormatSize(info.Size()) + ")"
+				}
+				fmt.Fprintf(out, "%s%s%s%s\n", prefix, branch, name, size)
+				continue
+			}
+
+			dirs++
+			fmt.Fprintf(out, "%s%s%s/\n", prefix, branch, name)
+			if fs.maxDepth >= 0 && depth >= fs.maxDepth {
+				continue
+			}
+			path := filepath.Join(dir, name)
+			children, err := os.ReadDir(path)
+			if err != nil {
+				if !fs.suppressWarnings {
+					fmt.Fprintf(os.Stderr, "Warning: Could not read %s: %v\n", path, err)
+				}
+				continue
+			}
+			walk(path, children, prefix+indent, depth+1)
+		}
+	}
+
+	fmt.Fprintln(out, root)
+	walk(root, entries, "", 0)
+	if opts.dirsOnly {
+		fmt.Fprintf(out, "\n%d directories\n", dirs)
+	} else {
+		fmt.Fprintf(out, "\n%d directories, %d files\n", dirs, files)
+	}
+}
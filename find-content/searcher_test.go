@@ -0,0 +1,1582 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestGrepRecursiveJSONOutput(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.txt"), "alpha\nneedle: one\nbeta\n")
+	writeFile(t, filepath.Join(root, "b.go"), "package b\n")
+
+	fs := NewFileSearcher(false, true, false, nil, nil, nil)
+	output, matches := runGrep(t, fs, root, "needle", searchOptions{jsonOutput: true})
+
+	if matches != 1 {
+		t.Fatalf("matches = %d, want 1", matches)
+	}
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d NDJSON lines, want 2:\n%s", len(lines), output)
+	}
+
+	var match jsonMatch
+	if err := json.Unmarshal([]byte(lines[0]), &match); err != nil {
+		t.Fatalf("invalid match line %q: %v", lines[0], err)
+	}
+	want := jsonMatch{Path: filepath.Join(root, "a.txt"), Line: 2, EndLine: 2, Content: "needle: one"}
+	if match != want {
+		t.Fatalf("match = %#v, want %#v", match, want)
+	}
+
+	var summary jsonSummary
+	if err := json.Unmarshal([]byte(lines[1]), &summary); err != nil {
+		t.Fatalf("invalid summary line %q: %v", lines[1], err)
+	}
+	if summary.TotalMatches != 1 || summary.FilesScanned != 2 {
+		t.Fatalf("summary = %#v, want 1 match across 2 files", summary)
+	}
+}
+
+func TestGrepRecursiveJSONMultilineKeepsNewlines(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.txt"), "first\nsecond\nthird\n")
+
+	fs := NewFileSearcher(false, true, false, nil, nil, nil)
+	output, _ := runGrep(t, fs, root, `first\nsecond`, searchOptions{multiline: true, jsonOutput: true})
+
+	var match jsonMatch
+	firstLine := strings.SplitN(output, "\n", 2)[0]
+	if err := json.Unmarshal([]byte(firstLine), &match); err != nil {
+		t.Fatalf("invalid match line %q: %v", firstLine, err)
+	}
+	if match.Line != 1 || match.EndLine != 2 || match.Content != "first\nsecond" {
+		t.Fatalf("match = %#v, want lines 1..2 with content %q", match, "first\nsecond")
+	}
+}
+
+func TestGrepRecursiveJSONDocument(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "b.txt"), "needle b\n")
+	writeFile(t, filepath.Join(root, "a.txt"), "x\nneedle a1\nneedle a2\n")
+
+	tests := []struct {
+		name          string
+		maxResults    int
+		wantMatches   []jsonMatch
+		wantTruncated bool
+	}{
+		{
+			name: "all matches sorted by path and line",
+			wantMatches: []jsonMatch{
+				{Path: filepath.Join(root, "a.txt"), Line: 2, EndLine: 2, Content: "needle a1"},
+				{Path: filepath.Join(root, "a.txt"), Line: 3, EndLine: 3, Content: "needle a2"},
+				{Path: filepath.Join(root, "b.txt"), Line: 1, EndLine: 1, Content: "needle b"},
+			},
+		},
+		{name: "max results truncates", maxResults: 1, wantTruncated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := NewFileSearcher(false, true, false, nil, nil, nil)
+			output, _ := runGrep(t, fs, root, "needle", searchOptions{jsonDocument: true, maxResults: tt.maxResults})
+
+			var doc jsonDocument
+			if err := json.Unmarshal([]byte(output), &doc); err != nil {
+				t.Fatalf("output is not a single JSON document: %v\n%s", err, output)
+			}
+			if doc.Truncated != tt.wantTruncated {
+				t.Errorf("truncated = %v, want %v", doc.Truncated, tt.wantTruncated)
+			}
+			if tt.wantMatches == nil {
+				if len(doc.Matches) != tt.maxResults || doc.TotalMatches != int64(tt.maxResults) {
+					t.Errorf("got %d matches (total %d), want %d", len(doc.Matches), doc.TotalMatches, tt.maxResults)
+				}
+				return
+			}
+			if doc.TotalMatches != int64(len(tt.wantMatches)) || doc.FilesScanned != 2 {
+				t.Errorf("totals = %d matches / %d files, want %d / 2", doc.TotalMatches, doc.FilesScanned, len(tt.wantMatches))
+			}
+			if len(doc.Matches) != len(tt.wantMatches) {
+				t.Fatalf("matches = %#v, want %#v", doc.Matches, tt.wantMatches)
+			}
+			for i := range tt.wantMatches {
+				if doc.Matches[i] != tt.wantMatches[i] {
+					t.Errorf("matches[%d] = %#v, want %#v", i, doc.Matches[i], tt.wantMatches[i])
+				}
+			}
+		})
+	}
+}
+
+func TestGrepRecursiveJSONDocumentNoMatches(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.txt"), "nothing here\n")
+
+	fs := NewFileSearcher(false, true, false, nil, nil, nil)
+	output, _ := runGrep(t, fs, root, "needle", searchOptions{jsonDocument: true})
+
+	if want := `{"matches":[],"total_matches":0,"files_scanned":1,"truncated":false}` + "\n"; output != want {
+		t.Errorf("output = %q, want %q", output, want)
+	}
+}
+
+func TestGrepRecursiveTextOutput(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "a.txt")
+	writeFile(t, path, "first\nsecond\n")
+
+	fs := NewFileSearcher(false, true, false, nil, nil, nil)
+	output, _ := runGrep(t, fs, root, `first\nsecond`, searchOptions{multiline: true, showLineNumbers: true, showFilePath: true})
+
+	want := path + `:1..2:first\nsecond` + "\n"
+	if output != want {
+		t.Fatalf("output = %q, want %q", output, want)
+	}
+}
+
+func TestGrepRecursiveColorHighlighting(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "a.txt")
+	writeFile(t, path, "Error: disk ERROR here\n")
+
+	tests := []struct {
+		name    string
+		keyword string
+		regex   bool
+		want    string
+	}{
+		{
+			name:    "case-insensitive literal keeps original case",
+			keyword: "error",
+			want:    colorMatch + "Error" + colorReset + ": disk " + colorMatch + "ERROR" + colorReset + " here",
+		},
+		{
+			name:    "regex",
+			keyword: "d[a-z]+k",
+			regex:   true,
+			want:    "Error: " + colorMatch + "disk" + colorReset + " ERROR here",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := NewFileSearcher(false, true, false, nil, nil, nil)
+			output, _ := runGrep(t, fs, root, tt.keyword, searchOptions{
+				useRegex:        tt.regex,
+				showLineNumbers: true,
+				showFilePath:    true,
+				color:           true,
+			})
+
+			prefix := colorPath + path + colorReset + ":" + colorLineNo + "1" + colorReset + ":"
+			if want := prefix + tt.want + "\n"; output != want {
+				t.Fatalf("output = %q, want %q", output, want)
+			}
+		})
+	}
+}
+
+func TestGrepRecursiveInvertMatch(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "app.log")
+	writeFile(t, path, "DEBUG start\nINFO ready\ndebug tick\nERROR failed\n")
+
+	tests := []struct {
+		name    string
+		keyword string
+		regex   bool
+		want    string
+	}{
+		{name: "literal", keyword: "debug", want: "2:INFO ready\n4:ERROR failed\n"},
+		{name: "regex", keyword: "^(INFO|ERROR)", regex: true, want: "1:DEBUG start\n3:debug tick\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := NewFileSearcher(false, true, false, nil, nil, nil)
+			output, matches := runGrep(t, fs, root, tt.keyword, searchOptions{
+				useRegex:        tt.regex,
+				showLineNumbers: true,
+				invertMatch:     true,
+			})
+
+			if matches != 2 {
+				t.Fatalf("matches = %d, want 2", matches)
+			}
+			if output != tt.want {
+				t.Fatalf("output = %q, want %q", output, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrepRecursiveWholeWord(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.txt"), strings.Join([]string{
+		"err at line start",       // 1: start of line
+		"terrain transferred",     // 2: embedded only
+		"ends with err",           // 3: end of line
+		"éerr and errü",           // 4: unicode letters adjacent
+		"(err) ERR_CODE Err.",     // 5: punctuation boundaries; ERR_CODE is one word
+		"errerr err_x x_err 9err", // 6: no whole word
+	}, "\n")+"\n")
+
+	tests := []struct {
+		name          string
+		keyword       string
+		regex         bool
+		caseSensitive bool
+		wantLines     string
+	}{
+		{name: "literal", keyword: "err", wantLines: "1,3,5"},
+		{name: "literal case-sensitive", keyword: "Err", caseSensitive: true, wantLines: "5"},
+		// Go's \b only knows ASCII word characters, so "éerr" still matches in regex mode
+		{name: "regex", keyword: "e[r]+", regex: true, wantLines: "1,3,4,5"},
+		{name: "regex case-sensitive", keyword: "ERR", regex: true, caseSensitive: true, wantLines: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := NewFileSearcher(tt.caseSensitive, true, false, nil, nil, nil)
+			output, _ := runGrep(t, fs, root, tt.keyword, searchOptions{
+				useRegex:        tt.regex,
+				showLineNumbers: true,
+				wholeWord:       true,
+				jsonOutput:      true,
+			})
+
+			var got []string
+			for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+				var match jsonMatch
+				if err := json.Unmarshal([]byte(line), &match); err != nil || match.Line == 0 {
+					continue // summary line
+				}
+				got = append(got, strconv.Itoa(match.Line))
+			}
+			if strings.Join(got, ",") != tt.wantLines {
+				t.Fatalf("matched lines = %v, want %s\n%s", got, tt.wantLines, output)
+			}
+		})
+	}
+}
+
+func TestGrepRecursiveWholeWordHighlighting(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "a.txt")
+	writeFile(t, path, "terr Err err_ err\n")
+
+	fs := NewFileSearcher(false, true, false, nil, nil, nil)
+	output, _ := runGrep(t, fs, root, "err", searchOptions{wholeWord: true, color: true})
+
+	want := "terr " + colorMatch + "Err" + colorReset + " err_ " + colorMatch + "err" + colorReset + "\n"
+	if output != want {
+		t.Fatalf("output = %q, want %q", output, want)
+	}
+}
+
+func TestLiteralWordSpansOverlapping(t *testing.T) {
+	matcher, err := newSearchMatcher("aa", false, true, false, true)
+	if err != nil {
+		t.Fatalf("newSearchMatcher returned error: %v", err)
+	}
+
+	// "aaa" has no whole-word "aa", but the candidate at offset 1 must still be tried
+	if spans := matcher.matchSpans("aaa aa"); len(spans) != 1 || spans[0][0] != 4 {
+		t.Fatalf("spans = %v, want [[4 6]]", spans)
+	}
+}
+
+func TestGrepRecursiveContext(t *testing.T) {
+	root := t.TempDir()
+	lines := make([]string, 0, 12)
+	for i := 1; i <= 12; i++ {
+		lines = append(lines, "line"+strconv.Itoa(i))
+	}
+	lines[2] = "match3"   // line 3
+	lines[4] = "match5"   // line 5: context overlaps with line 3's
+	lines[10] = "match11" // line 11: separate group
+	writeFile(t, filepath.Join(root, "a.txt"), strings.Join(lines, "\n")+"\n")
+
+	tests := []struct {
+		name   string
+		before int
+		after  int
+		want   string
+	}{
+		{
+			name:   "context merges overlapping groups",
+			before: 1,
+			after:  1,
+			want:   "2-line2\n3:match3\n4-line4\n5:match5\n6-line6\n--\n10-line10\n11:match11\n12-line12\n",
+		},
+		{
+			name:   "before only, ring keeps the latest lines",
+			before: 3,
+			want:   "1-line1\n2-line2\n3:match3\n4-line4\n5:match5\n--\n8-line8\n9-line9\n10-line10\n11:match11\n",
+		},
+		{
+			name:  "after only",
+			after: 2,
+			want:  "3:match3\n4-line4\n5:match5\n6-line6\n7-line7\n--\n11:match11\n12-line12\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := NewFileSearcher(false, true, false, nil, nil, nil)
+			output, matches := runGrep(t, fs, root, "match", searchOptions{
+				showLineNumbers: true,
+				before:          tt.before,
+				after:           tt.after,
+			})
+
+			if matches != 3 {
+				t.Errorf("matches = %d, want 3 (context lines must not be counted)", matches)
+			}
+			if output != tt.want {
+				t.Errorf("output =\n%s\nwant\n%s", output, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrepRecursiveContextOfDroppedMatches(t *testing.T) {
+	root := t.TempDir()
+	content := "needle1\nline2\nline3\nline4\nline5\nline6\nline7\nneedle8\nline9\n"
+	writeFile(t, filepath.Join(root, "sub", "a.txt"), content)
+
+	tests := []struct {
+		name string
+		opts searchOptions
+		want string
+	}{
+		{"max-results drops the before-context of the cut match", searchOptions{maxResults: 1, before: 2}, "1:needle1\n"},
+		{"max-results keeps the after-context of the last match", searchOptions{maxResults: 1, after: 1, before: 2}, "1:needle1\n2-line2\n"},
+		{"max-per-dir drops the context of a suppressed match", searchOptions{maxPerDir: 1, before: 1, after: 1}, "1:needle1\n2-line2\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := NewFileSearcher(false, true, false, nil, nil, nil)
+			tt.opts.showLineNumbers = true
+			output, matches := runGrep(t, fs, root, "needle", tt.opts)
+			output, _, _ = strings.Cut(output, "(suppressed")
+			if matches != 1 || output != tt.want {
+				t.Errorf("output =\n%s(%d matches), want\n%s", output, matches, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrepRecursiveContextJSON(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "a.txt")
+	writeFile(t, path, "before\nneedle\nafter\n")
+
+	fs := NewFileSearcher(false, true, false, nil, nil, nil)
+	output, _ := runGrep(t, fs, root, "needle", searchOptions{jsonOutput: true, before: 1, after: 1})
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("got %d NDJSON lines, want 4:\n%s", len(lines), output)
+	}
+	want := []jsonMatch{
+		{Path: path, Line: 1, EndLine: 1, Content: "before", Context: true},
+		{Path: path, Line: 2, EndLine: 2, Content: "needle"},
+		{Path: path, Line: 3, EndLine: 3, Content: "after", Context: true},
+	}
+	for i, w := range want {
+		var got jsonMatch
+		if err := json.Unmarshal([]byte(lines[i]), &got); err != nil {
+			t.Fatalf("invalid line %q: %v", lines[i], err)
+		}
+		if got != w {
+			t.Errorf("line %d = %#v, want %#v", i, got, w)
+		}
+	}
+}
+
+func TestGrepRecursivePathFilterPrecedence(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".gitignore"), "generated.go\nvendor/\n")
+	for _, name := range []string{"main.go", "main_test.go", "generated.go", "notes.md", "data.bin", "vendor/lib.go"} {
+		writeFile(t, filepath.Join(root, name), "needle\n")
+	}
+
+	tests := []struct {
+		name      string
+		include   []string
+		exclude   []string
+		gitignore bool
+		want      []string
+	}{
+		{
+			name: "built-in extension filter only",
+			want: []string{"generated.go", "main.go", "main_test.go", "notes.md", "vendor/lib.go"},
+		},
+		{
+			name:      "gitignore prunes files and directories",
+			gitignore: true,
+			want:      []string{"main.go", "main_test.go", "notes.md"},
+		},
+		{
+			name:      "include beats gitignore and the extension filter",
+			include:   []string{"*.go", "*.bin"},
+			gitignore: true,
+			want:      []string{"data.bin", "generated.go", "main.go", "main_test.go"},
+		},
+		{
+			name:    "exclude beats include",
+			include: []string{"*.go"},
+			exclude: []string{"*_test.go"},
+			want:    []string{"generated.go", "main.go", "vendor/lib.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := NewFileSearcher(false, true, false, nil, nil, nil)
+			fs.setPathFilters(tt.include, tt.exclude, tt.gitignore)
+			output, _ := runGrep(t, fs, root, "needle", searchOptions{showFilePath: true})
+
+			var got []string
+			for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+				rel, _ := filepath.Rel(root, strings.TrimSuffix(line, ":needle"))
+				got = append(got, filepath.ToSlash(rel))
+			}
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("searched %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrepRecursiveCaseInsensitiveRegex(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.log"), "Error one\nok\nERROR two\nan error three\nterror\n")
+
+	opts := searchOptions{showLineNumbers: true}
+	literal, literalMatches := runGrep(t, NewFileSearcher(false, true, false, nil, nil, nil), root, "error", opts)
+
+	regexOpts := opts
+	regexOpts.useRegex = true
+	inline, inlineMatches := runGrep(t, NewFileSearcher(true, true, false, nil, nil, nil), root, "(?i)error", regexOpts)
+	flagged, flaggedMatches := runGrep(t, NewFileSearcher(false, true, false, nil, nil, nil), root, "error", regexOpts)
+
+	if literalMatches != 4 {
+		t.Fatalf("literal matches = %d, want 4:\n%s", literalMatches, literal)
+	}
+	if inline != literal || inlineMatches != literalMatches {
+		t.Errorf("--regex \"(?i)error\" output = %q, want literal output %q", inline, literal)
+	}
+	if flagged != literal || flaggedMatches != literalMatches {
+		t.Errorf("case-insensitive --regex output = %q, want literal output %q", flagged, literal)
+	}
+}
+
+func TestGrepRecursiveInvalidRegex(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.txt"), "anything\n")
+
+	fs := NewFileSearcher(false, true, false, nil, nil, nil)
+	var err error
+	output := captureStdout(t, func() {
+		_, err = fs.grepRecursive([]string{root}, []string{"(unclosed"}, searchOptions{useRegex: true})
+	})
+
+	if err == nil || !strings.Contains(err.Error(), "invalid regex pattern") {
+		t.Fatalf("err = %v, want invalid regex pattern error", err)
+	}
+	if output != "" {
+		t.Errorf("expected no output before the walk, got %q", output)
+	}
+}
+
+func TestGrepRecursiveOnlyMatching(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "a.log")
+	writeFile(t, path, "from alice@example.com to bob@example.org\nno address\nERROR again\n")
+
+	tests := []struct {
+		name        string
+		keyword     string
+		opts        searchOptions
+		want        string
+		wantMatches int
+	}{
+		{
+			name:        "regex emits every occurrence",
+			keyword:     `\w+@[\w.]+`,
+			opts:        searchOptions{useRegex: true, showLineNumbers: true},
+			want:        "1:alice@example.com\n1:bob@example.org\n",
+			wantMatches: 2,
+		},
+		{
+			name:        "case-insensitive literal keeps original case",
+			keyword:     "error",
+			opts:        searchOptions{showLineNumbers: true},
+			want:        "3:ERROR\n",
+			wantMatches: 1,
+		},
+		{
+			name:        "max results counts occurrences",
+			keyword:     `\w+@[\w.]+`,
+			opts:        searchOptions{useRegex: true, maxResults: 1},
+			want:        "alice@example.com\n",
+			wantMatches: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.onlyMatching = true
+			fs := NewFileSearcher(false, true, false, nil, nil, nil)
+			output, matches := runGrep(t, fs, root, tt.keyword, tt.opts)
+
+			if matches != tt.wantMatches {
+				t.Errorf("matches = %d, want %d", matches, tt.wantMatches)
+			}
+			if output != tt.want {
+				t.Errorf("output = %q, want %q", output, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrepRecursiveMultiplePatterns(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "app.log")
+	writeFile(t, path, "ERROR user_id=7 failed\nFATAL boot\nINFO user_id=8 ok\npanic: nil map\n")
+
+	tests := []struct {
+		name     string
+		keywords []string
+		opts     searchOptions
+		want     string
+	}{
+		{
+			name:     "any",
+			keywords: []string{"error", "fatal", "panic"},
+			opts:     searchOptions{showLineNumbers: true},
+			want:     "1:ERROR user_id=7 failed\n2:FATAL boot\n4:panic: nil map\n",
+		},
+		{
+			name:     "all",
+			keywords: []string{"user_id", "failed"},
+			opts:     searchOptions{showLineNumbers: true, matchAll: true},
+			want:     "1:ERROR user_id=7 failed\n",
+		},
+		{
+			name:     "all inverted",
+			keywords: []string{"user_id", "failed"},
+			opts:     searchOptions{showLineNumbers: true, matchAll: true, invertMatch: true},
+			want:     "2:FATAL boot\n3:INFO user_id=8 ok\n4:panic: nil map\n",
+		},
+		{
+			name:     "distinct colors per keyword",
+			keywords: []string{"failed", "user_id"},
+			opts:     searchOptions{color: true, matchAll: true},
+			want:     "ERROR " + patternColors[1] + "user_id" + colorReset + "=7 " + colorMatch + "failed" + colorReset + "\n",
+		},
+		{
+			name:     "only matching in line order",
+			keywords: []string{"failed", `user_id=\d`},
+			opts:     searchOptions{useRegex: true, onlyMatching: true, matchAll: true},
+			want:     "user_id=7\nfailed\n",
+		},
+		{
+			name:     "multiline any",
+			keywords: []string{`boot\nINFO`, `nil\s+map`},
+			opts:     searchOptions{useRegex: true, multiline: true, showLineNumbers: true},
+			want:     `2..3:boot\nINFO` + "\n" + "4:nil map\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := NewFileSearcher(false, true, false, nil, nil, nil)
+			var err error
+			output := captureStdout(t, func() {
+				_, err = fs.grepRecursive([]string{root}, tt.keywords, tt.opts)
+			})
+			if err != nil {
+				t.Fatalf("grepRecursive returned error: %v", err)
+			}
+			if output != tt.want {
+				t.Errorf("output = %q, want %q", output, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrepRecursiveKeywordStats(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.log"), "ERROR timeout\nERROR refused\nWARN timeout\n")
+	writeFile(t, filepath.Join(root, "b.log"), "ERROR disk\n")
+	keywords := []string{"error", "timeout", "tiemout"}
+
+	fs := NewFileSearcher(false, true, false, nil, nil, nil)
+	search := func(opts searchOptions) string {
+		t.Helper()
+		var err error
+		output := captureStdout(t, func() {
+			_, err = fs.grepRecursive([]string{root}, keywords, opts)
+		})
+		if err != nil {
+			t.Fatalf("grepRecursive returned error: %v", err)
+		}
+		return output
+	}
+	output := search(searchOptions{keywordStats: true, sorted: true})
+	want := "ERROR timeout\nERROR refused\nWARN timeout\nERROR disk\n" + `
+Keyword  Matches  Files
+error          3      2
+timeout        2      1
+tiemout        0      0
+`
+	if output != want {
+		t.Errorf("output = %q, want %q", output, want)
+	}
+
+	// Only reported matches count, and the JSON summary carries the same numbers
+	output = search(searchOptions{keywordStats: true, sorted: true, maxResults: 1, jsonOutput: true})
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	var summary jsonSummary
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &summary); err != nil {
+		t.Fatalf("decode summary %q: %v", lines[len(lines)-1], err)
+	}
+	wantStats := []jsonKeywordStat{{"error", 1, 1}, {"timeout", 1, 1}, {"tiemout", 0, 0}}
+	if !reflect.DeepEqual(summary.Keywords, wantStats) {
+		t.Errorf("keywords = %+v, want %+v", summary.Keywords, wantStats)
+	}
+}
+
+func TestGrepRecursiveCount(t *testing.T) {
+	root := t.TempDir()
+	a := filepath.Join(root, "a.txt")
+	b := filepath.Join(root, "b.txt")
+	writeFile(t, a, "TODO one\nnothing\nTODO two\nTODO three\n")
+	writeFile(t, b, "nothing to do\n")
+
+	tests := []struct {
+		name        string
+		opts        searchOptions
+		want        []string
+		wantMatches int
+	}{
+		{
+			name:        "files with matches",
+			opts:        searchOptions{showFilePath: true, showLineNumbers: true},
+			want:        []string{a + ":3", "Total: 3 matches in 1 files"},
+			wantMatches: 3,
+		},
+		{
+			name:        "include zeros",
+			opts:        searchOptions{showFilePath: true, includeZeros: true},
+			want:        []string{a + ":3", b + ":0", "Total: 3 matches in 1 files"},
+			wantMatches: 3,
+		},
+		{
+			name:        "max results caps the count",
+			opts:        searchOptions{showFilePath: true, maxResults: 2},
+			want:        []string{a + ":2", "Total: 2 matches in 1 files"},
+			wantMatches: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.count = true
+			fs := NewFileSearcher(true, true, false, nil, nil, nil)
+			output, matches := runGrep(t, fs, root, "TODO", tt.opts)
+
+			if matches != tt.wantMatches {
+				t.Errorf("matches = %d, want %d", matches, tt.wantMatches)
+			}
+			// Files are reported in completion order; the Total line is always last
+			lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+			sort.Strings(lines[:len(lines)-1])
+			if got := strings.Join(lines, "\n"); got != strings.Join(tt.want, "\n") {
+				t.Errorf("output =\n%s\nwant\n%s", got, strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestGrepRecursiveSizeLimits(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "small.txt"), "needle\n")
+	writeFile(t, filepath.Join(root, "big.txt"), "needle\n"+strings.Repeat("x", 2048)+"\n")
+	// One line above bufio.Scanner's default 64 KB limit, with the match at its end.
+	// --count keeps the captured output small.
+	writeFile(t, filepath.Join(root, "min.js"), strings.Repeat("a", 100*1024)+"needle\n")
+
+	fs := NewFileSearcher(false, true, false, nil, nil, nil)
+	_, matches := runGrep(t, fs, root, "needle", searchOptions{count: true})
+	if matches != 3 || fs.skippedForSize.Load() != 0 {
+		t.Fatalf("without limits: matches = %d, skipped = %d, want 3 and 0", matches, fs.skippedForSize.Load())
+	}
+
+	fs = NewFileSearcher(false, true, false, nil, nil, nil)
+	fs.setLimits(1024, defaultMaxLineLength)
+	_, matches = runGrep(t, fs, root, "needle", searchOptions{count: true})
+	if matches != 1 || fs.skippedForSize.Load() != 2 {
+		t.Fatalf("with --max-file-size 1KB: matches = %d, skipped = %d, want 1 and 2", matches, fs.skippedForSize.Load())
+	}
+
+	fs = NewFileSearcher(false, true, false, nil, nil, nil)
+	fs.setLimits(0, 64*1024)
+	_, matches = runGrep(t, fs, root, "needle", searchOptions{count: true})
+	if matches != 2 {
+		t.Fatalf("with --max-line-length 64KB: matches = %d, want 2", matches)
+	}
+}
+
+func TestTruncateLine(t *testing.T) {
+	tests := []struct {
+		name      string
+		line      string
+		spans     [][]int
+		width     int
+		want      string
+		wantSpans [][]int
+	}{
+		{name: "fits", line: "short line", spans: [][]int{{0, 5}}, width: 20, want: "short line", wantSpans: [][]int{{0, 5}}},
+		{name: "cut at the end", line: "needle and a long tail", spans: [][]int{{0, 6}}, width: 10, want: "needle an…", wantSpans: [][]int{{0, 6}}},
+		{
+			name:      "window moves to a late match",
+			line:      strings.Repeat("x", 40) + "needle" + strings.Repeat("y", 40),
+			spans:     [][]int{{40, 46, 1}},
+			width:     20,
+			want:      "…xxxxxneedleyyyyyyy…",
+			wantSpans: [][]int{{len("…") + 5, len("…") + 11, 1}},
+		},
+		{name: "multibyte runes", line: "ééééééééééé", width: 5, want: "éééé…", wantSpans: [][]int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, spans := truncateLine(tt.line, tt.spans, tt.width)
+			if got != tt.want {
+				t.Errorf("text = %q, want %q", got, tt.want)
+			}
+			if fmt.Sprint(spans) != fmt.Sprint(tt.wantSpans) {
+				t.Errorf("spans = %v, want %v", spans, tt.wantSpans)
+			}
+		})
+	}
+}
+
+func TestGrepRecursiveGroup(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "a.txt")
+	writeFile(t, path, "needle one\nplain\nneedle two\nx\ny\nz\nneedle three\n")
+
+	tests := []struct {
+		name string
+		opts searchOptions
+		want string
+	}{
+		{
+			name: "header and indented rows",
+			opts: searchOptions{showFilePath: true, showLineNumbers: true},
+			want: path + "\n  1: needle one\n  3: needle two\n  7: needle three\n",
+		},
+		{
+			name: "context",
+			opts: searchOptions{showFilePath: true, showLineNumbers: true, after: 1},
+			want: path + "\n  1: needle one\n  2- plain\n  3: needle two\n  4- x\n--\n  7: needle three\n",
+		},
+		{
+			name: "max results",
+			opts: searchOptions{showFilePath: true, showLineNumbers: true, maxResults: 1},
+			want: path + "\n  1: needle one\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.group = true
+			fs := NewFileSearcher(false, true, false, nil, nil, nil)
+			output, _ := runGrep(t, fs, root, "needle", tt.opts)
+			if output != tt.want {
+				t.Errorf("output = %q, want %q", output, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrepRecursiveGroupSeparatesFiles(t *testing.T) {
+	root := t.TempDir()
+	a := filepath.Join(root, "a.txt")
+	b := filepath.Join(root, "b.txt")
+	writeFile(t, a, "needle a\n")
+	writeFile(t, b, "needle b\n")
+
+	fs := NewFileSearcher(false, true, false, nil, nil, nil)
+	output, _ := runGrep(t, fs, root, "needle", searchOptions{group: true, showFilePath: true, showLineNumbers: true})
+
+	// Files finish in any order, but each block must stay intact
+	blocks := strings.Split(output, "\n\n")
+	sort.Strings(blocks)
+	want := []string{a + "\n  1: needle a\n", b + "\n  1: needle b\n"}
+	if len(blocks) != 2 || strings.TrimSuffix(blocks[0], "\n")+"\n" != want[0] || strings.TrimSuffix(blocks[1], "\n")+"\n" != want[1] {
+		t.Errorf("output = %q, want blocks %q", output, want)
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		limit int
+		want  int
+	}{
+		{"kitten", "sitting", 5, 3},
+		{"receive", "recieve", 2, 2},
+		{"", "abc", 5, 3},
+		{"café", "cafe", 2, 1},
+		{"kitten", "sitting", 1, 2},   // stops early at limit+1
+		{"short", "muchlonger", 2, 3}, // length difference alone exceeds the limit
+	}
+
+	for _, tt := range tests {
+		if got := levenshtein([]rune(tt.a), []rune(tt.b), tt.limit); got != tt.want {
+			t.Errorf("levenshtein(%q, %q, %d) = %d, want %d", tt.a, tt.b, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestGrepRecursiveFuzzy(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "a.txt")
+	writeFile(t, path, "we recieve data\nreceived ok\nRECEIVE loud\nnothing relevant\n")
+
+	tests := []struct {
+		name     string
+		distance int
+		opts     searchOptions
+		want     string
+	}{
+		{name: "distance 2", distance: 2, opts: searchOptions{showLineNumbers: true}, want: "1:we recieve data\n2:received ok\n3:RECEIVE loud\n"},
+		{name: "distance 0 is exact token match", distance: 0, opts: searchOptions{showLineNumbers: true}, want: "3:RECEIVE loud\n"},
+		{name: "only matching returns tokens", distance: 2, opts: searchOptions{onlyMatching: true}, want: "recieve\nreceived\nRECEIVE\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.fuzzy = true
+			tt.opts.fuzzyDistance = tt.distance
+			fs := NewFileSearcher(false, true, false, nil, nil, nil)
+			output, _ := runGrep(t, fs, root, "receive", tt.opts)
+			if output != tt.want {
+				t.Errorf("output = %q, want %q", output, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrepRecursiveColumn(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "a.txt")
+	writeFile(t, path, "id=ab12 and id=cd34\nnone\n  tail id=ef56\n")
+
+	tests := []struct {
+		name    string
+		keyword string
+		opts    searchOptions
+		want    string
+	}{
+		{
+			name:    "first match per line",
+			keyword: `id=\w+`,
+			opts:    searchOptions{useRegex: true, showLineNumbers: true},
+			want:    "1:1:id=ab12 and id=cd34\n3:8:  tail id=ef56\n",
+		},
+		{
+			name:    "only matching",
+			keyword: `id=\w+`,
+			opts:    searchOptions{useRegex: true, onlyMatching: true, showLineNumbers: true},
+			want:    "1:1:id=ab12\n1:13:id=cd34\n3:8:id=ef56\n",
+		},
+		{
+			name:    "multiline",
+			keyword: `none\n  tail`,
+			opts:    searchOptions{multiline: true, showLineNumbers: true},
+			want:    `2..3:1:none\n  tail` + "\n",
+		},
+		{
+			name:    "literal",
+			keyword: "cd34",
+			opts:    searchOptions{showLineNumbers: true},
+			want:    "1:16:id=ab12 and id=cd34\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.column = true
+			fs := NewFileSearcher(false, true, false, nil, nil, nil)
+			output, _ := runGrep(t, fs, root, tt.keyword, tt.opts)
+			if output != tt.want {
+				t.Errorf("output = %q, want %q", output, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrepRecursiveColumnJSON(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "a.txt")
+	writeFile(t, path, "x id=ab12\n")
+
+	fs := NewFileSearcher(false, true, false, nil, nil, nil)
+	output, _ := runGrep(t, fs, root, `id=\w+`, searchOptions{useRegex: true, onlyMatching: true, column: true, jsonOutput: true})
+
+	var match jsonMatch
+	firstLine := strings.SplitN(output, "\n", 2)[0]
+	if err := json.Unmarshal([]byte(firstLine), &match); err != nil {
+		t.Fatalf("invalid match line %q: %v", firstLine, err)
+	}
+	want := jsonMatch{Path: path, Line: 1, EndLine: 1, Content: "id=ab12", Column: 3}
+	if match != want {
+		t.Errorf("match = %#v, want %#v", match, want)
+	}
+}
+
+func TestGrepRecursiveShowScope(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.go"), "package a\n\n// TODO top\nfunc (c *Cache) Get() {\n\t// TODO get\n}\n")
+	writeFile(t, filepath.Join(root, "b.txt"), "TODO plain\n")
+
+	fs := NewFileSearcher(false, true, false, nil, nil, nil)
+	output, _ := runGrep(t, fs, root, "TODO", searchOptions{showLineNumbers: true, sorted: true, showScope: true, before: 1})
+	want := "2-\n3:// TODO top\n4-func (c *Cache) Get() {\n5:[Cache.Get] \t// TODO get\n--\n1:TODO plain\n"
+	if output != want {
+		t.Errorf("output = %q, want %q", output, want)
+	}
+
+	output, _ = runGrep(t, fs, filepath.Join(root, "a.go"), "get", searchOptions{jsonOutput: true, showScope: true})
+	var match jsonMatch
+	firstLine := strings.SplitN(output, "\n", 2)[0]
+	if err := json.Unmarshal([]byte(firstLine), &match); err != nil {
+		t.Fatalf("invalid match line %q: %v", firstLine, err)
+	}
+	if match.Scope != "Cache.Get" {
+		t.Errorf("scope = %q, want %q", match.Scope, "Cache.Get")
+	}
+}
+
+func TestGrepRecursiveDedupe(t *testing.T) {
+	root := t.TempDir()
+	a, b := filepath.Join(root, "a.go"), filepath.Join(root, "b.go")
+	writeFile(t, a, "\treturn nil, err\n// unique err\n\treturn nil, err\n")
+	writeFile(t, b, "  return nil, err\n")
+
+	fs := NewFileSearcher(false, true, false, nil, nil, nil)
+	output, matches := runGrep(t, fs, root, "err", searchOptions{showFilePath: true, showLineNumbers: true, sorted: true, dedupe: true})
+	want := "return nil, err\n  … seen 3 times in 2 files (first: " + a + ":1)\n" +
+		"// unique err\n  … seen in 1 file (first: " + a + ":2)\n"
+	if output != want {
+		t.Errorf("output = %q, want %q", output, want)
+	}
+	// The count covers every occurrence, not the distinct lines
+	if matches != 4 {
+		t.Errorf("matches = %d, want 4", matches)
+	}
+}
+
+func TestGrepRecursiveMaxPerFile(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.txt"), "hit 1\nhit 2\nmiss\nhit 3\nhit 4\n")
+
+	tests := []struct {
+		name        string
+		opts        searchOptions
+		want        string
+		wantMatches int
+	}{
+		{
+			name:        "limit below matches",
+			opts:        searchOptions{showLineNumbers: true, maxPerFile: 2},
+			want:        "1:hit 1\n2:hit 2 (more matches suppressed)\n",
+			wantMatches: 2,
+		},
+		{
+			name:        "limit equal to matches",
+			opts:        searchOptions{showLineNumbers: true, maxPerFile: 4},
+			want:        "1:hit 1\n2:hit 2\n4:hit 3\n5:hit 4\n",
+			wantMatches: 4,
+		},
+		{
+			name:        "trailing context still shown",
+			opts:        searchOptions{showLineNumbers: true, maxPerFile: 2, after: 1},
+			want:        "1:hit 1\n2:hit 2 (more matches suppressed)\n3-miss\n",
+			wantMatches: 2,
+		},
+		{
+			name:        "max results wins when lower",
+			opts:        searchOptions{showLineNumbers: true, maxPerFile: 3, maxResults: 1},
+			want:        "1:hit 1\n",
+			wantMatches: 1,
+		},
+		{
+			name:        "only matching",
+			opts:        searchOptions{showLineNumbers: true, maxPerFile: 1, onlyMatching: true},
+			want:        "1:hit (more matches suppressed)\n",
+			wantMatches: 1,
+		},
+		{
+			name:        "multiline",
+			opts:        searchOptions{showLineNumbers: true, maxPerFile: 1, multiline: true},
+			want:        "1:hit (more matches suppressed)\n",
+			wantMatches: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := NewFileSearcher(false, true, false, nil, nil, nil)
+			output, matches := runGrep(t, fs, root, "hit", tt.opts)
+			if output != tt.want || matches != tt.wantMatches {
+				t.Errorf("output = %q (%d matches), want %q (%d)", output, matches, tt.want, tt.wantMatches)
+			}
+		})
+	}
+
+	fs := NewFileSearcher(false, true, false, nil, nil, nil)
+	output, _ := runGrep(t, fs, root, "hit", searchOptions{maxPerFile: 1, jsonOutput: true})
+	var match jsonMatch
+	if err := json.Unmarshal([]byte(strings.SplitN(output, "\n", 2)[0]), &match); err != nil {
+		t.Fatalf("invalid match line in %q: %v", output, err)
+	}
+	if !match.More || match.Line != 1 {
+		t.Errorf("JSON match = %#v, want line 1 with more_suppressed", match)
+	}
+}
+
+func TestGrepRecursiveMaxPerDir(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "top.txt"), "hit\nhit\nhit\n")
+	writeFile(t, filepath.Join(root, "vendor", "a.txt"), "hit\nhit\n")
+	writeFile(t, filepath.Join(root, "vendor", "deep", "b.txt"), "hit\nhit\nhit\n")
+	writeFile(t, filepath.Join(root, "src", "c.txt"), "hit\n")
+
+	fs := NewFileSearcher(false, true, false, nil, nil, nil)
+	output, matches := runGrep(t, fs, root, "hit", searchOptions{showFilePath: true, maxPerDir: 2})
+
+	// Files directly in the root are not capped; vendor keeps 2 of its 5
+	if matches != 6 {
+		t.Errorf("matches = %d, want 6\n%s", matches, output)
+	}
+	vendor := filepath.Join(root, "vendor")
+	if got := strings.Count(output, vendor+string(filepath.Separator)); got != 2 {
+		t.Errorf("%d vendor matches printed, want 2\n%s", got, output)
+	}
+	if want := "(suppressed 3 more under " + vendor + ")\n"; strings.Count(output, want) != 1 {
+		t.Errorf("output missing a single %q notice:\n%s", want, output)
+	}
+	if strings.Contains(output, "more under "+filepath.Join(root, "src")) {
+		t.Errorf("uncapped directory got a notice:\n%s", output)
+	}
+}
+
+func TestGrepRecursiveLineRange(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.txt"), "hit 1\nhit 2\nhit 3\nmiss\nhit 5\nhit 6\n")
+	ranges := []lineRange{{2, 3}, {5, 5}}
+
+	tests := []struct {
+		name string
+		opts searchOptions
+		want string
+	}{
+		{"literal", searchOptions{showLineNumbers: true, lineRanges: ranges}, "2:hit 2\n3:hit 3\n5:hit 5\n"},
+		{"regex", searchOptions{showLineNumbers: true, useRegex: true, lineRanges: ranges}, "2:hit 2\n3:hit 3\n5:hit 5\n"},
+		{"trailing context past the last range", searchOptions{showLineNumbers: true, after: 1, lineRanges: []lineRange{{5, 5}}}, "5:hit 5\n6-hit 6\n"},
+		{"invert stays inside the ranges", searchOptions{showLineNumbers: true, invertMatch: true, lineRanges: []lineRange{{3, 4}}}, "4:miss\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := NewFileSearcher(false, true, false, nil, nil, nil)
+			keyword := "hit"
+			if tt.opts.useRegex {
+				keyword = `hit \d`
+			}
+			if output, _ := runGrep(t, fs, root, keyword, tt.opts); output != tt.want {
+				t.Errorf("output = %q, want %q", output, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrepRecursiveMaxDepthAndSymlinks(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "top.txt"), "needle\n")
+	writeFile(t, filepath.Join(root, "a", "mid.txt"), "needle\n")
+	writeFile(t, filepath.Join(root, "a", "b", "deep.txt"), "needle\n")
+	outside := t.TempDir()
+	writeFile(t, filepath.Join(outside, "linked.txt"), "needle\n")
+	if err := os.Symlink(outside, filepath.Join(root, "a", "ext")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	// A loop back to the root must not be followed forever
+	if err := os.Symlink(root, filepath.Join(root, "a", "b", "loop")); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		maxDepth    int
+		follow      bool
+		want        []string
+		wantSkipped int64
+	}{
+		{name: "unlimited", maxDepth: -1, want: []string{"a/b/deep.txt", "a/mid.txt", "top.txt"}},
+		{name: "depth 0", maxDepth: 0, want: []string{"top.txt"}, wantSkipped: 1},
+		{name: "depth 1", maxDepth: 1, want: []string{"a/mid.txt", "top.txt"}, wantSkipped: 1},
+		{name: "follow symlinks", maxDepth: -1, follow: true, want: []string{"a/b/deep.txt", "a/ext/linked.txt", "a/mid.txt", "top.txt"}},
+		{name: "follow within depth", maxDepth: 1, follow: true, want: []string{"a/mid.txt", "top.txt"}, wantSkipped: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := NewFileSearcher(false, true, false, nil, nil, nil)
+			fs.setWalkOptions(tt.maxDepth, tt.follow)
+			output, _ := runGrep(t, fs, root, "needle", searchOptions{count: true, showFilePath: true})
+
+			var got []string
+			for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+				if path, _, ok := strings.Cut(line, ":"); ok && !strings.HasPrefix(line, "Total") {
+					rel, _ := filepath.Rel(root, path)
+					got = append(got, filepath.ToSlash(rel))
+				}
+			}
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("searched %v, want %v", got, tt.want)
+			}
+			if skipped := fs.skippedForDepth.Load(); skipped != tt.wantSkipped {
+				t.Errorf("skippedForDepth = %d, want %d", skipped, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+func TestGrepRecursiveUncleanRoot(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "src", "a.txt"), "needle\n")
+	t.Chdir(root)
+
+	want := map[string]string{
+		".":                                filepath.Join("src", "a.txt"),
+		"src" + string(filepath.Separator): filepath.Join("src", "a.txt"),
+		"./src":                            filepath.Join("src", "a.txt"),
+	}
+	for dir, wantPath := range want {
+		fs := NewFileSearcher(false, false, false, nil, nil, nil)
+		output, matches := runGrep(t, fs, dir, "needle", searchOptions{showFilePath: true})
+		if matches != 1 || output != wantPath+":needle\n" {
+			t.Errorf("root %q: output = %q (%d matches), want %q", dir, output, matches, wantPath+":needle\n")
+		}
+	}
+}
+
+func TestGrepRecursiveSorted(t *testing.T) {
+	root := t.TempDir()
+	var want []string
+	for _, name := range []string{"a.txt", "b.txt", "c/d.txt", "e.txt", "f.txt"} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		writeFile(t, path, "needle 1\nhay\nneedle 3\n")
+		want = append(want, path+":1:needle 1", path+":3:needle 3")
+	}
+
+	fs := NewFileSearcher(false, true, false, nil, nil, nil)
+	output, matches := runGrep(t, fs, root, "needle", searchOptions{sorted: true, showFilePath: true, showLineNumbers: true})
+	if got := strings.TrimSuffix(output, "\n"); got != strings.Join(want, "\n") || matches != len(want) {
+		t.Errorf("output =\n%s\nwant\n%s", got, strings.Join(want, "\n"))
+	}
+
+	// --max-results keeps the first results in sorted order, not the first found
+	output, matches = runGrep(t, fs, root, "needle", searchOptions{sorted: true, showFilePath: true, showLineNumbers: true, maxResults: 3})
+	if got := strings.TrimSuffix(output, "\n"); got != strings.Join(want[:3], "\n") || matches != 3 {
+		t.Errorf("with --max-results 3, output =\n%s\nwant\n%s", got, strings.Join(want[:3], "\n"))
+	}
+}
+
+func TestIsTextFileSniffing(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"Dockerfile":  "FROM alpine\n",
+		"Makefile":    "all:\n",
+		"Jenkinsfile": "pipeline {}\n",
+		"LICENSE":     "MIT\n",
+		"deploy":      "#!/bin/bash\necho hi\n",
+		"blob":        "\x00\x01binary",
+		"notes.xyz":   "#!/bin/sh\n", // shebangs are only sniffed without an extension
+		"main.go":     "package main\n",
+	}
+	for name, content := range files {
+		writeFile(t, filepath.Join(root, name), content)
+	}
+
+	tests := []struct {
+		name       string
+		noSniff    bool
+		extensions []string
+		want       map[string]bool
+	}{
+		{
+			name: "well-known names and shebangs",
+			want: map[string]bool{"Dockerfile": true, "Makefile": true, "Jenkinsfile": true, "LICENSE": true, "deploy": true, "blob": false, "notes.xyz": false, "main.go": true},
+		},
+		{
+			name:    "--no-sniff",
+			noSniff: true,
+			want:    map[string]bool{"Dockerfile": false, "Makefile": false, "Jenkinsfile": false, "LICENSE": false, "deploy": false, "main.go": true},
+		},
+		{
+			name:       "--extensions takes precedence",
+			extensions: []string{"go"},
+			want:       map[string]bool{"Dockerfile": false, "deploy": false, "main.go": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := NewFileSearcher(false, true, false, tt.extensions, nil, nil)
+			fs.noSniff = tt.noSniff
+			for name, want := range tt.want {
+				if got := fs.isTextFile(filepath.Join(root, name)); got != want {
+					t.Errorf("isTextFile(%s) = %v, want %v", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGrepRecursiveStdin(t *testing.T) {
+	const input = "alpha\nneedle one\nbeta\nneedle two\n"
+
+	tests := []struct {
+		name        string
+		keyword     string
+		opts        searchOptions
+		want        string
+		wantMatches int
+	}{
+		{
+			name:        "path prefix omitted without label",
+			keyword:     "needle",
+			opts:        searchOptions{showLineNumbers: true, showFilePath: true},
+			want:        "2:needle one\n4:needle two\n",
+			wantMatches: 2,
+		},
+		{
+			name:        "label names the stream",
+			keyword:     "needle",
+			opts:        searchOptions{showLineNumbers: true, showFilePath: true, label: "app.log"},
+			want:        "app.log:2:needle one\napp.log:4:needle two\n",
+			wantMatches: 2,
+		},
+		{
+			name:        "max results",
+			keyword:     "needle",
+			opts:        searchOptions{showLineNumbers: true, showFilePath: true, maxResults: 1},
+			want:        "2:needle one\n",
+			wantMatches: 1,
+		},
+		{
+			name:        "multiline",
+			keyword:     `one\nbeta`,
+			opts:        searchOptions{multiline: true, showLineNumbers: true, showFilePath: true},
+			want:        `2..3:one\nbeta` + "\n",
+			wantMatches: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := NewFileSearcher(false, true, false, nil, nil, nil)
+			fs.stdin = strings.NewReader(input)
+			output, matches := runGrep(t, fs, stdinPath, tt.keyword, tt.opts)
+
+			if matches != tt.wantMatches {
+				t.Errorf("matches = %d, want %d", matches, tt.wantMatches)
+			}
+			if output != tt.want {
+				t.Errorf("output = %q, want %q", output, tt.want)
+			}
+		})
+	}
+}
+
+func BenchmarkSearchInFileRegex(b *testing.B) {
+	path := filepath.Join(b.TempDir(), "bench.log")
+	var content strings.Builder
+	for i := 0; i < 10000; i++ {
+		fmt.Fprintf(&content, "2024-01-01 12:00:%02d INFO request %d handled\n", i%60, i)
+	}
+	content.WriteString("2024-01-01 12:01:00 ERROR request failed\n")
+	if err := os.WriteFile(path, []byte(content.String()), 0o644); err != nil {
+		b.Fatalf("write %s: %v", path, err)
+	}
+
+	fs := NewFileSearcher(false, true, false, nil, nil, nil)
+	matcher, err := newSearchMatcher(`error\s+request`, true, false, false, false)
+	if err != nil {
+		b.Fatalf("newSearchMatcher returned error: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if matches := fs.searchInFile(path, matcher, false); len(matches) != 1 {
+			b.Fatalf("got %d matches, want 1", len(matches))
+		}
+	}
+}
+
+// TestReadModesAgree is a differential test: reading files whole must report
+// exactly what the streaming scanner does
+func TestReadModesAgree(t *testing.T) {
+	var many strings.Builder
+	for i := range 300 {
+		if i%7 == 0 {
+			fmt.Fprintf(&many, "%d needle\n", i)
+		} else {
+			fmt.Fprintf(&many, "%d hay\n", i)
+		}
+	}
+	corpus := map[string]string{
+		"empty.txt":            "",
+		"newline.txt":          "\n",
+		"crlf.txt":             "alpha needle\r\nbeta\r\n\r\nneedle gamma\r\n",
+		"no-final-newline.txt": "one\nneedle two",
+		"lone-cr.txt":          "needle\rstill one line\nnext\r\r\nneedle\r",
+		"blank-lines.txt":      "\n\nneedle\n\n\nneedle needle\n\n",
+		"unicode.txt":          "héllo nëedle\n日本 needle 語\n",
+		"binary.txt":           "needle\x00binary\n",
+		"long-line.txt":        "needle before\n" + strings.Repeat("x", 64<<10) + "needle\nneedle after\n",
+		"at-limit.txt":         strings.Repeat("z", 64<<10) + "\nneedle\n",
+		"below-limit.txt":      strings.Repeat("y", 64<<10-1) + "\nneedle after\n",
+		"last-line-long.txt":   "needle\n" + strings.Repeat("w", 64<<10),
+		"code.go":              "package p\n\nfunc (s *S) Run() {\n\t// needle\n}\n\nfunc other() {\n\treturn // needle\r\n}\n",
+		"many.txt":             many.String(),
+	}
+	root := t.TempDir()
+	for name, content := range corpus {
+		writeFile(t, filepath.Join(root, name), content)
+	}
+
+	tests := []struct {
+		name    string
+		keyword string
+		regex   bool
+		setup   func(sm *searchMatcher)
+	}{
+		{"plain", "needle", false, func(sm *searchMatcher) {}},
+		{"invert", "needle", false, func(sm *searchMatcher) { sm.invert = true }},
+		{"context", "needle", false, func(sm *searchMatcher) { sm.before, sm.after = 2, 1 }},
+		{"only matching", `ne+dle`, true, func(sm *searchMatcher) { sm.onlyMatching, sm.column = true, true }},
+		{"line range", "needle", false, func(sm *searchMatcher) { sm.lineRanges = []lineRange{{2, 5}}; sm.after = 1 }},
+		{"max per file", "needle", false, func(sm *searchMatcher) { sm.maxPerFile = 2 }},
+		{"column and scope", "needle", false, func(sm *searchMatcher) { sm.column, sm.showScope = true, true }},
+		{"empty lines", `^$`, true, func(sm *searchMatcher) {}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher, err := newSearchMatcher(tt.keyword, tt.regex, false, false, false)
+			if err != nil {
+				t.Fatalf("newSearchMatcher returned error: %v", err)
+			}
+			tt.setup(matcher)
+			for name := range corpus {
+				path := filepath.Join(root, name)
+				results := map[string][]matchResult{}
+				for _, mode := range []string{readModeScan, readModeFull} {
+					fs := NewFileSearcher(false, true, true, nil, nil, nil)
+					fs.setLimits(0, 1) // the scanner's 64 KB minimum
+					fs.readMode = mode
+					results[mode] = fs.searchInFile(path, matcher, false)
+				}
+				if !reflect.DeepEqual(results[readModeScan], results[readModeFull]) {
+					t.Errorf("%s: scan = %+v\nfull = %+v", name, results[readModeScan], results[readModeFull])
+				}
+			}
+		})
+	}
+}
+
+func TestMatchSpansCaseSensitiveLiteral(t *testing.T) {
+	matcher, err := newSearchMatcher("ab", false, true, false, false)
+	if err != nil {
+		t.Fatalf("newSearchMatcher returned error: %v", err)
+	}
+
+	spans := matcher.matchSpans("ab AB abab")
+	want := [][]int{{0, 2}, {6, 8}, {8, 10}}
+	if len(spans) != len(want) {
+		t.Fatalf("spans = %v, want %v", spans, want)
+	}
+	for i := range want {
+		if spans[i][0] != want[i][0] || spans[i][1] != want[i][1] {
+			t.Fatalf("spans = %v, want %v", spans, want)
+		}
+	}
+}
+
+// failingWriter fails every write, like a full disk
+type failingWriter struct{ writes int }
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return 0, errors.New("no space left on device")
+}
+
+func TestGrepRecursiveWriteError(t *testing.T) {
+	root := t.TempDir()
+	for i := range 20 {
+		writeFile(t, filepath.Join(root, fmt.Sprintf("f%02d.txt", i)), "needle\n")
+	}
+
+	fs := NewFileSearcher(false, true, false, nil, nil, nil)
+	out := &failingWriter{}
+	fs.out = out
+	_, err := fs.grepRecursive([]string{root}, []string{"needle"}, searchOptions{showFilePath: true, sorted: true})
+	if err == nil || !strings.Contains(err.Error(), "no space left on device") {
+		t.Fatalf("err = %v, want the write error", err)
+	}
+	if fs.brokenPipe.Load() {
+		t.Error("a write error was taken for a broken pipe")
+	}
+	// Nothing more is written after the first failure
+	if out.writes != 1 {
+		t.Errorf("output was written %d times after failing, want 1", out.writes)
+	}
+}
+
+func runGrep(t *testing.T, fs *FileSearcher, root, keyword string, opts searchOptions) (string, int) {
+	t.Helper()
+
+	var matches int
+	var err error
+	output := captureStdout(t, func() {
+		matches, err = fs.grepRecursive([]string{root}, []string{keyword}, opts)
+	})
+	if err != nil {
+		t.Fatalf("grepRecursive(%q) returned error: %v", keyword, err)
+	}
+	return output, matches
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("create parent of %s: %v", path, err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+
+	oldStdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe stdout: %v", err)
+	}
+	defer func() {
+		os.Stdout = oldStdout
+	}()
+
+	os.Stdout = w
+	fn()
+	if err := w.Close(); err != nil {
+		t.Fatalf("close stdout writer: %v", err)
+	}
+
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read stdout: %v", err)
+	}
+	return string(out)
+}
+
+// BenchmarkSearchSmallFiles compares the read modes on a tree of small files,
+// the common case in source trees
+func BenchmarkSearchSmallFiles(b *testing.B) {
+	dir := b.TempDir()
+	var paths []string
+	for i := range 200 {
+		var content strings.Builder
+		for line := range 60 {
+			fmt.Fprintf(&content, "\tresult, err := process(ctx, item%d, options) // line %d\n", i, line)
+		}
+		path := filepath.Join(dir, fmt.Sprintf("file%03d.go", i))
+		if err := os.WriteFile(path, []byte(content.String()), 0o644); err != nil {
+			b.Fatalf("write %s: %v", path, err)
+		}
+		paths = append(paths, path)
+	}
+	matcher, err := newSearchMatcher("item7,", false, false, false, false)
+	if err != nil {
+		b.Fatalf("newSearchMatcher returned error: %v", err)
+	}
+
+	for _, mode := range []string{readModeScan, readModeFull} {
+		b.Run(mode, func(b *testing.B) {
+			fs := NewFileSearcher(false, true, false, nil, nil, nil)
+			fs.readMode = mode
+			b.ReportAllocs()
+			for b.Loop() {
+				found := 0
+				for _, path := range paths {
+					found += len(fs.searchInFile(path, matcher, false))
+				}
+				if found != 60 {
+					b.Fatalf("got %d matches, want 60", found)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkSearchInFileFuzzy(b *testing.B) {
+	path := filepath.Join(b.TempDir(), "bench.log")
+	var content strings.Builder
+	for content.Len() < 10<<20 {
+		fmt.Fprintf(&content, "2024-01-01 12:00:00 INFO request %d handled by worker pool\n", content.Len())
+	}
+	if err := os.WriteFile(path, []byte(content.String()), 0o644); err != nil {
+		b.Fatalf("write %s: %v", path, err)
+	}
+
+	fs := NewFileSearcher(false, true, false, nil, nil, nil)
+	matcher, err := newSearchMatcher("requets", false, false, false, false)
+	if err != nil {
+		b.Fatalf("newSearchMatcher returned error: %v", err)
+	}
+	matcher.setFuzzy(2)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if matches := fs.searchInFile(path, matcher, false); len(matches) == 0 {
+			b.Fatal("expected fuzzy matches")
+		}
+	}
+}
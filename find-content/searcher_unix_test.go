@@ -0,0 +1,124 @@
+//go:build unix
+
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func mkfifo(t *testing.T, path string) {
+	t.Helper()
+	if err := syscall.Mkfifo(path, 0o644); err != nil {
+		t.Skipf("mkfifo unavailable: %v", err)
+	}
+}
+
+func TestGrepRecursiveSkipsSpecialFiles(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.txt"), "needle\n")
+	// Opening a FIFO with no writer blocks forever
+	mkfifo(t, filepath.Join(root, "pipe.txt"))
+	if err := os.Symlink(filepath.Join(root, "pipe.txt"), filepath.Join(root, "link.txt")); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+
+	fs := NewFileSearcher(false, false, false, nil, nil, nil)
+	done := make(chan string, 1)
+	go func() {
+		output, _ := runGrep(t, fs, root, "needle", searchOptions{showLineNumbers: true, showFilePath: true})
+		done <- output
+	}()
+	select {
+	case output := <-done:
+		if !strings.Contains(output, "a.txt:1:needle") {
+			t.Errorf("output = %q, want the a.txt match", output)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("search blocked on the FIFO")
+	}
+}
+
+func TestSearchInFileTimeout(t *testing.T) {
+	pipe := filepath.Join(t.TempDir(), "pipe.txt")
+	mkfifo(t, pipe)
+
+	fs := NewFileSearcher(false, true, false, nil, nil, nil)
+	fs.fileTimeout = 50 * time.Millisecond
+	matcher, err := newSearchMatcher("needle", false, false, false, false)
+	if err != nil {
+		t.Fatalf("newSearchMatcher: %v", err)
+	}
+
+	if matches := fs.searchInFile(pipe, matcher, false); matches != nil {
+		t.Errorf("matches = %+v, want none from an abandoned file", matches)
+	}
+	if got := fs.timedOut.Load(); got != 1 {
+		t.Errorf("timedOut = %d, want 1", got)
+	}
+	if got := fs.abandonedReads.Load(); got != 1 {
+		t.Fatalf("abandonedReads = %d, want 1 while the open is blocked", got)
+	}
+
+	// Once the FIFO gets a writer the abandoned read finishes and is released
+	w, err := os.OpenFile(pipe, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("open FIFO for writing: %v", err)
+	}
+	w.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for fs.abandonedReads.Load() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("abandoned read was never released")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSearchInFileTimeoutWarnsOnce(t *testing.T) {
+	pipe := filepath.Join(t.TempDir(), "pipe.txt")
+	mkfifo(t, pipe)
+
+	oldStderr := os.Stderr
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe stderr: %v", err)
+	}
+	os.Stderr = w
+	defer func() { os.Stderr = oldStderr }()
+
+	fs := NewFileSearcher(false, false, false, nil, nil, nil)
+	fs.fileTimeout = 50 * time.Millisecond
+	matcher, err := newSearchMatcher("needle", false, false, false, false)
+	if err != nil {
+		t.Fatalf("newSearchMatcher: %v", err)
+	}
+	fs.searchInFile(pipe, matcher, false)
+
+	// The abandoned read gets its data only now, and fails
+	writer, err := os.OpenFile(pipe, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("open FIFO for writing: %v", err)
+	}
+	writer.WriteString("needle\n")
+	writer.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for fs.abandonedReads.Load() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("abandoned read was never released")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	os.Stderr = oldStderr
+	w.Close()
+	stderr, _ := io.ReadAll(r)
+	if want := "Warning: Gave up on " + pipe + " after --file-timeout 50ms\n"; string(stderr) != want {
+		t.Errorf("stderr = %q, want only %q", stderr, want)
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// matchHistory remembers which matches of each file were already printed.
+// Matches are keyed by content rather than line number, so lines appended to
+// a log (or inserted above a match) do not make earlier matches look new.
+type matchHistory struct {
+	seen map[string]map[string]int // path -> line content -> occurrences
+}
+
+func newMatchHistory() *matchHistory {
+	return &matchHistory{seen: make(map[string]map[string]int)}
+}
+
+// newMatches returns the matches of path not reported by an earlier run. It
+// is used as searchOptions.filter. Lines gone from the file are forgotten, so
+// they count as new when they come back. The returned matches are recorded
+// by printed once they are written.
+func (h *matchHistory) newMatches(path string, matches []matchResult) []matchResult {
+	prev := h.seen[path]
+	curr := make(map[string]int, len(matches))
+	var fresh []matchResult
+	for _, m := range matches {
+		curr[m.content]++
+		if curr[m.content] > prev[m.content] {
+			fresh = append(fresh, m)
+		}
+	}
+
+	kept := make(map[string]int, len(prev))
+	for content, n := range prev {
+		if n = min(n, curr[content]); n > 0 {
+			kept[content] = n
+		}
+	}
+	h.store(path, kept)
+	return fresh
+}
+
+// printed records the matches of path that were written. It is used as
+// searchOptions.reported, so matches held back by --max-results are still
+// new on the next run.
+func (h *matchHistory) printed(path string, matches []matchResult) {
+	if len(matches) == 0 {
+		return
+	}
+	seen := h.seen[path]
+	if seen == nil {
+		seen = make(map[string]int, len(matches))
+	}
+	for _, m := range matches {
+		seen[m.content]++
+	}
+	h.store(path, seen)
+}
+
+// forget drops the history of path and of every file below it, after it was
+// removed or renamed
+func (h *matchHistory) forget(path string) {
+	prefix := path + string(filepath.Separator)
+	for p := range h.seen {
+		if p == path || strings.HasPrefix(p, prefix) {
+			delete(h.seen, p)
+		}
+	}
+}
+
+func (h *matchHistory) store(path string, seen map[string]int) {
+	if len(seen) == 0 {
+		delete(h.seen, path)
+	} else {
+		h.seen[path] = seen
+	}
+}
+
+// watch runs the search once, then re-runs it whenever a file below rootDir
+// is created or modified, printing only matches that are new since the
+// previous run. Bursts of events within debounce are coalesced into one run.
+// onRun, if set, is called after every run with the number of new matches.
+// It returns when ctx is cancelled or nothing reads the output any more.
+func (fs *FileSearcher) watch(ctx context.Context, rootDir string, keywords []string, opts searchOptions, debounce time.Duration, onRun func(int)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("starting file watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	rules := fs.ignoreFiles[rootDir]
+	if err := fs.watchTree(watcher, rootDir, rules); err != nil {
+		return err
+	}
+
+	history := newMatchHistory()
+	opts.filter = history.newMatches
+	opts.reported = history.printed
+	run := func() error {
+		n, err := fs.grepRecursive([]string{rootDir}, keywords, opts)
+		if err != nil {
+			return err
+		}
+		if onRun != nil {
+			onRun(n)
+		}
+		return nil
+	}
+	if err := run(); err != nil || fs.brokenPipe.Load() {
+		return err
+	}
+
+	var pending <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Has(fsnotify.Create) {
+				// New directories must be watched explicitly; fsnotify is not recursive
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := fs.watchTree(watcher, event.Name, rules); err != nil && !fs.suppressWarnings {
+						fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+					}
+				}
+			}
+			if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+				// Runs and events are handled by this goroutine only
+				history.forget(event.Name)
+			}
+			if event.Has(fsnotify.Create) || event.Has(fsnotify.Write) {
+				pending = time.After(debounce)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			if !fs.suppressWarnings {
+				fmt.Fprintf(os.Stderr, "Warning: watch error: %v\n", err)
+			}
+		case <-pending:
+			pending = nil
+			if err := run(); err != nil || fs.brokenPipe.Load() {
+				return err
+			}
+		}
+	}
+}
+
+// watchTree adds dir and every searchable directory below it to watcher;
+// rules are the watched root's ignore file entries
+func (fs *FileSearcher) watchTree(watcher *fsnotify.Watcher, dir string, rules *ignoreRules) error {
+	return filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil // unreadable entries are reported by the search itself
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != dir && (fs.skipWalkDir(d.Name()) || rules.skipDir(d.Name())) {
+			return filepath.SkipDir
+		}
+		if err := watcher.Add(path); err != nil {
+			return fmt.Errorf("watching %s: %w", path, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMatchHistoryNewMatches(t *testing.T) {
+	h := newMatchHistory()
+	// run reports every new match, as the search does without limits
+	run := func(path string, matches []matchResult) []matchResult {
+		fresh := h.newMatches(path, matches)
+		h.printed(path, fresh)
+		return fresh
+	}
+
+	first := []matchResult{{lineNum: 1, content: "ERROR a"}, {lineNum: 2, content: "ERROR b"}}
+	if got := run("x.log", first); len(got) != 2 {
+		t.Fatalf("first run: got %d new matches, want 2", len(got))
+	}
+
+	// A line inserted above shifts line numbers but the old matches are not new
+	second := []matchResult{{lineNum: 2, content: "ERROR a"}, {lineNum: 3, content: "ERROR b"}, {lineNum: 4, content: "ERROR a"}}
+	got := run("x.log", second)
+	if len(got) != 1 || got[0].lineNum != 4 {
+		t.Fatalf("second run: got %+v, want only the repeated line 4", got)
+	}
+
+	if got := run("x.log", nil); len(got) != 0 {
+		t.Fatalf("empty run: got %+v, want none", got)
+	}
+	if got := run("x.log", first); len(got) != 2 {
+		t.Fatalf("matches removed and added back should be reported again, got %+v", got)
+	}
+
+	// Matches held back (e.g. by --max-results) are still new next time
+	third := append(first, matchResult{lineNum: 3, content: "ERROR c"})
+	if got := h.newMatches("x.log", third); len(got) != 1 {
+		t.Fatalf("third run: got %+v, want ERROR c", got)
+	}
+	if got := run("x.log", third); len(got) != 1 || got[0].content != "ERROR c" {
+		t.Fatalf("an unprinted match should stay new, got %+v", got)
+	}
+
+	// A deleted file, or one below a deleted directory, starts over
+	run(filepath.Join("logs", "y.log"), first)
+	h.forget("x.log")
+	h.forget("logs")
+	if len(h.seen) != 0 {
+		t.Fatalf("history after forget = %v, want empty", h.seen)
+	}
+}
+
+func TestWatchReportsOnlyNewMatches(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "app.log")
+	writeFile(t, path, "ERROR one\nINFO ok\n")
+
+	fs := NewFileSearcher(false, true, false, nil, nil, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	runs := make(chan int, 16)
+	errc := make(chan error, 1)
+
+	waitForRun := func(want int) {
+		t.Helper()
+		deadline := time.After(5 * time.Second)
+		for {
+			select {
+			case n := <-runs:
+				if n == want {
+					return
+				}
+			case <-deadline:
+				t.Fatalf("timed out waiting for a run with %d new match(es)", want)
+			}
+		}
+	}
+
+	output := captureStdout(t, func() {
+		go func() {
+			errc <- fs.watch(ctx, root, []string{"error"}, searchOptions{showLineNumbers: true}, 20*time.Millisecond, func(n int) { runs <- n })
+		}()
+		waitForRun(1)
+
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+		if err != nil {
+			t.Fatalf("open %s: %v", path, err)
+		}
+		f.WriteString("ERROR two\n")
+		f.Close()
+		waitForRun(1)
+
+		cancel()
+		if err := <-errc; err != nil {
+			t.Errorf("watch returned error: %v", err)
+		}
+	})
+
+	if want := "1:ERROR one\n3:ERROR two\n"; output != want {
+		t.Errorf("output = %q, want %q", output, want)
+	}
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"strings"
+
+	"find-everything/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+// commonFileTypes lists the extensions offered when completing --file-types.
+var commonFileTypes = []string{
+	".txt", ".md", ".log", ".json", ".yaml", ".yml", ".xml", ".csv", ".ini", ".conf",
+	".go", ".py", ".js", ".ts", ".jsx", ".tsx", ".java", ".kt", ".rs", ".c", ".cpp", ".h",
+	".cs", ".rb", ".php", ".sh", ".sql", ".html", ".css",
+	".jpg", ".jpeg", ".png", ".gif", ".svg", ".pdf", ".zip", ".tar", ".gz",
+	".mp3", ".mp4", ".mkv",
+}
+
+// commonExcludeDirs lists the well-known directory names offered when completing --exclude-dirs.
+var commonExcludeDirs = []string{
+	"node_modules", ".git", "target", "vendor", "dist", "build",
+	".idea", ".vscode", "__pycache__", ".venv", ".cache",
+}
+
+// registerCompletions hides the default completion command and wires dynamic
+// completions for positional arguments and enum-like flags.
+func registerCompletions(rootCmd *cobra.Command) {
+	rootCmd.CompletionOptions.HiddenDefaultCmd = true
+
+	rootCmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return nil, cobra.ShellCompDirectiveFilterDirs
+		}
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	_ = rootCmd.RegisterFlagCompletionFunc("file-types", staticCompletion(commonFileTypes))
+	_ = rootCmd.RegisterFlagCompletionFunc("exclude-dirs", staticCompletion(commonExcludeDirs))
+	_ = rootCmd.RegisterFlagCompletionFunc("large-results-action", staticCompletion([]string{
+		ui.LargeResultsActionAsk,
+		ui.LargeResultsActionSave,
+		ui.LargeResultsActionDisplay,
+	}))
+	_ = rootCmd.RegisterFlagCompletionFunc("hash", staticCompletion([]string{"md5", "sha1", "sha256"}))
+}
+
+// staticCompletion returns a completion func that offers values matching the typed prefix.
+func staticCompletion(values []string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		var matches []string
+		for _, v := range values {
+			if strings.HasPrefix(v, toComplete) {
+				matches = append(matches, v)
+			}
+		}
+		return matches, cobra.ShellCompDirectiveNoFileComp
+	}
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func runRootCmd(t *testing.T, args ...string) string {
+	t.Helper()
+
+	var out bytes.Buffer
+	rootCmd := newRootCmd()
+	rootCmd.SetOut(&out)
+	rootCmd.SetErr(&out)
+	rootCmd.SetArgs(args)
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("execute %v: %v", args, err)
+	}
+	return out.String()
+}
+
+func TestCompletionScripts(t *testing.T) {
+	tests := []struct {
+		shell string
+		want  string
+	}{
+		{shell: "bash", want: "__start_find-everything"},
+		{shell: "zsh", want: "#compdef find-everything"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			out := runRootCmd(t, "completion", tt.shell)
+			if !strings.Contains(out, tt.want) {
+				t.Fatalf("%s completion script does not contain %q:\n%s", tt.shell, tt.want, out)
+			}
+		})
+	}
+}
+
+func TestCompletionHiddenFromHelp(t *testing.T) {
+	out := runRootCmd(t, "--help")
+	if strings.Contains(out, "completion") {
+		t.Fatalf("help output lists the hidden completion command:\n%s", out)
+	}
+}
+
+func TestFlagCompletions(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "file types",
+			args: []string{"__complete", ".", "*", "--file-types", ""},
+			want: []string{".go", ".txt", ".json"},
+		},
+		{
+			name:    "file types prefix",
+			args:    []string{"__complete", ".", "*", "--file-types", ".j"},
+			want:    []string{".json", ".js", ".jpg"},
+			notWant: []string{".go"},
+		},
+		{
+			name: "exclude dirs",
+			args: []string{"__complete", ".", "*", "--exclude-dirs", ""},
+			want: []string{"node_modules", ".git", "target", "vendor"},
+		},
+		{
+			name: "large results action",
+			args: []string{"__complete", ".", "*", "--large-results-action", ""},
+			want: []string{"ask", "save", "display"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := strings.Split(runRootCmd(t, tt.args...), "\n")
+			for _, want := range tt.want {
+				if !containsLine(lines, want) {
+					t.Errorf("completions %v missing %q", lines, want)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if containsLine(lines, notWant) {
+					t.Errorf("completions %v unexpectedly contain %q", lines, notWant)
+				}
+			}
+		})
+	}
+}
+
+func containsLine(lines []string, want string) bool {
+	for _, line := range lines {
+		if line == want {
+			return true
+		}
+	}
+	return false
+}
@@ -1,20 +1,43 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"runtime"
-	"strconv"
 	"strings"
+	"text/template"
+	"time"
 
 	"common-module/utils"
 	"find-everything/internal/finder"
+	"find-everything/internal/types"
 	"find-everything/internal/ui"
 
 	"github.com/spf13/cobra"
 )
 
+// errNoMatches makes the command exit with status 1 when nothing matched,
+// so scripts can test for existence; it is not printed
+var errNoMatches = errors.New("no matches found")
+
 func Execute() {
+	// Errors raised before the flags are parsed still respect NO_COLOR and redirection
+	ui.SetColor(utils.ColorEnabled(os.Stdout, false))
+	rootCmd := newRootCmd()
+	if err := rootCmd.Execute(); err != nil {
+		if errors.Is(err, errNoMatches) {
+			os.Exit(1)
+		}
+		fmt.Printf("%sError: %v%s\n", ui.Colors.Fail, err, ui.Colors.EndC)
+		os.Exit(1)
+	}
+}
+
+// newRootCmd builds the root command with all flags and completions registered.
+func newRootCmd() *cobra.Command {
 	var (
 		caseSensitive      bool
 		maxWorkers         int
@@ -30,6 +53,24 @@ func Execute() {
 		displayAll         bool
 		outputPath         string
 		largeResultsAction string
+		hashAlgorithm      string
+		hashMaxSize        string
+		noColor            bool
+		relative           bool
+		first              bool
+		countOnly          bool
+		newerThan          string
+		olderThan          string
+		executable         bool
+		format             string
+		printStats         bool
+		xdev               bool
+		minDepth           int
+		maxDepth           int
+		hidden             bool
+		noHidden           bool
+		jsonLines          bool
+		pruneEmptyDirs     bool
 	)
 
 	rootCmd := &cobra.Command{
@@ -42,28 +83,90 @@ support for glob patterns, size filtering, file type filtering, and exclusion ru
 		Example: `  find-everything "C:\" "*.txt" --file-types .txt .log
   find-everything "/home/user" "*.py" --exclude-dirs node_modules .git
   find-everything "D:\" "zalo*" --min-size 1MB --max-size 100MB
-  find-everything "." "*.jpg" --case-sensitive --show-details`,
+  find-everything "." "*.jpg" --case-sensitive --show-details
+  find-everything "." "*.iso" --hash sha256 --hash-max-size 8GB`,
 		Args: cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			basePath := args[0]
 			pattern := args[1]
+			ui.SetColor(utils.ColorEnabled(os.Stdout, noColor))
 
 			resolvedLargeResultsAction, err := resolveLargeResultsAction(cmd, largeResultsAction, displayAll, outputPath)
 			if err != nil {
 				return err
 			}
+			if countOnly && (displayAll || outputPath != "") {
+				return fmt.Errorf("--count prints no paths and cannot be combined with --display-all or --output")
+			}
+			if format != "" && (countOnly || displayAll || outputPath != "") {
+				return fmt.Errorf("--format prints every result itself and cannot be combined with --count, --display-all or --output")
+			}
+			if noHidden && cmd.Flags().Changed("hidden") && hidden {
+				return fmt.Errorf("--hidden and --no-hidden cannot be used together")
+			}
+			if minDepth < 0 || maxDepth < 0 {
+				return fmt.Errorf("--min-depth and --max-depth must not be negative")
+			}
+			if maxDepth > 0 && minDepth > maxDepth {
+				return fmt.Errorf("--min-depth %d is greater than --max-depth %d: nothing could match", minDepth, maxDepth)
+			}
+			if jsonLines && (format != "" || displayAll || outputPath != "") {
+				return fmt.Errorf("--jsonl streams every result itself and cannot be combined with --format, --display-all or --output")
+			}
+			if pruneEmptyDirs && (jsonLines || first) {
+				return fmt.Errorf("--prune-empty-dirs needs the whole search to finish and cannot be combined with --jsonl or --first")
+			}
+			// Only the counts, formatted lines or JSON Lines are printed, so scripts can read them as is
+			plain := countOnly || format != "" || jsonLines
+			if plain {
+				noProgress = true
+			}
+			var formatTmpl *template.Template
+			if format != "" {
+				if formatTmpl, err = ui.ParseFormat(format); err != nil {
+					return err
+				}
+			}
 
 			// Parse size arguments
-			minSizeBytes, err := parseSize(minSize)
+			minSizeBytes, err := utils.ParseSize(minSize)
 			if err != nil {
 				return fmt.Errorf("error parsing min-size: %v", err)
 			}
 
-			maxSizeBytes, err := parseSize(maxSize)
+			maxSizeBytes, err := utils.ParseSize(maxSize)
 			if err != nil {
 				return fmt.Errorf("error parsing max-size: %v", err)
 			}
 
+			hashMaxSizeBytes, err := utils.ParseSize(hashMaxSize)
+			if err != nil {
+				return fmt.Errorf("error parsing hash-max-size: %v", err)
+			}
+
+			newerThanTime, err := referenceModTime("newer-than", newerThan)
+			if err != nil {
+				return err
+			}
+			olderThanTime, err := referenceModTime("older-than", olderThan)
+			if err != nil {
+				return err
+			}
+
+			if xdev && runtime.GOOS == "windows" {
+				fmt.Fprintf(os.Stderr, "%sWarning: --xdev has no effect on Windows, where directories carry no device ID%s\n", ui.Colors.Warning, ui.Colors.EndC)
+			}
+			if executable && runtime.GOOS == "windows" {
+				fmt.Fprintf(os.Stderr, "%sWarning: --executable matches .exe, .bat, .cmd and .ps1 files on Windows; there are no Unix execute bits%s\n", ui.Colors.Warning, ui.Colors.EndC)
+			}
+
+			hashAlgorithm = strings.ToLower(strings.TrimSpace(hashAlgorithm))
+			if hashAlgorithm != "" {
+				if _, err := finder.NewHasher(hashAlgorithm); err != nil {
+					return err
+				}
+			}
+
 			// Process exclude_dirs to handle comma-separated values
 			processedExcludeDirs := []string{}
 			for _, item := range excludeDirs {
@@ -75,12 +178,21 @@ support for glob patterns, size filtering, file type filtering, and exclusion ru
 				}
 			}
 
-			// Clear screen
-			utils.CLS()
+			if !plain {
+				// Clear screen
+				utils.CLS()
+
+				fmt.Printf("%s%sEnhanced File and Directory Finder%s\n", ui.Colors.Bold, ui.Colors.Header, ui.Colors.EndC)
+				fmt.Printf("%sSearching in: %s%s\n", ui.Colors.OKBlue, basePath, ui.Colors.EndC)
+				fmt.Printf("%sPattern: %s%s\n", ui.Colors.OKBlue, pattern, ui.Colors.EndC)
+				if hashAlgorithm != "" {
+					fmt.Printf("%sHash: %s%s\n", ui.Colors.OKBlue, hashAlgorithm, ui.Colors.EndC)
+				}
+			}
 
-			fmt.Printf("%s%sEnhanced File and Directory Finder%s\n", ui.ColorBold, ui.ColorHeader, ui.ColorEndC)
-			fmt.Printf("%sSearching in: %s%s\n", ui.ColorOKBlue, basePath, ui.ColorEndC)
-			fmt.Printf("%sPattern: %s%s\n", ui.ColorOKBlue, pattern, ui.ColorEndC)
+			// Ctrl+C stops the walk (and any in-flight hash) and prints partial results
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
 
 			options := finder.FinderOptions{
 				CaseSensitive:   caseSensitive,
@@ -90,9 +202,28 @@ support for glob patterns, size filtering, file type filtering, and exclusion ru
 				FileTypes:       fileTypes,
 				MinSize:         minSizeBytes,
 				MaxSize:         maxSizeBytes,
+				NewerThan:       newerThanTime,
+				OlderThan:       olderThanTime,
+				Executable:      executable,
+				SameFilesystem:  xdev,
+				MinDepth:        minDepth,
+				MaxDepth:        maxDepth,
+				SkipHidden:      noHidden || !hidden,
+				PruneEmptyDirs:  pruneEmptyDirs,
 				MaxResults:      maxResults,
 				ShowProgress:    !noProgress,
 				NoSort:          noSort,
+				First:           first,
+				HashAlgorithm:   hashAlgorithm,
+				HashMaxSize:     hashMaxSizeBytes,
+				Ctx:             ctx,
+			}
+			// With --count, --jsonl only changes how the counts are printed
+			streaming := jsonLines && !countOnly
+			var streamed chan types.SearchResult
+			if streaming {
+				streamed = make(chan types.SearchResult, 256)
+				options.Results = streamed
 			}
 
 			f, err := finder.NewFileFinder(basePath, pattern, options)
@@ -100,15 +231,69 @@ support for glob patterns, size filtering, file type filtering, and exclusion ru
 				return err
 			}
 
+			// --jsonl: one goroutine writes the results while the workers find them
+			var streamedFiles, streamedDirs int
+			var streamErr error
+			streamDone := make(chan struct{})
+			if streaming {
+				go func() {
+					defer close(streamDone)
+					streamedFiles, streamedDirs, streamErr = ui.WriteJSONLines(os.Stdout, streamed, ui.ResultsOutputOptions{
+						BasePath: basePath,
+						Relative: relative,
+					})
+				}()
+			}
+
 			files, dirs := f.FindFilesAndDirs()
-			return ui.PrintResults(files, dirs, ui.ResultsOutputOptions{
-				ShowDetails:        showDetails,
-				Pattern:            pattern,
-				BasePath:           basePath,
-				NoSort:             noSort,
-				LargeResultsAction: resolvedLargeResultsAction,
-				OutputPath:         outputPath,
-			})
+			filesCount, dirsCount := len(files), len(dirs)
+			if streaming {
+				<-streamDone
+				if streamErr != nil {
+					return fmt.Errorf("writing results: %v", streamErr)
+				}
+				filesCount, dirsCount = streamedFiles, streamedDirs
+			} else if countOnly && jsonLines {
+				ui.PrintCountsJSON(os.Stdout, filesCount, dirsCount)
+			} else if countOnly {
+				ui.PrintCounts(os.Stdout, filesCount, dirsCount)
+			} else if formatTmpl != nil {
+				err = ui.PrintFormatted(os.Stdout, formatTmpl, files, dirs, ui.ResultsOutputOptions{
+					BasePath: basePath,
+					NoSort:   noSort,
+					Relative: relative,
+				})
+				if err != nil {
+					return err
+				}
+			} else {
+				err = ui.PrintResults(files, dirs, ui.ResultsOutputOptions{
+					ShowDetails:        showDetails,
+					Pattern:            pattern,
+					BasePath:           basePath,
+					NoSort:             noSort,
+					Relative:           relative,
+					LargeResultsAction: resolvedLargeResultsAction,
+					OutputPath:         outputPath,
+				})
+				if err != nil {
+					return err
+				}
+			}
+			if printStats {
+				// Keep stdout to the counts, formatted lines or JSON when a script reads them
+				statsOut := os.Stdout
+				if plain {
+					statsOut = os.Stderr
+				}
+				ui.PrintStats(statsOut, f.Stats(), filesCount, dirsCount)
+			}
+			if filesCount+dirsCount == 0 {
+				cmd.SilenceErrors = true
+				cmd.SilenceUsage = true
+				return errNoMatches
+			}
+			return nil
 		},
 	}
 
@@ -120,18 +305,49 @@ support for glob patterns, size filtering, file type filtering, and exclusion ru
 	rootCmd.Flags().StringSliceVarP(&fileTypes, "file-types", "t", []string{}, "File extensions to include")
 	rootCmd.Flags().StringVar(&minSize, "min-size", "0", "Minimum file size (e.g., 1KB, 1MB, 1GB)")
 	rootCmd.Flags().StringVar(&maxSize, "max-size", "inf", "Maximum file size (e.g., 1KB, 1MB, 1GB)")
+	rootCmd.Flags().StringVar(&newerThan, "newer-than", "", "Only match files modified after this reference file was")
+	rootCmd.Flags().StringVar(&olderThan, "older-than", "", "Only match files modified before this reference file was")
+	rootCmd.Flags().BoolVar(&executable, "executable", false, "Only match files with an execute bit set (on Windows: .exe, .bat, .cmd and .ps1 files)")
+	rootCmd.Flags().BoolVar(&xdev, "xdev", false, "Do not descend into directories on other file systems, e.g. /proc or network mounts (no effect on Windows)")
+	rootCmd.Flags().IntVar(&minDepth, "min-depth", 0, "Only match entries at least this many levels below base-path; its own entries are level 1 (deeper levels are still walked)")
+	rootCmd.Flags().IntVar(&maxDepth, "max-depth", 0, "Do not descend more than this many levels below base-path; 1 searches only its own entries (0 = unlimited)")
+	rootCmd.Flags().BoolVar(&hidden, "hidden", true, "Include entries whose name starts with a dot")
+	rootCmd.Flags().BoolVar(&noHidden, "no-hidden", false, "Skip entries whose name starts with a dot and do not descend into such directories (on Windows also entries with the hidden attribute)")
+	rootCmd.Flags().BoolVar(&pruneEmptyDirs, "prune-empty-dirs", false, "Only list matched directories that contain a matched file at some depth")
 	rootCmd.Flags().IntVar(&maxResults, "max-results", 10000, "Maximum number of results to find")
 	rootCmd.Flags().BoolVar(&noProgress, "no-progress", false, "Disable progress display")
 	rootCmd.Flags().BoolVarP(&showDetails, "show-details", "d", false, "Show file sizes and details")
+	rootCmd.Flags().BoolVar(&noColor, "no-color", false, "Disable ANSI colors (also off when NO_COLOR is set or stdout is not a terminal)")
+	rootCmd.Flags().BoolVar(&first, "first", false, "Stop at the first match (exit status is 0 when something matched, 1 otherwise)")
+	rootCmd.Flags().BoolVar(&first, "stop-first-match", false, "Alias for --first")
+	rootCmd.Flags().BoolVar(&countOnly, "count", false, "Print only the number of matching files and directories (implies --no-progress); with --jsonl as {\"files\":N,\"dirs\":M,\"total\":K}")
+	rootCmd.Flags().StringVar(&format, "format", "", "Print each result with a Go template, e.g. '{{.FullPath}}\\t{{.Size}}' (fields: Path, FullPath, RelPath, IsDir, Size, Hash, ModTime; func: humanSize)")
+	rootCmd.Flags().BoolVar(&jsonLines, "jsonl", false, "Stream each result as a JSON object on its own line as soon as it is found, unsorted (fields: path, full_path, rel_path with --relative, is_dir, size, hash)")
+	rootCmd.Flags().BoolVar(&printStats, "print-stats", false, "Print directories walked and excluded, matches and elapsed time after the results (stderr with --count or --format)")
+	rootCmd.Flags().BoolVar(&relative, "relative", false, "Print result paths relative to base-path")
 	rootCmd.Flags().BoolVar(&noSort, "no-sort", false, "Skip sorting results (faster for large result sets)")
 	rootCmd.Flags().BoolVar(&displayAll, "display-all", false, "Display all results in terminal when result count exceeds 100")
 	rootCmd.Flags().StringVar(&outputPath, "output", "", "Save large result output to the specified file path")
 	rootCmd.Flags().StringVar(&largeResultsAction, "large-results-action", ui.LargeResultsActionAsk, "Action for more than 100 results: ask, save, or display")
+	rootCmd.Flags().StringVar(&hashAlgorithm, "hash", "", "Compute a checksum for each matched file: md5, sha1, or sha256")
+	rootCmd.Flags().StringVar(&hashMaxSize, "hash-max-size", "1GB", "Skip hashing files larger than this size (e.g., 100MB, 1GB)")
 
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Printf("%sError: %v%s\n", ui.ColorFail, err, ui.ColorEndC)
-		os.Exit(1)
+	registerCompletions(rootCmd)
+
+	return rootCmd
+}
+
+// referenceModTime returns the modification time of the reference file given
+// to --flag, or the zero time when the flag is empty
+func referenceModTime(flag, path string) (time.Time, error) {
+	if path == "" {
+		return time.Time{}, nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("--%s reference: %v", flag, err)
 	}
+	return info.ModTime(), nil
 }
 
 func resolveLargeResultsAction(cmd *cobra.Command, action string, displayAll bool, outputPath string) (string, error) {
@@ -167,38 +383,3 @@ func resolveLargeResultsAction(cmd *cobra.Command, action string, displayAll boo
 
 	return normalizedAction, nil
 }
-
-func parseSize(sizeStr string) (int64, error) {
-	if strings.ToLower(sizeStr) == "inf" {
-		return 1<<63 - 1, nil // Max int64
-	}
-
-	sizeStr = strings.ToUpper(sizeStr)
-
-	// Ordered from longest suffix to shortest to avoid ambiguous matching
-	// (e.g., "1KB" matching "B" before "KB")
-	units := []struct {
-		suffix     string
-		multiplier int64
-	}{
-		{"TB", 1024 * 1024 * 1024 * 1024},
-		{"GB", 1024 * 1024 * 1024},
-		{"MB", 1024 * 1024},
-		{"KB", 1024},
-		{"B", 1},
-	}
-
-	for _, u := range units {
-		if strings.HasSuffix(sizeStr, u.suffix) {
-			numStr := strings.TrimSuffix(sizeStr, u.suffix)
-			num, err := strconv.ParseFloat(numStr, 64)
-			if err != nil {
-				return 0, err
-			}
-			return int64(num * float64(u.multiplier)), nil
-		}
-	}
-
-	// No unit specified, assume bytes
-	return strconv.ParseInt(sizeStr, 10, 64)
-}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// captureStdout runs the root command with args and returns what it wrote
+// to os.Stdout, where the result writers print directly.
+func captureStdout(t *testing.T, args ...string) string {
+	t.Helper()
+
+	oldStdout := os.Stdout
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("create stdout pipe: %v", err)
+	}
+	done := make(chan []byte)
+	go func() {
+		output, _ := io.ReadAll(reader)
+		done <- output
+	}()
+
+	os.Stdout = writer
+	rootCmd := newRootCmd()
+	rootCmd.SetArgs(args)
+	execErr := rootCmd.Execute()
+	writer.Close()
+	os.Stdout = oldStdout
+
+	output := <-done
+	if execErr != nil {
+		t.Fatalf("execute %v: %v", args, execErr)
+	}
+	return string(output)
+}
+
+func TestJSONLinesWithUnreadableDir(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("needs a directory the current user cannot read")
+	}
+	base := t.TempDir()
+	locked := filepath.Join(base, "locked")
+	if err := os.Mkdir(locked, 0o755); err != nil {
+		t.Fatalf("create locked: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(base, "app.log"), []byte("x"), 0o644); err != nil {
+		t.Fatalf("write app.log: %v", err)
+	}
+	if err := os.Chmod(locked, 0); err != nil {
+		t.Fatalf("chmod locked: %v", err)
+	}
+	t.Cleanup(func() { os.Chmod(locked, 0o755) })
+
+	output := captureStdout(t, base, "*.log", "--jsonl")
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	for _, line := range lines {
+		var result map[string]any
+		if err := json.Unmarshal([]byte(line), &result); err != nil {
+			t.Errorf("stdout line %q is not JSON: %v", line, err)
+		}
+	}
+	if len(lines) != 1 || !strings.Contains(output, "app.log") {
+		t.Errorf("stdout = %q, want only the app.log result", output)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package finder
+
+// deviceID is unavailable without syscall.Stat_t.Dev (e.g. on Windows), which
+// makes --xdev a no-op
+func deviceID(path string) (uint64, bool) {
+	return 0, false
+}
@@ -0,0 +1,14 @@
+//go:build unix
+
+package finder
+
+import "syscall"
+
+// deviceID returns the ID of the device (file system) path lives on
+func deviceID(path string) (uint64, bool) {
+	var st syscall.Stat_t
+	if err := syscall.Stat(path, &st); err != nil {
+		return 0, false
+	}
+	return uint64(st.Dev), true
+}
@@ -3,12 +3,17 @@ package finder
 import (
 	"context"
 	"fmt"
+	"hash"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"strings"
+	"sync/atomic"
+	"time"
 
+	"find-everything/internal/types"
 	"find-everything/internal/ui"
 )
 
@@ -21,9 +26,27 @@ type FinderOptions struct {
 	FileTypes       []string
 	MinSize         int64
 	MaxSize         int64
+	NewerThan       time.Time // zero means no lower bound on ModTime
+	OlderThan       time.Time // zero means no upper bound on ModTime
+	Executable      bool      // only files with an execute bit (an executable extension on Windows)
+	SameFilesystem  bool      // do not descend into directories on another device (--xdev)
+	MinDepth        int       // only match entries at least this deep; basePath's entries are depth 1 (0 = no limit)
+	MaxDepth        int       // do not walk deeper than this (0 = unlimited)
+	SkipHidden      bool      // skip dot-entries (and, on Windows, entries with the hidden attribute)
+	PruneEmptyDirs  bool      // only return matched directories that contain a matched file at some depth
 	ShowProgress    bool
 	MaxResults      int
 	NoSort          bool
+	First           bool            // stop the search at the first match
+	HashAlgorithm   string          // md5, sha1, sha256; empty disables hashing
+	HashMaxSize     int64           // files larger than this are not hashed
+	Ctx             context.Context // optional parent context for cancellation
+
+	// Results, when set, receives every match as soon as it is found (with
+	// Path and FullPath both the walked path) instead of FindFilesAndDirs
+	// returning it. FindFilesAndDirs closes the channel when the search ends;
+	// the receiver must keep reading until then.
+	Results chan<- types.SearchResult
 }
 
 // FileFinder handles file and directory searching
@@ -37,17 +60,43 @@ type FileFinder struct {
 	fileTypes       map[string]bool
 	minSize         int64
 	maxSize         int64
+	newerThan       time.Time
+	olderThan       time.Time
+	executable      bool
 	showProgress    bool
 	maxResults      int
 	noSort          bool
+	first           bool
+	newHash         func() hash.Hash
+	hashMaxSize     int64
 	progressTracker *ui.ProgressTracker
 	patternRegex    *regexp.Regexp
 	fastMatch       func(string) bool
+	pathPattern     bool // the pattern has a '/' or "**": match paths relative to basePath, not names
 	ctx             context.Context
 	cancel          context.CancelFunc
+
+	// --xdev: stay on the file system of basePath
+	xdev    bool
+	baseDev uint64
+
+	// --min-depth / --max-depth, in levels below basePath (0 = no limit)
+	minDepth int
+	maxDepth int
+
+	skipHidden     bool // --no-hidden
+	pruneEmptyDirs bool // --prune-empty-dirs
+
+	results  chan<- types.SearchResult
+	streamed atomic.Int64 // matches claimed for results, to enforce maxResults
 }
 
 func NewFileFinder(basePath, pattern string, opts FinderOptions) (*FileFinder, error) {
+	pathPattern := strings.Contains(pattern, "/") || strings.Contains(pattern, "**")
+	if pathPattern {
+		pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "./")
+	}
+
 	// Compile pattern regex
 	regexPattern := GlobToRegex(pattern)
 	if !opts.CaseSensitive {
@@ -80,14 +129,36 @@ func NewFileFinder(basePath, pattern string, opts FinderOptions) (*FileFinder, e
 		fileTypes[strings.ToLower(ext)] = true
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
+	var newHash func() hash.Hash
+	if opts.HashAlgorithm != "" {
+		newHash, err = NewHasher(opts.HashAlgorithm)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	parent := opts.Ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parent)
 	maxWorkers := opts.MaxWorkers
 	if maxWorkers <= 0 {
 		maxWorkers = 1
 	}
 
-	// Build fast matcher for simple glob patterns
-	fastMatch := buildFastMatcher(pattern, opts.CaseSensitive)
+	// Build fast matcher for simple glob patterns; its '*' would cross '/'
+	var fastMatch func(string) bool
+	if !pathPattern {
+		fastMatch = buildFastMatcher(pattern, opts.CaseSensitive)
+	}
+
+	// Without device IDs (Windows) --xdev cannot tell mounts apart and is off
+	var baseDev uint64
+	xdev := false
+	if opts.SameFilesystem {
+		baseDev, xdev = deviceID(basePath)
+	}
 
 	return &FileFinder{
 		basePath:        basePath,
@@ -99,17 +170,36 @@ func NewFileFinder(basePath, pattern string, opts FinderOptions) (*FileFinder, e
 		fileTypes:       fileTypes,
 		minSize:         opts.MinSize,
 		maxSize:         opts.MaxSize,
+		newerThan:       opts.NewerThan,
+		olderThan:       opts.OlderThan,
+		executable:      opts.Executable,
 		showProgress:    opts.ShowProgress,
 		maxResults:      opts.MaxResults,
 		noSort:          opts.NoSort,
+		first:           opts.First,
+		newHash:         newHash,
+		hashMaxSize:     opts.HashMaxSize,
 		progressTracker: ui.NewProgressTracker(),
 		patternRegex:    patternRegex,
 		fastMatch:       fastMatch,
+		pathPattern:     pathPattern,
+		xdev:            xdev,
+		baseDev:         baseDev,
+		minDepth:        opts.MinDepth,
+		maxDepth:        opts.MaxDepth,
+		skipHidden:      opts.SkipHidden,
+		pruneEmptyDirs:  opts.PruneEmptyDirs,
+		results:         opts.Results,
 		ctx:             ctx,
 		cancel:          cancel,
 	}, nil
 }
 
+// Stats returns the walk counters and elapsed time of the search
+func (ff *FileFinder) Stats() ui.SearchStats {
+	return ff.progressTracker.Stats()
+}
+
 // ShouldExcludeDir checks if a directory should be excluded by name.
 // Only needs the directory's own name — parent directories were already
 // checked during traversal, so excluded parents are never queued.
@@ -127,6 +217,8 @@ func (ff *FileFinder) ShouldExcludeByPattern(fullPath string) bool {
 	return false
 }
 
+// MatchesPattern checks name against the pattern. For a path pattern name
+// must be the '/'-separated path relative to basePath (see matchTarget).
 func (ff *FileFinder) MatchesPattern(name string) bool {
 	if ff.fastMatch != nil {
 		return ff.fastMatch(name)
@@ -134,32 +226,111 @@ func (ff *FileFinder) MatchesPattern(name string) bool {
 	return ff.patternRegex.MatchString(name)
 }
 
-// GetFileSizeFromEntry gets file size from a DirEntry.
-// For symlinks, falls back to os.Stat to follow the link and get the target size.
-func (ff *FileFinder) GetFileSizeFromEntry(entry fs.DirEntry, fullPath string) (int64, bool) {
-	// Symlink: entry.Info() returns symlink size, not target size
+// infoFromEntry gets the FileInfo of a DirEntry.
+// For symlinks, falls back to os.Stat to follow the link and describe the target.
+func infoFromEntry(entry fs.DirEntry, fullPath string) (fs.FileInfo, bool) {
+	// Symlink: entry.Info() describes the symlink, not its target
 	if entry.Type()&fs.ModeSymlink != 0 {
 		info, err := os.Stat(fullPath)
 		if err != nil {
-			return 0, false
+			return nil, false
 		}
-		return info.Size(), true
+		return info, true
 	}
 	info, err := entry.Info()
 	if err != nil {
+		return nil, false
+	}
+	return info, true
+}
+
+// otherDevice reports whether dir lies on another file system than basePath
+// and must not be entered with --xdev
+func (ff *FileFinder) otherDevice(dir string) bool {
+	if !ff.xdev {
+		return false
+	}
+	dev, ok := deviceID(dir)
+	return ok && dev != ff.baseDev
+}
+
+// isHidden reports whether an entry is hidden: its name starts with a dot or,
+// on Windows, it has the hidden attribute
+func isHidden(entryName, fullPath string) bool {
+	return strings.HasPrefix(entryName, ".") || hasHiddenAttribute(fullPath)
+}
+
+// entryDepth returns the depth of the entries of dir, a walked directory:
+// 1 for those of basePath itself
+func (ff *FileFinder) entryDepth(dir string) int {
+	if len(dir) == len(ff.basePath) {
+		return 1
+	}
+	// Every walked path is basePath + pathSep + ...
+	return strings.Count(dir[len(ff.basePath)+len(pathSep):], pathSep) + 2
+}
+
+// matchTarget returns what MatchesPattern checks for the entry at fullPath:
+// its name, or with a path pattern its path relative to basePath
+func (ff *FileFinder) matchTarget(fullPath, entryName string) string {
+	if !ff.pathPattern {
+		return entryName
+	}
+	// Every walked path is basePath + pathSep + ...
+	return filepath.ToSlash(fullPath[len(ff.basePath)+len(pathSep):])
+}
+
+// GetFileSizeFromEntry gets file size from a DirEntry.
+// For symlinks, falls back to os.Stat to follow the link and get the target size.
+func (ff *FileFinder) GetFileSizeFromEntry(entry fs.DirEntry, fullPath string) (int64, bool) {
+	info, ok := infoFromEntry(entry, fullPath)
+	if !ok {
 		return 0, false
 	}
 	return info.Size(), true
 }
 
-// CheckFileSize validates file size against min/max bounds using DirEntry.
+// hasInfoFilter reports whether files must be stat'ed to be filtered
+func (ff *FileFinder) hasInfoFilter() bool {
+	return ff.minSize > 0 || ff.maxSize < (1<<63-1) || !ff.newerThan.IsZero() || !ff.olderThan.IsZero() || ff.executable
+}
+
+// CheckFileInfo validates file size against min/max bounds, the
+// modification time against newerThan/olderThan and, with executable, the
+// mode using DirEntry.
 // Returns (size, passedFilter).
-func (ff *FileFinder) CheckFileSize(entry fs.DirEntry, fullPath string) (int64, bool) {
-	size, ok := ff.GetFileSizeFromEntry(entry, fullPath)
+func (ff *FileFinder) CheckFileInfo(entry fs.DirEntry, fullPath string) (int64, bool) {
+	info, ok := infoFromEntry(entry, fullPath)
 	if !ok {
 		return 0, false
 	}
-	return size, size >= ff.minSize && size <= ff.maxSize
+	size := info.Size()
+	if size < ff.minSize || size > ff.maxSize {
+		return size, false
+	}
+	modTime := info.ModTime()
+	if !ff.newerThan.IsZero() && !modTime.After(ff.newerThan) {
+		return size, false
+	}
+	if !ff.olderThan.IsZero() && !modTime.Before(ff.olderThan) {
+		return size, false
+	}
+	if ff.executable && !IsExecutable(info, runtime.GOOS) {
+		return size, false
+	}
+	return size, true
+}
+
+// windowsExecutableExts approximates the execute bit, which Windows does not have
+var windowsExecutableExts = map[string]bool{".exe": true, ".bat": true, ".cmd": true, ".ps1": true}
+
+// IsExecutable reports whether info describes an executable file on goos:
+// any execute bit set on Unix, a well-known executable extension on Windows.
+func IsExecutable(info fs.FileInfo, goos string) bool {
+	if goos == "windows" {
+		return windowsExecutableExts[strings.ToLower(filepath.Ext(info.Name()))]
+	}
+	return info.Mode()&0o111 != 0
 }
 
 func (ff *FileFinder) CheckFileType(entryName string) bool {
@@ -172,10 +343,18 @@ func (ff *FileFinder) CheckFileType(entryName string) bool {
 
 // Utility functions
 
+// GlobToRegex translates a glob to an anchored regex. '*' matches within one
+// path component and '?' one character; "**" matches across components, and
+// "**/" any number of leading directories, including none, so "**/*.go"
+// matches both "main.go" and "a/b/main.go".
 func GlobToRegex(pattern string) string {
 	pattern = regexp.QuoteMeta(pattern)
-	pattern = strings.ReplaceAll(pattern, "\\*", ".*")
-	pattern = strings.ReplaceAll(pattern, "\\?", ".")
+	pattern = strings.ReplaceAll(pattern, `\*\*/`, "\x00")
+	pattern = strings.ReplaceAll(pattern, `\*\*`, "\x01")
+	pattern = strings.ReplaceAll(pattern, `\*`, "[^/]*")
+	pattern = strings.ReplaceAll(pattern, `\?`, "[^/]")
+	pattern = strings.ReplaceAll(pattern, "\x00", "(?:.*/)?")
+	pattern = strings.ReplaceAll(pattern, "\x01", ".*")
 	return "^" + pattern + "$"
 }
 
@@ -225,4 +404,3 @@ func buildFastMatcher(pattern string, caseSensitive bool) func(string) bool {
 
 	return nil // complex pattern, fallback to regex
 }
-
@@ -0,0 +1,80 @@
+package finder
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"testing"
+)
+
+func TestGlobToRegex(t *testing.T) {
+	tests := []struct {
+		pattern string
+		match   []string
+		noMatch []string
+	}{
+		{"*.go", []string{"main.go", ".go"}, []string{"main.go.bak", "a/main.go"}},
+		{"file?.txt", []string{"file1.txt"}, []string{"file10.txt", "file/.txt"}},
+		{"**/*.go", []string{"main.go", "a/main.go", "a/b/c/main.go"}, []string{"main.js", "a/main.go/x"}},
+		{"src/**/test_*.py", []string{"src/test_a.py", "src/x/y/test_b.py"}, []string{"test_a.py", "lib/src/test_a.py"}},
+		{"docs/*", []string{"docs/readme.md"}, []string{"docs/a/readme.md"}},
+		{"logs/**", []string{"logs/a", "logs/a/b.log"}, []string{"logs", "other/a"}},
+		{"a+b(1).txt", []string{"a+b(1).txt"}, []string{"aab1.txt"}},
+	}
+	for _, tt := range tests {
+		re := regexp.MustCompile(GlobToRegex(tt.pattern))
+		for _, s := range tt.match {
+			if !re.MatchString(s) {
+				t.Errorf("%q should match %q (regex %s)", tt.pattern, s, re)
+			}
+		}
+		for _, s := range tt.noMatch {
+			if re.MatchString(s) {
+				t.Errorf("%q should not match %q (regex %s)", tt.pattern, s, re)
+			}
+		}
+	}
+}
+
+func TestFindFilesAndDirsPathPattern(t *testing.T) {
+	base := t.TempDir()
+	for _, name := range []string{"main.go", "a/b/c/main.go", "a/b/util.go", "a/readme.md", "vendor/x.go"} {
+		path := filepath.Join(base, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("create %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"**/*.go", []string{"a/b/c/main.go", "a/b/util.go", "main.go", "vendor/x.go"}},
+		{"./a/**/main.go", []string{"a/b/c/main.go"}},
+		{"a/*/util.go", []string{"a/b/util.go"}},
+		{"a/*", []string{"a/b", "a/readme.md"}},
+	}
+	for _, tt := range tests {
+		ff, err := NewFileFinder(base, tt.pattern, FinderOptions{MaxWorkers: 2, MaxSize: 1<<63 - 1, MaxResults: 100})
+		if err != nil {
+			t.Fatalf("NewFileFinder(%q) returned error: %v", tt.pattern, err)
+		}
+		files, dirs := ff.FindFilesAndDirs()
+		var got []string
+		for _, f := range files {
+			got = append(got, ff.matchTarget(f.Path, ""))
+		}
+		for _, d := range dirs {
+			got = append(got, ff.matchTarget(d, ""))
+		}
+		sort.Strings(got)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%q: got %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+}
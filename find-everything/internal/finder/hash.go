@@ -0,0 +1,81 @@
+package finder
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"sync"
+)
+
+// HashSkipped marks files larger than the configured hash size limit.
+const HashSkipped = "(skipped)"
+
+// HashError marks files whose contents could not be read for hashing.
+const HashError = "(error)"
+
+// hashBufferSize is the read chunk size used while streaming file contents.
+const hashBufferSize = 1 << 20
+
+var hashBufferPool = sync.Pool{
+	New: func() any {
+		buf := make([]byte, hashBufferSize)
+		return &buf
+	},
+}
+
+// NewHasher returns a constructor for the named digest algorithm.
+func NewHasher(algorithm string) (func() hash.Hash, error) {
+	switch algorithm {
+	case "md5":
+		return md5.New, nil
+	case "sha1":
+		return sha1.New, nil
+	case "sha256":
+		return sha256.New, nil
+	default:
+		return nil, fmt.Errorf("unsupported hash algorithm %q (supported: md5, sha1, sha256)", algorithm)
+	}
+}
+
+// hashFile streams the file through the configured digest. It checks ctx
+// between chunks so cancellation does not wait for a huge file to finish.
+func (ff *FileFinder) hashFile(ctx context.Context, path string, size int64) (string, error) {
+	if size > ff.hashMaxSize {
+		return HashSkipped, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return HashError, nil
+	}
+	defer file.Close()
+
+	h := ff.newHash()
+	bufPtr := hashBufferPool.Get().(*[]byte)
+	defer hashBufferPool.Put(bufPtr)
+	buf := *bufPtr
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		n, readErr := file.Read(buf)
+		if n > 0 {
+			h.Write(buf[:n])
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return HashError, nil
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,67 @@
+package finder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindFilesAndDirsHashesMatches(t *testing.T) {
+	base := t.TempDir()
+	if err := os.WriteFile(filepath.Join(base, "small.txt"), []byte("hello"), 0o644); err != nil {
+		t.Fatalf("write small file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(base, "large.txt"), make([]byte, 64), 0o644); err != nil {
+		t.Fatalf("write large file: %v", err)
+	}
+
+	ff, err := NewFileFinder(base, "*.txt", FinderOptions{
+		MaxWorkers:    2,
+		MaxSize:       1<<63 - 1,
+		MaxResults:    100,
+		HashAlgorithm: "sha256",
+		HashMaxSize:   32,
+	})
+	if err != nil {
+		t.Fatalf("NewFileFinder returned error: %v", err)
+	}
+
+	files, _ := ff.FindFilesAndDirs()
+	hashes := make(map[string]string)
+	for _, f := range files {
+		hashes[filepath.Base(f.Path)] = f.Hash
+	}
+
+	const helloSHA256 = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	if hashes["small.txt"] != helloSHA256 {
+		t.Errorf("small.txt hash = %q, want %q", hashes["small.txt"], helloSHA256)
+	}
+	if hashes["large.txt"] != HashSkipped {
+		t.Errorf("large.txt hash = %q, want %q", hashes["large.txt"], HashSkipped)
+	}
+}
+
+func TestHashFileStopsOnCancelledContext(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.bin")
+	if err := os.WriteFile(path, []byte("data"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	ff, err := NewFileFinder(t.TempDir(), "*", FinderOptions{HashAlgorithm: "md5", HashMaxSize: 1 << 20})
+	if err != nil {
+		t.Fatalf("NewFileFinder returned error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ff.hashFile(ctx, path, 4); err == nil {
+		t.Fatal("hashFile returned nil error for cancelled context")
+	}
+}
+
+func TestNewHasherRejectsUnknownAlgorithm(t *testing.T) {
+	if _, err := NewHasher("crc32"); err == nil {
+		t.Fatal("NewHasher returned nil error for unsupported algorithm")
+	}
+}
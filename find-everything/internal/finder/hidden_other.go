@@ -0,0 +1,9 @@
+//go:build !windows
+
+package finder
+
+// hasHiddenAttribute is always false outside Windows, where only the leading
+// dot marks hidden entries
+func hasHiddenAttribute(path string) bool {
+	return false
+}
@@ -0,0 +1,15 @@
+//go:build windows
+
+package finder
+
+import "syscall"
+
+// hasHiddenAttribute reports whether path carries the Windows hidden attribute
+func hasHiddenAttribute(path string) bool {
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return false
+	}
+	attrs, err := syscall.GetFileAttributes(p)
+	return err == nil && attrs&syscall.FILE_ATTRIBUTE_HIDDEN != 0
+}
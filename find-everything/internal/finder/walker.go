@@ -3,6 +3,7 @@ package finder
 import (
 	"fmt"
 	"os"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -17,7 +18,7 @@ func (ff *FileFinder) FindFilesAndDirs() ([]types.FileResult, []string) {
 	defer ff.cancel()
 
 	if ff.showProgress {
-		fmt.Printf("%sStarting search...%s\n", ui.ColorOKBlue, ui.ColorEndC)
+		fmt.Printf("%sStarting search...%s\n", ui.Colors.OKBlue, ui.Colors.EndC)
 	}
 
 	// Start progress updater goroutine
@@ -53,7 +54,7 @@ func (ff *FileFinder) FindFilesAndDirs() ([]types.FileResult, []string) {
 	var skippedDirs int64
 
 	hasExcludePatterns := len(ff.excludePatterns) > 0
-	hasSizeFilter := ff.minSize > 0 || ff.maxSize < (1<<63-1)
+	hasInfoFilter := ff.hasInfoFilter()
 
 	// Start workers
 	for i := 0; i < ff.maxWorkers; i++ {
@@ -87,7 +88,7 @@ func (ff *FileFinder) FindFilesAndDirs() ([]types.FileResult, []string) {
 			defer flush()
 
 			for path := range dirQueue {
-				processDir(ff, path, dirQueue, &processingWg, &localFiles, &localDirs, &totalDirs, &skippedDirs, hasExcludePatterns, hasSizeFilter)
+				processDir(ff, path, dirQueue, &processingWg, &localFiles, &localDirs, &totalDirs, &skippedDirs, hasExcludePatterns, hasInfoFilter)
 
 				// Flush periodically
 				if len(localFiles)+len(localDirs) > 100 {
@@ -114,20 +115,91 @@ func (ff *FileFinder) FindFilesAndDirs() ([]types.FileResult, []string) {
 
 	// Wait for all workers to finish
 	workerWg.Wait()
+	ff.progressTracker.Stop()
+	if ff.results != nil {
+		close(ff.results)
+	}
 
 	if ff.showProgress {
 		fmt.Println() // New line after progress
 	}
 
+	// Workers racing the cancellation may have found a few more
+	if ff.first {
+		if len(matchedFiles) > 0 {
+			matchedFiles, matchedDirs = matchedFiles[:1], nil
+		} else if len(matchedDirs) > 0 {
+			matchedDirs = matchedDirs[:1]
+		}
+	}
+
+	if ff.pruneEmptyDirs {
+		matchedDirs = ff.dirsWithFiles(matchedDirs, matchedFiles)
+	}
+
 	if skipped := atomic.LoadInt64(&skippedDirs); skipped > 0 {
-		fmt.Printf("%sWarning: %d directories could not be read (permission denied or other errors)%s\n",
-			ui.ColorWarning, skipped, ui.ColorEndC)
+		// stderr keeps --jsonl, --count and --format output parseable
+		fmt.Fprintf(os.Stderr, "%sWarning: %d directories could not be read (permission denied or other errors)%s\n",
+			ui.Colors.Warning, skipped, ui.Colors.EndC)
 	}
 
 	return matchedFiles, matchedDirs
 }
 
-func processDir(ff *FileFinder, path string, dirQueue chan string, wg *sync.WaitGroup, localFiles *[]types.FileResult, localDirs *[]string, totalDirs *int64, skippedDirs *int64, hasExcludePatterns bool, hasSizeFilter bool) {
+// dirsWithFiles returns the dirs that contain one of files at some depth.
+// Whether a directory holds a match is only known once everything below it
+// was walked, so this filters the results rather than the walk.
+func (ff *FileFinder) dirsWithFiles(dirs []string, files []types.FileResult) []string {
+	// Walked paths are basePath + pathSep + ..., so parents are string prefixes
+	ancestors := make(map[string]bool)
+	for _, f := range files {
+		for dir := f.Path; ; {
+			i := strings.LastIndex(dir, pathSep)
+			if i <= len(ff.basePath) {
+				break
+			}
+			dir = dir[:i]
+			if ancestors[dir] {
+				break // its own parents were added with it
+			}
+			ancestors[dir] = true
+		}
+	}
+	kept := dirs[:0]
+	for _, dir := range dirs {
+		if ancestors[dir] {
+			kept = append(kept, dir)
+		}
+	}
+	return kept
+}
+
+// stream hands a match to the Results channel, up to maxResults of them (one
+// with first). It returns false once the search is over.
+func (ff *FileFinder) stream(r types.SearchResult) bool {
+	limit := int64(ff.maxResults)
+	if ff.first {
+		limit = 1
+	}
+	n := ff.streamed.Add(1)
+	if n > limit {
+		ff.cancel()
+		return false
+	}
+	// The reader drains the channel until it is closed, so this cannot block for good
+	ff.results <- r
+	if n == limit {
+		ff.cancel()
+	}
+	return true
+}
+
+func processDir(ff *FileFinder, path string, dirQueue chan string, wg *sync.WaitGroup, localFiles *[]types.FileResult, localDirs *[]string, totalDirs *int64, skippedDirs *int64, hasExcludePatterns bool, hasInfoFilter bool) {
+	// Directories still queued when the search is cancelled are drained unread
+	if ff.ctx.Err() != nil {
+		return
+	}
+
 	entries, err := os.ReadDir(path)
 	if err != nil {
 		atomic.AddInt64(skippedDirs, 1)
@@ -137,6 +209,16 @@ func processDir(ff *FileFinder, path string, dirQueue chan string, wg *sync.Wait
 	ff.progressTracker.UpdateProcessedDirs(1)
 
 	var newDirCount int64
+	var excludedDirCount int
+
+	// Entries above --min-depth are walked but not matched, and the
+	// directories at --max-depth are not entered
+	depth := 0
+	if ff.minDepth > 0 || ff.maxDepth > 0 {
+		depth = ff.entryDepth(path)
+	}
+	tooShallow := depth < ff.minDepth
+	atMaxDepth := ff.maxDepth > 0 && depth >= ff.maxDepth
 
 	for _, entry := range entries {
 		entryName := entry.Name()
@@ -145,6 +227,7 @@ func processDir(ff *FileFinder, path string, dirQueue chan string, wg *sync.Wait
 		// Exclude dirs: fast map lookup on entry name only
 		if isDir {
 			if ff.ShouldExcludeDir(entryName) {
+				excludedDirCount++
 				continue
 			}
 		}
@@ -152,46 +235,79 @@ func processDir(ff *FileFinder, path string, dirQueue chan string, wg *sync.Wait
 		// Phase 3a: Avoid filepath.Join — direct string concat
 		fullPath := path + pathSep + entryName
 
+		// --no-hidden: hidden directories are not entered either
+		if ff.skipHidden && isHidden(entryName, fullPath) {
+			if isDir {
+				excludedDirCount++
+			}
+			continue
+		}
+
 		// Exclude patterns (regex): applies to both files and directories
 		if hasExcludePatterns {
 			if ff.ShouldExcludeByPattern(fullPath) {
+				if isDir {
+					excludedDirCount++
+				}
 				continue
 			}
 		}
 
-		// Check for match
-		if ff.MatchesPattern(entryName) {
+		// Check for match. --executable only matches files: a directory's x
+		// bit means "searchable", so directories are still walked but never listed
+		if !tooShallow && !(isDir && ff.executable) && ff.MatchesPattern(ff.matchTarget(fullPath, entryName)) {
 			if isDir {
-				*localDirs = append(*localDirs, fullPath)
+				if ff.results == nil {
+					*localDirs = append(*localDirs, fullPath)
+				} else if !ff.stream(types.SearchResult{Path: fullPath, FullPath: fullPath, IsDir: true}) {
+					return
+				}
 				ff.progressTracker.Update(0, 1)
-			} else {
-				shouldAdd := true
-
-				// Phase 3c: CheckFileType uses entryName instead of fullPath
-				if !ff.CheckFileType(entryName) {
-					shouldAdd = false
-				} else if hasSizeFilter {
-					size, ok := ff.CheckFileSize(entry, fullPath)
-					if !ok {
-						shouldAdd = false
-					} else if shouldAdd {
-						*localFiles = append(*localFiles, types.FileResult{Path: fullPath, Size: size})
-						ff.progressTracker.Update(1, 0)
-						shouldAdd = false // already added
-					}
+				if ff.first {
+					ff.cancel()
+					return
+				}
+			} else if ff.CheckFileType(entryName) { // Phase 3c: CheckFileType uses entryName instead of fullPath
+				var size int64
+				passed := true
+				if hasInfoFilter {
+					size, passed = ff.CheckFileInfo(entry, fullPath)
+				} else {
+					// No size or time filter — get size for display
+					size, _ = ff.GetFileSizeFromEntry(entry, fullPath)
 				}
 
-				if shouldAdd {
-					// No size filter — get size for display
-					size, _ := ff.GetFileSizeFromEntry(entry, fullPath)
-					*localFiles = append(*localFiles, types.FileResult{Path: fullPath, Size: size})
+				if passed {
+					result := types.FileResult{Path: fullPath, Size: size}
+					if ff.newHash != nil {
+						digest, err := ff.hashFile(ff.ctx, fullPath, size)
+						if err != nil {
+							return // search cancelled mid-hash
+						}
+						result.Hash = digest
+					}
+					if ff.results == nil {
+						*localFiles = append(*localFiles, result)
+					} else if !ff.stream(types.SearchResult{Path: fullPath, FullPath: fullPath, Size: size, Hash: result.Hash}) {
+						return
+					}
 					ff.progressTracker.Update(1, 0)
+					if ff.first {
+						ff.cancel()
+						return
+					}
 				}
 			}
 		}
 
+		// Mount points are matched above but not entered with --xdev
+		if isDir && ff.otherDevice(fullPath) {
+			excludedDirCount++
+			continue
+		}
+
 		// If directory, queue for traversal
-		if isDir {
+		if isDir && !atMaxDepth {
 			select {
 			case <-ff.ctx.Done():
 				return
@@ -212,7 +328,10 @@ func processDir(ff *FileFinder, path string, dirQueue chan string, wg *sync.Wait
 		}
 	}
 
-	// Phase 4a: Batch update progress counter
+	// Phase 4a: Batch update progress counters
+	if excludedDirCount > 0 {
+		ff.progressTracker.AddExcludedDirs(excludedDirCount)
+	}
 	if newDirCount > 0 {
 		newTotal := atomic.AddInt64(totalDirs, newDirCount)
 		ff.progressTracker.SetTotalDirs(int(newTotal))
@@ -0,0 +1,390 @@
+package finder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+
+	"find-everything/internal/types"
+)
+
+func TestFindFilesAndDirsFirst(t *testing.T) {
+	base := t.TempDir()
+	for i := 0; i < 20; i++ {
+		dir := filepath.Join(base, fmt.Sprintf("dir%d", i))
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("create %s: %v", dir, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "go.lock"), nil, 0o644); err != nil {
+			t.Fatalf("write lock file: %v", err)
+		}
+	}
+
+	for _, first := range []bool{false, true} {
+		ff, err := NewFileFinder(base, "*.lock", FinderOptions{
+			MaxWorkers: 4,
+			MaxSize:    1<<63 - 1,
+			MaxResults: 100,
+			First:      first,
+		})
+		if err != nil {
+			t.Fatalf("NewFileFinder returned error: %v", err)
+		}
+
+		files, dirs := ff.FindFilesAndDirs()
+		want := 20
+		if first {
+			want = 1
+		}
+		if len(files) != want || len(dirs) != 0 {
+			t.Errorf("first=%v: got %d files and %d dirs, want %d files", first, len(files), len(dirs), want)
+		}
+	}
+}
+
+func TestFindFilesAndDirsModTime(t *testing.T) {
+	base := t.TempDir()
+	ref := time.Now().Add(-time.Hour)
+	for name, mtime := range map[string]time.Time{
+		"old.log":  ref.Add(-time.Hour),
+		"same.log": ref,
+		"new.log":  ref.Add(time.Minute),
+	} {
+		path := filepath.Join(base, name)
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatalf("set mtime of %s: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		opts FinderOptions
+		want string
+	}{
+		{"newer than", FinderOptions{NewerThan: ref}, "new.log"},
+		{"older than", FinderOptions{OlderThan: ref}, "old.log"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.MaxWorkers = 2
+			tt.opts.MaxSize = 1<<63 - 1
+			tt.opts.MaxResults = 100
+			ff, err := NewFileFinder(base, "*.log", tt.opts)
+			if err != nil {
+				t.Fatalf("NewFileFinder returned error: %v", err)
+			}
+			// The reference time itself is excluded both ways, as with find -newer
+			files, _ := ff.FindFilesAndDirs()
+			if len(files) != 1 || filepath.Base(files[0].Path) != tt.want {
+				t.Errorf("got %+v, want only %s", files, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsExecutable(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test files cannot be given execute bits on Windows")
+	}
+	dir := t.TempDir()
+	write := func(name string, perm os.FileMode) os.FileInfo {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, nil, perm); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+		// The umask may drop bits given to WriteFile
+		if err := os.Chmod(path, perm); err != nil {
+			t.Fatalf("chmod %s: %v", name, err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("stat %s: %v", name, err)
+		}
+		return info
+	}
+	script := write("build.sh", 0o755)
+	groupOnly := write("tool", 0o610)
+	plain := write("notes.txt", 0o644)
+	batch := write("setup.BAT", 0o644)
+
+	tests := []struct {
+		name string
+		info os.FileInfo
+		goos string
+		want bool
+	}{
+		{"owner execute bit", script, "linux", true},
+		{"group execute bit", groupOnly, "darwin", true},
+		{"no execute bit", plain, "linux", false},
+		{"extension ignored on unix", batch, "linux", false},
+		{"windows extension", batch, "windows", true},
+		{"windows ignores mode", script, "windows", false},
+	}
+	for _, tt := range tests {
+		if got := IsExecutable(tt.info, tt.goos); got != tt.want {
+			t.Errorf("%s: IsExecutable = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFindFilesAndDirsExecutableSkipsDirs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test files cannot be given execute bits on Windows")
+	}
+	base := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(base, "bin"), 0o755); err != nil {
+		t.Fatalf("create bin: %v", err)
+	}
+	for name, perm := range map[string]os.FileMode{"bin/run": 0o755, "notes.txt": 0o644} {
+		path := filepath.Join(base, name)
+		if err := os.WriteFile(path, nil, perm); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+		if err := os.Chmod(path, perm); err != nil {
+			t.Fatalf("chmod %s: %v", name, err)
+		}
+	}
+
+	ff, err := NewFileFinder(base, "*", FinderOptions{
+		MaxWorkers: 2,
+		MaxSize:    1<<63 - 1,
+		MaxResults: 100,
+		Executable: true,
+	})
+	if err != nil {
+		t.Fatalf("NewFileFinder returned error: %v", err)
+	}
+	files, dirs := ff.FindFilesAndDirs()
+
+	// bin is searchable (0755) but is still walked, not listed
+	if len(dirs) != 0 {
+		t.Errorf("dirs = %v, want none with --executable", dirs)
+	}
+	if len(files) != 1 || filepath.Base(files[0].Path) != "run" {
+		t.Errorf("files = %v, want only bin/run", files)
+	}
+}
+
+func TestFindFilesAndDirsStats(t *testing.T) {
+	base := t.TempDir()
+	for _, dir := range []string{"src/app", "node_modules/pkg", "build"} {
+		if err := os.MkdirAll(filepath.Join(base, dir), 0o755); err != nil {
+			t.Fatalf("create %s: %v", dir, err)
+		}
+	}
+
+	ff, err := NewFileFinder(base, "*.go", FinderOptions{
+		MaxWorkers:      2,
+		MaxSize:         1<<63 - 1,
+		MaxResults:      100,
+		ExcludeDirs:     []string{"node_modules"},
+		ExcludePatterns: []string{"build$"},
+	})
+	if err != nil {
+		t.Fatalf("NewFileFinder returned error: %v", err)
+	}
+	ff.FindFilesAndDirs()
+
+	// base, src and src/app are walked; node_modules and build are pruned
+	stats := ff.Stats()
+	if stats.WalkedDirs != 3 || stats.ExcludedDirs != 2 {
+		t.Errorf("stats = %+v, want 3 walked and 2 excluded dirs", stats)
+	}
+	if stats.Elapsed <= 0 || ff.Stats().Elapsed != stats.Elapsed {
+		t.Errorf("elapsed = %v, want a positive time frozen at the end of the walk", stats.Elapsed)
+	}
+}
+
+func TestFindFilesAndDirsXdev(t *testing.T) {
+	base := t.TempDir()
+	for _, name := range []string{"top.go", "mnt/inner.go"} {
+		path := filepath.Join(base, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("create %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	ff, err := NewFileFinder(base, "*", FinderOptions{MaxWorkers: 2, MaxSize: 1<<63 - 1, MaxResults: 100, SameFilesystem: true})
+	if err != nil {
+		t.Fatalf("NewFileFinder returned error: %v", err)
+	}
+	if !ff.xdev {
+		t.Skip("no device IDs on this platform")
+	}
+	// Everything is on one file system in a temp dir, so pretend the base is not
+	ff.baseDev++
+
+	files, dirs := ff.FindFilesAndDirs()
+	if len(files) != 1 || filepath.Base(files[0].Path) != "top.go" {
+		t.Errorf("files = %+v, want only top.go", files)
+	}
+	if len(dirs) != 1 || filepath.Base(dirs[0]) != "mnt" {
+		t.Errorf("dirs = %v, want the mount point itself", dirs)
+	}
+	if got := ff.Stats().ExcludedDirs; got != 1 {
+		t.Errorf("excluded dirs = %d, want 1", got)
+	}
+}
+
+func TestFindFilesAndDirsDepth(t *testing.T) {
+	base := t.TempDir()
+	for _, name := range []string{"a.go", "one/b.go", "one/two/c.go", "one/two/three/d.go"} {
+		path := filepath.Join(base, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("create %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		minDepth int
+		maxDepth int
+		want     []string
+	}{
+		{"unlimited", 0, 0, []string{"a.go", "b.go", "c.go", "d.go"}},
+		{"min depth", 2, 0, []string{"b.go", "c.go", "d.go"}},
+		{"max depth", 0, 2, []string{"a.go", "b.go"}},
+		{"both", 3, 3, []string{"c.go"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ff, err := NewFileFinder(base, "*.go", FinderOptions{MaxWorkers: 2, MaxSize: 1<<63 - 1, MaxResults: 100, MinDepth: tt.minDepth, MaxDepth: tt.maxDepth})
+			if err != nil {
+				t.Fatalf("NewFileFinder returned error: %v", err)
+			}
+			files, _ := ff.FindFilesAndDirs()
+			var got []string
+			for _, f := range files {
+				got = append(got, filepath.Base(f.Path))
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("files = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindFilesAndDirsHidden(t *testing.T) {
+	base := t.TempDir()
+	for _, name := range []string{"a.txt", ".env.txt", ".config/b.txt", "src/c.txt"} {
+		path := filepath.Join(base, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("create %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		skipHidden bool
+		want       []string
+	}{
+		{"included by default", false, []string{".env.txt", "a.txt", "b.txt", "c.txt"}},
+		{"skipped", true, []string{"a.txt", "c.txt"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ff, err := NewFileFinder(base, "*.txt", FinderOptions{MaxWorkers: 2, MaxSize: 1<<63 - 1, MaxResults: 100, SkipHidden: tt.skipHidden})
+			if err != nil {
+				t.Fatalf("NewFileFinder returned error: %v", err)
+			}
+			files, _ := ff.FindFilesAndDirs()
+			var got []string
+			for _, f := range files {
+				got = append(got, filepath.Base(f.Path))
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("files = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindFilesAndDirsStream(t *testing.T) {
+	base := t.TempDir()
+	for i := range 20 {
+		if err := os.WriteFile(filepath.Join(base, fmt.Sprintf("f%02d.txt", i)), nil, 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	results := make(chan types.SearchResult)
+	ff, err := NewFileFinder(base, "*.txt", FinderOptions{MaxWorkers: 4, MaxSize: 1<<63 - 1, MaxResults: 5, Results: results})
+	if err != nil {
+		t.Fatalf("NewFileFinder returned error: %v", err)
+	}
+	var streamed []types.SearchResult
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for r := range results {
+			streamed = append(streamed, r)
+		}
+	}()
+
+	files, dirs := ff.FindFilesAndDirs()
+	<-done // the channel was closed
+	if len(files)+len(dirs) != 0 {
+		t.Errorf("returned %d files and %d dirs, want none when streaming", len(files), len(dirs))
+	}
+	if len(streamed) != 5 {
+		t.Errorf("streamed %d results, want --max-results 5", len(streamed))
+	}
+	for _, r := range streamed {
+		if r.IsDir || r.FullPath != r.Path || filepath.Dir(r.Path) != base {
+			t.Errorf("unexpected result %+v", r)
+		}
+	}
+}
+
+func TestFindFilesAndDirsPruneEmptyDirs(t *testing.T) {
+	base := t.TempDir()
+	for _, name := range []string{"logs/app/x.log", "logs/empty/readme.txt", "logs-old/y.txt", "other/z.log"} {
+		path := filepath.Join(base, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("create %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	for _, prune := range []bool{false, true} {
+		ff, err := NewFileFinder(base, "*log*", FinderOptions{MaxWorkers: 2, MaxSize: 1<<63 - 1, MaxResults: 100, PruneEmptyDirs: prune})
+		if err != nil {
+			t.Fatalf("NewFileFinder returned error: %v", err)
+		}
+		files, dirs := ff.FindFilesAndDirs()
+		var got []string
+		for _, dir := range dirs {
+			rel, _ := filepath.Rel(base, dir)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		slices.Sort(got)
+		want := []string{"logs", "logs-old"}
+		if prune {
+			want = []string{"logs"} // logs-old holds no *log* file
+		}
+		if len(files) != 2 || !slices.Equal(got, want) {
+			t.Errorf("prune=%v: %d files, dirs %v; want 2 files, dirs %v", prune, len(files), got, want)
+		}
+	}
+}
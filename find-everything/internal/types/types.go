@@ -1,7 +1,40 @@
 package types
 
+import (
+	"os"
+	"time"
+)
+
 // FileResult holds a matched file path along with its metadata.
 type FileResult struct {
 	Path string
 	Size int64
+	Hash string // hex digest, "(skipped)"/"(error)" marker, or empty when hashing is off
+}
+
+// SearchResult is what a --format template sees for each file or directory,
+// and the object written per line by --jsonl.
+type SearchResult struct {
+	Path     string `json:"path"`               // as printed: relative to the base path with --relative
+	FullPath string `json:"full_path"`          // absolute path
+	RelPath  string `json:"rel_path,omitempty"` // relative to the base path; set only with --relative
+	IsDir    bool   `json:"is_dir"`
+	Size     int64  `json:"size"`           // 0 for directories
+	Hash     string `json:"hash,omitempty"` // see FileResult.Hash
+
+	modTime *time.Time
+}
+
+// ModTime returns the last modification time (zero if the path cannot be
+// stat'ed). It is read on first use, so templates that do not print it cost
+// no extra stat.
+func (r *SearchResult) ModTime() time.Time {
+	if r.modTime == nil {
+		var t time.Time
+		if info, err := os.Stat(r.FullPath); err == nil {
+			t = info.ModTime()
+		}
+		r.modTime = &t
+	}
+	return *r.modTime
 }
@@ -5,29 +5,58 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"common-module/utils"
 	"find-everything/internal/types"
 
 	"golang.org/x/term"
 )
 
-// Colors for terminal output
-const (
-	ColorHeader    = "\033[95m"
-	ColorOKBlue    = "\033[94m"
-	ColorOKCyan    = "\033[96m"
-	ColorOKGreen   = "\033[92m"
-	ColorWarning   = "\033[93m"
-	ColorFail      = "\033[91m"
-	ColorEndC      = "\033[0m"
-	ColorBold      = "\033[1m"
-	ColorUnderline = "\033[4m"
-)
+// ColorScheme holds the ANSI escape codes used for terminal output. The zero
+// value writes plain text.
+type ColorScheme struct {
+	Header    string
+	OKBlue    string
+	OKCyan    string
+	OKGreen   string
+	Warning   string
+	Fail      string
+	EndC      string
+	Bold      string
+	Underline string
+}
+
+// DefaultColors is the scheme used on color terminals
+var DefaultColors = ColorScheme{
+	Header:    "\033[95m",
+	OKBlue:    "\033[94m",
+	OKCyan:    "\033[96m",
+	OKGreen:   "\033[92m",
+	Warning:   "\033[93m",
+	Fail:      "\033[91m",
+	EndC:      "\033[0m",
+	Bold:      "\033[1m",
+	Underline: "\033[4m",
+}
+
+// Colors is the scheme in use; see SetColor
+var Colors = DefaultColors
+
+// SetColor switches between DefaultColors and plain output
+func SetColor(enabled bool) {
+	if enabled {
+		Colors = DefaultColors
+	} else {
+		Colors = ColorScheme{}
+	}
+}
 
 const (
 	LargeResultsActionAsk     = "ask"
@@ -41,6 +70,7 @@ type ResultsOutputOptions struct {
 	Pattern            string
 	BasePath           string
 	NoSort             bool
+	Relative           bool // show paths relative to BasePath
 	LargeResultsAction string
 	OutputPath         string
 	PromptReader       io.Reader
@@ -54,6 +84,16 @@ type ProgressTracker struct {
 	foundFiles    int64
 	foundDirs     int64
 	startTime     time.Time
+
+	excludedDirs int64     // directories pruned by --exclude-dirs or --exclude-patterns
+	endTime      time.Time // set by Stop once the walk is over
+}
+
+// SearchStats summarizes a finished search for --print-stats
+type SearchStats struct {
+	WalkedDirs   int64
+	ExcludedDirs int64
+	Elapsed      time.Duration
 }
 
 func NewProgressTracker() *ProgressTracker {
@@ -75,26 +115,89 @@ func (pt *ProgressTracker) SetTotalDirs(total int) {
 	atomic.StoreInt64(&pt.totalDirs, int64(total))
 }
 
+func (pt *ProgressTracker) AddExcludedDirs(count int) {
+	atomic.AddInt64(&pt.excludedDirs, int64(count))
+}
+
+// Stop freezes the elapsed time reported by Stats
+func (pt *ProgressTracker) Stop() {
+	pt.endTime = time.Now()
+}
+
+func (pt *ProgressTracker) Stats() SearchStats {
+	end := pt.endTime
+	if end.IsZero() {
+		end = time.Now()
+	}
+	return SearchStats{
+		WalkedDirs:   atomic.LoadInt64(&pt.processedDirs),
+		ExcludedDirs: atomic.LoadInt64(&pt.excludedDirs),
+		Elapsed:      end.Sub(pt.startTime),
+	}
+}
+
 func (pt *ProgressTracker) PrintProgress() {
 	elapsed := time.Since(pt.startTime).Seconds()
 	processedDirs := atomic.LoadInt64(&pt.processedDirs)
 	foundFiles := atomic.LoadInt64(&pt.foundFiles)
 	foundDirs := atomic.LoadInt64(&pt.foundDirs)
 	fmt.Printf("\r%sProcessed: %d | Found: %d files, %d dirs | Time: %.1fs%s",
-		ColorOKCyan, processedDirs, foundFiles, foundDirs, elapsed, ColorEndC)
+		Colors.OKCyan, processedDirs, foundFiles, foundDirs, elapsed, Colors.EndC)
+}
+
+// formatFileLine renders a matched file with its optional size and checksum.
+func formatFileLine(f types.FileResult, showDetails bool) string {
+	line := f.Path
+	if showDetails {
+		line += " (" + utils.FormatSize(f.Size) + ")"
+	}
+	if f.Hash != "" {
+		line += " " + f.Hash
+	}
+	return line
+}
+
+// pathDisplay renders result paths, relative to basePath when relative is set.
+type pathDisplay struct {
+	basePath string
+	relative bool
+	warned   bool
 }
 
-func FormatSize(sizeBytes int64) string {
-	const unit = 1024
-	if sizeBytes < unit {
-		return fmt.Sprintf("%d B", sizeBytes)
+// show returns path as it should be printed. A path that cannot be made
+// relative (e.g. on another drive on Windows) is shown absolute, with a
+// single warning per output.
+func (pd *pathDisplay) show(path string) string {
+	if !pd.relative {
+		return path
+	}
+	rel, err := filepath.Rel(pd.basePath, path)
+	if err == nil {
+		return rel
+	}
+	if !pd.warned {
+		pd.warned = true
+		fmt.Fprintf(os.Stderr, "%sWarning: cannot show paths relative to %s (%v); using absolute paths%s\n", Colors.Warning, pd.basePath, err, Colors.EndC)
+	}
+	if abs, absErr := filepath.Abs(path); absErr == nil {
+		return abs
+	}
+	return path
+}
+
+// fill turns the paths of a result as found into the printed Path, the
+// absolute FullPath and, with relative set, the RelPath. RelPath stays empty
+// when the path cannot be made relative.
+func (pd *pathDisplay) fill(r *types.SearchResult) {
+	if pd.relative {
+		if rel, err := filepath.Rel(pd.basePath, r.FullPath); err == nil {
+			r.RelPath = rel
+		}
 	}
-	div, exp := int64(unit), 0
-	for n := sizeBytes / unit; n >= unit; n /= unit {
-		div *= unit
-		exp++
+	r.Path = pd.show(r.Path)
+	if abs, err := filepath.Abs(r.FullPath); err == nil {
+		r.FullPath = abs
 	}
-	return fmt.Sprintf("%.1f %cB", float64(sizeBytes)/float64(div), "KMGTPE"[exp])
 }
 
 // sortResults sorts files and dirs in parallel.
@@ -112,7 +215,7 @@ func sortResults(files []types.FileResult, dirs []string) {
 	wg.Wait()
 }
 
-func SaveResultsToFile(files []types.FileResult, dirs []string, pattern, basePath string, showDetails bool, noSort bool, outputPath string) (string, error) {
+func SaveResultsToFile(files []types.FileResult, dirs []string, pattern, basePath string, showDetails bool, noSort bool, relative bool, outputPath string) (string, error) {
 	filename := outputPath
 	if filename == "" {
 		timestamp := time.Now().Format("20060102_150405")
@@ -141,15 +244,13 @@ func SaveResultsToFile(files []types.FileResult, dirs []string, pattern, basePat
 		sortResults(files, dirs)
 	}
 
+	paths := &pathDisplay{basePath: basePath, relative: relative}
 	if len(files) > 0 {
 		fmt.Fprintf(writer, "MATCHING FILES:\n")
 		fmt.Fprintf(writer, "%s\n", strings.Repeat("-", 40))
 		for _, f := range files {
-			if showDetails {
-				fmt.Fprintf(writer, "  %s (%s)\n", f.Path, FormatSize(f.Size))
-			} else {
-				fmt.Fprintf(writer, "  %s\n", f.Path)
-			}
+			f.Path = paths.show(f.Path)
+			fmt.Fprintf(writer, "  %s\n", formatFileLine(f, showDetails))
 		}
 		fmt.Fprintf(writer, "\n")
 	}
@@ -158,7 +259,7 @@ func SaveResultsToFile(files []types.FileResult, dirs []string, pattern, basePat
 		fmt.Fprintf(writer, "MATCHING DIRECTORIES:\n")
 		fmt.Fprintf(writer, "%s\n", strings.Repeat("-", 40))
 		for _, dirPath := range dirs {
-			fmt.Fprintf(writer, "  %s\n", dirPath)
+			fmt.Fprintf(writer, "  %s\n", paths.show(dirPath))
 		}
 		fmt.Fprintf(writer, "\n")
 	}
@@ -175,7 +276,7 @@ func PrintResults(files []types.FileResult, dirs []string, options ResultsOutput
 
 	if totalResults <= 100 {
 		printResultsSummary(len(files), len(dirs), totalResults, false)
-		printResultDetails(files, dirs, options.ShowDetails, options.NoSort)
+		printResultDetails(files, dirs, options)
 		return nil
 	}
 
@@ -191,48 +292,77 @@ func PrintResults(files []types.FileResult, dirs []string, options ResultsOutput
 	}
 
 	if action == LargeResultsActionDisplay {
-		printResultDetails(files, dirs, options.ShowDetails, options.NoSort)
+		printResultDetails(files, dirs, options)
 		return nil
 	}
 
-	filename, err := SaveResultsToFile(files, dirs, options.Pattern, options.BasePath, options.ShowDetails, options.NoSort, options.OutputPath)
+	filename, err := SaveResultsToFile(files, dirs, options.Pattern, options.BasePath, options.ShowDetails, options.NoSort, options.Relative, options.OutputPath)
 	if err != nil {
 		return fmt.Errorf("save results: %w", err)
 	}
 
-	fmt.Printf("%sResults saved to: %s%s\n", ColorOKCyan, filename, ColorEndC)
+	fmt.Printf("%sResults saved to: %s%s\n", Colors.OKCyan, filename, Colors.EndC)
 	return nil
 }
 
+// PrintCounts writes the --count summary: one "Label: N" line per total
+func PrintCounts(w io.Writer, filesCount, dirsCount int) {
+	fmt.Fprintf(w, "Files: %d\nDirectories: %d\nTotal: %d\n", filesCount, dirsCount, filesCount+dirsCount)
+}
+
+// PrintCountsJSON writes the --count summary as one JSON object, for --count --jsonl
+func PrintCountsJSON(w io.Writer, filesCount, dirsCount int) {
+	fmt.Fprintf(w, "{\"files\":%d,\"dirs\":%d,\"total\":%d}\n", filesCount, dirsCount, filesCount+dirsCount)
+}
+
+// PrintStats writes the --print-stats line for a search that matched
+// filesCount files and dirsCount directories
+func PrintStats(w io.Writer, stats SearchStats, filesCount, dirsCount int) {
+	fmt.Fprintf(w, "Walked: %s dirs | Excluded: %s dirs | Matched: %s files, %s dirs | Time: %.1fs\n",
+		groupDigits(stats.WalkedDirs), groupDigits(stats.ExcludedDirs),
+		groupDigits(int64(filesCount)), groupDigits(int64(dirsCount)), stats.Elapsed.Seconds())
+}
+
+// groupDigits formats n with a comma between groups of three digits, e.g. 12,345
+func groupDigits(n int64) string {
+	s := strconv.FormatInt(n, 10)
+	sign := ""
+	if n < 0 {
+		sign, s = "-", s[1:]
+	}
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return sign + s
+}
+
 func printResultsSummary(filesCount, dirsCount, totalResults int, exceededLimit bool) {
-	fmt.Printf("\n%s%sSearch Results:%s\n", ColorBold, ColorHeader, ColorEndC)
-	fmt.Printf("%sFiles found: %d%s\n", ColorOKGreen, filesCount, ColorEndC)
-	fmt.Printf("%sDirectories found: %d%s\n", ColorOKBlue, dirsCount, ColorEndC)
+	fmt.Printf("\n%s%sSearch Results:%s\n", Colors.Bold, Colors.Header, Colors.EndC)
+	fmt.Printf("%sFiles found: %d%s\n", Colors.OKGreen, filesCount, Colors.EndC)
+	fmt.Printf("%sDirectories found: %d%s\n", Colors.OKBlue, dirsCount, Colors.EndC)
 	if exceededLimit {
-		fmt.Printf("%sTotal results: %d (exceeds 100)%s\n", ColorWarning, totalResults, ColorEndC)
+		fmt.Printf("%sTotal results: %d (exceeds 100)%s\n", Colors.Warning, totalResults, Colors.EndC)
 	}
 }
 
-func printResultDetails(files []types.FileResult, dirs []string, showDetails bool, noSort bool) {
-	if !noSort {
+func printResultDetails(files []types.FileResult, dirs []string, options ResultsOutputOptions) {
+	if !options.NoSort {
 		sortResults(files, dirs)
 	}
 
+	paths := &pathDisplay{basePath: options.BasePath, relative: options.Relative}
 	if len(files) > 0 {
-		fmt.Printf("\n%s%sMatching Files:%s\n", ColorBold, ColorOKGreen, ColorEndC)
+		fmt.Printf("\n%s%sMatching Files:%s\n", Colors.Bold, Colors.OKGreen, Colors.EndC)
 		for _, f := range files {
-			if showDetails {
-				fmt.Printf("  %s (%s)\n", f.Path, FormatSize(f.Size))
-			} else {
-				fmt.Printf("  %s\n", f.Path)
-			}
+			f.Path = paths.show(f.Path)
+			fmt.Printf("  %s\n", formatFileLine(f, options.ShowDetails))
 		}
 	}
 
 	if len(dirs) > 0 {
-		fmt.Printf("\n%s%sMatching Directories:%s\n", ColorBold, ColorOKBlue, ColorEndC)
+		fmt.Printf("\n%s%sMatching Directories:%s\n", Colors.Bold, Colors.OKBlue, Colors.EndC)
 		for _, dirPath := range dirs {
-			fmt.Printf("  %s\n", dirPath)
+			fmt.Printf("  %s\n", paths.show(dirPath))
 		}
 	}
 }
@@ -253,7 +383,7 @@ func promptWriter(options ResultsOutputOptions) io.Writer {
 
 func resolvePromptedLargeResultsAction(reader io.Reader, writer io.Writer) string {
 	if !canPrompt(reader, writer) {
-		fmt.Fprintf(writer, "%sNon-interactive terminal detected; saving results to file.%s\n", ColorWarning, ColorEndC)
+		fmt.Fprintf(writer, "%sNon-interactive terminal detected; saving results to file.%s\n", Colors.Warning, Colors.EndC)
 		return LargeResultsActionSave
 	}
 
@@ -8,6 +8,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"find-everything/internal/types"
 )
@@ -17,7 +18,7 @@ func TestSaveResultsToFileExplicitOutputPath(t *testing.T) {
 	files := []types.FileResult{{Path: "b.txt", Size: 2048}, {Path: "a.txt", Size: 1024}}
 	dirs := []string{"dir-b", "dir-a"}
 
-	filename, err := SaveResultsToFile(files, dirs, "*.txt", "/tmp/base", true, false, outputPath)
+	filename, err := SaveResultsToFile(files, dirs, "*.txt", "/tmp/base", true, false, false, outputPath)
 	if err != nil {
 		t.Fatalf("SaveResultsToFile returned error: %v", err)
 	}
@@ -47,10 +48,97 @@ func TestSaveResultsToFileExplicitOutputPath(t *testing.T) {
 	}
 }
 
+func TestSaveResultsToFileIncludesHashes(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "results.txt")
+	files := []types.FileResult{
+		{Path: "a.txt", Size: 1024, Hash: "5d41402abc4b2a76b9719d911017c592"},
+		{Path: "b.iso", Size: 4096, Hash: "(skipped)"},
+	}
+
+	if _, err := SaveResultsToFile(files, nil, "*", "/tmp/base", true, false, false, outputPath); err != nil {
+		t.Fatalf("SaveResultsToFile returned error: %v", err)
+	}
+
+	contentBytes, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("read output file: %v", err)
+	}
+	content := string(contentBytes)
+
+	for _, want := range []string{
+		"  a.txt (1.0 KB) 5d41402abc4b2a76b9719d911017c592",
+		"  b.iso (4.0 KB) (skipped)",
+	} {
+		if !strings.Contains(content, want) {
+			t.Fatalf("output file does not contain %q\ncontent:\n%s", want, content)
+		}
+	}
+}
+
+func TestRelativePaths(t *testing.T) {
+	base := filepath.Join("/tmp", "base")
+	files := []types.FileResult{{Path: filepath.Join(base, "sub", "a.txt"), Size: 1}}
+	dirs := []string{filepath.Join(base, "sub")}
+	wantFile := "  " + filepath.Join("sub", "a.txt") + "\n"
+
+	output := captureStdout(t, func() error {
+		return PrintResults(files, dirs, ResultsOutputOptions{BasePath: base, Relative: true})
+	})
+	if !strings.Contains(output, wantFile) || !strings.Contains(output, "  sub\n") {
+		t.Errorf("printed paths are not relative:\n%s", output)
+	}
+
+	outputPath := filepath.Join(t.TempDir(), "results.txt")
+	if _, err := SaveResultsToFile(files, dirs, "*", base, false, false, true, outputPath); err != nil {
+		t.Fatalf("SaveResultsToFile returned error: %v", err)
+	}
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("read output file: %v", err)
+	}
+	if !strings.Contains(string(content), wantFile) || strings.Contains(string(content), "  "+files[0].Path) {
+		t.Errorf("saved paths are not relative:\n%s", content)
+	}
+
+	// A path that cannot be made relative falls back to the absolute path
+	pd := &pathDisplay{basePath: "relative/base", relative: true}
+	if got := pd.show("/abs/file"); got != "/abs/file" {
+		t.Errorf("show(/abs/file) = %q, want the absolute path", got)
+	}
+}
+
+func TestPrintCounts(t *testing.T) {
+	var buf bytes.Buffer
+	PrintCounts(&buf, 3, 2)
+	if want := "Files: 3\nDirectories: 2\nTotal: 5\n"; buf.String() != want {
+		t.Errorf("PrintCounts wrote %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	PrintCountsJSON(&buf, 3, 2)
+	if want := `{"files":3,"dirs":2,"total":5}` + "\n"; buf.String() != want {
+		t.Errorf("PrintCountsJSON wrote %q, want %q", buf.String(), want)
+	}
+}
+
+func TestPrintStats(t *testing.T) {
+	var buf bytes.Buffer
+	PrintStats(&buf, SearchStats{WalkedDirs: 12345, ExcludedDirs: 3, Elapsed: 3200 * time.Millisecond}, 42, 7)
+	if want := "Walked: 12,345 dirs | Excluded: 3 dirs | Matched: 42 files, 7 dirs | Time: 3.2s\n"; buf.String() != want {
+		t.Errorf("PrintStats wrote %q, want %q", buf.String(), want)
+	}
+
+	for n, want := range map[int64]string{0: "0", 999: "999", 1000: "1,000", 1234567: "1,234,567", -1000: "-1,000"} {
+		if got := groupDigits(n); got != want {
+			t.Errorf("groupDigits(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
 func TestSaveResultsToFileReturnsErrorForInvalidPath(t *testing.T) {
 	outputPath := filepath.Join(t.TempDir(), "missing", "results.txt")
 
-	filename, err := SaveResultsToFile(nil, nil, "*", "/tmp/base", false, false, outputPath)
+	filename, err := SaveResultsToFile(nil, nil, "*", "/tmp/base", false, false, false, outputPath)
 	if err == nil {
 		t.Fatal("SaveResultsToFile returned nil error for invalid path")
 	}
@@ -109,6 +197,23 @@ func TestPrintResultsLargeDisplayActionPrintsDetails(t *testing.T) {
 	}
 }
 
+func TestSetColorDisablesEscapeCodes(t *testing.T) {
+	t.Cleanup(func() { SetColor(true) })
+
+	for _, enabled := range []bool{true, false} {
+		SetColor(enabled)
+		output := captureStdout(t, func() error {
+			return PrintResults(makeFileResults(1), []string{"dir-a"}, ResultsOutputOptions{})
+		})
+		if got := strings.Contains(output, "\033["); got != enabled {
+			t.Errorf("color %v: output has escape codes = %v\n%q", enabled, got, output)
+		}
+		if !strings.Contains(output, "Files found: 1") {
+			t.Errorf("color %v: output missing summary:\n%s", enabled, output)
+		}
+	}
+}
+
 func TestPrintResultsLargeSaveUsesExplicitOutputPath(t *testing.T) {
 	outputPath := filepath.Join(t.TempDir(), "results.txt")
 
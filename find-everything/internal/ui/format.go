@@ -0,0 +1,80 @@
+package ui
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"common-module/utils"
+	"find-everything/internal/types"
+)
+
+// formatEscapes lets shell users write tabs and newlines in a --format
+// template without $'...' quoting
+var formatEscapes = strings.NewReplacer(`\t`, "\t", `\n`, "\n", `\\`, `\`)
+
+// ParseFormat compiles a --format template. The template is executed once per
+// result with a *types.SearchResult; humanSize formats a byte count.
+func ParseFormat(format string) (*template.Template, error) {
+	tmpl, err := template.New("format").
+		Funcs(template.FuncMap{"humanSize": utils.FormatSize}).
+		Parse(formatEscapes.Replace(format))
+	if err != nil {
+		return nil, fmt.Errorf("invalid --format template: %v", err)
+	}
+	return tmpl, nil
+}
+
+// PrintFormatted writes one line per result rendered with tmpl: files first,
+// then directories, each sorted unless options.NoSort is set
+func PrintFormatted(w io.Writer, tmpl *template.Template, files []types.FileResult, dirs []string, options ResultsOutputOptions) error {
+	if !options.NoSort {
+		sortResults(files, dirs)
+	}
+
+	out := bufio.NewWriter(w)
+	paths := &pathDisplay{basePath: options.BasePath, relative: options.Relative}
+	write := func(r *types.SearchResult) error {
+		paths.fill(r)
+		if err := tmpl.Execute(out, r); err != nil {
+			return fmt.Errorf("--format: %v", err)
+		}
+		return out.WriteByte('\n')
+	}
+	for _, f := range files {
+		if err := write(&types.SearchResult{Path: f.Path, FullPath: f.Path, Size: f.Size, Hash: f.Hash}); err != nil {
+			return err
+		}
+	}
+	for _, dir := range dirs {
+		if err := write(&types.SearchResult{Path: dir, FullPath: dir, IsDir: true}); err != nil {
+			return err
+		}
+	}
+	return out.Flush()
+}
+
+// WriteJSONLines writes each result received from results as one JSON object
+// per line as soon as it arrives (--jsonl), and returns how many files and
+// directories it saw. It reads until results is closed, even after a write
+// error, so the finder sending to it never blocks.
+func WriteJSONLines(w io.Writer, results <-chan types.SearchResult, options ResultsOutputOptions) (files, dirs int, err error) {
+	enc := json.NewEncoder(w)
+	paths := &pathDisplay{basePath: options.BasePath, relative: options.Relative}
+	for r := range results {
+		if r.IsDir {
+			dirs++
+		} else {
+			files++
+		}
+		if err != nil {
+			continue
+		}
+		paths.fill(&r)
+		err = enc.Encode(&r)
+	}
+	return files, dirs, err
+}
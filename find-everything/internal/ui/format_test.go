@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"find-everything/internal/types"
+)
+
+func TestPrintFormatted(t *testing.T) {
+	base := t.TempDir()
+	file := filepath.Join(base, "sub", "b.log")
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(file, []byte("hello"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	mtime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.Local)
+	if err := os.Chtimes(file, mtime, mtime); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	files := []types.FileResult{{Path: file, Size: 5}, {Path: filepath.Join(base, "a.log"), Size: 2048}}
+	dirs := []string{filepath.Join(base, "sub")}
+
+	tmpl, err := ParseFormat(`{{.Path}}\t{{if .IsDir}}dir{{else}}{{humanSize .Size}}{{end}}`)
+	if err != nil {
+		t.Fatalf("ParseFormat returned error: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := PrintFormatted(&buf, tmpl, files, dirs, ResultsOutputOptions{BasePath: base, Relative: true}); err != nil {
+		t.Fatalf("PrintFormatted returned error: %v", err)
+	}
+	want := "a.log\t2.0 KB\n" + filepath.Join("sub", "b.log") + "\t5 B\nsub\tdir\n"
+	if buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+
+	// ModTime is read from disk when the template asks for it
+	tmpl, err = ParseFormat(`{{.ModTime.Format "2006-01-02"}} {{.FullPath}}`)
+	if err != nil {
+		t.Fatalf("ParseFormat returned error: %v", err)
+	}
+	buf.Reset()
+	if err := PrintFormatted(&buf, tmpl, []types.FileResult{{Path: file}}, nil, ResultsOutputOptions{}); err != nil {
+		t.Fatalf("PrintFormatted returned error: %v", err)
+	}
+	if want := "2024-03-01 " + file + "\n"; buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+
+	if _, err := ParseFormat("{{.Path"); err == nil || !strings.Contains(err.Error(), "invalid --format template") {
+		t.Errorf("ParseFormat error = %v, want an invalid template error", err)
+	}
+	tmpl, _ = ParseFormat("{{.Owner}}")
+	if err := PrintFormatted(&buf, tmpl, files, nil, ResultsOutputOptions{}); err == nil || !strings.Contains(err.Error(), "--format") {
+		t.Errorf("PrintFormatted error = %v, want an unknown field error", err)
+	}
+}
+
+func TestWriteJSONLines(t *testing.T) {
+	base := t.TempDir()
+	results := make(chan types.SearchResult, 2)
+	results <- types.SearchResult{Path: filepath.Join(base, "a.log"), FullPath: filepath.Join(base, "a.log"), Size: 3, Hash: "abc"}
+	results <- types.SearchResult{Path: filepath.Join(base, "sub"), FullPath: filepath.Join(base, "sub"), IsDir: true}
+	close(results)
+
+	var buf bytes.Buffer
+	files, dirs, err := WriteJSONLines(&buf, results, ResultsOutputOptions{BasePath: base, Relative: true})
+	if err != nil {
+		t.Fatalf("WriteJSONLines returned error: %v", err)
+	}
+	if files != 1 || dirs != 1 {
+		t.Errorf("counts = %d files, %d dirs; want 1 and 1", files, dirs)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %q", len(lines), buf.String())
+	}
+	var got types.SearchResult
+	if err := json.Unmarshal([]byte(lines[0]), &got); err != nil {
+		t.Fatalf("invalid JSON line %q: %v", lines[0], err)
+	}
+	want := types.SearchResult{Path: "a.log", FullPath: filepath.Join(base, "a.log"), RelPath: "a.log", Size: 3, Hash: "abc"}
+	if got != want {
+		t.Errorf("first line = %+v, want %+v", got, want)
+	}
+	if !strings.Contains(lines[1], `"is_dir":true`) || strings.Contains(lines[1], `"hash"`) {
+		t.Errorf("directory line = %s", lines[1])
+	}
+}
+
+func TestWriteJSONLinesRelPathOnlyWithRelative(t *testing.T) {
+	base := t.TempDir()
+	path := filepath.Join(base, "sub", "a.log")
+	for _, relative := range []bool{false, true} {
+		results := make(chan types.SearchResult, 1)
+		results <- types.SearchResult{Path: path, FullPath: path}
+		close(results)
+
+		var buf bytes.Buffer
+		if _, _, err := WriteJSONLines(&buf, results, ResultsOutputOptions{BasePath: base, Relative: relative}); err != nil {
+			t.Fatalf("WriteJSONLines returned error: %v", err)
+		}
+		want := `"rel_path":` + strconv.Quote(filepath.Join("sub", "a.log"))
+		if got := strings.Contains(buf.String(), want); got != relative {
+			t.Errorf("relative=%v: output %s, want rel_path present = %v", relative, buf.String(), relative)
+		}
+		if !strings.Contains(buf.String(), `"full_path":`+strconv.Quote(path)) {
+			t.Errorf("relative=%v: full_path is not absolute in %s", relative, buf.String())
+		}
+	}
+}